@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/backup"
+	"github.com/johnfox/claudectx/internal/printer"
+)
+
+// Backup subcommands
+const (
+	BackupList    = "list"
+	BackupVerify  = "verify"
+	BackupGC      = "gc"
+	BackupPin     = "pin"
+	BackupUnpin   = "unpin"
+	BackupPrune   = "prune"
+	BackupExport  = "export"
+	BackupImport  = "import"
+	BackupRestore = "restore"
+)
+
+// BackupOptions holds the parsed arguments for the backup command.
+type BackupOptions struct {
+	Action string
+	// IDs are the backups to verify (all if empty), pin, unpin or restore
+	IDs  []string
+	JSON bool
+	// DryRun makes "backup prune" only list what it would delete
+	DryRun bool
+
+	// All exports every backup
+	All bool
+	// Output is the archive written by "backup export" (stdout if empty)
+	Output string
+	// File is the archive read by "backup import" ("-" for stdin)
+	File string
+	// Yes skips the confirmation prompt of "backup restore"
+	Yes bool
+}
+
+// BackupDetails is the JSON form of a backup in "backup list --json"
+type BackupDetails struct {
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Reason        string    `json:"reason,omitempty"`
+	ActiveProfile string    `json:"activeProfile,omitempty"`
+	Profile       string    `json:"profile,omitempty"`
+	Files         int       `json:"files"`
+	Size          int64     `json:"size"`
+	Pinned        bool      `json:"pinned,omitempty"`
+}
+
+// ParseBackupArgs parses the arguments following "claudectx backup".
+// Valid forms:
+//
+//	backup list [--json]
+//	backup verify [id...]
+//	backup gc
+//	backup pin|unpin [id...]
+//	backup prune [--dry-run]
+//	backup export <id...|--all> [-o FILE]
+//	backup import <FILE|->
+//	backup restore [id] [--yes]
+func ParseBackupArgs(args []string) (BackupOptions, error) {
+	if len(args) == 0 {
+		return BackupOptions{}, errors.New("backup subcommand required (list, verify, gc, pin, unpin, prune, export, import, restore)")
+	}
+
+	opts := BackupOptions{Action: args[0]}
+	switch opts.Action {
+	case BackupList, BackupVerify, BackupGC, BackupPin, BackupUnpin, BackupPrune, BackupExport, BackupImport, BackupRestore:
+	default:
+		return BackupOptions{}, fmt.Errorf("unknown backup subcommand %q (valid: list, verify, gc, pin, unpin, prune, export, import, restore)", args[0])
+	}
+
+	rest := args[1:]
+	for i := 0; i < len(rest); i++ {
+		a := rest[i]
+		switch {
+		case a == "--json" && opts.Action == BackupList:
+			opts.JSON = true
+		case a == "--dry-run" && opts.Action == BackupPrune:
+			opts.DryRun = true
+		case a == "--all" && opts.Action == BackupExport:
+			opts.All = true
+		case (a == "--output" || a == "-o") && opts.Action == BackupExport:
+			if i+1 >= len(rest) {
+				return BackupOptions{}, fmt.Errorf("%s requires a file", a)
+			}
+			i++
+			opts.Output = rest[i]
+		case strings.HasPrefix(a, "--output=") && opts.Action == BackupExport:
+			opts.Output = strings.TrimPrefix(a, "--output=")
+		case opts.Action == BackupImport && opts.File == "" && (a == "-" || !strings.HasPrefix(a, "-")):
+			opts.File = a
+		case (a == "--yes" || a == "-y") && opts.Action == BackupRestore:
+			opts.Yes = true
+		case opts.Action == BackupRestore && len(opts.IDs) == 0 && len(a) > 0 && a[0] != '-':
+			opts.IDs = append(opts.IDs, a)
+		case (opts.Action == BackupVerify || opts.Action == BackupPin || opts.Action == BackupUnpin || opts.Action == BackupExport) && len(a) > 0 && a[0] != '-':
+			opts.IDs = append(opts.IDs, a)
+		default:
+			return BackupOptions{}, fmt.Errorf("unexpected backup %s argument %q", opts.Action, a)
+		}
+	}
+
+	if opts.Action == BackupExport && (len(opts.IDs) == 0) == !opts.All {
+		return BackupOptions{}, errors.New("backup export requires backup IDs or --all, but not both")
+	}
+	if opts.Action == BackupImport && opts.File == "" {
+		return BackupOptions{}, errors.New("backup import requires an archive file")
+	}
+
+	return opts, nil
+}
+
+// RunBackup executes a parsed backup command
+func RunBackup(opts BackupOptions) error {
+	backupMgr, err := backup.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize backup manager: %w", err)
+	}
+
+	switch opts.Action {
+	case BackupList:
+		return ListBackups(backupMgr, opts.JSON)
+	case BackupVerify:
+		return VerifyBackups(backupMgr, opts.IDs)
+	case BackupPin, BackupUnpin:
+		if len(opts.IDs) == 0 {
+			title := "Pin which backup?"
+			if opts.Action == BackupUnpin {
+				title = "Unpin which backup?"
+			}
+			id, ok, err := chooseBackup(backupMgr, "", title, nil)
+			if err != nil || !ok {
+				return err
+			}
+			opts.IDs = []string{id}
+		}
+		return PinBackups(backupMgr, opts.IDs, opts.Action == BackupPin)
+	case BackupPrune:
+		return PruneBackups(backupMgr, opts.DryRun)
+	case BackupExport:
+		return ExportBackups(backupMgr, opts.IDs, opts.All, opts.Output)
+	case BackupImport:
+		return ImportBackups(backupMgr, opts.File)
+	case BackupRestore:
+		if len(opts.IDs) == 0 {
+			restorable := func(manifest *backup.Manifest) bool { return !manifest.IsProfileSnapshot() }
+			id, ok, err := chooseBackup(backupMgr, "", "Restore which backup?", restorable)
+			if err != nil || !ok {
+				return err
+			}
+			opts.IDs = []string{id}
+		}
+		return RestoreBackup(backupMgr, opts.IDs[0], opts.Yes)
+	default:
+		return CollectBackupGarbage(backupMgr)
+	}
+}
+
+// ListBackups prints the backups, newest first, with the reason each was
+// taken and the profile that was active
+func ListBackups(backupMgr *backup.Manager, asJSON bool) error {
+	backups, err := backupMgr.List()
+	if err != nil {
+		return err
+	}
+
+	details := make([]BackupDetails, 0, len(backups))
+	for _, b := range backups {
+		d := BackupDetails{ID: b.ID, CreatedAt: b.CreatedAt, Pinned: b.Pinned}
+		manifest, err := backupMgr.ReadManifest(b.ID)
+		if err != nil {
+			printer.Warning("Warning: %s: %v", b.ID, err)
+		}
+		if manifest != nil {
+			d.Reason = manifest.Reason
+			d.Profile = manifest.Profile
+			if manifest.Trackers != nil {
+				d.ActiveProfile = manifest.Trackers.Current
+			}
+			d.Files = len(manifest.Files)
+			for _, f := range manifest.Files {
+				d.Size += f.Size
+			}
+		}
+		details = append(details, d)
+	}
+
+	if asJSON {
+		return encodeJSON(details)
+	}
+
+	if len(details) == 0 {
+		printer.Info("No backups")
+		return nil
+	}
+
+	for _, d := range details {
+		line := fmt.Sprintf("%s  %s", d.ID, d.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+		if d.Reason != "" {
+			line += "  " + d.Reason
+		}
+		if d.ActiveProfile != "" {
+			line += " " + printer.Dim(fmt.Sprintf("(active: %s)", d.ActiveProfile))
+		}
+		if d.Pinned {
+			line += " " + printer.Colorize("[pinned]", printer.Yellow)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// RestoreBackup replaces the live configuration and the profile trackers
+// with a backup's, after confirming and taking a backup of the current state
+// so the restore can itself be undone
+func RestoreBackup(backupMgr *backup.Manager, id string, yes bool) error {
+	if err := backupMgr.CheckRestorable(id); err != nil {
+		return err
+	}
+
+	if !yes {
+		question := fmt.Sprintf("Restore backup %s over the live configuration?", id)
+		if manifest, err := backupMgr.ReadManifest(id); err == nil && manifest != nil {
+			if manifest.Reason != "" {
+				question = fmt.Sprintf("Restore backup %s (%s) over the live configuration?", id, manifest.Reason)
+			}
+		}
+		if !confirm(question, false) {
+			printer.Info("Restore cancelled")
+			return nil
+		}
+	}
+
+	safetyID, err := backupMgr.Create(fmt.Sprintf("before restoring %s", id))
+	if err != nil {
+		return fmt.Errorf("failed to back up the current configuration: %w", err)
+	}
+	printer.Info("Created backup: %s", safetyID)
+
+	if err := backupMgr.Restore(id); err != nil {
+		rollback(backupMgr, safetyID)
+		return fmt.Errorf("failed to restore backup %s: %w", id, err)
+	}
+
+	printer.Success("Restored backup %s", id)
+	printer.Info("Undo with: claudectx backup restore %s", safetyID)
+	return nil
+}
+
+// VerifyBackups checks the files of the given backups (all if none are
+// given) against their hashes and reports any that are missing or corrupt
+func VerifyBackups(backupMgr *backup.Manager, ids []string) error {
+	problems, err := backupMgr.Verify(ids...)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		for _, p := range problems {
+			printer.Error("✗ %s", p)
+		}
+		return fmt.Errorf("%d backup file(s) failed verification", len(problems))
+	}
+
+	if len(ids) == 0 {
+		printer.Success("All backups verified")
+	} else {
+		printer.Success("Verified %d backup(s)", len(ids))
+	}
+	return nil
+}
+
+// CollectBackupGarbage removes stored file contents no backup refers to
+func CollectBackupGarbage(backupMgr *backup.Manager) error {
+	result, err := backupMgr.GC()
+	if err != nil {
+		return err
+	}
+
+	if result.Objects == 0 {
+		printer.Info("No unused backup objects")
+		return nil
+	}
+	printer.Success("Removed %d unused backup object(s), %d bytes", result.Objects, result.Bytes)
+	return nil
+}
+
+// PinBackups pins or unpins backups, so pruning keeps or may remove them
+func PinBackups(backupMgr *backup.Manager, ids []string, pinned bool) error {
+	for _, id := range ids {
+		if err := backupMgr.Pin(id, pinned); err != nil {
+			return err
+		}
+		if pinned {
+			printer.Success("Pinned backup %s", id)
+		} else {
+			printer.Success("Unpinned backup %s", id)
+		}
+	}
+	return nil
+}
+
+// PruneBackups removes the backups the retention policy does not keep. With
+// dryRun set it only lists them.
+func PruneBackups(backupMgr *backup.Manager, dryRun bool) error {
+	policy, err := backup.LoadPolicy()
+	if err != nil {
+		return err
+	}
+
+	removed, err := backupMgr.PruneWithPolicy(policy, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		printer.Info("No backups to prune (keepLast %d, keepDaily %d, keepWeekly %d)", policy.KeepLast, policy.KeepDaily, policy.KeepWeekly)
+		return nil
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	for _, b := range removed {
+		fmt.Printf("%s %s  %s\n", verb, b.ID, b.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if dryRun {
+		printer.Info("%d backup(s) would be deleted", len(removed))
+	} else {
+		printer.Success("Deleted %d backup(s)", len(removed))
+	}
+	return nil
+}
+
+// ExportBackups writes backups, their manifests and stored files to a
+// compressed archive, or to stdout when output is empty
+func ExportBackups(backupMgr *backup.Manager, ids []string, all bool, output string) error {
+	if all {
+		backups, err := backupMgr.List()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			return errors.New("there are no backups to export")
+		}
+		ids = nil
+		for _, b := range backups {
+			ids = append(ids, b.ID)
+		}
+	}
+
+	if output == "" {
+		if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return errors.New("refusing to write a compressed archive to the terminal (use -o FILE)")
+		}
+		return backupMgr.Export(os.Stdout, ids)
+	}
+
+	// Write next to the destination and rename, so a failed export never
+	// leaves a truncated archive behind
+	tmp, err := os.CreateTemp(filepath.Dir(output), ".claudectx-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := backupMgr.Export(tmp, ids); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	printer.Success("Exported %d backup(s) to %s", len(ids), output)
+	return nil
+}
+
+// ImportBackups adds the backups in an archive made by "backup export".
+// Backups that already exist are left unchanged.
+func ImportBackups(backupMgr *backup.Manager, file string) error {
+	input := os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	result, err := backupMgr.Import(input)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", file, err)
+	}
+
+	for _, id := range result.Imported {
+		fmt.Printf("  + %s\n", id)
+	}
+	for _, id := range result.Skipped {
+		fmt.Printf("  = %s (already exists)\n", id)
+	}
+	printer.Success("Imported %d backup(s), skipped %d", len(result.Imported), len(result.Skipped))
+	return nil
+}
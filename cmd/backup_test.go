@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/backup"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+func TestParseBackupArgs(t *testing.T) {
+	opts, err := ParseBackupArgs([]string{"verify", "backup-1", "backup-2"})
+	if err != nil || opts.Action != BackupVerify || len(opts.IDs) != 2 {
+		t.Errorf("verify: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseBackupArgs([]string{"list", "--json"})
+	if err != nil || !opts.JSON {
+		t.Errorf("list: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseBackupArgs([]string{"prune", "--dry-run"})
+	if err != nil || opts.Action != BackupPrune || !opts.DryRun {
+		t.Errorf("prune: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseBackupArgs([]string{"pin", "backup-1"})
+	if err != nil || opts.Action != BackupPin || opts.IDs[0] != "backup-1" {
+		t.Errorf("pin: opts = %+v, err = %v", opts, err)
+	}
+
+	// Without an ID the backup is picked interactively
+	opts, err = ParseBackupArgs([]string{"unpin"})
+	if err != nil || opts.Action != BackupUnpin || len(opts.IDs) != 0 {
+		t.Errorf("unpin: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseBackupArgs([]string{"export", "--all", "-o", "backups.tar.gz"})
+	if err != nil || !opts.All || opts.Output != "backups.tar.gz" {
+		t.Errorf("export: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseBackupArgs([]string{"import", "-"})
+	if err != nil || opts.File != "-" {
+		t.Errorf("import: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseBackupArgs([]string{"restore", "backup-1", "--yes"})
+	if err != nil || opts.Action != BackupRestore || opts.IDs[0] != "backup-1" || !opts.Yes {
+		t.Errorf("restore: opts = %+v, err = %v", opts, err)
+	}
+
+	for _, args := range [][]string{nil, {"revert"}, {"restore", "backup-1", "backup-2"}, {"gc", "--json"}, {"list", "backup-1"}, {"prune", "backup-1"},
+		{"export"}, {"export", "backup-1", "--all"}, {"export", "--all", "-o"}, {"import"}, {"import", "a.tar.gz", "b.tar.gz"}} {
+		if _, err := ParseBackupArgs(args); err == nil {
+			t.Errorf("ParseBackupArgs(%q) should fail", args)
+		}
+	}
+}
+
+func TestSwitchProfile_BackupsShareObjects(t *testing.T) {
+	s, home := setupRunTest(t)
+
+	for _, name := range []string{"work", "personal"} {
+		prof := profile.NewProfile(name)
+		prof.Settings.Model = "opus"
+		saveProfile(t, s, prof)
+	}
+
+	// Switching back and forth between identical profiles stores one copy
+	for _, name := range []string{"work", "personal", "work", "personal"} {
+		if err := SwitchProfile(s, name); err != nil {
+			t.Fatalf("SwitchProfile(%s) failed: %v", name, err)
+		}
+	}
+
+	backupMgr, _ := backup.NewManager()
+	backups, _ := backupMgr.List()
+	if len(backups) != 4 {
+		t.Fatalf("expected 4 backups, got %d", len(backups))
+	}
+
+	objects := 0
+	filepath.WalkDir(filepath.Join(home, ".claude", "backups", "objects"), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			objects++
+		}
+		return nil
+	})
+	// The first backup has no settings.json; the rest share one
+	if objects > 2 {
+		t.Errorf("object store has %d objects, want identical files stored once", objects)
+	}
+
+	if err := VerifyBackups(backupMgr, nil); err != nil {
+		t.Errorf("VerifyBackups() failed: %v", err)
+	}
+	if err := CollectBackupGarbage(backupMgr); err != nil {
+		t.Errorf("CollectBackupGarbage() failed: %v", err)
+	}
+}
+
+func TestPruneBackups_UsesConfiguredPolicy(t *testing.T) {
+	_, home := setupRunTest(t)
+	os.WriteFile(filepath.Join(home, ".claude", "claudectx.json"), []byte(`{"backups": {"keepLast": 1, "keepDaily": 0, "keepWeekly": 0}}`), 0644)
+
+	backupMgr, _ := backup.NewManager()
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := backupMgr.Create("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := PinBackups(backupMgr, ids[:1], true); err != nil {
+		t.Fatalf("PinBackups() failed: %v", err)
+	}
+
+	if err := PruneBackups(backupMgr, true); err != nil {
+		t.Fatalf("PruneBackups(dry run) failed: %v", err)
+	}
+	if backups, _ := backupMgr.List(); len(backups) != 3 {
+		t.Fatalf("dry run deleted backups, %d left", len(backups))
+	}
+
+	if err := PruneBackups(backupMgr, false); err != nil {
+		t.Fatalf("PruneBackups() failed: %v", err)
+	}
+	// The newest and the pinned backup are kept
+	if !backupMgr.Exists(ids[0]) || backupMgr.Exists(ids[1]) || !backupMgr.Exists(ids[2]) {
+		t.Errorf("unexpected backups left: %v", ids)
+	}
+}
+
+func TestExportImportBackups(t *testing.T) {
+	_, home := setupRunTest(t)
+	writeSettings(t, filepath.Join(home, ".claude", "settings.json"), `{"model": "opus"}`)
+
+	backupMgr, _ := backup.NewManager()
+	id, err := backupMgr.Create("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "backups.tar.gz")
+	if err := ExportBackups(backupMgr, nil, true, archive); err != nil {
+		t.Fatalf("ExportBackups() failed: %v", err)
+	}
+	info, err := os.Stat(archive)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("archive = %v, %v; want a 0600 file", info, err)
+	}
+
+	setupRunTest(t)
+	otherMgr, _ := backup.NewManager()
+	if err := ImportBackups(otherMgr, archive); err != nil {
+		t.Fatalf("ImportBackups() failed: %v", err)
+	}
+	if !otherMgr.Exists(id) {
+		t.Errorf("backup %s was not imported", id)
+	}
+
+	if err := ExportBackups(otherMgr, []string{"backup-missing"}, false, archive); err == nil {
+		t.Error("ExportBackups() should fail for an unknown backup")
+	}
+	// The failed export leaves the earlier archive alone
+	if err := ImportBackups(otherMgr, archive); err != nil {
+		t.Errorf("archive was damaged by a failed export: %v", err)
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	setupRunTest(t)
+
+	settingsPath, _ := paths.SettingsFile()
+	writeSettings(t, settingsPath, `{"model":"haiku"}`)
+	backupMgr, err := backup.NewManager()
+	if err != nil {
+		t.Fatalf("failed to create backup manager: %v", err)
+	}
+	id, err := backupMgr.Create("test")
+	if err != nil {
+		t.Fatalf("failed to create backup: %v", err)
+	}
+	writeSettings(t, settingsPath, `{"model":"opus"}`)
+
+	// Declining leaves the live configuration alone
+	stubPrompt(t, "n\n")
+	if err := RestoreBackup(backupMgr, id, false); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if data, _ := os.ReadFile(settingsPath); !strings.Contains(string(data), "opus") {
+		t.Fatalf("declined restore changed settings: %s", data)
+	}
+
+	stubPrompt(t, "y\n")
+	if err := RestoreBackup(backupMgr, id, false); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if data, _ := os.ReadFile(settingsPath); !strings.Contains(string(data), "haiku") {
+		t.Errorf("settings not restored: %s", data)
+	}
+
+	// The state before the restore was backed up first
+	latest := backupMgr.GetLatest()
+	manifest, err := backupMgr.ReadManifest(latest)
+	if err != nil || manifest == nil || manifest.Reason != "before restoring "+id {
+		t.Fatalf("expected a safety backup, got %s (%+v, %v)", latest, manifest, err)
+	}
+	if err := RestoreBackup(backupMgr, latest, true); err != nil {
+		t.Fatalf("undoing the restore failed: %v", err)
+	}
+	if data, _ := os.ReadFile(settingsPath); !strings.Contains(string(data), "opus") {
+		t.Errorf("undo did not bring back the previous settings: %s", data)
+	}
+}
+
+func TestRestoreBackup_Unknown(t *testing.T) {
+	setupRunTest(t)
+	backupMgr, _ := backup.NewManager()
+
+	if err := RestoreBackup(backupMgr, "backup-1", true); err == nil {
+		t.Fatal("expected error for an unknown backup")
+	}
+}
+
+func TestRestoreBackup_RefusesProfileSnapshot(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	settingsPath, _ := paths.SettingsFile()
+	writeSettings(t, settingsPath, `{"model":"opus"}`)
+	prof := profile.NewProfile("work")
+	prof.Settings.Model = "haiku"
+	saveProfile(t, s, prof)
+
+	backupMgr, _ := backup.NewManager()
+	id, err := backupMgr.CreateFromProfile(prof)
+	if err != nil {
+		t.Fatalf("failed to snapshot profile: %v", err)
+	}
+
+	err = RestoreBackup(backupMgr, id, true)
+	if err == nil || !strings.Contains(err.Error(), "claudectx cp") {
+		t.Fatalf("expected a profile snapshot to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(settingsPath); !strings.Contains(string(data), "opus") {
+		t.Errorf("live settings changed: %s", data)
+	}
+	if err := backupMgr.Restore(id); err == nil {
+		t.Error("Restore should refuse a profile snapshot too")
+	}
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/backup"
+	"github.com/johnfox/claudectx/internal/exporter"
+	"github.com/johnfox/claudectx/internal/keypath"
+	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
+	"github.com/johnfox/claudectx/internal/store"
+	"github.com/johnfox/claudectx/internal/validator"
+)
+
+// CopyOptions holds the parsed arguments for the cp command.
+type CopyOptions struct {
+	// Source is a profile name, backup ID or export file path. When empty
+	// a profile is picked interactively.
+	Source string
+	Dest   string
+	// Sets holds KEY=VALUE overrides applied to the copy
+	Sets  []string
+	Model string
+}
+
+// ParseCopyArgs parses the arguments following "claudectx cp".
+// Valid forms:
+//
+//	cp <src> <dst> [--set KEY=VALUE]... [--with KEY=VALUE]... [--model MODEL]
+func ParseCopyArgs(args []string) (CopyOptions, error) {
+	var opts CopyOptions
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--set" || a == "--with":
+			if i+1 >= len(args) {
+				return CopyOptions{}, fmt.Errorf("%s requires KEY=VALUE", a)
+			}
+			i++
+			opts.Sets = append(opts.Sets, args[i])
+		case strings.HasPrefix(a, "--set="):
+			opts.Sets = append(opts.Sets, strings.TrimPrefix(a, "--set="))
+		case strings.HasPrefix(a, "--with="):
+			opts.Sets = append(opts.Sets, strings.TrimPrefix(a, "--with="))
+		case a == "--model":
+			if i+1 >= len(args) {
+				return CopyOptions{}, errors.New("--model requires a value")
+			}
+			i++
+			opts.Model = args[i]
+		case strings.HasPrefix(a, "--model="):
+			opts.Model = strings.TrimPrefix(a, "--model=")
+		case strings.HasPrefix(a, "--"):
+			return CopyOptions{}, fmt.Errorf("unknown cp flag %q", a)
+		default:
+			positional = append(positional, a)
+		}
+	}
+
+	switch len(positional) {
+	case 1:
+		// Only the new name: the source is picked interactively
+		opts.Dest = positional[0]
+	case 2:
+		opts.Source, opts.Dest = positional[0], positional[1]
+	default:
+		return CopyOptions{}, errors.New("source and destination required")
+	}
+
+	for _, set := range opts.Sets {
+		idx := strings.Index(set, "=")
+		if idx <= 0 {
+			return CopyOptions{}, fmt.Errorf("invalid override %q (expected KEY=VALUE)", set)
+		}
+		if _, err := keypath.Parse(set[:idx]); err != nil {
+			return CopyOptions{}, err
+		}
+	}
+
+	return opts, nil
+}
+
+// CopyProfile creates a new stored profile from an existing profile, a backup
+// snapshot or an export file, applying any overrides. The live configuration
+// and current/previous trackers are never touched.
+func CopyProfile(s *store.Store, opts CopyOptions) error {
+	if err := profile.ValidateProfileName(opts.Dest); err != nil {
+		return fmt.Errorf("invalid profile name: %w", err)
+	}
+
+	if s.Exists(opts.Dest) {
+		return fmt.Errorf("profile %q already exists", opts.Dest)
+	}
+
+	source, ok, err := chooseProfile(s, opts.Source, fmt.Sprintf("Copy which profile to %q?", opts.Dest))
+	if err != nil || !ok {
+		return err
+	}
+
+	prof, origin, err := loadCopySource(s, source, opts.Dest)
+	if err != nil {
+		return err
+	}
+
+	// Apply overrides
+	if opts.Model != "" {
+		opts.Sets = append([]string{"model=" + opts.Model}, opts.Sets...)
+	}
+	for _, set := range opts.Sets {
+		idx := strings.Index(set, "=")
+		path, err := keypath.Parse(set[:idx])
+		if err != nil {
+			return err
+		}
+		keyOpts := KeyOptions{Op: KeySet, Path: set[:idx], Value: set[idx+1:], ValueMode: ValueAuto}
+		if _, err := modifyProfile(prof, path, keyOpts); err != nil {
+			return fmt.Errorf("override %q: %w", set, err)
+		}
+	}
+
+	// Validate the copy before saving
+	if err := prof.Validate(); err != nil {
+		return fmt.Errorf("profile validation failed: %w", err)
+	}
+	if err := validator.ValidateSettings(prof.Settings); err != nil {
+		return fmt.Errorf("profile settings are invalid: %w", err)
+	}
+	if err := validator.ValidateClaudeMD(prof.ClaudeMD); err != nil {
+		return fmt.Errorf("profile CLAUDE.md is invalid: %w", err)
+	}
+	if prof.Project != nil && prof.Project.Settings != nil {
+		if err := validator.ValidateSettings(prof.Project.Settings); err != nil {
+			return fmt.Errorf("profile project settings are invalid: %w", err)
+		}
+	}
+
+	if err := s.Save(prof); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	printer.Success("Copied %s to profile %q", origin, opts.Dest)
+	if len(opts.Sets) > 0 {
+		printer.Info("  Overrides applied: %d", len(opts.Sets))
+	}
+	return nil
+}
+
+// loadCopySource resolves a cp source, trying a stored profile first, then a
+// backup ID, then an export file. It returns the loaded profile (renamed to
+// dest) and a description of where it came from.
+func loadCopySource(s *store.Store, source, dest string) (*profile.Profile, string, error) {
+	if profile.ValidateProfileName(source) == nil && s.Exists(source) {
+		prof, err := s.Load(source)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load profile: %w", err)
+		}
+		copied, err := cloneProfile(dest, prof)
+		if err != nil {
+			return nil, "", err
+		}
+		return copied, fmt.Sprintf("profile %q", source), nil
+	}
+
+	backupMgr, err := backup.NewManager()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to initialize backup manager: %w", err)
+	}
+	if backupMgr.Exists(source) {
+		prof, err := backupMgr.LoadProfile(source, dest)
+		if err != nil {
+			return nil, "", err
+		}
+		return prof, fmt.Sprintf("backup %s", source), nil
+	}
+
+	if info, err := os.Stat(source); err == nil && !info.IsDir() {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open export file: %w", err)
+		}
+		defer f.Close()
+
+		exported, err := exporter.ReadExport(f)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := validator.ValidateSettings(exported.Settings); err != nil {
+			return nil, "", fmt.Errorf("exported settings are invalid: %w", err)
+		}
+		copied, err := cloneProfile(dest, exported.Profile(dest))
+		if err != nil {
+			return nil, "", err
+		}
+		return copied, fmt.Sprintf("export %s", source), nil
+	}
+
+	return nil, "", fmt.Errorf("%q is not a profile, backup ID or export file", source)
+}
+
+// cloneProfile copies a profile's configuration and descriptive metadata
+// under a new name, with fresh timestamps and usage stats
+func cloneProfile(name string, src *profile.Profile) (*profile.Profile, error) {
+	copied, err := src.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy profile: %w", err)
+	}
+	now := time.Now()
+	copied.Name = name
+	copied.CreatedAt = now
+	copied.UpdatedAt = now
+	copied.LastUsedAt = time.Time{}
+	copied.UseCount = 0
+	return copied, nil
+}
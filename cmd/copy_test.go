@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/backup"
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/exporter"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+func TestParseCopyArgs(t *testing.T) {
+	opts, err := ParseCopyArgs([]string{"work", "client", "--set", "env.X=Y", "--with=env.Z=1", "--model", "opus"})
+	if err != nil {
+		t.Fatalf("ParseCopyArgs failed: %v", err)
+	}
+	if opts.Source != "work" || opts.Dest != "client" || opts.Model != "opus" || len(opts.Sets) != 2 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	// A single name is the destination; the source is picked interactively
+	opts, err = ParseCopyArgs([]string{"client"})
+	if err != nil || opts.Source != "" || opts.Dest != "client" {
+		t.Errorf("ParseCopyArgs(client) = %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{
+		nil,
+		{"work", "client", "extra"},
+		{"work", "client", "--set", "novalue"},
+		{"work", "client", "--set"},
+		{"work", "client", "--force"},
+	} {
+		if _, err := ParseCopyArgs(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestCopyProfile_FromProfileWithOverrides(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	src := profile.NewProfile("work")
+	src.Settings.Model = "sonnet"
+	src.Settings.Env["ANTHROPIC_BASE_URL"] = "https://old"
+	src.ClaudeMD = "# Work\n"
+	src.MCPServers = mcpconfig.MCPServers{"github": {Command: "npx"}}
+	saveProfile(t, s, src)
+	setCurrentProfile(t, s, "work")
+
+	opts, _ := ParseCopyArgs([]string{"work", "client", "--set", "env.ANTHROPIC_BASE_URL=https://new", "--model", "opus"})
+	if err := CopyProfile(s, opts); err != nil {
+		t.Fatalf("CopyProfile failed: %v", err)
+	}
+
+	copied, err := s.Load("client")
+	if err != nil {
+		t.Fatalf("failed to load copy: %v", err)
+	}
+	if copied.Settings.Model != "opus" || copied.Settings.Env["ANTHROPIC_BASE_URL"] != "https://new" {
+		t.Errorf("overrides not applied: %+v", copied.Settings)
+	}
+	if copied.ClaudeMD != "# Work\n" || len(copied.MCPServers) != 1 {
+		t.Error("expected CLAUDE.md and MCP servers to be copied")
+	}
+
+	original, _ := s.Load("work")
+	if original.Settings.Model != "sonnet" || original.Settings.Env["ANTHROPIC_BASE_URL"] != "https://old" {
+		t.Error("source profile must not be modified")
+	}
+
+	// Live config and trackers are untouched
+	settingsPath, _ := paths.SettingsFile()
+	if _, err := os.Stat(settingsPath); !os.IsNotExist(err) {
+		t.Error("cp must not write the live settings.json")
+	}
+	if cur, _ := s.GetCurrent(); cur != "work" {
+		t.Errorf("current = %q, want work", cur)
+	}
+}
+
+func TestCopyProfile_DestinationExists(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+	saveProfile(t, s, profile.NewProfile("client"))
+
+	if err := CopyProfile(s, CopyOptions{Source: "work", Dest: "client"}); err == nil {
+		t.Fatal("expected error when destination exists")
+	}
+}
+
+func TestCopyProfile_FromBackup(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	settingsPath, _ := paths.SettingsFile()
+	if err := os.WriteFile(settingsPath, []byte(`{"model":"haiku"}`), 0644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+	mgr, err := backup.NewManager()
+	if err != nil {
+		t.Fatalf("failed to create backup manager: %v", err)
+	}
+	backupID, err := mgr.Create("test")
+	if err != nil {
+		t.Fatalf("failed to create backup: %v", err)
+	}
+
+	if err := CopyProfile(s, CopyOptions{Source: backupID, Dest: "restored"}); err != nil {
+		t.Fatalf("CopyProfile failed: %v", err)
+	}
+
+	restored, err := s.Load("restored")
+	if err != nil {
+		t.Fatalf("failed to load restored profile: %v", err)
+	}
+	if restored.Settings.Model != "haiku" {
+		t.Errorf("model = %q, want haiku", restored.Settings.Model)
+	}
+}
+
+func TestCopyProfile_FromExportFile(t *testing.T) {
+	s, home := setupRunTest(t)
+
+	src := profile.NewProfile("shared")
+	src.Settings.Model = "opus"
+	saveProfile(t, s, src)
+
+	exportPath := filepath.Join(home, "shared.json")
+	f, err := os.Create(exportPath)
+	if err != nil {
+		t.Fatalf("failed to create export file: %v", err)
+	}
+	if err := exporter.ExportProfile(s, "shared", f); err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	f.Close()
+
+	if err := CopyProfile(s, CopyOptions{Source: exportPath, Dest: "mine"}); err != nil {
+		t.Fatalf("CopyProfile failed: %v", err)
+	}
+
+	mine, err := s.Load("mine")
+	if err != nil {
+		t.Fatalf("failed to load copy: %v", err)
+	}
+	if mine.Settings.Model != "opus" {
+		t.Errorf("model = %q, want opus", mine.Settings.Model)
+	}
+}
+
+func TestCopyProfile_UnknownSource(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	if err := CopyProfile(s, CopyOptions{Source: "nowhere", Dest: "new"}); err == nil {
+		t.Fatal("expected error for unknown source")
+	}
+}
+
+func TestCopyProfile_CopiesProjectConfig(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	src := profile.NewProfile("repo")
+	src.Project = &profile.ProjectConfig{
+		Settings: &config.Settings{Model: "opus"},
+		ClaudeMD: "# Repo\n",
+	}
+	saveProfile(t, s, src)
+
+	if err := CopyProfile(s, CopyOptions{Source: "repo", Dest: "fork"}); err != nil {
+		t.Fatalf("CopyProfile failed: %v", err)
+	}
+
+	fork, err := s.Load("fork")
+	if err != nil {
+		t.Fatalf("failed to load copy: %v", err)
+	}
+	if fork.Project == nil || fork.Project.Settings.Model != "opus" || fork.Project.ClaudeMD != "# Repo\n" {
+		t.Errorf("project config not copied: %+v", fork.Project)
+	}
+}
+
+func TestCopyProfile_CopiesProjectMCPServers(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	src := profile.NewProfile("repos")
+	src.ProjectMCPServers = mcpconfig.ProjectServers{
+		"~/src/*": {"db": {Command: "db-mcp"}},
+	}
+	saveProfile(t, s, src)
+
+	if err := CopyProfile(s, CopyOptions{Source: "repos", Dest: "fork"}); err != nil {
+		t.Fatalf("CopyProfile failed: %v", err)
+	}
+
+	fork, err := s.Load("fork")
+	if err != nil {
+		t.Fatalf("failed to load copy: %v", err)
+	}
+	if fork.ProjectMCPServers["~/src/*"]["db"].Command != "db-mcp" {
+		t.Errorf("project MCP servers not copied: %+v", fork.ProjectMCPServers)
+	}
+}
+
+func TestCopyProfile_CopiesDisabledMCPServers(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	src := profile.NewProfile("work")
+	src.DisabledMCPServers = mcpconfig.MCPServers{"github": {Command: "npx"}}
+	src.UseCount = 7
+	saveProfile(t, s, src)
+
+	if err := CopyProfile(s, CopyOptions{Source: "work", Dest: "client"}); err != nil {
+		t.Fatalf("CopyProfile failed: %v", err)
+	}
+
+	client, err := s.Load("client")
+	if err != nil {
+		t.Fatalf("failed to load copy: %v", err)
+	}
+	if client.DisabledMCPServers["github"].Command != "npx" {
+		t.Errorf("disabled MCP servers not copied: %+v", client.DisabledMCPServers)
+	}
+	if client.UseCount != 0 {
+		t.Errorf("use count = %d, want a fresh 0", client.UseCount)
+	}
+}
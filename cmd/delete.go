@@ -9,6 +9,11 @@ import (
 
 // DeleteProfile deletes a profile with safety checks
 func DeleteProfile(s *store.Store, name string) error {
+	name, ok, err := chooseProfile(s, name, "Delete which profile?")
+	if err != nil || !ok {
+		return err
+	}
+
 	// Check if profile exists
 	if !s.Exists(name) {
 		return fmt.Errorf("profile %q does not exist", name)
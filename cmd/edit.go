@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/health"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
+	"github.com/johnfox/claudectx/internal/store"
+	"github.com/johnfox/claudectx/internal/validator"
+)
+
+// editTarget describes one editable file within a profile
+type editTarget struct {
+	// File is the name of the file inside the profile directory
+	File string
+	// Empty is the content used when the file does not exist yet
+	Empty string
+	// Validate checks the edited temp file, returning warnings and an error
+	Validate func(path string) ([]string, error)
+	// IsEmpty reports whether the edited content means "remove the file"
+	IsEmpty func(data []byte) bool
+}
+
+// editTargets maps the names accepted by "claudectx edit" to their files
+var editTargets = map[string]editTarget{
+	"settings": {
+		File:     "settings.json",
+		Empty:    "{}\n",
+		Validate: validateEditedSettings,
+		IsEmpty:  func([]byte) bool { return false },
+	},
+	"claude-md": {
+		File:     "CLAUDE.md",
+		Validate: validateEditedClaudeMD,
+		IsEmpty:  func(data []byte) bool { return len(bytes.TrimSpace(data)) == 0 },
+	},
+	"mcp": {
+		File:     "mcp.json",
+		Empty:    "{}\n",
+		Validate: validateEditedMCP,
+		IsEmpty: func(data []byte) bool {
+			trimmed := bytes.TrimSpace(data)
+			return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("{}"))
+		},
+	},
+	"project-mcp": {
+		File:     "project-mcp.json",
+		Empty:    "{}\n",
+		Validate: validateEditedProjectMCP,
+		IsEmpty: func(data []byte) bool {
+			trimmed := bytes.TrimSpace(data)
+			return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("{}"))
+		},
+	},
+}
+
+// runEditor opens path in the user's editor and waits for it to exit
+// (replaced in tests)
+var runEditor = func(path string) error {
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", strings.Join(editor, " "), err)
+	}
+	return nil
+}
+
+// editorCommand returns the editor command line from $VISUAL or $EDITOR,
+// defaulting to vi
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// EditProfile opens one of a profile's files in $VISUAL/$EDITOR. The file is
+// edited in a temp copy and only written back once it validates. Edits to
+// the current profile are also applied to the live configuration, so the
+// next auto-sync cannot overwrite them.
+func EditProfile(s *store.Store, name, target string) error {
+	if target == "" {
+		target = "settings"
+	}
+
+	spec, ok := editTargets[target]
+	if !ok {
+		return fmt.Errorf("unknown edit target %q (valid: settings, claude-md, mcp, project-mcp)", target)
+	}
+
+	name, ok, err := chooseProfile(s, name, "Edit which profile?")
+	if err != nil || !ok {
+		return err
+	}
+
+	if err := profile.ValidateProfileName(name); err != nil {
+		return fmt.Errorf("invalid profile name: %w", err)
+	}
+
+	if !s.Exists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	current, err := s.GetCurrent()
+	if err != nil {
+		return fmt.Errorf("failed to get current profile: %w", err)
+	}
+	isCurrent := current == name
+	if isCurrent {
+		syncBeforeModify(s, name)
+	}
+
+	profilePath, err := paths.ProfileFile(name, spec.File)
+	if err != nil {
+		return err
+	}
+
+	original := []byte(spec.Empty)
+	existed := config.FileExists(profilePath)
+	if existed {
+		original, err = os.ReadFile(profilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", spec.File, err)
+		}
+	}
+
+	// Edit a temp copy, keeping the original file name as a suffix so
+	// editors pick the right syntax highlighting
+	tmp, err := os.CreateTemp("", "claudectx-"+name+"-*-"+spec.File)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(original); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	var edited []byte
+	for {
+		if err := runEditor(tmpPath); err != nil {
+			return err
+		}
+
+		edited, err = os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to read edited file: %w", err)
+		}
+
+		if bytes.Equal(edited, original) {
+			printer.Info("No changes made to %s in profile %q", spec.File, name)
+			return nil
+		}
+
+		warnings, err := spec.Validate(tmpPath)
+		if err == nil {
+			for _, warning := range warnings {
+				printer.Warning("  ⚠ %s", warning)
+			}
+			break
+		}
+
+		printer.Error("Validation failed: %v", err)
+		if !confirm("Re-open the editor to fix it?", true) {
+			return fmt.Errorf("changes to %s discarded: %w", spec.File, err)
+		}
+	}
+
+	// The live configuration of the current profile is synced back into it
+	// on the next switch, so its edits are saved only if they are applied
+	if isCurrent && !confirm(fmt.Sprintf("Profile %q is active. Save and apply the change to the live configuration?", name), true) {
+		printer.Info("Changes to %s discarded; profile %q is unchanged", spec.File, name)
+		return nil
+	}
+
+	// The per-project MCP servers applied now, replaced by the edited ones
+	var previous mcpconfig.ProjectServers
+	if isCurrent {
+		before, err := s.Load(name)
+		if err != nil {
+			return fmt.Errorf("failed to load profile: %w", err)
+		}
+		previous = before.ProjectMCPServers
+	}
+
+	// Commit the change atomically
+	if err := writeEditedFile(profilePath, edited, spec); err != nil {
+		return err
+	}
+
+	printer.Success("Saved %s in profile %q", spec.File, name)
+
+	if !isCurrent {
+		return nil
+	}
+
+	prof, err := s.Load(name)
+	if err == nil {
+		err = reapplyProfile(prof, previous)
+	}
+	if err != nil {
+		// Put the profile back, so it matches the live configuration again
+		var restored []byte
+		if existed {
+			restored = original
+		}
+		if restoreErr := writeEditedFile(profilePath, restored, spec); restoreErr != nil {
+			printer.Warning("Warning: Failed to restore %s: %v", spec.File, restoreErr)
+		}
+		return fmt.Errorf("failed to apply changes, %s left unchanged: %w", spec.File, err)
+	}
+
+	printer.Success("Applied changes to the live configuration")
+	return nil
+}
+
+// writeEditedFile saves an edited profile file atomically, removing it when
+// the content means the file should not exist
+func writeEditedFile(path string, data []byte, spec editTarget) error {
+	if data == nil || spec.IsEmpty(data) {
+		if config.FileExists(path) {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", spec.File, err)
+			}
+		}
+		return nil
+	}
+	if err := config.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save %s: %w", spec.File, err)
+	}
+	return nil
+}
+
+// validateEditedSettings checks an edited settings.json file
+func validateEditedSettings(path string) ([]string, error) {
+	if err := validator.ValidateSettingsFile(path); err != nil {
+		return nil, err
+	}
+
+	settings, err := config.LoadSettings(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return healthWarnings(health.CheckProfile("", settings, ""))
+}
+
+// validateEditedClaudeMD checks an edited CLAUDE.md file
+func validateEditedClaudeMD(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return nil, validator.ValidateClaudeMD(string(data))
+}
+
+// validateEditedMCP checks an edited mcp.json file
+func validateEditedMCP(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	servers, err := mcpconfig.LoadFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return nil, validator.ValidateMCPServers(servers)
+}
+
+// validateEditedProjectMCP checks an edited project-mcp.json file, which
+// maps project paths or globs to MCP servers
+func validateEditedProjectMCP(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var projects mcpconfig.ProjectServers
+	if err := json.Unmarshal(data, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse project MCP servers: %w", err)
+	}
+
+	var warnings []string
+	for projectPath := range projects {
+		expanded := mcpconfig.ExpandProjectPath(projectPath)
+		if !filepath.IsAbs(expanded) {
+			return nil, fmt.Errorf("project path %q must be absolute or start with ~/", projectPath)
+		}
+		if _, err := filepath.Match(expanded, ""); err != nil {
+			return nil, fmt.Errorf("invalid project glob %q: %w", projectPath, err)
+		}
+		if err := validator.ValidateMCPServers(projects[projectPath]); err != nil {
+			return nil, fmt.Errorf("project %s: %w", projectPath, err)
+		}
+		if !mcpconfig.IsProjectGlob(projectPath) && !config.FileExists(expanded) {
+			warnings = append(warnings, fmt.Sprintf("project %s does not exist", projectPath))
+		}
+	}
+	return warnings, nil
+}
+
+// healthWarnings converts a health report into warnings or an error
+func healthWarnings(report *health.ProfileHealthReport) ([]string, error) {
+	if report.Overall.Error != nil {
+		return nil, report.Overall.Error
+	}
+	return report.Overall.Warnings, nil
+}
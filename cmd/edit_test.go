@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+// stubEditor replaces runEditor with one that writes each content in turn
+func stubEditor(t *testing.T, contents ...string) *int {
+	t.Helper()
+	calls := 0
+	orig := runEditor
+	runEditor = func(path string) error {
+		content := contents[len(contents)-1]
+		if calls < len(contents) {
+			content = contents[calls]
+		}
+		calls++
+		return os.WriteFile(path, []byte(content), 0644)
+	}
+	t.Cleanup(func() { runEditor = orig })
+	return &calls
+}
+
+// stubPrompt feeds answers to confirm/readLine
+func stubPrompt(t *testing.T, answers string) {
+	t.Helper()
+	origInput, origReader := promptInput, promptReader
+	promptInput = strings.NewReader(answers)
+	promptReader = nil
+	t.Cleanup(func() {
+		promptInput = origInput
+		promptReader = origReader
+	})
+}
+
+func TestEditProfile_SavesValidSettings(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+	stubEditor(t, `{"model":"opus","effortLevel":"high"}`)
+
+	if err := EditProfile(s, "work", ""); err != nil {
+		t.Fatalf("EditProfile failed: %v", err)
+	}
+
+	settingsPath, _ := paths.ProfileFile("work", "settings.json")
+	settings, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		t.Fatalf("failed to load settings: %v", err)
+	}
+	if settings.Model != "opus" {
+		t.Errorf("model = %q, want opus", settings.Model)
+	}
+	if _, ok := settings.Extras()["effortLevel"]; !ok {
+		t.Error("expected effortLevel to be saved")
+	}
+}
+
+func TestEditProfile_InvalidSettingsReopensEditor(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+	calls := stubEditor(t, `{"model": `, `{"model":"sonnet"}`)
+	stubPrompt(t, "y\n")
+
+	if err := EditProfile(s, "work", "settings"); err != nil {
+		t.Fatalf("EditProfile failed: %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("editor opened %d times, want 2", *calls)
+	}
+
+	prof, err := s.Load("work")
+	if err != nil {
+		t.Fatalf("failed to load profile: %v", err)
+	}
+	if prof.Settings.Model != "sonnet" {
+		t.Errorf("model = %q, want sonnet", prof.Settings.Model)
+	}
+}
+
+func TestEditProfile_InvalidSettingsDiscarded(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.Settings.Model = "haiku"
+	saveProfile(t, s, work)
+	stubEditor(t, `not json`)
+	stubPrompt(t, "n\n")
+
+	if err := EditProfile(s, "work", "settings"); err == nil {
+		t.Fatal("expected error when invalid edit is discarded")
+	}
+
+	prof, err := s.Load("work")
+	if err != nil {
+		t.Fatalf("failed to load profile: %v", err)
+	}
+	if prof.Settings.Model != "haiku" {
+		t.Errorf("stored profile should be unchanged, got model %q", prof.Settings.Model)
+	}
+}
+
+func TestEditProfile_InvalidMCPRejected(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+	stubEditor(t, `{"github": "not an object"}`)
+	stubPrompt(t, "n\n")
+
+	if err := EditProfile(s, "work", "mcp"); err == nil {
+		t.Fatal("expected error for invalid mcp.json")
+	}
+
+	mcpPath, _ := paths.ProfileFile("work", "mcp.json")
+	if config.FileExists(mcpPath) {
+		t.Error("mcp.json should not be created from an invalid edit")
+	}
+}
+
+func TestEditProfile_MCPServerWithoutCommandRejected(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+	stubEditor(t, `{"github": {"args": ["-y", "@github/mcp"]}}`)
+	stubPrompt(t, "n\n")
+
+	err := EditProfile(s, "work", "mcp")
+	if err == nil || !strings.Contains(err.Error(), "need a command") {
+		t.Fatalf("expected missing command error, got %v", err)
+	}
+
+	mcpPath, _ := paths.ProfileFile("work", "mcp.json")
+	if config.FileExists(mcpPath) {
+		t.Error("mcp.json should not be created from an invalid edit")
+	}
+}
+
+func TestEditProfile_EmptyClaudeMDRemovesFile(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.ClaudeMD = "# Rules\n"
+	saveProfile(t, s, work)
+	stubEditor(t, "  \n")
+
+	if err := EditProfile(s, "work", "claude-md"); err != nil {
+		t.Fatalf("EditProfile failed: %v", err)
+	}
+
+	claudeMDPath, _ := paths.ProfileFile("work", "CLAUDE.md")
+	if config.FileExists(claudeMDPath) {
+		t.Error("expected CLAUDE.md to be removed")
+	}
+}
+
+func TestEditProfile_CurrentProfileAppliesToLiveConfig(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+	setCurrentProfile(t, s, "work")
+	stubEditor(t, `{"model":"opus"}`)
+	stubPrompt(t, "\n")
+
+	if err := EditProfile(s, "work", "settings"); err != nil {
+		t.Fatalf("EditProfile failed: %v", err)
+	}
+
+	settingsPath, _ := paths.SettingsFile()
+	live, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		t.Fatalf("failed to load live settings: %v", err)
+	}
+	if live.Model != "opus" {
+		t.Errorf("live model = %q, want opus", live.Model)
+	}
+}
+
+func TestEditProfile_CurrentProfileDeclinedKeepsProfile(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.Settings.Model = "haiku"
+	saveProfile(t, s, work)
+	setCurrentProfile(t, s, "work")
+	settingsPath, _ := paths.SettingsFile()
+	writeSettings(t, settingsPath, `{"model":"haiku"}`)
+	stubEditor(t, `{"model":"opus"}`)
+	stubPrompt(t, "n\n")
+
+	if err := EditProfile(s, "work", "settings"); err != nil {
+		t.Fatalf("EditProfile failed: %v", err)
+	}
+
+	// Declining must not leave the profile different from the live config
+	prof, err := s.Load("work")
+	if err != nil {
+		t.Fatalf("failed to load profile: %v", err)
+	}
+	if prof.Settings.Model != "haiku" {
+		t.Errorf("stored model = %q, want haiku", prof.Settings.Model)
+	}
+	live, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		t.Fatalf("failed to load live settings: %v", err)
+	}
+	if live.Model != "haiku" {
+		t.Errorf("live model = %q, want haiku", live.Model)
+	}
+}
+
+func TestEditProfile_UnknownTarget(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	if err := EditProfile(s, "work", "hooks"); err == nil {
+		t.Fatal("expected error for unknown target")
+	}
+}
+
+func TestEditProfile_ClearedProjectMCPServersRemovedFromLiveConfig(t *testing.T) {
+	s, home := setupRunTest(t)
+	claudeJSON := filepath.Join(home, ".claude.json")
+	writeSettings(t, claudeJSON, `{"projects": {"/src/app": {"mcpServers": {"local": {"command": "local-mcp"}}}}}`)
+
+	work := profile.NewProfile("work")
+	work.ProjectMCPServers = mcpconfig.ProjectServers{"/src/app": {"db": {Command: "db-mcp"}}}
+	saveProfile(t, s, work)
+	if err := SwitchProfile(s, "work"); err != nil {
+		t.Fatalf("SwitchProfile failed: %v", err)
+	}
+
+	stubEditor(t, `{}`)
+	stubPrompt(t, "\n")
+	if err := EditProfile(s, "work", "project-mcp"); err != nil {
+		t.Fatalf("EditProfile failed: %v", err)
+	}
+
+	projects, _ := mcpconfig.LoadProjectMCPServers(claudeJSON)
+	if _, ok := projects["/src/app"]["db"]; ok {
+		t.Errorf("a server removed from the profile should leave the live config, got %+v", projects["/src/app"])
+	}
+	if projects["/src/app"]["local"].Command != "local-mcp" {
+		t.Errorf("local-scope server should be kept, got %+v", projects["/src/app"])
+	}
+}
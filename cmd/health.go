@@ -1,55 +1,498 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/johnfox/claudectx/internal/backup"
+	"github.com/johnfox/claudectx/internal/diff"
 	"github.com/johnfox/claudectx/internal/health"
+	"github.com/johnfox/claudectx/internal/paths"
 	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
 	"github.com/johnfox/claudectx/internal/store"
+	"github.com/johnfox/claudectx/internal/validator"
 )
 
+// onlineClient is the HTTP client used by health --online and --mcp
+// (replaced in tests). It never follows redirects, so the credentials a
+// probe sends only reach the host the profile names.
+var onlineClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Exit codes for the health command, suitable for gating CI jobs
+const (
+	// HealthExitOK means every checked profile is healthy
+	HealthExitOK = 0
+	// HealthExitUnhealthy means a profile has errors (or warnings with --strict)
+	HealthExitUnhealthy = 1
+	// HealthExitFailed means the checks could not run (bad flags, an invalid
+	// rule file, a missing profile)
+	HealthExitFailed = 2
+)
+
+// UnhealthyError reports the profiles that failed their health checks
+type UnhealthyError struct {
+	Profiles []string
+}
+
+func (e *UnhealthyError) Error() string {
+	if len(e.Profiles) == 1 {
+		return fmt.Sprintf("profile %q is unhealthy", e.Profiles[0])
+	}
+	return fmt.Sprintf("%d profiles are unhealthy: %s", len(e.Profiles), strings.Join(e.Profiles, ", "))
+}
+
+// HealthExitCode maps the error returned by Health to a process exit code
+func HealthExitCode(err error) int {
+	var unhealthy *UnhealthyError
+	switch {
+	case err == nil:
+		return HealthExitOK
+	case errors.As(err, &unhealthy):
+		return HealthExitUnhealthy
+	default:
+		return HealthExitFailed
+	}
+}
+
+// HealthOptions holds the parsed arguments for the health command.
+type HealthOptions struct {
+	ProfileName string
+	// All checks every stored profile
+	All bool
+	// Online probes the profile's API endpoint with its credentials
+	Online bool
+	// MCP probes the profile's MCP servers (starting stdio servers)
+	MCP bool
+	// Strict treats warnings as failures
+	Strict bool
+	// Fix applies the available automatic fixes to the stored profile
+	Fix bool
+	// DryRun shows the fixes as a diff without saving them
+	DryRun bool
+	// RulesFile is an extra rule file loaded after the user and repo files
+	RulesFile string
+	Timeout   time.Duration
+}
+
+// ParseHealthArgs parses the arguments following "claudectx health".
+// Valid forms:
+//
+//	health [name | --all] [--online] [--mcp] [--strict] [--rules FILE] [--timeout DURATION]
+//	       [--fix [--dry-run]]
+func ParseHealthArgs(args []string) (HealthOptions, error) {
+	opts := HealthOptions{Timeout: health.DefaultOnlineTimeout}
+
+	parseTimeout := func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q (e.g. 5s)", value)
+		}
+		opts.Timeout = d
+		return nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--all" || a == "-a":
+			opts.All = true
+		case a == "--online":
+			opts.Online = true
+		case a == "--mcp":
+			opts.MCP = true
+		case a == "--strict":
+			opts.Strict = true
+		case a == "--fix":
+			opts.Fix = true
+		case a == "--dry-run" || a == "-n":
+			opts.DryRun = true
+		case a == "--rules":
+			if i+1 >= len(args) {
+				return HealthOptions{}, errors.New("--rules requires a file")
+			}
+			i++
+			opts.RulesFile = args[i]
+		case strings.HasPrefix(a, "--rules="):
+			opts.RulesFile = strings.TrimPrefix(a, "--rules=")
+		case a == "--timeout":
+			if i+1 >= len(args) {
+				return HealthOptions{}, errors.New("--timeout requires a duration")
+			}
+			i++
+			if err := parseTimeout(args[i]); err != nil {
+				return HealthOptions{}, err
+			}
+		case strings.HasPrefix(a, "--timeout="):
+			if err := parseTimeout(strings.TrimPrefix(a, "--timeout=")); err != nil {
+				return HealthOptions{}, err
+			}
+		case strings.HasPrefix(a, "-"):
+			return HealthOptions{}, fmt.Errorf("unknown health flag %q", a)
+		default:
+			if opts.ProfileName != "" {
+				return HealthOptions{}, errors.New("only one profile name may be given")
+			}
+			opts.ProfileName = a
+		}
+	}
+
+	if opts.All && opts.ProfileName != "" {
+		return HealthOptions{}, errors.New("--all cannot be combined with a profile name")
+	}
+	if opts.DryRun && !opts.Fix {
+		return HealthOptions{}, errors.New("--dry-run requires --fix")
+	}
+
+	return opts, nil
+}
+
 // Health checks the health of a profile
 func Health(args []string) error {
+	opts, err := ParseHealthArgs(args)
+	if err != nil {
+		return err
+	}
+
 	s, err := store.NewStore()
 	if err != nil {
 		return err
 	}
 
+	return CheckHealth(s, opts)
+}
+
+// CheckHealth runs the health checks described by opts. It returns an
+// *UnhealthyError when a profile fails, and other errors when the checks
+// could not run.
+func CheckHealth(s *store.Store, opts HealthOptions) error {
+	rules, err := loadHealthRules(opts.RulesFile)
+	if err != nil {
+		return err
+	}
+
+	if opts.All {
+		return checkAllProfiles(s, opts, rules)
+	}
+
 	// Determine which profile to check
-	profileName := ""
-	if len(args) > 0 {
-		profileName = args[0]
-	} else {
+	profileName := opts.ProfileName
+	if profileName == "" {
 		// Check current profile
 		current, err := s.GetCurrent()
-		if err != nil {
+		if err != nil || current == "" {
 			return fmt.Errorf("no profile specified and no current profile set")
 		}
 		profileName = current
 	}
 
 	// Load the profile
-	prof, err := s.Load(profileName)
+	prof, err := loadForHealth(s, profileName, opts)
 	if err != nil {
-		return fmt.Errorf("failed to load profile %q: %w", profileName, err)
+		return err
 	}
 
-	// Run health checks
-	report := health.CheckProfile(prof.Name, prof.Settings, prof.ClaudeMD)
+	// Run health checks, fixing what can be fixed first
+	report := checkProfileHealth(prof, opts, rules)
+	if opts.Fix {
+		fixed, err := fixProfile(s, prof, report, opts, rules)
+		if err != nil {
+			return err
+		}
+		if fixed != nil {
+			report = checkProfileHealth(fixed, opts, rules)
+		}
+		fmt.Println()
+	}
 
 	// Display the report
-	displayHealthReport(report)
+	displayHealthReport(report, len(rules.Registry.Rules()) > 0)
+	if !opts.Fix && report.Fixable(rules.Registry) {
+		fmt.Println()
+		printer.Info("Some findings can be fixed automatically: claudectx health %s --fix [--dry-run]", profileName)
+	}
 
 	// Return error if unhealthy
-	if !report.IsHealthy() {
-		return fmt.Errorf("profile %q is unhealthy", profileName)
+	if failsHealth(report, opts.Strict) {
+		return &UnhealthyError{Profiles: []string{profileName}}
 	}
 
 	return nil
 }
 
-// displayHealthReport prints the health report with colored output
-func displayHealthReport(report *health.ProfileHealthReport) {
+// loadForHealth loads a profile to check. When fixes will be saved to the
+// current profile, unsynced live changes are captured first.
+func loadForHealth(s *store.Store, name string, opts HealthOptions) (*profile.Profile, error) {
+	if opts.Fix && !opts.DryRun {
+		if current, err := s.GetCurrent(); err == nil && current == name {
+			syncBeforeModify(s, name)
+		}
+	}
+
+	prof, err := s.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile %q: %w", name, err)
+	}
+	return prof, nil
+}
+
+// fixProfile applies the available fixers to a copy of prof and lists the
+// changes. With --dry-run it prints a diff of the result; otherwise it
+// backs up the stored profile, saves the fixed copy (updating the live
+// configuration if it is the current profile) and returns it. It returns
+// nil when nothing was saved.
+func fixProfile(s *store.Store, prof *profile.Profile, report *health.ProfileHealthReport, opts HealthOptions, rules *health.RuleSet) (*profile.Profile, error) {
+	fixed, err := prof.Clone()
+	if err != nil {
+		return nil, err
+	}
+	in := health.RuleInput{
+		Profile:    fixed.Name,
+		Tags:       fixed.Tags,
+		Settings:   fixed.Settings,
+		ClaudeMD:   fixed.ClaudeMD,
+		MCPServers: fixed.MCPServers,
+	}
+	fixes := health.ApplyFixes(report, &in, rules.Registry)
+	if len(fixes) == 0 {
+		printer.Info("No automatic fixes available for profile %q", prof.Name)
+		return nil, nil
+	}
+	if fixed.Settings != nil {
+		if err := validator.ValidateSettings(fixed.Settings); err != nil {
+			return nil, fmt.Errorf("fixes for profile %q produced invalid settings: %w", prof.Name, err)
+		}
+	}
+
+	verb := "Fixed"
+	if opts.DryRun {
+		verb = "Would fix"
+	}
+	for _, fix := range fixes {
+		fmt.Printf("  %s %s %s\n", verb, printer.Dim("["+fix.Rule+"]"), fix.Description)
+	}
+
+	if opts.DryRun {
+		before, after, err := profileFilesForDiff(prof, fixed)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range []string{"settings.json", "mcp.json"} {
+			if d := diff.Unified(prof.Name+"/"+name, prof.Name+"/"+name+" (fixed)", before[name], after[name], diff.DefaultContext); d != "" {
+				fmt.Println()
+				printDiff(d)
+			}
+		}
+		return nil, nil
+	}
+
+	backupMgr, err := backup.NewManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize backup manager: %w", err)
+	}
+	backupID, err := backupMgr.CreateFromProfile(prof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up profile %q: %w", prof.Name, err)
+	}
+
+	fixed.Touch()
+	if err := s.Save(fixed); err != nil {
+		return nil, fmt.Errorf("failed to save profile %q: %w", fixed.Name, err)
+	}
+	printer.Success("Applied %d fix(es) to profile %q (backup: %s)", len(fixes), fixed.Name, backupID)
+
+	if current, err := s.GetCurrent(); err == nil && current == fixed.Name {
+		if err := reapplyProfile(fixed, prof.ProjectMCPServers); err != nil {
+			restoreProfile(s, prof)
+			return nil, fmt.Errorf("failed to update live configuration, profile %q left unchanged: %w", prof.Name, err)
+		}
+		printer.Info("Applied fixes to the live configuration")
+	}
+
+	return fixed, nil
+}
+
+// profileFilesForDiff renders the settings.json and mcp.json of two
+// profiles as they would be written to disk
+func profileFilesForDiff(before, after *profile.Profile) (map[string]string, map[string]string, error) {
+	render := func(prof *profile.Profile) (map[string]string, error) {
+		files := map[string]string{}
+		if prof.Settings != nil {
+			data, err := json.MarshalIndent(prof.Settings, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode settings: %w", err)
+			}
+			files["settings.json"] = string(data) + "\n"
+		}
+		if len(prof.MCPServers) > 0 {
+			data, err := json.MarshalIndent(prof.MCPServers, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode MCP servers: %w", err)
+			}
+			files["mcp.json"] = string(data) + "\n"
+		}
+		return files, nil
+	}
+
+	b, err := render(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := render(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, a, nil
+}
+
+// printDiff prints a unified diff with removed lines in red and added lines
+// in green
+func printDiff(d string) {
+	for _, line := range strings.Split(strings.TrimSuffix(d, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			fmt.Println(printer.Dim(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(printer.Colorize(line, printer.Cyan))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(printer.Colorize(line, printer.Red))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(printer.Colorize(line, printer.Green))
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+// loadHealthRules loads the user rule file, the nearest repo rule file and
+// the file given with --rules, in that order
+func loadHealthRules(extra string) (*health.RuleSet, error) {
+	var files []string
+	if userFile, err := paths.RulesFile(); err == nil {
+		files = append(files, userFile)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		files = append(files, health.FindRepoRulesFile(cwd))
+	}
+	if extra != "" {
+		if _, err := os.Stat(extra); err != nil {
+			return nil, fmt.Errorf("failed to read rule file: %w", err)
+		}
+		files = append(files, extra)
+	}
+	return health.LoadRuleSet(files...)
+}
+
+// checkProfileHealth runs the built-in checks, the optional live probes and
+// the custom rules against a profile, then applies its suppressions
+func checkProfileHealth(prof *profile.Profile, opts HealthOptions, rules *health.RuleSet) *health.ProfileHealthReport {
+	report := health.CheckProfile(prof.Name, prof.Settings, prof.ClaudeMD)
+	if opts.Online && prof.Settings != nil {
+		report.AddOnline(health.CheckOnline(onlineClient, prof.Settings, opts.Timeout))
+	}
+	if len(prof.MCPServers) > 0 {
+		report.AddMCP(health.CheckMCPServers(prof.MCPServers, health.MCPCheckOptions{
+			Probe:   opts.MCP,
+			Timeout: opts.Timeout,
+			Client:  onlineClient,
+		}))
+	}
+	rules.Apply(report, health.RuleInput{
+		Profile:    prof.Name,
+		Tags:       prof.Tags,
+		Settings:   prof.Settings,
+		ClaudeMD:   prof.ClaudeMD,
+		MCPServers: prof.MCPServers,
+	})
+	report.Suppress(prof.Suppress)
+	return report
+}
+
+// failsHealth reports whether a report should fail the command
+func failsHealth(report *health.ProfileHealthReport, strict bool) bool {
+	return !report.IsHealthy() || (strict && report.TotalWarnings() > 0)
+}
+
+// checkAllProfiles checks every stored profile, printing a compact report
+func checkAllProfiles(s *store.Store, opts HealthOptions, rules *health.RuleSet) error {
+	names, err := s.List()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+	if len(names) == 0 {
+		return errors.New("no profiles found")
+	}
+
+	var failed []string
+	healthy, warned, unhealthy := 0, 0, 0
+	for _, name := range names {
+		// A profile that cannot be loaded or fixed counts as unhealthy, so
+		// the rest are still checked and listed
+		prof, err := loadForHealth(s, name, opts)
+		if err != nil {
+			unhealthy++
+			printer.Error("✗ %s: %v", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		report := checkProfileHealth(prof, opts, rules)
+		if opts.Fix {
+			fixed, err := fixProfile(s, prof, report, opts, rules)
+			if err != nil {
+				unhealthy++
+				printer.Error("✗ %s: %v", name, err)
+				failed = append(failed, name)
+				continue
+			}
+			if fixed != nil {
+				report = checkProfileHealth(fixed, opts, rules)
+			}
+		}
+
+		switch {
+		case !report.IsHealthy():
+			unhealthy++
+			printer.Error("✗ %s: %s", name, report.Summary())
+		case report.TotalWarnings() > 0:
+			warned++
+			printer.Warning("⚠ %s: %s", name, report.Summary())
+		default:
+			healthy++
+			printer.Success("✓ %s: %s", name, report.Summary())
+		}
+		for _, f := range report.Overall.Findings {
+			if f.Severity > health.SeverityInfo {
+				displayFinding(f)
+			}
+		}
+
+		if failsHealth(report, opts.Strict) {
+			failed = append(failed, name)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Checked %d profiles: %d healthy, %d with warnings, %d unhealthy\n",
+		len(names), healthy, warned, unhealthy)
+
+	if len(failed) > 0 {
+		return &UnhealthyError{Profiles: failed}
+	}
+	return nil
+}
+
+// displayHealthReport prints the health report with colored output.
+// The custom rules section is shown when customRules is set.
+func displayHealthReport(report *health.ProfileHealthReport, customRules bool) {
 	fmt.Printf("Health Check for Profile: %s\n", printer.Colorize(report.Profile, printer.Cyan))
 	fmt.Println()
 
@@ -73,6 +516,26 @@ func displayHealthReport(report *health.ProfileHealthReport) {
 	// Environment variables check
 	displayHealthResult("Environment Variables", report.EnvVars)
 
+	// Live API probe
+	if report.Online != nil {
+		displayOnlineResult(*report.Online)
+	}
+
+	// MCP servers
+	for _, server := range report.MCP {
+		displayMCPResult(server)
+	}
+
+	// Custom rules
+	if customRules {
+		displayHealthResult("Custom Rules", report.Rules)
+	}
+
+	if report.Suppressed > 0 {
+		fmt.Println()
+		printer.Info("%d finding(s) suppressed by the profile", report.Suppressed)
+	}
+
 	// Summary
 	if report.TotalWarnings() > 0 {
 		fmt.Println()
@@ -92,13 +555,100 @@ func displayHealthResult(name string, result health.HealthResult) {
 	} else if result.IsValid {
 		if len(result.Warnings) > 0 {
 			printer.Warning("⚠ %s: Valid with warnings", name)
-			for _, warning := range result.Warnings {
-				fmt.Printf("  - %s\n", warning)
-			}
 		} else {
 			printer.Success("✓ %s: Valid", name)
 		}
 	} else {
 		printer.Error("✗ %s: Invalid", name)
 	}
+
+	for _, f := range result.Findings {
+		displayFinding(f)
+	}
+}
+
+// displayFinding prints one finding with its severity and fix-it hint
+func displayFinding(f health.Finding) {
+	label := f.Severity.String()
+	switch f.Severity {
+	case health.SeverityError:
+		label = printer.Colorize(label, printer.Red)
+	case health.SeverityWarning:
+		label = printer.Colorize(label, printer.Yellow)
+	default:
+		label = printer.Dim(label)
+	}
+
+	fmt.Printf("  - [%s] %s\n", label, f.Message)
+	if f.Hint != "" {
+		fmt.Printf("    %s %s\n", printer.Dim("fix:"), f.Hint)
+	}
+}
+
+// displayOnlineResult prints the live API probe result
+func displayOnlineResult(result health.OnlineResult) {
+	status := ""
+	if result.Status != 0 {
+		status = fmt.Sprintf(" (HTTP %d, %s)", result.Status, result.Latency.Round(time.Millisecond))
+	}
+
+	switch {
+	case result.Error != nil:
+		printer.Error("✗ API Connectivity: Failed%s", status)
+	case len(result.Warnings) > 0:
+		printer.Warning("⚠ API Connectivity: Valid with warnings%s", status)
+	case result.Status != 0:
+		printer.Success("✓ API Connectivity: OK%s", status)
+	default:
+		printer.Info("- API Connectivity: skipped")
+	}
+
+	for _, f := range result.Findings {
+		if f.Rule == health.RuleOnlineOK {
+			continue
+		}
+		displayFinding(f)
+	}
+	if result.Endpoint != "" {
+		fmt.Printf("  %s %s\n", printer.Dim("endpoint:"), result.Endpoint)
+	}
+	if result.AuthSource != "" {
+		fmt.Printf("  %s %s\n", printer.Dim("credentials:"), result.AuthSource)
+	}
+}
+
+// displayMCPResult prints one MCP server's result
+func displayMCPResult(result health.MCPServerResult) {
+	name := fmt.Sprintf("MCP %s (%s)", result.Name, result.Transport)
+
+	detail := ""
+	if result.Probed && result.IsValid && len(result.Warnings) == 0 {
+		var parts []string
+		if result.ServerName != "" {
+			parts = append(parts, strings.TrimSpace(result.ServerName+" "+result.ServerVersion))
+		}
+		if result.ToolCount >= 0 {
+			parts = append(parts, fmt.Sprintf("%d tools", result.ToolCount))
+		}
+		parts = append(parts, result.Latency.Round(time.Millisecond).String())
+		detail = " (" + strings.Join(parts, ", ") + ")"
+	}
+
+	switch {
+	case result.Error != nil:
+		printer.Error("✗ %s: %s", name, result.Error.Message)
+	case len(result.Warnings) > 0:
+		printer.Warning("⚠ %s: Valid with warnings", name)
+	case result.Probed:
+		printer.Success("✓ %s: OK%s", name, detail)
+	default:
+		printer.Success("✓ %s: Valid", name)
+	}
+
+	for _, f := range result.Findings {
+		if f.Rule == health.RuleMCPOK {
+			continue
+		}
+		displayFinding(f)
+	}
 }
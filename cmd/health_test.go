@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/backup"
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+func TestParseHealthArgs(t *testing.T) {
+	opts, err := ParseHealthArgs([]string{"work", "--online", "--mcp", "--timeout", "3s"})
+	if err != nil {
+		t.Fatalf("ParseHealthArgs failed: %v", err)
+	}
+	if opts.ProfileName != "work" || !opts.Online || !opts.MCP || opts.Timeout != 3*time.Second {
+		t.Errorf("ParseHealthArgs() = %+v", opts)
+	}
+
+	opts, err = ParseHealthArgs([]string{"--all", "--strict", "--rules=ci.json"})
+	if err != nil {
+		t.Fatalf("ParseHealthArgs failed: %v", err)
+	}
+	if !opts.All || !opts.Strict || opts.RulesFile != "ci.json" {
+		t.Errorf("ParseHealthArgs() = %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"--dry-run"},
+		{"--all", "work"},
+		{"--rules"},
+		{"--timeout"},
+		{"--timeout=soon"},
+		{"a", "b"},
+		{"--bogus"},
+	} {
+		if _, err := ParseHealthArgs(args); err == nil {
+			t.Errorf("ParseHealthArgs(%v): expected error", args)
+		}
+	}
+}
+
+func TestCheckHealth_Online(t *testing.T) {
+	s, _ := setupRunTest(t)
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	orig := onlineClient
+	onlineClient = server.Client()
+	t.Cleanup(func() { onlineClient = orig })
+
+	prof := profile.NewProfile("proxy")
+	prof.Settings.Model = "sonnet"
+	prof.Settings.Env = map[string]string{
+		"ANTHROPIC_BASE_URL":   server.URL,
+		"ANTHROPIC_AUTH_TOKEN": "tok",
+	}
+	saveProfile(t, s, prof)
+
+	opts := HealthOptions{ProfileName: "proxy", Online: true, Timeout: time.Second}
+	if err := CheckHealth(s, opts); err != nil {
+		t.Fatalf("expected healthy profile, got %v", err)
+	}
+
+	status = http.StatusUnauthorized
+	if err := CheckHealth(s, opts); err == nil {
+		t.Error("expected auth failure to make the profile unhealthy")
+	}
+
+	// Without --online the endpoint is not contacted
+	opts.Online = false
+	if err := CheckHealth(s, opts); err != nil {
+		t.Errorf("offline check should pass, got %v", err)
+	}
+}
+
+func TestCheckHealth_MCPServers(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	prof := profile.NewProfile("tools")
+	prof.Settings.Model = "sonnet"
+	prof.Settings.Env = map[string]string{"X": "1"}
+	prof.MCPServers = mcpconfig.MCPServers{
+		"broken": {Command: "definitely-not-a-real-mcp-binary"},
+	}
+	saveProfile(t, s, prof)
+
+	// Static checks run without --mcp
+	if err := CheckHealth(s, HealthOptions{ProfileName: "tools"}); err == nil {
+		t.Error("expected unresolvable MCP command to make the profile unhealthy")
+	}
+
+	prof.MCPServers = mcpconfig.MCPServers{"shell": {Command: "sh", Args: []string{"-c", "exit 1"}}}
+	saveProfile(t, s, prof)
+
+	if err := CheckHealth(s, HealthOptions{ProfileName: "tools"}); err != nil {
+		t.Errorf("static checks should pass, got %v", err)
+	}
+	if err := CheckHealth(s, HealthOptions{ProfileName: "tools", MCP: true, Timeout: time.Second}); err == nil {
+		t.Error("expected failed handshake with --mcp")
+	}
+
+	// --online only probes the API, so the server is not started
+	if err := CheckHealth(s, HealthOptions{ProfileName: "tools", Online: true, Timeout: time.Second}); err != nil {
+		t.Errorf("--online should not start MCP servers, got %v", err)
+	}
+}
+
+func TestCheckHealth_RulesAndSuppressions(t *testing.T) {
+	s, home := setupRunTest(t)
+
+	rules := `{"rules": [{"id": "no-bash-wildcard", "severity": "error",
+		"path": "permissions.allow", "notContains": ["Bash(*)"]}]}`
+	if err := os.WriteFile(filepath.Join(home, ".claude", "claudectx-rules.json"), []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prof := profile.NewProfile("loose")
+	prof.Settings.Model = "sonnet"
+	prof.Settings.Env = map[string]string{"X": "1"}
+	prof.Settings.Permissions = &config.Permissions{Allow: []string{"Bash(*)"}}
+	saveProfile(t, s, prof)
+
+	err := CheckHealth(s, HealthOptions{ProfileName: "loose"})
+	if HealthExitCode(err) != HealthExitUnhealthy {
+		t.Fatalf("expected custom rule to fail the profile, got %v", err)
+	}
+
+	prof.Suppress = []string{"no-bash-wildcard"}
+	saveProfile(t, s, prof)
+	if err := CheckHealth(s, HealthOptions{ProfileName: "loose"}); err != nil {
+		t.Errorf("suppressed rule should not fail the profile, got %v", err)
+	}
+}
+
+func TestCheckHealth_AllAndExitCodes(t *testing.T) {
+	s, home := setupRunTest(t)
+
+	good := profile.NewProfile("good")
+	good.Settings.Model = "sonnet"
+	good.Settings.Env = map[string]string{"X": "1"}
+	saveProfile(t, s, good)
+
+	warned := profile.NewProfile("warned")
+	warned.Settings.Model = "sonnet"
+	saveProfile(t, s, warned)
+
+	if err := CheckHealth(s, HealthOptions{All: true}); err != nil {
+		t.Fatalf("warnings should pass without --strict, got %v", err)
+	}
+
+	err := CheckHealth(s, HealthOptions{All: true, Strict: true})
+	var unhealthy *UnhealthyError
+	if !errors.As(err, &unhealthy) || len(unhealthy.Profiles) != 1 || unhealthy.Profiles[0] != "warned" {
+		t.Fatalf("expected only 'warned' to fail with --strict, got %v", err)
+	}
+	if HealthExitCode(err) != HealthExitUnhealthy {
+		t.Errorf("exit code = %d, want %d", HealthExitCode(err), HealthExitUnhealthy)
+	}
+
+	// Problems running the checks are distinguished from unhealthy profiles
+	bad := filepath.Join(home, "bad-rules.json")
+	os.WriteFile(bad, []byte(`{"rules": [{"id": "x"}]}`), 0644)
+	for _, opts := range []HealthOptions{
+		{All: true, RulesFile: bad},
+		{All: true, RulesFile: filepath.Join(home, "missing.json")},
+		{ProfileName: "nope"},
+	} {
+		if code := HealthExitCode(CheckHealth(s, opts)); code != HealthExitFailed {
+			t.Errorf("CheckHealth(%+v) exit code = %d, want %d", opts, code, HealthExitFailed)
+		}
+	}
+	if HealthExitCode(nil) != HealthExitOK {
+		t.Error("nil error should map to HealthExitOK")
+	}
+}
+
+func TestCheckHealth_Fix(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	prof := profile.NewProfile("stale")
+	prof.Settings.Model = "claude-3-opus-20240229"
+	prof.Settings.Env = map[string]string{"X": "1", "EMPTY": ""}
+	saveProfile(t, s, prof)
+
+	// --dry-run leaves the stored profile alone
+	if err := CheckHealth(s, HealthOptions{ProfileName: "stale", Fix: true, DryRun: true}); err != nil {
+		t.Fatalf("CheckHealth(--fix --dry-run) failed: %v", err)
+	}
+	loaded, _ := s.Load("stale")
+	if loaded.Settings.Model != "claude-3-opus-20240229" || len(loaded.Settings.Env) != 2 {
+		t.Fatalf("dry run changed the profile: %+v", loaded.Settings)
+	}
+
+	if err := CheckHealth(s, HealthOptions{ProfileName: "stale", Fix: true, Strict: true}); err != nil {
+		t.Fatalf("fixed profile should pass --strict, got %v", err)
+	}
+	loaded, _ = s.Load("stale")
+	if loaded.Settings.Model != "opus" || len(loaded.Settings.Env) != 1 {
+		t.Errorf("fixes not saved: %+v", loaded.Settings)
+	}
+
+	// The original profile is kept in a backup
+	backupMgr, _ := backup.NewManager()
+	backups, _ := backupMgr.List()
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, got %d", len(backups))
+	}
+	saved, err := backupMgr.LoadProfile(backups[0].ID, "saved")
+	if err != nil || saved.Settings.Model != "claude-3-opus-20240229" {
+		t.Errorf("backup should hold the original settings, got %+v (%v)", saved, err)
+	}
+}
+
+func TestCheckHealth_FixApplyFailureKeepsProfile(t *testing.T) {
+	s, home := setupRunTest(t)
+
+	prof := profile.NewProfile("stale")
+	prof.ClaudeMD = "# Stale\n"
+	prof.Settings.Model = "claude-3-opus-20240229"
+	prof.Settings.Env = map[string]string{"X": "1"}
+	saveProfile(t, s, prof)
+	if err := SwitchProfile(s, "stale"); err != nil {
+		t.Fatal(err)
+	}
+	blockLiveClaudeMD(t, home)
+
+	if err := CheckHealth(s, HealthOptions{ProfileName: "stale", Fix: true}); err == nil {
+		t.Fatal("expected error when the live configuration cannot be updated")
+	}
+	loaded, _ := s.Load("stale")
+	if loaded.Settings.Model != "claude-3-opus-20240229" {
+		t.Errorf("model = %q, want the fix rolled back", loaded.Settings.Model)
+	}
+}
+
+func TestCheckHealth_AllReportsUnloadableProfile(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	for _, name := range []string{"broken", "good"} {
+		prof := profile.NewProfile(name)
+		prof.Settings.Model = "sonnet"
+		prof.Settings.Env = map[string]string{"X": "1"}
+		saveProfile(t, s, prof)
+	}
+	settingsPath, _ := paths.ProfileFile("broken", "settings.json")
+	writeSettings(t, settingsPath, `{not json`)
+
+	err := CheckHealth(s, HealthOptions{All: true})
+	var unhealthy *UnhealthyError
+	if !errors.As(err, &unhealthy) || len(unhealthy.Profiles) != 1 || unhealthy.Profiles[0] != "broken" {
+		t.Fatalf("expected only 'broken' to be unhealthy, got %v", err)
+	}
+	if HealthExitCode(err) != HealthExitUnhealthy {
+		t.Errorf("exit code = %d, want %d", HealthExitCode(err), HealthExitUnhealthy)
+	}
+}
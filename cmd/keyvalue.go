@@ -0,0 +1,535 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/keypath"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
+	"github.com/johnfox/claudectx/internal/store"
+	"github.com/johnfox/claudectx/internal/validator"
+)
+
+// Key operations supported by get/set/unset
+const (
+	KeyGet    = "get"
+	KeySet    = "set"
+	KeyAppend = "append"
+	KeyRemove = "remove"
+	KeyUnset  = "unset"
+)
+
+// Value decoding modes for set
+const (
+	ValueAuto   = "auto"
+	ValueJSON   = "json"
+	ValueString = "string"
+)
+
+// KeyOptions holds the parsed arguments for get, set and unset.
+type KeyOptions struct {
+	// Target is a profile name or glob pattern; ignored when All is set
+	Target    string
+	All       bool
+	Op        string
+	Path      string
+	Value     string
+	ValueMode string
+}
+
+// ParseKeyArgs parses the arguments following "claudectx get|set|unset".
+// Valid forms:
+//
+//	get   <profile|GLOB|--all> <path>
+//	set   <profile|GLOB|--all> <path> <value>
+//	set   <profile|GLOB|--all> <path>=<value>
+//	set   <profile|GLOB|--all> <path>+=<value>   (append to list)
+//	set   <profile|GLOB|--all> <path>-=<value>   (remove from list)
+//	unset <profile|GLOB|--all> <path>
+//
+// Paths starting with "mcp." address MCP servers and paths starting with
+// "meta." address profile.json (description, tags, owner); anything else
+// addresses settings.json.
+//
+// set also accepts --json (value is JSON) and --string (value is a literal
+// string). By default a value that parses as JSON is used as JSON.
+func ParseKeyArgs(command string, args []string) (KeyOptions, error) {
+	opts := KeyOptions{Op: command, ValueMode: ValueAuto}
+
+	var positional []string
+	for _, a := range args {
+		switch a {
+		case "--all", "-A":
+			opts.All = true
+		case "--json":
+			opts.ValueMode = ValueJSON
+		case "--string":
+			opts.ValueMode = ValueString
+		default:
+			if strings.HasPrefix(a, "--") {
+				return KeyOptions{}, fmt.Errorf("unknown %s flag %q", command, a)
+			}
+			positional = append(positional, a)
+		}
+	}
+
+	if !opts.All {
+		if len(positional) == 0 {
+			return KeyOptions{}, errors.New("profile name required")
+		}
+		opts.Target = positional[0]
+		positional = positional[1:]
+	}
+
+	if len(positional) == 0 {
+		return KeyOptions{}, errors.New("key path required")
+	}
+
+	switch command {
+	case KeyGet, KeyUnset:
+		if len(positional) != 1 {
+			return KeyOptions{}, fmt.Errorf("%s takes exactly one key path", command)
+		}
+		opts.Path = positional[0]
+
+	case KeySet:
+		switch len(positional) {
+		case 1:
+			expr := positional[0]
+			idx := strings.Index(expr, "=")
+			if idx <= 0 {
+				return KeyOptions{}, errors.New("value required (use: set <profile> <path> <value>)")
+			}
+			opts.Path, opts.Value = expr[:idx], expr[idx+1:]
+			switch {
+			case strings.HasSuffix(opts.Path, "+"):
+				opts.Op = KeyAppend
+				opts.Path = strings.TrimSuffix(opts.Path, "+")
+			case strings.HasSuffix(opts.Path, "-"):
+				opts.Op = KeyRemove
+				opts.Path = strings.TrimSuffix(opts.Path, "-")
+			}
+		case 2:
+			opts.Path, opts.Value = positional[0], positional[1]
+		default:
+			return KeyOptions{}, errors.New("too many arguments (quote values containing spaces)")
+		}
+
+	default:
+		return KeyOptions{}, fmt.Errorf("unknown key command %q", command)
+	}
+
+	if _, err := keypath.Parse(opts.Path); err != nil {
+		return KeyOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// GetValue prints the value at a key path for one or more profiles
+func GetValue(s *store.Store, opts KeyOptions) error {
+	names, err := resolveProfiles(s, opts.Target, opts.All)
+	if err != nil {
+		return err
+	}
+
+	path, err := keypath.Parse(opts.Path)
+	if err != nil {
+		return err
+	}
+
+	multi := len(names) > 1 || opts.All || isGlob(opts.Target)
+	for _, name := range names {
+		prof, err := s.Load(name)
+		if err != nil {
+			return fmt.Errorf("failed to load profile %q: %w", name, err)
+		}
+
+		value, found, err := profileValue(prof, path)
+		if err != nil {
+			return err
+		}
+
+		if !multi {
+			if !found {
+				return fmt.Errorf("%s is not set in profile %q", opts.Path, name)
+			}
+			fmt.Println(formatValue(value))
+			continue
+		}
+
+		if found {
+			fmt.Printf("%s: %s\n", name, formatValue(value))
+		} else {
+			fmt.Printf("%s: %s\n", name, printer.Dim("(unset)"))
+		}
+	}
+
+	return nil
+}
+
+// SetValue applies a set, append, remove or unset operation to one or more
+// profiles. All profiles are validated before any is written, and before
+// unsynced live changes are captured into the current profile, so a
+// rejected change leaves everything on disk as it was. When the current
+// profile is modified the live configuration is updated too.
+func SetValue(s *store.Store, opts KeyOptions) error {
+	names, err := resolveProfiles(s, opts.Target, opts.All)
+	if err != nil {
+		return err
+	}
+
+	path, err := keypath.Parse(opts.Path)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.GetCurrent()
+	if err != nil {
+		return fmt.Errorf("failed to get current profile: %w", err)
+	}
+
+	// Try the change on the stored profiles first; syncing is only worth
+	// doing once the key path and value are known to be valid
+	for _, name := range names {
+		prof, err := s.Load(name)
+		if err != nil {
+			return fmt.Errorf("failed to load profile %q: %w", name, err)
+		}
+		if _, err := modifyProfile(prof, path, opts); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+
+	for _, name := range names {
+		if name == current {
+			syncBeforeModify(s, name)
+		}
+	}
+
+	var updated []*profile.Profile
+	var original *profile.Profile
+	var previous mcpconfig.ProjectServers
+	for _, name := range names {
+		prof, err := s.Load(name)
+		if err != nil {
+			return fmt.Errorf("failed to load profile %q: %w", name, err)
+		}
+		if name == current {
+			if original, err = prof.Clone(); err != nil {
+				return fmt.Errorf("failed to copy profile %q: %w", name, err)
+			}
+			previous = prof.ProjectMCPServers.Clone()
+		}
+
+		changed, err := modifyProfile(prof, path, opts)
+		if err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		if !changed {
+			printer.Info("Profile %q unchanged", name)
+			continue
+		}
+		updated = append(updated, prof)
+	}
+
+	for _, prof := range updated {
+		prof.Touch()
+		if err := s.Save(prof); err != nil {
+			return fmt.Errorf("failed to save profile %q: %w", prof.Name, err)
+		}
+		printer.Success("Updated %s in profile %q", opts.Path, prof.Name)
+
+		if prof.Name == current {
+			if err := reapplyProfile(prof, previous); err != nil {
+				restoreProfile(s, original)
+				return fmt.Errorf("failed to update live configuration, profile %q left unchanged: %w", prof.Name, err)
+			}
+			printer.Info("Applied change to the live configuration")
+		}
+	}
+
+	return nil
+}
+
+// syncBeforeModify captures unsynced live changes to the current profile
+// before it is modified, so reapplying it afterwards doesn't discard them
+func syncBeforeModify(s *store.Store, name string) {
+	if changed, err := hasConfigChanged(s, name); err == nil && changed {
+		printer.Info("Syncing active configuration to profile %q first...", name)
+		if err := syncCurrentProfile(s, name); err != nil {
+			printer.Warning("Warning: Failed to sync active configuration: %v", err)
+		}
+	}
+}
+
+// resolveProfiles expands a profile name, glob pattern or --all into the
+// matching stored profile names
+func resolveProfiles(s *store.Store, target string, all bool) ([]string, error) {
+	if !all && !isGlob(target) {
+		if err := profile.ValidateProfileName(target); err != nil {
+			return nil, fmt.Errorf("invalid profile name: %w", err)
+		}
+		if !s.Exists(target) {
+			return nil, fmt.Errorf("profile %q does not exist", target)
+		}
+		return []string{target}, nil
+	}
+
+	profiles, err := s.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	sort.Strings(profiles)
+
+	if all {
+		if len(profiles) == 0 {
+			return nil, errors.New("no profiles found")
+		}
+		return profiles, nil
+	}
+
+	var matched []string
+	for _, name := range profiles {
+		ok, err := filepath.Match(target, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", target, err)
+		}
+		if ok {
+			matched = append(matched, name)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no profiles match %q", target)
+	}
+	return matched, nil
+}
+
+// isGlob reports whether a profile argument is a glob pattern
+func isGlob(target string) bool {
+	return strings.ContainsAny(target, "*?[")
+}
+
+// editableMetadata lists the profile.json fields that can be set by key path
+var editableMetadata = []string{"description", "tags", "owner", "suppress"}
+
+// splitProfilePath returns the document a key path addresses ("settings",
+// "mcp" or "meta") and the path within it
+func splitProfilePath(path []string) (string, []string) {
+	switch path[0] {
+	case "mcp", "meta":
+		return path[0], path[1:]
+	}
+	return "settings", path
+}
+
+// profileDocument returns the JSON document for one part of a profile
+func profileDocument(prof *profile.Profile, part string) (map[string]any, error) {
+	var data []byte
+	var err error
+	switch part {
+	case "mcp":
+		data, err = json.Marshal(prof.MCPServers)
+	case "meta":
+		data, err = json.Marshal(prof.Metadata())
+	default:
+		data, err = json.Marshal(prof.Settings)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", part, err)
+	}
+
+	doc := map[string]any{}
+	if err := unmarshalJSON(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", part, err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	return doc, nil
+}
+
+// profileValue returns the value at path within a profile
+func profileValue(prof *profile.Profile, path []string) (any, bool, error) {
+	part, rest := splitProfilePath(path)
+	doc, err := profileDocument(prof, part)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rest) == 0 {
+		return doc, true, nil
+	}
+	value, found := keypath.Get(doc, rest)
+	return value, found, nil
+}
+
+// modifyProfile applies a key operation to a profile in memory, validating
+// the result. It reports whether the profile changed.
+func modifyProfile(prof *profile.Profile, path []string, opts KeyOptions) (bool, error) {
+	part, rest := splitProfilePath(path)
+	if len(rest) == 0 {
+		return false, fmt.Errorf("cannot modify %q as a whole; specify a key inside it", part)
+	}
+	if part == "meta" && !containsString(editableMetadata, rest[0]) {
+		return false, fmt.Errorf("meta.%s is read-only (editable: %s)", rest[0], strings.Join(editableMetadata, ", "))
+	}
+
+	candidates, err := decodeValues(opts.Value, opts.ValueMode)
+	if err != nil && opts.Op != KeyUnset {
+		return false, err
+	}
+	if opts.Op == KeyUnset {
+		candidates = []any{nil}
+	}
+
+	// Try each interpretation of the value in turn; a value such as 8080
+	// is tried as a number first and falls back to the string "8080" if
+	// the field requires a string (e.g. env values)
+	var lastErr error
+	for _, value := range candidates {
+		changed, err := applyKeyOp(prof, part, rest, opts.Op, value)
+		if err == nil {
+			return changed, nil
+		}
+		lastErr = err
+	}
+	return false, lastErr
+}
+
+// applyKeyOp performs one key operation against a profile part
+func applyKeyOp(prof *profile.Profile, part string, path []string, op string, value any) (bool, error) {
+	doc, err := profileDocument(prof, part)
+	if err != nil {
+		return false, err
+	}
+	before, _ := json.Marshal(doc)
+
+	switch op {
+	case KeySet:
+		err = keypath.Set(doc, path, value)
+	case KeyAppend:
+		err = keypath.Append(doc, path, value)
+	case KeyRemove:
+		_, err = keypath.Remove(doc, path, value)
+	case KeyUnset:
+		_, err = keypath.Delete(doc, path)
+	default:
+		err = fmt.Errorf("unknown operation %q", op)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	after, err := json.Marshal(doc)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode %s: %w", part, err)
+	}
+	if bytes.Equal(before, after) {
+		return false, nil
+	}
+
+	if part == "meta" {
+		var meta profile.Metadata
+		if err := json.Unmarshal(after, &meta); err != nil {
+			return false, fmt.Errorf("invalid metadata: %w", err)
+		}
+		if err := profile.ValidateMetadata(meta); err != nil {
+			return false, err
+		}
+		prof.Description = meta.Description
+		prof.Tags = meta.Tags
+		prof.Owner = meta.Owner
+		prof.Suppress = meta.Suppress
+		return true, nil
+	}
+
+	if part == "mcp" {
+		var servers mcpconfig.MCPServers
+		if err := json.Unmarshal(after, &servers); err != nil {
+			return false, fmt.Errorf("invalid MCP server configuration: %w", err)
+		}
+		// Check the changed server as mcp add and mcp import do; other
+		// servers are left to health, so one broken server does not block
+		// edits to the rest
+		if server, ok := servers[path[0]]; ok {
+			if err := validator.ValidateMCPServer(server); err != nil {
+				return false, fmt.Errorf("invalid MCP server %q: %w", path[0], err)
+			}
+		}
+		if servers == nil {
+			servers = make(mcpconfig.MCPServers)
+		}
+		prof.MCPServers = servers
+		return true, nil
+	}
+
+	var settings config.Settings
+	if err := json.Unmarshal(after, &settings); err != nil {
+		return false, fmt.Errorf("invalid settings: %w", err)
+	}
+	if err := validator.ValidateSettings(&settings); err != nil {
+		return false, err
+	}
+	prof.Settings = &settings
+	return true, nil
+}
+
+// decodeValues returns the interpretations of a command-line value to try,
+// in order of preference
+func decodeValues(raw, mode string) ([]any, error) {
+	switch mode {
+	case ValueString:
+		return []any{raw}, nil
+	case ValueJSON:
+		var value any
+		if err := unmarshalJSON([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON value: %w", err)
+		}
+		return []any{value}, nil
+	default:
+		var value any
+		if err := unmarshalJSON([]byte(raw), &value); err == nil {
+			if _, isString := value.(string); !isString {
+				return []any{value, raw}, nil
+			}
+			return []any{value}, nil
+		}
+		return []any{raw}, nil
+	}
+}
+
+// unmarshalJSON decodes data like json.Unmarshal, but keeps numbers as
+// json.Number so that integers too large for a float64 round-trip exactly
+func unmarshalJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// formatValue renders a value for get output: strings are printed as-is,
+// anything else as indented JSON
+func formatValue(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+func TestParseKeyArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		want    KeyOptions
+		wantErr bool
+	}{
+		{
+			name:    "get",
+			command: "get",
+			args:    []string{"work", "env.ANTHROPIC_BASE_URL"},
+			want:    KeyOptions{Target: "work", Op: KeyGet, Path: "env.ANTHROPIC_BASE_URL", ValueMode: ValueAuto},
+		},
+		{
+			name:    "set two args",
+			command: "set",
+			args:    []string{"work", "model", "opus"},
+			want:    KeyOptions{Target: "work", Op: KeySet, Path: "model", Value: "opus", ValueMode: ValueAuto},
+		},
+		{
+			name:    "set assignment",
+			command: "set",
+			args:    []string{"work", "env.FOO=a=b"},
+			want:    KeyOptions{Target: "work", Op: KeySet, Path: "env.FOO", Value: "a=b", ValueMode: ValueAuto},
+		},
+		{
+			name:    "append",
+			command: "set",
+			args:    []string{"work", "permissions.allow+=Bash(git *)"},
+			want:    KeyOptions{Target: "work", Op: KeyAppend, Path: "permissions.allow", Value: "Bash(git *)", ValueMode: ValueAuto},
+		},
+		{
+			name:    "remove with --all",
+			command: "set",
+			args:    []string{"--all", "permissions.deny-=WebFetch", "--string"},
+			want:    KeyOptions{All: true, Op: KeyRemove, Path: "permissions.deny", Value: "WebFetch", ValueMode: ValueString},
+		},
+		{
+			name:    "unset",
+			command: "unset",
+			args:    []string{"client-*", "env.FOO"},
+			want:    KeyOptions{Target: "client-*", Op: KeyUnset, Path: "env.FOO", ValueMode: ValueAuto},
+		},
+		{name: "missing path", command: "get", args: []string{"work"}, wantErr: true},
+		{name: "missing value", command: "set", args: []string{"work", "model"}, wantErr: true},
+		{name: "too many", command: "set", args: []string{"work", "model", "a", "b"}, wantErr: true},
+		{name: "unknown flag", command: "get", args: []string{"work", "model", "--raw"}, wantErr: true},
+		{name: "bad path", command: "get", args: []string{"work", "env..X"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyArgs(tt.command, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeyArgs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetValue_SettingsAndExtras(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	steps := [][]string{
+		{"work", "model", "opus"},
+		{"work", "env.PORT", "8080"},
+		{"work", "permissions.allow+=Bash(git *)"},
+		{"work", "effortLevel", "high"},
+		{"work", "includeCoAuthoredBy", "false"},
+	}
+	for _, args := range steps {
+		opts, err := ParseKeyArgs("set", args)
+		if err != nil {
+			t.Fatalf("ParseKeyArgs(%v) failed: %v", args, err)
+		}
+		if err := SetValue(s, opts); err != nil {
+			t.Fatalf("SetValue(%v) failed: %v", args, err)
+		}
+	}
+
+	prof, err := s.Load("work")
+	if err != nil {
+		t.Fatalf("failed to load profile: %v", err)
+	}
+	if prof.Settings.Model != "opus" {
+		t.Errorf("model = %q, want opus", prof.Settings.Model)
+	}
+	if prof.Settings.Env["PORT"] != "8080" {
+		t.Errorf("env.PORT = %q, want string 8080", prof.Settings.Env["PORT"])
+	}
+	if prof.Settings.Permissions == nil || !reflect.DeepEqual(prof.Settings.Permissions.Allow, []string{"Bash(git *)"}) {
+		t.Errorf("permissions = %+v", prof.Settings.Permissions)
+	}
+	extras := prof.Settings.Extras()
+	if string(extras["effortLevel"]) != `"high"` || string(extras["includeCoAuthoredBy"]) != "false" {
+		t.Errorf("extras = %s, %s", extras["effortLevel"], extras["includeCoAuthoredBy"])
+	}
+
+	unset, _ := ParseKeyArgs("unset", []string{"work", "env.PORT"})
+	if err := SetValue(s, unset); err != nil {
+		t.Fatalf("unset failed: %v", err)
+	}
+	prof, _ = s.Load("work")
+	if _, ok := prof.Settings.Env["PORT"]; ok {
+		t.Error("expected env.PORT to be removed")
+	}
+}
+
+func TestSetValue_KeepsLargeIntegers(t *testing.T) {
+	s, _ := setupRunTest(t)
+	settings := &config.Settings{}
+	if err := settings.UnmarshalJSON([]byte(`{"model":"opus","feedbackSurveyState":{"lastShownTime":1754089004345678901}}`)); err != nil {
+		t.Fatalf("failed to parse settings: %v", err)
+	}
+	work := profile.NewProfile("work")
+	work.Settings = settings
+	saveProfile(t, s, work)
+
+	for _, args := range [][]string{
+		{"work", "model", "sonnet"},
+		{"work", "feedbackSurveyState.count", "9007199254740993"},
+	} {
+		opts, err := ParseKeyArgs("set", args)
+		if err != nil {
+			t.Fatalf("ParseKeyArgs(%q) failed: %v", args, err)
+		}
+		if err := SetValue(s, opts); err != nil {
+			t.Fatalf("SetValue(%q) failed: %v", args, err)
+		}
+	}
+
+	prof, _ := s.Load("work")
+	if prof.Settings.Model != "sonnet" {
+		t.Errorf("model = %q, want sonnet", prof.Settings.Model)
+	}
+	var state bytes.Buffer
+	if err := json.Compact(&state, prof.Settings.Extras()["feedbackSurveyState"]); err != nil {
+		t.Fatalf("invalid feedbackSurveyState: %v", err)
+	}
+	if got, want := state.String(), `{"lastShownTime":1754089004345678901,"count":9007199254740993}`; got != want {
+		t.Errorf("feedbackSurveyState = %s, want %s", got, want)
+	}
+}
+
+func TestSetValue_InvalidValueRejected(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	opts, _ := ParseKeyArgs("set", []string{"work", "permissions.allow", "--json", `{"a":1}`})
+	if err := SetValue(s, opts); err == nil {
+		t.Fatal("expected error for object in permissions.allow")
+	}
+}
+
+func TestSetValue_MCPServer(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.MCPServers = mcpconfig.MCPServers{"github": {Command: "npx", Args: []string{"old"}}}
+	saveProfile(t, s, work)
+
+	opts, _ := ParseKeyArgs("set", []string{"work", "mcp.github.args", "--json", `["-y","@github/mcp"]`})
+	if err := SetValue(s, opts); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+
+	prof, _ := s.Load("work")
+	if got := prof.MCPServers["github"].Args; !reflect.DeepEqual(got, []string{"-y", "@github/mcp"}) {
+		t.Errorf("args = %v", got)
+	}
+}
+
+func TestSetValue_MCPServerFields(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.MCPServers = mcpconfig.MCPServers{"github": {Command: "npx"}}
+	saveProfile(t, s, work)
+
+	opts, _ := ParseKeyArgs("set", []string{"work", "mcp.github.timeout", "30000"})
+	if err := SetValue(s, opts); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	prof, _ := s.Load("work")
+	if timeout, err := prof.MCPServers["github"].Timeout(); err != nil || timeout != 30000 {
+		t.Errorf("timeout = %d, %v", timeout, err)
+	}
+
+	// oauth only applies to remote servers
+	opts, _ = ParseKeyArgs("set", []string{"work", "mcp.github.oauth.clientId", "abc"})
+	if err := SetValue(s, opts); err == nil {
+		t.Error("expected error setting oauth on a stdio server")
+	}
+}
+
+func TestSetValue_InvalidMCPServerRejected(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.MCPServers = mcpconfig.MCPServers{"github": {Command: "npx"}}
+	saveProfile(t, s, work)
+
+	for _, args := range [][]string{
+		{"work", "mcp.github.command", ""},
+		{"work", "mcp.foo.args", "--json", `["-y","srv"]`},
+		{"work", "mcp.linear.url", "not-a-url"},
+	} {
+		opts, err := ParseKeyArgs("set", args)
+		if err != nil {
+			t.Fatalf("ParseKeyArgs(%q) failed: %v", args, err)
+		}
+		if err := SetValue(s, opts); err == nil {
+			t.Errorf("SetValue(%q) should fail", args)
+		}
+	}
+
+	opts, _ := ParseKeyArgs("unset", []string{"work", "mcp.github.command"})
+	if err := SetValue(s, opts); err == nil {
+		t.Error("unsetting the only command should fail")
+	}
+
+	prof, _ := s.Load("work")
+	if len(prof.MCPServers) != 1 || prof.MCPServers["github"].Command != "npx" {
+		t.Errorf("MCP servers should be unchanged, got %+v", prof.MCPServers)
+	}
+}
+
+func TestSetValue_GlobAndCurrentProfileLiveUpdate(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("client-a"))
+	saveProfile(t, s, profile.NewProfile("client-b"))
+	saveProfile(t, s, profile.NewProfile("personal"))
+	setCurrentProfile(t, s, "client-a")
+
+	opts, _ := ParseKeyArgs("set", []string{"client-*", "env.DISABLE_TELEMETRY", "1"})
+	if err := SetValue(s, opts); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+
+	for _, name := range []string{"client-a", "client-b"} {
+		prof, _ := s.Load(name)
+		if prof.Settings.Env["DISABLE_TELEMETRY"] != "1" {
+			t.Errorf("%s not updated", name)
+		}
+	}
+	personal, _ := s.Load("personal")
+	if _, ok := personal.Settings.Env["DISABLE_TELEMETRY"]; ok {
+		t.Error("personal should not match the glob")
+	}
+
+	settingsPath, _ := paths.SettingsFile()
+	live, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		t.Fatalf("failed to load live settings: %v", err)
+	}
+	if live.Env["DISABLE_TELEMETRY"] != "1" {
+		t.Error("expected live settings to be updated for the current profile")
+	}
+}
+
+func TestSetValue_RejectedChangeDoesNotSync(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.Settings.Model = "opus"
+	saveProfile(t, s, work)
+	setCurrentProfile(t, s, "work")
+
+	// Unsynced live change that a valid set would capture first
+	settingsPath, _ := paths.SettingsFile()
+	if err := config.SaveSettings(settingsPath, &config.Settings{Model: "sonnet"}); err != nil {
+		t.Fatalf("failed to write live settings: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"set", "work", "permissions.allow", "--json", `{"a":1}`},
+		{"set", "work", "meta.createdAt", "now"},
+		{"unset", "work", "mcp"},
+	} {
+		opts, err := ParseKeyArgs(args[0], args[1:])
+		if err != nil {
+			t.Fatalf("ParseKeyArgs(%q) failed: %v", args, err)
+		}
+		if err := SetValue(s, opts); err == nil {
+			t.Errorf("SetValue(%q) should fail", args)
+		}
+	}
+
+	prof, _ := s.Load("work")
+	if prof.Settings.Model != "opus" {
+		t.Errorf("model = %q, want the stored profile left unsynced", prof.Settings.Model)
+	}
+}
+
+func TestSetValue_ApplyFailureKeepsProfile(t *testing.T) {
+	s, home := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.ClaudeMD = "# Work\n"
+	work.Settings.Model = "opus"
+	saveProfile(t, s, work)
+	if err := SwitchProfile(s, "work"); err != nil {
+		t.Fatal(err)
+	}
+	blockLiveClaudeMD(t, home)
+
+	opts, _ := ParseKeyArgs("set", []string{"work", "model", "sonnet"})
+	if err := SetValue(s, opts); err == nil {
+		t.Fatal("expected error when the live configuration cannot be updated")
+	}
+	prof, _ := s.Load("work")
+	if prof.Settings.Model != "opus" {
+		t.Errorf("model = %q, want the change rolled back", prof.Settings.Model)
+	}
+}
+
+func TestResolveProfiles_NoMatch(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	if _, err := resolveProfiles(s, "client-*", false); err == nil {
+		t.Error("expected error when glob matches nothing")
+	}
+	if _, err := resolveProfiles(s, "missing", false); err == nil {
+		t.Error("expected error for missing profile")
+	}
+}
+
+func TestSetValue_Metadata(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	for _, args := range [][]string{
+		{"work", "meta.description", "Client A via proxy"},
+		{"work", "meta.tags+=client"},
+		{"work", "meta.suppress+=allow-wildcard"},
+	} {
+		opts, _ := ParseKeyArgs("set", args)
+		if err := SetValue(s, opts); err != nil {
+			t.Fatalf("SetValue(%v) failed: %v", args, err)
+		}
+	}
+
+	prof, _ := s.Load("work")
+	if prof.Description != "Client A via proxy" || !reflect.DeepEqual(prof.Tags, []string{"client"}) ||
+		!reflect.DeepEqual(prof.Suppress, []string{"allow-wildcard"}) {
+		t.Errorf("metadata = %+v", prof.Metadata())
+	}
+
+	opts, _ := ParseKeyArgs("set", []string{"work", "meta.useCount", "5"})
+	if err := SetValue(s, opts); err == nil {
+		t.Error("expected error setting read-only metadata")
+	}
+}
@@ -3,13 +3,69 @@ package cmd
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
 	"github.com/johnfox/claudectx/internal/store"
 )
 
+// List sort orders
+const (
+	SortByName   = "name"
+	SortByRecent = "recent"
+	SortByCount  = "count"
+)
+
+// ListOptions holds the parsed arguments for the list command.
+type ListOptions struct {
+	// Tags restricts the list to profiles carrying every given tag
+	Tags []string
+	Sort string
+}
+
+// ParseListArgs parses the arguments following "claudectx -l".
+// Valid forms:
+//
+//	-l [--tag TAG]... [--sort name|recent|count]
+func ParseListArgs(args []string) (ListOptions, error) {
+	opts := ListOptions{Sort: SortByName}
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--tag" || a == "-t":
+			if i+1 >= len(args) {
+				return ListOptions{}, fmt.Errorf("%s requires a tag", a)
+			}
+			i++
+			opts.Tags = append(opts.Tags, args[i])
+		case strings.HasPrefix(a, "--tag="):
+			opts.Tags = append(opts.Tags, strings.TrimPrefix(a, "--tag="))
+		case a == "--sort":
+			if i+1 >= len(args) {
+				return ListOptions{}, fmt.Errorf("--sort requires a value")
+			}
+			i++
+			opts.Sort = args[i]
+		case strings.HasPrefix(a, "--sort="):
+			opts.Sort = strings.TrimPrefix(a, "--sort=")
+		default:
+			return ListOptions{}, fmt.Errorf("unknown list argument %q", a)
+		}
+	}
+
+	switch opts.Sort {
+	case SortByName, SortByRecent, SortByCount:
+	default:
+		return ListOptions{}, fmt.Errorf("unknown sort order %q (valid: name, recent, count)", opts.Sort)
+	}
+
+	return opts, nil
+}
+
 // ListProfiles lists all available profiles, highlighting the current one
-func ListProfiles(s *store.Store) error {
+func ListProfiles(s *store.Store, opts ListOptions) error {
 	profiles, err := s.List()
 	if err != nil {
 		return fmt.Errorf("failed to list profiles: %w", err)
@@ -26,8 +82,10 @@ func ListProfiles(s *store.Store) error {
 		return fmt.Errorf("failed to get current profile: %w", err)
 	}
 
-	// Sort profiles alphabetically
-	sort.Strings(profiles)
+	profiles, err = filterAndSortProfiles(s, profiles, opts)
+	if err != nil {
+		return err
+	}
 
 	// Build highlight map
 	highlightMap := make(map[string]string)
@@ -40,3 +98,50 @@ func ListProfiles(s *store.Store) error {
 
 	return nil
 }
+
+// filterAndSortProfiles applies tag filters and sort order using each
+// profile's profile.json metadata
+func filterAndSortProfiles(s *store.Store, names []string, opts ListOptions) ([]string, error) {
+	metas := make(map[string]profile.Metadata, len(names))
+	var kept []string
+	for _, name := range names {
+		meta, err := s.LoadMetadata(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load metadata for %q: %w", name, err)
+		}
+
+		matches := true
+		for _, tag := range opts.Tags {
+			if !meta.HasTag(tag) {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		metas[name] = meta
+		kept = append(kept, name)
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		a, b := metas[kept[i]], metas[kept[j]]
+		switch opts.Sort {
+		case SortByRecent:
+			if (a.LastUsedAt == nil) != (b.LastUsedAt == nil) {
+				return a.LastUsedAt != nil
+			}
+			if a.LastUsedAt != nil && !a.LastUsedAt.Equal(*b.LastUsedAt) {
+				return a.LastUsedAt.After(*b.LastUsedAt)
+			}
+		case SortByCount:
+			if a.UseCount != b.UseCount {
+				return a.UseCount > b.UseCount
+			}
+		}
+		return kept[i] < kept[j]
+	})
+
+	return kept, nil
+}
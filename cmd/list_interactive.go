@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 
+	"github.com/johnfox/claudectx/internal/backup"
 	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
 	"github.com/johnfox/claudectx/internal/selector"
 	"github.com/johnfox/claudectx/internal/store"
 	"golang.org/x/term"
@@ -23,6 +27,12 @@ func ListProfilesInteractive(s *store.Store) error {
 		return nil
 	}
 
+	// Check if we're in a TTY
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		// Not a TTY, fall back to simple list
+		return ListProfiles(s, ListOptions{Sort: SortByName})
+	}
+
 	// Get current profile
 	current, err := s.GetCurrent()
 	if err != nil {
@@ -30,43 +40,250 @@ func ListProfilesInteractive(s *store.Store) error {
 		current = ""
 	}
 
-	// Sort profiles alphabetically
-	sort.Strings(profiles)
+	// Show interactive selector (fzf when available)
+	selectedProfile, err := pickProfile(s, "Select a profile:")
+	if err != nil {
+		// User cancelled or error occurred
+		if errors.Is(err, selector.ErrCancelled) {
+			return nil // Exit gracefully
+		}
+		return err
+	}
 
-	// Check if we're in a TTY
-	if !term.IsTerminal(int(os.Stdout.Fd())) {
-		// Not a TTY, fall back to simple list
-		return ListProfiles(s)
+	// If it's already the current profile, no need to switch
+	if selectedProfile == current {
+		printer.Info("Already using profile %q", selectedProfile)
+		return nil
+	}
+
+	// Switch to the selected profile
+	return SwitchProfile(s, selectedProfile)
+}
+
+// pickProfile asks the user to choose a stored profile, using an external
+// fuzzy finder when available and the built-in selector otherwise
+func pickProfile(s *store.Store, title string) (string, error) {
+	profiles, err := s.List()
+	if err != nil {
+		return "", fmt.Errorf("failed to list profiles: %w", err)
 	}
 
+	if len(profiles) == 0 {
+		return "", fmt.Errorf("no profiles found")
+	}
+
+	current, err := s.GetCurrent()
+	if err != nil {
+		current = ""
+	}
+
+	// Sort profiles alphabetically
+	sort.Strings(profiles)
+
 	// Build options for selector
 	options := make([]selector.Option, len(profiles))
-	for i, profile := range profiles {
+	for i, name := range profiles {
 		options[i] = selector.Option{
-			Label:     profile,
-			IsCurrent: profile == current,
+			Label:     name,
+			IsCurrent: name == current,
+		}
+		if meta, err := s.LoadMetadata(name); err == nil {
+			options[i].Description = meta.Description
 		}
 	}
 
-	// Show interactive selector
-	selected, err := selector.Select("Select a profile:", options)
+	selected, err := selector.Pick(title, options, previewCommand(PreviewProfile))
 	if err != nil {
-		// User cancelled or error occurred
-		if err.Error() == "cancelled" {
-			return nil // Exit gracefully
+		return "", err
+	}
+
+	return profiles[selected], nil
+}
+
+// pickBackup asks the user to choose a backup, newest first. When include
+// is set, only backups whose manifest it accepts are offered.
+func pickBackup(backupMgr *backup.Manager, title string, include func(*backup.Manifest) bool) (string, error) {
+	backups, err := backupMgr.List()
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	var options []selector.Option
+	for _, b := range backups {
+		manifest, _ := backupMgr.ReadManifest(b.ID)
+		if include != nil && !include(manifest) {
+			continue
 		}
-		return err
+		option := selector.Option{
+			Label:       b.ID,
+			Description: b.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+		}
+		if manifest != nil && manifest.Reason != "" {
+			option.Description += "  " + manifest.Reason
+		}
+		ids = append(ids, b.ID)
+		options = append(options, option)
 	}
 
-	// Get selected profile
-	selectedProfile := profiles[selected]
+	if len(options) == 0 {
+		return "", fmt.Errorf("no backups found")
+	}
 
-	// If it's already the current profile, no need to switch
-	if selectedProfile == current {
-		printer.Info("Already using profile %q", selectedProfile)
-		return nil
+	selected, err := selector.Pick(title, options, previewCommand(PreviewBackup))
+	if err != nil {
+		return "", err
 	}
 
-	// Switch to the selected profile
-	return SwitchProfile(s, selectedProfile)
+	return ids[selected], nil
+}
+
+// chooseProfile returns name, or lets the user pick a stored profile when
+// it is empty. ok is false if the user cancelled the picker.
+func chooseProfile(s *store.Store, name, title string) (string, bool, error) {
+	if name != "" {
+		return name, true, nil
+	}
+	if !isInteractive() {
+		return "", false, errors.New("profile name required")
+	}
+	return cancelled(pickProfile(s, title))
+}
+
+// chooseBackup returns id, or lets the user pick a backup that include
+// accepts (any if nil) when it is empty. ok is false if the user cancelled
+// the picker.
+func chooseBackup(backupMgr *backup.Manager, id, title string, include func(*backup.Manifest) bool) (string, bool, error) {
+	if id != "" {
+		return id, true, nil
+	}
+	if !isInteractive() {
+		return "", false, errors.New("backup ID required")
+	}
+	return cancelled(pickBackup(backupMgr, title, include))
+}
+
+// cancelled turns a cancelled pick into ok == false rather than an error
+func cancelled(choice string, err error) (string, bool, error) {
+	if errors.Is(err, selector.ErrCancelled) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return choice, true, nil
+}
+
+// isInteractive reports whether the user can be shown a picker
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Preview kinds, for the hidden "claudectx __preview KIND NAME" command run
+// by the fzf preview pane
+const (
+	PreviewProfile = "profile"
+	PreviewBackup  = "backup"
+)
+
+// previewCommand returns an fzf preview command that describes the
+// highlighted entry's first field (the name, without any description)
+func previewCommand(kind string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "claudectx"
+	}
+	return fmt.Sprintf("%s __preview %s {1}", shellQuote(exe), kind)
+}
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Preview prints a short summary of a profile or backup for the picker's
+// preview pane. Only names are shown, never setting or env values, so the
+// pane cannot expose secrets.
+func Preview(s *store.Store, kind, name string) error {
+	switch kind {
+	case PreviewProfile:
+		return previewProfile(s, name)
+	case PreviewBackup:
+		backupMgr, err := backup.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to initialize backup manager: %w", err)
+		}
+		return previewBackup(backupMgr, name)
+	default:
+		return fmt.Errorf("unknown preview kind %q", kind)
+	}
+}
+
+// previewProfile prints a profile's description, model and the names of its
+// env variables and MCP servers
+func previewProfile(s *store.Store, name string) error {
+	if err := profile.ValidateProfileName(name); err != nil {
+		return fmt.Errorf("invalid profile name: %w", err)
+	}
+	prof, err := s.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	fmt.Println(printer.Bold(prof.Name))
+	if prof.Description != "" {
+		fmt.Println(prof.Description)
+	}
+	if len(prof.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(prof.Tags, ", "))
+	}
+	if prof.Settings != nil {
+		if prof.Settings.Model != "" {
+			fmt.Printf("Model: %s\n", prof.Settings.Model)
+		}
+		if len(prof.Settings.Env) > 0 {
+			fmt.Printf("Env: %s\n", strings.Join(sortedKeys(prof.Settings.Env), ", "))
+		}
+	}
+	if len(prof.MCPServers) > 0 {
+		fmt.Printf("MCP servers: %s\n", strings.Join(sortedKeys(prof.MCPServers), ", "))
+	}
+	if len(prof.DisabledMCPServers) > 0 {
+		fmt.Printf("Disabled MCP servers: %s\n", strings.Join(sortedKeys(prof.DisabledMCPServers), ", "))
+	}
+	if claudeMD := strings.TrimSpace(prof.ClaudeMD); claudeMD != "" {
+		fmt.Printf("CLAUDE.md: %d lines\n", strings.Count(claudeMD, "\n")+1)
+	}
+	if !prof.Project.IsEmpty() {
+		fmt.Println("Has a project configuration")
+	}
+	return nil
+}
+
+// previewBackup prints when and why a backup was taken and the files in it
+func previewBackup(backupMgr *backup.Manager, id string) error {
+	manifest, err := backupMgr.ReadManifest(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(printer.Bold(id))
+	if manifest == nil {
+		fmt.Println(printer.Dim("(no manifest)"))
+		return nil
+	}
+	fmt.Printf("Created: %s\n", manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if manifest.Reason != "" {
+		fmt.Printf("Reason: %s\n", manifest.Reason)
+	}
+	if manifest.Trackers != nil && manifest.Trackers.Current != "" {
+		fmt.Printf("Active profile: %s\n", manifest.Trackers.Current)
+	}
+	if manifest.Profile != "" {
+		fmt.Printf("Profile: %s\n", manifest.Profile)
+	}
+	for _, f := range manifest.Files {
+		fmt.Printf("  %s\n", f.Path)
+	}
+	return nil
 }
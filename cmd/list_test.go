@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+func TestParseListArgs(t *testing.T) {
+	opts, err := ParseListArgs([]string{"--tag", "client", "--tag=proxy", "--sort", "recent"})
+	if err != nil {
+		t.Fatalf("ParseListArgs failed: %v", err)
+	}
+	want := ListOptions{Tags: []string{"client", "proxy"}, Sort: SortByRecent}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("ParseListArgs() = %+v, want %+v", opts, want)
+	}
+
+	if _, err := ParseListArgs([]string{"--sort", "size"}); err == nil {
+		t.Error("expected error for unknown sort order")
+	}
+	if _, err := ParseListArgs([]string{"--tag"}); err == nil {
+		t.Error("expected error for missing tag")
+	}
+}
+
+func TestFilterAndSortProfiles(t *testing.T) {
+	s, _ := setupRunTest(t)
+
+	for _, p := range []struct {
+		name string
+		tags []string
+	}{
+		{"alpha", []string{"client"}},
+		{"beta", []string{"Client", "proxy"}},
+		{"gamma", nil},
+	} {
+		prof := profile.NewProfile(p.name)
+		prof.Tags = p.tags
+		saveProfile(t, s, prof)
+	}
+
+	// beta used twice (most recently), gamma once
+	for _, name := range []string{"gamma", "beta", "beta"} {
+		if err := s.MarkUsed(name); err != nil {
+			t.Fatalf("MarkUsed failed: %v", err)
+		}
+	}
+
+	names, _ := s.List()
+
+	got, err := filterAndSortProfiles(s, names, ListOptions{Tags: []string{"client"}, Sort: SortByName})
+	if err != nil {
+		t.Fatalf("filterAndSortProfiles failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"alpha", "beta"}) {
+		t.Errorf("tag filter = %v, want [alpha beta]", got)
+	}
+
+	got, _ = filterAndSortProfiles(s, names, ListOptions{Sort: SortByRecent})
+	if !reflect.DeepEqual(got, []string{"beta", "gamma", "alpha"}) {
+		t.Errorf("recent sort = %v, want [beta gamma alpha]", got)
+	}
+
+	got, _ = filterAndSortProfiles(s, names, ListOptions{Sort: SortByCount})
+	if !reflect.DeepEqual(got, []string{"beta", "gamma", "alpha"}) {
+		t.Errorf("count sort = %v, want [beta gamma alpha]", got)
+	}
+}
+
+func TestMissingNameRequiresTerminal(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	// Tests do not run in a terminal, so no picker is shown
+	if err := DeleteProfile(s, ""); err == nil || err.Error() != "profile name required" {
+		t.Errorf("DeleteProfile() error = %v, want profile name required", err)
+	}
+	if err := EditProfile(s, "", ""); err == nil || err.Error() != "profile name required" {
+		t.Errorf("EditProfile() error = %v, want profile name required", err)
+	}
+	if err := CopyProfile(s, CopyOptions{Dest: "copy"}); err == nil || err.Error() != "profile name required" {
+		t.Errorf("CopyProfile() error = %v, want profile name required", err)
+	}
+	if err := RunBackup(BackupOptions{Action: BackupPin}); err == nil || err.Error() != "backup ID required" {
+		t.Errorf("RunBackup(pin) error = %v, want backup ID required", err)
+	}
+	if s.Exists("copy") || !s.Exists("work") {
+		t.Error("profiles should be unchanged")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, path := range []string{"/usr/bin/claudectx", "/tmp/it's $HOME/`id`/a\\x b"} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(path)).Output()
+		if err != nil {
+			t.Fatalf("sh failed for %q: %v", path, err)
+		}
+		if string(out) != path {
+			t.Errorf("shellQuote(%q) came back from sh as %q", path, out)
+		}
+	}
+}
@@ -0,0 +1,576 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
+	"github.com/johnfox/claudectx/internal/store"
+	"github.com/johnfox/claudectx/internal/validator"
+)
+
+// MCP subcommands
+const (
+	MCPList    = "list"
+	MCPShow    = "show"
+	MCPEnable  = "enable"
+	MCPDisable = "disable"
+	MCPAdd     = "add"
+	MCPRemove  = "rm"
+	MCPImport  = "import"
+	MCPExport  = "export"
+)
+
+// MCPOptions holds the parsed arguments for the mcp command.
+type MCPOptions struct {
+	Action      string
+	ProfileName string
+	Servers     []string
+	// Server is the configuration for "mcp add"
+	Server mcpconfig.MCPServer
+	JSON   bool
+	Reveal bool
+
+	// File is the file read by "mcp import"
+	File string
+	// Format is the foreign format for import and export
+	Format string
+	// Output is the file written by "mcp export" (stdout if empty)
+	Output string
+	// Overwrite lets "mcp import" replace servers with the same name
+	Overwrite bool
+}
+
+// ParseMCPArgs parses the arguments following "claudectx mcp".
+// Valid forms:
+//
+//	mcp list [profile] [--json]
+//	mcp show <profile> <server> [--reveal] [--json]
+//	mcp enable|disable|rm <profile> <server>...
+//	mcp add <profile> <server> [--type T] [--url URL] [--env K=V]... [--header K=V]... [-- command args...]
+//	mcp add <profile> <server> --config JSON
+//	mcp import <file> [--into profile] [--format auto|claude|desktop|cursor|vscode] [--overwrite]
+//	mcp export [profile] [--format claude|desktop|cursor|vscode] [--output FILE]
+func ParseMCPArgs(args []string) (MCPOptions, error) {
+	if len(args) == 0 {
+		return MCPOptions{}, errors.New("mcp subcommand required (list, show, enable, disable, add, rm, import, export)")
+	}
+
+	opts := MCPOptions{Action: args[0]}
+	if opts.Action == "remove" {
+		opts.Action = MCPRemove
+	}
+	switch opts.Action {
+	case MCPList, MCPShow, MCPEnable, MCPDisable, MCPAdd, MCPRemove, MCPImport, MCPExport:
+	default:
+		return MCPOptions{}, fmt.Errorf("unknown mcp subcommand %q (valid: list, show, enable, disable, add, rm, import, export)", args[0])
+	}
+
+	var positional []string
+	var configJSON string
+	hasConfig := false
+	rest := args[1:]
+	for i := 0; i < len(rest); i++ {
+		a := rest[i]
+		value := func() (string, error) {
+			if i+1 >= len(rest) {
+				return "", fmt.Errorf("%s requires a value", a)
+			}
+			i++
+			return rest[i], nil
+		}
+
+		switch {
+		case a == "--" && opts.Action == MCPAdd:
+			if i+1 >= len(rest) {
+				return MCPOptions{}, errors.New("-- must be followed by the server command")
+			}
+			opts.Server.Command = rest[i+1]
+			opts.Server.Args = rest[i+2:]
+			i = len(rest)
+		case a == "--json" && (opts.Action == MCPList || opts.Action == MCPShow):
+			opts.JSON = true
+		case a == "--reveal" && opts.Action == MCPShow:
+			opts.Reveal = true
+		case opts.Action == MCPAdd && (a == "--type" || a == "-t"):
+			v, err := value()
+			if err != nil {
+				return MCPOptions{}, err
+			}
+			opts.Server.Type = v
+		case opts.Action == MCPAdd && a == "--url":
+			v, err := value()
+			if err != nil {
+				return MCPOptions{}, err
+			}
+			opts.Server.URL = v
+		case opts.Action == MCPAdd && (a == "--env" || a == "-e"):
+			v, err := value()
+			if err != nil {
+				return MCPOptions{}, err
+			}
+			key, val, ok := strings.Cut(v, "=")
+			if !ok || key == "" {
+				return MCPOptions{}, fmt.Errorf("invalid env %q (expected KEY=VALUE)", v)
+			}
+			if opts.Server.Env == nil {
+				opts.Server.Env = make(map[string]string)
+			}
+			opts.Server.Env[key] = val
+		case opts.Action == MCPAdd && (a == "--header" || a == "-H"):
+			v, err := value()
+			if err != nil {
+				return MCPOptions{}, err
+			}
+			// Accept both NAME=VALUE and the curl-style "NAME: VALUE"
+			key, val, ok := strings.Cut(v, ":")
+			if eq := strings.Index(v, "="); eq >= 0 && (!ok || eq < len(key)) {
+				key, val, ok = v[:eq], v[eq+1:], true
+			}
+			if !ok || strings.TrimSpace(key) == "" {
+				return MCPOptions{}, fmt.Errorf("invalid header %q (expected NAME=VALUE or NAME: VALUE)", v)
+			}
+			if opts.Server.Headers == nil {
+				opts.Server.Headers = make(map[string]string)
+			}
+			opts.Server.Headers[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		case opts.Action == MCPAdd && a == "--config":
+			v, err := value()
+			if err != nil {
+				return MCPOptions{}, err
+			}
+			configJSON = v
+			hasConfig = true
+		case (opts.Action == MCPImport || opts.Action == MCPExport) && a == "--format":
+			v, err := value()
+			if err != nil {
+				return MCPOptions{}, err
+			}
+			opts.Format = v
+		case opts.Action == MCPImport && a == "--into":
+			v, err := value()
+			if err != nil {
+				return MCPOptions{}, err
+			}
+			opts.ProfileName = v
+		case opts.Action == MCPImport && a == "--overwrite":
+			opts.Overwrite = true
+		case opts.Action == MCPExport && (a == "--output" || a == "-o"):
+			v, err := value()
+			if err != nil {
+				return MCPOptions{}, err
+			}
+			opts.Output = v
+		case strings.HasPrefix(a, "-"):
+			return MCPOptions{}, fmt.Errorf("unknown mcp %s flag %q", opts.Action, a)
+		default:
+			positional = append(positional, a)
+		}
+	}
+
+	if hasConfig {
+		if opts.Server.Type != "" || opts.Server.Command != "" || opts.Server.URL != "" || opts.Server.Env != nil || opts.Server.Headers != nil {
+			return MCPOptions{}, errors.New("--config cannot be combined with other server options")
+		}
+		if err := json.Unmarshal([]byte(configJSON), &opts.Server); err != nil {
+			return MCPOptions{}, fmt.Errorf("invalid --config JSON: %w", err)
+		}
+	}
+
+	switch opts.Action {
+	case MCPImport:
+		if len(positional) != 1 {
+			return MCPOptions{}, errors.New("exactly one file to import required")
+		}
+		opts.File = positional[0]
+	case MCPList, MCPExport:
+		if len(positional) > 1 {
+			return MCPOptions{}, errors.New("only one profile name may be given")
+		}
+		if len(positional) == 1 {
+			opts.ProfileName = positional[0]
+		}
+	case MCPShow, MCPAdd:
+		if len(positional) != 2 {
+			return MCPOptions{}, errors.New("profile and server name required")
+		}
+		opts.ProfileName = positional[0]
+		opts.Servers = positional[1:]
+	default:
+		if len(positional) < 2 {
+			return MCPOptions{}, errors.New("profile and server name required")
+		}
+		opts.ProfileName = positional[0]
+		opts.Servers = positional[1:]
+	}
+
+	return opts, nil
+}
+
+// RunMCP performs a parsed mcp command
+func RunMCP(s *store.Store, opts MCPOptions) error {
+	name := opts.ProfileName
+	if name == "" {
+		current, err := s.GetCurrent()
+		if err != nil {
+			return fmt.Errorf("failed to get current profile: %w", err)
+		}
+		if current == "" {
+			return fmt.Errorf("no profile specified and no current profile set")
+		}
+		name = current
+	}
+	if err := profile.ValidateProfileName(name); err != nil {
+		return fmt.Errorf("invalid profile name: %w", err)
+	}
+	if !s.Exists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	switch opts.Action {
+	case MCPList:
+		return ListMCPServers(s, name, opts.JSON)
+	case MCPShow:
+		return ShowMCPServer(s, name, opts.Servers[0], opts.Reveal, opts.JSON)
+	case MCPEnable:
+		return SetMCPServersEnabled(s, name, opts.Servers, true)
+	case MCPDisable:
+		return SetMCPServersEnabled(s, name, opts.Servers, false)
+	case MCPAdd:
+		return AddMCPServer(s, name, opts.Servers[0], opts.Server)
+	case MCPImport:
+		return ImportMCPServers(s, name, opts.File, opts.Format, opts.Overwrite)
+	case MCPExport:
+		return ExportMCPServers(s, name, opts.Format, opts.Output)
+	default:
+		return RemoveMCPServers(s, name, opts.Servers)
+	}
+}
+
+// ListMCPServers prints a profile's MCP servers and whether each is enabled
+func ListMCPServers(s *store.Store, name string, asJSON bool) error {
+	prof, err := s.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	servers := profileMCPServerDetails(prof, false)
+	if asJSON {
+		if servers == nil {
+			servers = []MCPServerDetails{}
+		}
+		return encodeJSON(servers)
+	}
+
+	if len(servers) == 0 {
+		printer.Info("Profile %q has no MCP servers", name)
+		return nil
+	}
+
+	width := 0
+	for _, server := range servers {
+		width = max(width, len(server.Name))
+	}
+	for _, server := range servers {
+		mark := printer.Colorize("✓", printer.Green)
+		if server.Disabled {
+			mark = printer.Colorize("✗", printer.Yellow)
+		}
+		target := server.URL
+		if target == "" {
+			target = strings.TrimSpace(server.Command + " " + strings.Join(server.Args, " "))
+		}
+		line := fmt.Sprintf("%s %-*s  %-5s  %s", mark, width, server.Name, server.Transport, target)
+		if server.Disabled {
+			line += " " + printer.Dim("(disabled)")
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// ShowMCPServer prints one MCP server's configuration, masking secrets
+// unless reveal is set
+func ShowMCPServer(s *store.Store, name, serverName string, reveal, asJSON bool) error {
+	prof, err := s.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	server, ok := prof.MCPServers[serverName]
+	disabled := false
+	if !ok {
+		server, disabled = prof.DisabledMCPServers[serverName]
+		if !disabled {
+			return fmt.Errorf("profile %q has no MCP server %q", name, serverName)
+		}
+	}
+
+	details := mcpServerDetails(serverName, server, disabled, reveal)
+	if asJSON {
+		return encodeJSON(details)
+	}
+
+	printMCPServerDetails("", details)
+	if details.masked {
+		fmt.Println(printer.Dim("Secret values are masked; use --reveal to show them"))
+	}
+	return nil
+}
+
+// SetMCPServersEnabled enables or disables MCP servers in a profile.
+// Disabled servers move to the profile's disabled map, so switch and run
+// leave them out.
+func SetMCPServersEnabled(s *store.Store, name string, serverNames []string, enable bool) error {
+	verb := "Disabled"
+	if enable {
+		verb = "Enabled"
+	}
+
+	return updateMCPServers(s, name, func(prof *profile.Profile) (bool, error) {
+		from, to := &prof.MCPServers, &prof.DisabledMCPServers
+		if enable {
+			from, to = to, from
+		}
+
+		changed := false
+		for _, serverName := range serverNames {
+			if _, ok := (*to)[serverName]; ok {
+				if _, dup := (*from)[serverName]; dup {
+					return false, fmt.Errorf("MCP server %q is both enabled and disabled; remove one copy with: claudectx mcp rm %s %s", serverName, name, serverName)
+				}
+				printer.Info("MCP server %q is already %s", serverName, strings.ToLower(verb))
+				continue
+			}
+			server, ok := (*from)[serverName]
+			if !ok {
+				return false, fmt.Errorf("profile %q has no MCP server %q", name, serverName)
+			}
+			delete(*from, serverName)
+			if *to == nil {
+				*to = make(mcpconfig.MCPServers)
+			}
+			(*to)[serverName] = server
+			printer.Success("%s MCP server %q in profile %q", verb, serverName, name)
+			changed = true
+		}
+		return changed, nil
+	})
+}
+
+// AddMCPServer adds a new, enabled MCP server to a profile
+func AddMCPServer(s *store.Store, name, serverName string, server mcpconfig.MCPServer) error {
+	if err := validator.ValidateMCPServer(server); err != nil {
+		return fmt.Errorf("invalid MCP server %q: %w", serverName, err)
+	}
+
+	return updateMCPServers(s, name, func(prof *profile.Profile) (bool, error) {
+		_, enabled := prof.MCPServers[serverName]
+		_, disabled := prof.DisabledMCPServers[serverName]
+		if enabled || disabled {
+			return false, fmt.Errorf("profile %q already has an MCP server %q", name, serverName)
+		}
+		if prof.MCPServers == nil {
+			prof.MCPServers = make(mcpconfig.MCPServers)
+		}
+		prof.MCPServers[serverName] = server
+		printer.Success("Added MCP server %q to profile %q", serverName, name)
+		return true, nil
+	})
+}
+
+// RemoveMCPServers deletes MCP servers from a profile, enabled or disabled
+func RemoveMCPServers(s *store.Store, name string, serverNames []string) error {
+	return updateMCPServers(s, name, func(prof *profile.Profile) (bool, error) {
+		for _, serverName := range serverNames {
+			_, enabled := prof.MCPServers[serverName]
+			_, disabled := prof.DisabledMCPServers[serverName]
+			if !enabled && !disabled {
+				return false, fmt.Errorf("profile %q has no MCP server %q", name, serverName)
+			}
+			delete(prof.MCPServers, serverName)
+			delete(prof.DisabledMCPServers, serverName)
+			printer.Success("Removed MCP server %q from profile %q", serverName, name)
+		}
+		return true, nil
+	})
+}
+
+// ImportMCPServers adds the servers from another tool's configuration file
+// to a profile. Servers whose names are already in the profile with a
+// different configuration are reported and skipped unless overwrite is set.
+// A replaced server stays enabled or disabled as it was.
+func ImportMCPServers(s *store.Store, name, file, format string, overwrite bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	imported, err := mcpconfig.Import(file, data, format)
+	if err != nil {
+		return err
+	}
+	if len(imported.Servers) == 0 {
+		printer.Info("No MCP servers found in %s", file)
+		return nil
+	}
+	for serverName, server := range imported.Servers {
+		if err := validator.ValidateMCPServer(server); err != nil {
+			return fmt.Errorf("invalid MCP server %q in %s: %w", serverName, file, err)
+		}
+	}
+
+	printer.Info("Importing %s MCP config from %s", imported.Format, file)
+	for _, warning := range imported.Warnings {
+		printer.Warning("  ⚠ %s", warning)
+	}
+
+	var conflicts []string
+	err = updateMCPServers(s, name, func(prof *profile.Profile) (bool, error) {
+		changed := false
+		for _, serverName := range sortedServerNames(imported.Servers) {
+			server := imported.Servers[serverName]
+
+			existing, exists := prof.MCPServers[serverName]
+			disabled := false
+			if !exists {
+				existing, disabled = prof.DisabledMCPServers[serverName]
+				exists = disabled
+			}
+			if exists && hashMCPServers(mcpconfig.MCPServers{serverName: existing}) == hashMCPServers(mcpconfig.MCPServers{serverName: server}) {
+				printer.Info("  = %s (unchanged)", serverName)
+				continue
+			}
+			if exists && !overwrite {
+				conflicts = append(conflicts, serverName)
+				continue
+			}
+
+			changed = true
+			if disabled {
+				prof.DisabledMCPServers[serverName] = server
+				printer.Success("  ~ %s (replaced, still disabled)", serverName)
+				continue
+			}
+			if prof.MCPServers == nil {
+				prof.MCPServers = make(mcpconfig.MCPServers)
+			}
+			prof.MCPServers[serverName] = server
+			if exists {
+				printer.Success("  ~ %s (replaced)", serverName)
+			} else {
+				printer.Success("  + %s", serverName)
+			}
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		printer.Warning("Skipped %d server(s) already in profile %q with a different configuration: %s",
+			len(conflicts), name, strings.Join(conflicts, ", "))
+		printer.Warning("Re-run with --overwrite to replace them, or remove them first with: claudectx mcp rm %s <server>", name)
+	}
+	return nil
+}
+
+// ExportMCPServers writes a profile's enabled MCP servers in another tool's
+// format, to stdout or a file
+func ExportMCPServers(s *store.Store, name, format, output string) error {
+	if format == "" {
+		format = mcpconfig.FormatClaude
+	}
+
+	prof, err := s.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	data, warnings, err := mcpconfig.Export(prof.MCPServers, format)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	if output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	printer.Success("Exported MCP servers from profile %q to %s (%s format)", name, output, format)
+	return nil
+}
+
+// sortedServerNames returns the server names in sorted order
+func sortedServerNames(servers mcpconfig.MCPServers) []string {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// updateMCPServers loads a profile, applies modify and saves it when it
+// reports a change. The current profile is synced first and re-applied to
+// the live configuration afterwards.
+func updateMCPServers(s *store.Store, name string, modify func(*profile.Profile) (bool, error)) error {
+	current, err := s.GetCurrent()
+	if err != nil {
+		return fmt.Errorf("failed to get current profile: %w", err)
+	}
+	if name == current {
+		syncBeforeModify(s, name)
+	}
+
+	prof, err := s.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	original, err := prof.Clone()
+	if err != nil {
+		return fmt.Errorf("failed to copy profile: %w", err)
+	}
+	previous := prof.ProjectMCPServers.Clone()
+	changed, err := modify(prof)
+	if err != nil || !changed {
+		return err
+	}
+
+	prof.Touch()
+	if err := s.Save(prof); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	if name == current {
+		if err := reapplyProfile(prof, previous); err != nil {
+			restoreProfile(s, original)
+			return fmt.Errorf("failed to update live configuration, profile %q left unchanged: %w", name, err)
+		}
+		printer.Info("Applied change to the live configuration")
+	}
+	return nil
+}
+
+// encodeJSON writes v to stdout as indented JSON
+func encodeJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
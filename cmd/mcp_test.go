@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+func TestParseMCPArgs(t *testing.T) {
+	opts, err := ParseMCPArgs([]string{"add", "work", "github", "-e", "TOKEN=x", "--header", "Authorization: Bearer a=b", "--", "npx", "-y", "@github/mcp"})
+	if err != nil {
+		t.Fatalf("ParseMCPArgs failed: %v", err)
+	}
+	if opts.Action != MCPAdd || opts.ProfileName != "work" || opts.Servers[0] != "github" {
+		t.Errorf("opts = %+v", opts)
+	}
+	if opts.Server.Command != "npx" || len(opts.Server.Args) != 2 || opts.Server.Env["TOKEN"] != "x" {
+		t.Errorf("server = %+v", opts.Server)
+	}
+	if opts.Server.Headers["Authorization"] != "Bearer a=b" {
+		t.Errorf("headers = %v", opts.Server.Headers)
+	}
+
+	opts, err = ParseMCPArgs([]string{"add", "work", "linear", "--config", `{"type":"http","url":"https://x.test/mcp"}`})
+	if err != nil || opts.Server.URL != "https://x.test/mcp" {
+		t.Errorf("--config: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseMCPArgs([]string{"disable", "work", "a", "b"})
+	if err != nil || len(opts.Servers) != 2 {
+		t.Errorf("disable: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseMCPArgs([]string{"import", "mcp.json", "--into", "work", "--format", "cursor", "--overwrite"})
+	if err != nil || opts.File != "mcp.json" || opts.ProfileName != "work" || opts.Format != "cursor" || !opts.Overwrite {
+		t.Errorf("import: opts = %+v, err = %v", opts, err)
+	}
+
+	opts, err = ParseMCPArgs([]string{"export", "work", "--format", "vscode", "-o", "out.json"})
+	if err != nil || opts.ProfileName != "work" || opts.Format != "vscode" || opts.Output != "out.json" {
+		t.Errorf("export: opts = %+v, err = %v", opts, err)
+	}
+
+	for _, args := range [][]string{
+		nil,
+		{"bogus"},
+		{"disable", "work"},
+		{"show", "work"},
+		{"list", "--reveal"},
+		{"add", "work", "x", "--env", "NOEQUALS"},
+		{"import"},
+		{"import", "a.json", "--output", "x"},
+		{"export", "work", "--overwrite"},
+	} {
+		if _, err := ParseMCPArgs(args); err == nil {
+			t.Errorf("ParseMCPArgs(%q) should fail", args)
+		}
+	}
+}
+
+func TestSetMCPServersEnabled(t *testing.T) {
+	s, home := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.MCPServers = mcpconfig.MCPServers{
+		"github":     {Command: "gh-mcp"},
+		"playwright": {Command: "pw-mcp"},
+	}
+	saveProfile(t, s, work)
+	if err := SwitchProfile(s, "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetMCPServersEnabled(s, "work", []string{"playwright"}, false); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+
+	prof, _ := s.Load("work")
+	if _, ok := prof.MCPServers["playwright"]; ok {
+		t.Error("disabled server should not be enabled")
+	}
+	if prof.DisabledMCPServers["playwright"].Command != "pw-mcp" {
+		t.Errorf("disabled servers = %+v", prof.DisabledMCPServers)
+	}
+
+	// The current profile's live config is updated
+	live, _ := mcpconfig.LoadMCPServers(filepath.Join(home, ".claude.json"))
+	if _, ok := live["playwright"]; ok || len(live) != 1 {
+		t.Errorf("live servers = %+v, want only github", live)
+	}
+
+	// Disabled servers survive the auto-sync on switch
+	saveProfile(t, s, profile.NewProfile("other"))
+	if err := SwitchProfile(s, "other"); err != nil {
+		t.Fatal(err)
+	}
+	prof, _ = s.Load("work")
+	if _, ok := prof.DisabledMCPServers["playwright"]; !ok {
+		t.Error("disabled server lost after switching away")
+	}
+
+	if err := SetMCPServersEnabled(s, "work", []string{"playwright"}, true); err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	prof, _ = s.Load("work")
+	if _, ok := prof.MCPServers["playwright"]; !ok || len(prof.DisabledMCPServers) != 0 {
+		t.Errorf("after enable: enabled = %+v, disabled = %+v", prof.MCPServers, prof.DisabledMCPServers)
+	}
+
+	if err := SetMCPServersEnabled(s, "work", []string{"missing"}, false); err == nil {
+		t.Error("expected error for unknown server")
+	}
+}
+
+func TestSetMCPServersEnabled_ApplyFailureKeepsProfile(t *testing.T) {
+	s, home := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.ClaudeMD = "# Work\n"
+	work.MCPServers = mcpconfig.MCPServers{"github": {Command: "gh-mcp"}}
+	saveProfile(t, s, work)
+	if err := SwitchProfile(s, "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The live CLAUDE.md can no longer be written
+	blockLiveClaudeMD(t, home)
+
+	if err := SetMCPServersEnabled(s, "work", []string{"github"}, false); err == nil {
+		t.Fatal("expected error when the live configuration cannot be updated")
+	}
+
+	prof, _ := s.Load("work")
+	if _, ok := prof.MCPServers["github"]; !ok || len(prof.DisabledMCPServers) != 0 {
+		t.Errorf("profile changed despite the failure: enabled = %+v, disabled = %+v", prof.MCPServers, prof.DisabledMCPServers)
+	}
+}
+
+// blockLiveClaudeMD replaces the live CLAUDE.md with a directory, so
+// applying a profile to the live configuration fails
+func blockLiveClaudeMD(t *testing.T, home string) {
+	t.Helper()
+	claudeMD := filepath.Join(home, ".claude", "CLAUDE.md")
+	if err := os.RemoveAll(claudeMD); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(claudeMD, "blocker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddAndRemoveMCPServer(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	if err := AddMCPServer(s, "work", "bad", mcpconfig.MCPServer{Type: "http"}); err == nil {
+		t.Error("expected error for http server without a URL")
+	}
+
+	if err := AddMCPServer(s, "work", "github", mcpconfig.MCPServer{Command: "gh-mcp"}); err != nil {
+		t.Fatalf("AddMCPServer failed: %v", err)
+	}
+	if err := AddMCPServer(s, "work", "github", mcpconfig.MCPServer{Command: "other"}); err == nil {
+		t.Error("expected error adding a duplicate server")
+	}
+
+	if err := SetMCPServersEnabled(s, "work", []string{"github"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveMCPServers(s, "work", []string{"github"}); err != nil {
+		t.Fatalf("RemoveMCPServers failed: %v", err)
+	}
+
+	prof, _ := s.Load("work")
+	if len(prof.MCPServers) != 0 || len(prof.DisabledMCPServers) != 0 {
+		t.Errorf("servers left after rm: %+v %+v", prof.MCPServers, prof.DisabledMCPServers)
+	}
+}
+
+func TestImportMCPServers(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.MCPServers = mcpconfig.MCPServers{
+		"github": {Command: "gh-mcp"},
+		"same":   {Command: "same-mcp"},
+	}
+	saveProfile(t, s, work)
+
+	file := filepath.Join(t.TempDir(), "claude_desktop_config.json")
+	content := `{"mcpServers": {
+  "github": {"command": "other-gh-mcp"},
+  "same": {"command": "same-mcp"},
+  "fs": {"command": "npx", "args": ["-y", "@modelcontextprotocol/server-filesystem"]}
+}}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ImportMCPServers(s, "work", file, "", false); err != nil {
+		t.Fatalf("ImportMCPServers failed: %v", err)
+	}
+	prof, _ := s.Load("work")
+	if prof.MCPServers["github"].Command != "gh-mcp" {
+		t.Error("conflicting server should be kept without --overwrite")
+	}
+	if prof.MCPServers["fs"].Command != "npx" {
+		t.Errorf("servers = %+v, want fs imported", prof.MCPServers)
+	}
+
+	if err := ImportMCPServers(s, "work", file, "", true); err != nil {
+		t.Fatalf("ImportMCPServers --overwrite failed: %v", err)
+	}
+	prof, _ = s.Load("work")
+	if prof.MCPServers["github"].Command != "other-gh-mcp" {
+		t.Error("conflicting server should be replaced with --overwrite")
+	}
+
+	// A disabled server is replaced in place and stays disabled
+	if err := SetMCPServersEnabled(s, "work", []string{"github"}, false); err != nil {
+		t.Fatal(err)
+	}
+	content = `{"mcpServers": {"github": {"command": "newer-gh-mcp"}}}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportMCPServers(s, "work", file, "", true); err != nil {
+		t.Fatalf("ImportMCPServers --overwrite of a disabled server failed: %v", err)
+	}
+	prof, _ = s.Load("work")
+	if _, ok := prof.MCPServers["github"]; ok {
+		t.Error("replacing a disabled server should not enable it")
+	}
+	if prof.DisabledMCPServers["github"].Command != "newer-gh-mcp" {
+		t.Errorf("disabled servers = %+v, want github replaced", prof.DisabledMCPServers)
+	}
+
+	if err := ImportMCPServers(s, "work", filepath.Join(t.TempDir(), "missing.json"), "", false); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestExportMCPServers(t *testing.T) {
+	s, _ := setupRunTest(t)
+	work := profile.NewProfile("work")
+	work.MCPServers = mcpconfig.MCPServers{
+		"github": {Command: "gh-mcp", Env: map[string]string{"TOKEN": "${GITHUB_TOKEN}"}},
+	}
+	saveProfile(t, s, work)
+
+	out := filepath.Join(t.TempDir(), "mcp.json")
+	if err := ExportMCPServers(s, "work", mcpconfig.FormatVSCode, out); err != nil {
+		t.Fatalf("ExportMCPServers failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"servers"`) || !strings.Contains(string(data), "${env:GITHUB_TOKEN}") {
+		t.Errorf("exported = %s", data)
+	}
+
+	if err := ExportMCPServers(s, "work", "zed", out); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/health"
+	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
+	"github.com/johnfox/claudectx/internal/selector"
+	"github.com/johnfox/claudectx/internal/store"
+	"github.com/johnfox/claudectx/internal/templates"
+	"github.com/johnfox/claudectx/internal/validator"
+	"golang.org/x/term"
+)
+
+// NewOptions holds the parsed arguments for the new command.
+type NewOptions struct {
+	Name     string
+	Template string
+	// Vars holds template variable values given with --var
+	Vars map[string]string
+}
+
+// ParseNewArgs parses the arguments following "claudectx new".
+// Valid forms:
+//
+//	new <name> [--template NAME] [--var KEY=VALUE]...
+func ParseNewArgs(args []string) (NewOptions, error) {
+	opts := NewOptions{Vars: make(map[string]string)}
+	var positional []string
+
+	addVar := func(kv string) error {
+		idx := strings.Index(kv, "=")
+		if idx <= 0 {
+			return fmt.Errorf("invalid variable %q (expected KEY=VALUE)", kv)
+		}
+		opts.Vars[kv[:idx]] = kv[idx+1:]
+		return nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--template" || a == "-t":
+			if i+1 >= len(args) {
+				return NewOptions{}, fmt.Errorf("%s requires a template name", a)
+			}
+			i++
+			opts.Template = args[i]
+		case strings.HasPrefix(a, "--template="):
+			opts.Template = strings.TrimPrefix(a, "--template=")
+		case a == "--var":
+			if i+1 >= len(args) {
+				return NewOptions{}, errors.New("--var requires KEY=VALUE")
+			}
+			i++
+			if err := addVar(args[i]); err != nil {
+				return NewOptions{}, err
+			}
+		case strings.HasPrefix(a, "--var="):
+			if err := addVar(strings.TrimPrefix(a, "--var=")); err != nil {
+				return NewOptions{}, err
+			}
+		case strings.HasPrefix(a, "-"):
+			return NewOptions{}, fmt.Errorf("unknown new flag %q", a)
+		default:
+			positional = append(positional, a)
+		}
+	}
+
+	if len(positional) != 1 {
+		return NewOptions{}, errors.New("profile name required")
+	}
+	opts.Name = positional[0]
+
+	return opts, nil
+}
+
+// NewProfile creates a profile from a template, prompting for any variables
+// not given with --var. The profile is validated and saved to the store; the
+// live configuration is never touched.
+func NewProfile(s *store.Store, opts NewOptions) error {
+	if err := profile.ValidateProfileName(opts.Name); err != nil {
+		return fmt.Errorf("invalid profile name: %w", err)
+	}
+
+	if s.Exists(opts.Name) {
+		return fmt.Errorf("profile %q already exists", opts.Name)
+	}
+
+	tmpl, err := chooseTemplate(opts.Template)
+	if err != nil {
+		return err
+	}
+
+	values, err := promptTemplateVars(tmpl, opts.Vars)
+	if err != nil {
+		return err
+	}
+
+	prof, err := tmpl.Render(opts.Name, values)
+	if err != nil {
+		return err
+	}
+
+	// Validate the rendered profile before saving
+	if err := prof.Validate(); err != nil {
+		return fmt.Errorf("profile validation failed: %w", err)
+	}
+	if err := validator.ValidateSettings(prof.Settings); err != nil {
+		return fmt.Errorf("rendered settings are invalid: %w", err)
+	}
+	if err := validator.ValidateClaudeMD(prof.ClaudeMD); err != nil {
+		return fmt.Errorf("rendered CLAUDE.md is invalid: %w", err)
+	}
+	if _, err := healthWarnings(health.CheckProfile(prof.Name, prof.Settings, prof.ClaudeMD)); err != nil {
+		return fmt.Errorf("rendered profile failed health checks: %w", err)
+	}
+
+	if err := s.Save(prof); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	printer.Success("Created profile %q from template %q", opts.Name, tmpl.Name)
+	for _, v := range tmpl.Variables {
+		value := values[v.Name]
+		if value == "" {
+			continue
+		}
+		if v.Secret {
+			value = config.MaskSecret(value)
+		}
+		printer.Info("  %s: %s", v.Name, value)
+	}
+	printer.Info("Switch to it with: claudectx %s", opts.Name)
+
+	return nil
+}
+
+// chooseTemplate loads the named template, or lets the user pick one when
+// no name was given and stdin is a terminal
+func chooseTemplate(name string) (*templates.Template, error) {
+	if name != "" {
+		return templates.Get(name)
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, errors.New("--template is required when not running interactively")
+	}
+
+	all, err := templates.List()
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]selector.Option, len(all))
+	for i, t := range all {
+		options[i] = selector.Option{Label: t.Name, Description: t.Description}
+	}
+
+	idx, err := selector.Pick("Select a template:", options, "")
+	if err != nil {
+		return nil, err
+	}
+	return all[idx], nil
+}
+
+// promptTemplateVars asks for each template variable not given on the
+// command line, offering its default. When input is closed, defaults are
+// used and required variables without one are reported as missing.
+func promptTemplateVars(tmpl *templates.Template, given map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(tmpl.Variables))
+	for name, value := range given {
+		v, ok := tmpl.Variable(name)
+		if !ok {
+			return nil, fmt.Errorf("template %q has no variable %q", tmpl.Name, name)
+		}
+		if err := v.Check(value); err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+
+	interactive := true
+	for _, v := range tmpl.Variables {
+		if _, ok := values[v.Name]; ok {
+			continue
+		}
+
+		for {
+			value := v.Default
+			if interactive {
+				answer, err := readVariable(v)
+				if err != nil {
+					interactive = false
+				} else if answer != "" {
+					value = answer
+				}
+			}
+
+			err := v.Check(value)
+			if err == nil {
+				values[v.Name] = value
+				break
+			}
+			if !interactive {
+				return nil, fmt.Errorf("%w (pass --var %s=VALUE)", err, v.Name)
+			}
+			printer.Warning("%v", err)
+		}
+	}
+
+	return values, nil
+}
+
+// readVariable prompts for one template variable
+func readVariable(v templates.Variable) (string, error) {
+	question := v.Prompt
+	if question == "" {
+		question = v.Name
+	}
+	if v.Default != "" {
+		question += fmt.Sprintf(" [%s]", v.Default)
+	}
+	question += ": "
+
+	if v.Secret {
+		return readSecret(question)
+	}
+	return readLine(question)
+}
+
+// ListTemplates prints the available profile templates
+func ListTemplates() error {
+	all, err := templates.List()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range all {
+		line := t.Name
+		if t.Description != "" {
+			line += " - " + t.Description
+		}
+		if t.Source != templates.SourceBuiltin {
+			line += fmt.Sprintf(" (%s)", t.Source)
+		}
+		fmt.Println(line)
+
+		for _, v := range t.Variables {
+			detail := "    " + v.Name
+			switch {
+			case v.Default != "":
+				detail += fmt.Sprintf(" (default %s)", v.Default)
+			case v.Required:
+				detail += " (required)"
+			}
+			fmt.Println(detail)
+		}
+	}
+	return nil
+}
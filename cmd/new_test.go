@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseNewArgs(t *testing.T) {
+	opts, err := ParseNewArgs([]string{"aws", "--template", "bedrock", "--var", "region=eu-west-1", "--var=model=x=y"})
+	if err != nil {
+		t.Fatalf("ParseNewArgs failed: %v", err)
+	}
+	want := NewOptions{
+		Name:     "aws",
+		Template: "bedrock",
+		Vars:     map[string]string{"region": "eu-west-1", "model": "x=y"},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("ParseNewArgs() = %+v, want %+v", opts, want)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"a", "b"},
+		{"aws", "--var", "novalue"},
+		{"aws", "--template"},
+		{"aws", "--bogus"},
+	} {
+		if _, err := ParseNewArgs(args); err == nil {
+			t.Errorf("ParseNewArgs(%v): expected error", args)
+		}
+	}
+}
+
+func TestNewProfile_WithVars(t *testing.T) {
+	s, tmp := setupRunTest(t)
+	stubPrompt(t, "")
+
+	opts, _ := ParseNewArgs([]string{"glm", "-t", "zai", "--var", "token=abc.def123456"})
+	if err := NewProfile(s, opts); err != nil {
+		t.Fatalf("NewProfile failed: %v", err)
+	}
+
+	prof, err := s.Load("glm")
+	if err != nil {
+		t.Fatalf("failed to load profile: %v", err)
+	}
+	env := prof.Settings.Env
+	if env["ANTHROPIC_AUTH_TOKEN"] != "abc.def123456" || env["ANTHROPIC_BASE_URL"] != "https://api.z.ai/api/anthropic" {
+		t.Errorf("unexpected env: %v", env)
+	}
+	if !prof.Metadata().HasTag("zai") {
+		t.Errorf("expected template tags on profile, got %v", prof.Tags)
+	}
+
+	// The live config must be untouched
+	if _, err := os.Stat(filepath.Join(tmp, ".claude", "settings.json")); !os.IsNotExist(err) {
+		t.Error("new should not write the live settings.json")
+	}
+	if current, _ := s.GetCurrent(); current != "" {
+		t.Errorf("new should not change the current profile, got %q", current)
+	}
+}
+
+func TestNewProfile_PromptsForVariables(t *testing.T) {
+	s, _ := setupRunTest(t)
+	// project_id: first answer fails the pattern and is asked again;
+	// region: accept default; model and haiku_model: override/accept
+	stubPrompt(t, "Bad_Project\nmy-gcp-project\n\nclaude-opus-4-1@20250805\n\n")
+
+	opts, _ := ParseNewArgs([]string{"gcp", "--template", "vertex"})
+	if err := NewProfile(s, opts); err != nil {
+		t.Fatalf("NewProfile failed: %v", err)
+	}
+
+	prof, _ := s.Load("gcp")
+	want := map[string]string{
+		"CLAUDE_CODE_USE_VERTEX":        "1",
+		"ANTHROPIC_VERTEX_PROJECT_ID":   "my-gcp-project",
+		"CLOUD_ML_REGION":               "us-east5",
+		"ANTHROPIC_MODEL":               "claude-opus-4-1@20250805",
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL": "claude-haiku-4-5@20251001",
+	}
+	if !reflect.DeepEqual(prof.Settings.Env, want) {
+		t.Errorf("env = %v, want %v", prof.Settings.Env, want)
+	}
+}
+
+func TestNewProfile_MissingRequiredVariable(t *testing.T) {
+	s, _ := setupRunTest(t)
+	stubPrompt(t, "")
+
+	opts, _ := ParseNewArgs([]string{"router", "--template", "openrouter"})
+	if err := NewProfile(s, opts); err == nil {
+		t.Fatal("expected error for missing token")
+	}
+	if s.Exists("router") {
+		t.Error("profile should not be created when variables are missing")
+	}
+}
+
+func TestNewProfile_Errors(t *testing.T) {
+	s, _ := setupRunTest(t)
+	stubPrompt(t, "")
+
+	cases := map[string][]string{
+		"unknown template": {"x", "--template", "nope"},
+		"unknown variable": {"x", "--template", "bedrock", "--var", "colour=red"},
+		"bad value":        {"x", "--template", "bedrock", "--var", "region=mars"},
+		"invalid name":     {"bad/name", "--template", "bedrock"},
+	}
+	for name, args := range cases {
+		opts, err := ParseNewArgs(args)
+		if err != nil {
+			t.Fatalf("%s: ParseNewArgs failed: %v", name, err)
+		}
+		if err := NewProfile(s, opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/permissions"
+	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
+	"github.com/johnfox/claudectx/internal/store"
+)
+
+// PermissionsOptions holds the parsed arguments for the permissions command.
+type PermissionsOptions struct {
+	ProfileName string
+	// ProjectDir is the project whose .claude settings are merged in;
+	// defaults to the working directory
+	ProjectDir string
+	JSON       bool
+}
+
+// EffectivePermissions is the merged permission set for a profile in a
+// project, used for both the human-readable and --json output
+type EffectivePermissions struct {
+	Profile string `json:"profile"`
+	Project string `json:"project"`
+	// Mode is "switch" when the profile is current (it is the user
+	// settings) and "run" otherwise (it is passed with --settings)
+	Mode    string                 `json:"mode"`
+	Sources []PermissionSource     `json:"sources"`
+	Result  *permissions.Effective `json:"effective"`
+}
+
+// PermissionSource describes one settings file in the merge
+type PermissionSource struct {
+	Scope string `json:"scope"`
+	Path  string `json:"path"`
+	Found bool   `json:"found"`
+	Rules int    `json:"rules"`
+}
+
+// ParsePermissionsArgs parses the arguments following "claudectx permissions".
+// Valid forms:
+//
+//	permissions [profile] [--project DIR] [--json]
+func ParsePermissionsArgs(args []string) (PermissionsOptions, error) {
+	var opts PermissionsOptions
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--json":
+			opts.JSON = true
+		case a == "--project" || a == "-p":
+			if i+1 >= len(args) {
+				return PermissionsOptions{}, fmt.Errorf("%s requires a directory", a)
+			}
+			i++
+			opts.ProjectDir = args[i]
+		case strings.HasPrefix(a, "--project="):
+			opts.ProjectDir = strings.TrimPrefix(a, "--project=")
+		case strings.HasPrefix(a, "-"):
+			return PermissionsOptions{}, fmt.Errorf("unknown permissions flag %q", a)
+		default:
+			if opts.ProfileName != "" {
+				return PermissionsOptions{}, errors.New("only one profile name may be given")
+			}
+			opts.ProfileName = a
+		}
+	}
+
+	return opts, nil
+}
+
+// ShowPermissions prints the permissions Claude Code will use for a profile
+// in a project, merged across the user, project and local settings scopes
+func ShowPermissions(s *store.Store, opts PermissionsOptions) error {
+	result, err := BuildEffectivePermissions(s, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("failed to encode permissions: %w", err)
+		}
+		return nil
+	}
+
+	printEffectivePermissions(result)
+	return nil
+}
+
+// BuildEffectivePermissions loads every settings scope that applies to the
+// profile in the project and merges their permissions
+func BuildEffectivePermissions(s *store.Store, opts PermissionsOptions) (*EffectivePermissions, error) {
+	current, err := s.GetCurrent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current profile: %w", err)
+	}
+
+	name := opts.ProfileName
+	if name == "" {
+		if current == "" {
+			return nil, fmt.Errorf("no profile specified and no current profile set")
+		}
+		name = current
+	}
+	if err := profile.ValidateProfileName(name); err != nil {
+		return nil, fmt.Errorf("invalid profile name: %w", err)
+	}
+	if !s.Exists(name) {
+		return nil, fmt.Errorf("profile %q does not exist", name)
+	}
+
+	projectDir := opts.ProjectDir
+	if projectDir == "" {
+		if projectDir, err = os.Getwd(); err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if projectDir, err = filepath.Abs(projectDir); err != nil {
+		return nil, fmt.Errorf("invalid project directory: %w", err)
+	}
+	if info, err := os.Stat(projectDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("project directory %q does not exist", projectDir)
+	}
+
+	userPath, err := paths.SettingsFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings file path: %w", err)
+	}
+	project, err := loadPermissionSource(permissions.ScopeProject, filepath.Join(projectDir, ".claude", "settings.json"))
+	if err != nil {
+		return nil, err
+	}
+	local, err := loadPermissionSource(permissions.ScopeLocal, paths.ProjectSettingsLocalFile(projectDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []permissions.Source
+	mode := "run"
+	if name == current {
+		// The switched-to profile is the user settings file, including any
+		// changes not yet synced
+		mode = "switch"
+		prof, err := loadPermissionSource(permissions.ScopeProfile, userPath)
+		if err != nil {
+			return nil, err
+		}
+		sources = []permissions.Source{prof, project, local}
+	} else {
+		// claudectx run passes the profile with --settings, which takes
+		// precedence over the project and local scopes
+		user, err := loadPermissionSource(permissions.ScopeUser, userPath)
+		if err != nil {
+			return nil, err
+		}
+		prof, err := s.Load(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load profile: %w", err)
+		}
+		profPath, _ := paths.ProfileFile(name, "settings.json")
+		profSource := permissions.Source{Scope: permissions.ScopeProfile, Path: profPath, Found: true}
+		if prof.Settings != nil {
+			profSource.Permissions = prof.Settings.Permissions
+		}
+		sources = []permissions.Source{user, project, local, profSource}
+	}
+
+	result := &EffectivePermissions{
+		Profile: name,
+		Project: projectDir,
+		Mode:    mode,
+		Result:  permissions.Merge(sources, permissions.ScopeProfile),
+	}
+	for _, src := range sources {
+		ps := PermissionSource{Scope: src.Scope, Path: src.Path, Found: src.Found}
+		if src.Permissions != nil {
+			ps.Rules = len(src.Permissions.Allow) + len(src.Permissions.Deny) + len(src.Permissions.Ask)
+		}
+		result.Sources = append(result.Sources, ps)
+	}
+	return result, nil
+}
+
+// loadPermissionSource reads the permissions from a settings file. A missing
+// file is not an error.
+func loadPermissionSource(scope, path string) (permissions.Source, error) {
+	src := permissions.Source{Scope: scope, Path: path}
+	if !config.FileExists(path) {
+		return src, nil
+	}
+	settings, err := config.LoadSettings(path)
+	if err != nil {
+		return src, fmt.Errorf("failed to load %s settings %s: %w", scope, path, err)
+	}
+	src.Found = true
+	src.Permissions = settings.Permissions
+	return src, nil
+}
+
+// printEffectivePermissions renders EffectivePermissions for a terminal
+func printEffectivePermissions(e *EffectivePermissions) {
+	fmt.Printf("Effective permissions for %s in %s\n", printer.Colorize(e.Profile, printer.Cyan), e.Project)
+	if e.Mode == "switch" {
+		fmt.Println(printer.Dim("Current profile: applied as the user settings (lowest precedence)"))
+	} else {
+		fmt.Println(printer.Dim("Not current: applied with claudectx run --settings (above project and local)"))
+	}
+
+	fmt.Println()
+	fmt.Println(printer.Bold("Scopes") + printer.Dim(" (lowest to highest precedence)"))
+	for _, src := range e.Sources {
+		status := fmt.Sprintf("%d rules", src.Rules)
+		if src.Rules == 1 {
+			status = "1 rule"
+		}
+		if !src.Found {
+			status = "not found"
+		}
+		fmt.Printf("  %-8s %s %s\n", src.Scope, src.Path, printer.Dim("("+status+")"))
+	}
+
+	fmt.Println()
+	if e.Result.DefaultMode != "" {
+		fmt.Printf("Default mode: %s %s\n", e.Result.DefaultMode, printer.Dim("(from "+e.Result.DefaultModeScope+")"))
+	} else {
+		fmt.Printf("Default mode: %s\n", printer.Dim("default"))
+	}
+
+	printScopedRules("Deny", e.Result.Deny)
+	printScopedRules("Ask", e.Result.Ask)
+	printScopedRules("Allow", e.Result.Allow)
+
+	if len(e.Result.Overrides) > 0 {
+		fmt.Println()
+		printer.Warning("⚠ The profile's permissions are overridden:")
+		for _, override := range e.Result.Overrides {
+			fmt.Printf("  - %s\n", override)
+		}
+	}
+}
+
+// printScopedRules prints a merged rule list with the scopes that
+// contributed each rule
+func printScopedRules(label string, rules []permissions.ScopedRule) {
+	fmt.Println()
+	fmt.Println(printer.Bold(label))
+	if len(rules) == 0 {
+		fmt.Printf("  %s\n", printer.Dim("(none)"))
+		return
+	}
+	width := 0
+	for _, r := range rules {
+		width = max(width, len(r.Rule))
+	}
+	for _, r := range rules {
+		fmt.Printf("  %-*s  %s\n", width, r.Rule, printer.Dim(strings.Join(r.Scopes, ", ")))
+	}
+}
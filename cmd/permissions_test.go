@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/permissions"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+func TestParsePermissionsArgs(t *testing.T) {
+	opts, err := ParsePermissionsArgs([]string{"work", "--project", "/src/app", "--json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ProfileName != "work" || opts.ProjectDir != "/src/app" || !opts.JSON {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	for _, args := range [][]string{{"--project"}, {"a", "b"}, {"--bogus"}} {
+		if _, err := ParsePermissionsArgs(args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+// writeSettings writes a settings file, creating its directory
+func writeSettings(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildEffectivePermissions(t *testing.T) {
+	s, home := setupRunTest(t)
+	project := t.TempDir()
+
+	writeSettings(t, filepath.Join(home, ".claude", "settings.json"), `{"permissions":{"allow":["WebFetch"]}}`)
+	writeSettings(t, filepath.Join(project, ".claude", "settings.json"), `{"permissions":{"deny":["Bash(rm:*)"]}}`)
+	writeSettings(t, filepath.Join(project, ".claude", "settings.local.json"), `{"permissions":{"defaultMode":"acceptEdits"}}`)
+
+	work := profile.NewProfile("work")
+	work.Settings.Permissions = &config.Permissions{Allow: []string{"Bash(rm -rf build)", "Read"}}
+	saveProfile(t, s, work)
+
+	result, err := BuildEffectivePermissions(s, PermissionsOptions{ProfileName: "work", ProjectDir: project})
+	if err != nil {
+		t.Fatalf("BuildEffectivePermissions failed: %v", err)
+	}
+
+	if result.Mode != "run" || len(result.Sources) != 4 || result.Sources[3].Scope != permissions.ScopeProfile {
+		t.Errorf("unexpected sources %+v (mode %s)", result.Sources, result.Mode)
+	}
+	eff := result.Result
+	if len(eff.Allow) != 3 || len(eff.Deny) != 1 || eff.DefaultMode != "acceptEdits" || eff.DefaultModeScope != permissions.ScopeLocal {
+		t.Errorf("unexpected effective permissions %+v", eff)
+	}
+	// The project deny blocks the profile's allow, and the user allow
+	// widens it
+	if len(eff.Overrides) != 2 {
+		t.Errorf("expected two overrides, got %v", eff.Overrides)
+	}
+}
+
+func TestBuildEffectivePermissions_CurrentProfileUsesLiveSettings(t *testing.T) {
+	s, home := setupRunTest(t)
+	project := t.TempDir()
+
+	saveProfile(t, s, profile.NewProfile("work"))
+	setCurrentProfile(t, s, "work")
+	writeSettings(t, filepath.Join(home, ".claude", "settings.json"), `{"permissions":{"allow":["Read"]}}`)
+
+	result, err := BuildEffectivePermissions(s, PermissionsOptions{ProjectDir: project})
+	if err != nil {
+		t.Fatalf("BuildEffectivePermissions failed: %v", err)
+	}
+	if result.Profile != "work" || result.Mode != "switch" || len(result.Sources) != 3 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(result.Result.Allow) != 1 || result.Result.Allow[0].Scopes[0] != permissions.ScopeProfile {
+		t.Errorf("live settings should count as the profile, got %+v", result.Result.Allow)
+	}
+	if result.Sources[1].Found {
+		t.Error("missing project settings should be reported as not found")
+	}
+}
+
+func TestBuildEffectivePermissions_Errors(t *testing.T) {
+	s, _ := setupRunTest(t)
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	if _, err := BuildEffectivePermissions(s, PermissionsOptions{}); err == nil {
+		t.Error("expected an error with no profile and no current profile")
+	}
+	if _, err := BuildEffectivePermissions(s, PermissionsOptions{ProfileName: "nope"}); err == nil {
+		t.Error("expected an error for a missing profile")
+	}
+	if _, err := BuildEffectivePermissions(s, PermissionsOptions{ProfileName: "work", ProjectDir: "/does/not/exist"}); err == nil {
+		t.Error("expected an error for a missing project directory")
+	}
+
+	project := t.TempDir()
+	writeSettings(t, filepath.Join(project, ".claude", "settings.json"), `{not json`)
+	if _, err := BuildEffectivePermissions(s, PermissionsOptions{ProfileName: "work", ProjectDir: project}); err == nil {
+		t.Error("expected an error for invalid project settings")
+	}
+}
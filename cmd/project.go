@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/backup"
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
+	"github.com/johnfox/claudectx/internal/store"
+	"github.com/johnfox/claudectx/internal/validator"
+)
+
+// Project command actions
+const (
+	ProjectSwitch  = "switch"
+	ProjectToggle  = "toggle"
+	ProjectCurrent = "current"
+	ProjectSync    = "sync"
+)
+
+// ProjectOptions holds the parsed arguments for "claudectx --project".
+type ProjectOptions struct {
+	Dir         string
+	Action      string
+	ProfileName string
+}
+
+// ParseProjectArgs parses the arguments following "claudectx --project"
+// (or the value of --project=DIR followed by the rest). Valid forms:
+//
+//	--project DIR [NAME]       apply NAME's project part to DIR (picked if omitted)
+//	--project DIR -            switch DIR back to its previous profile
+//	--project DIR -c           show the profile applied to DIR
+//	--project DIR sync [NAME]  save DIR's local files to NAME (or DIR's current)
+func ParseProjectArgs(args []string) (ProjectOptions, error) {
+	if len(args) == 0 || args[0] == "" {
+		return ProjectOptions{}, errors.New("--project requires a directory")
+	}
+	opts := ProjectOptions{Dir: args[0]}
+	rest := args[1:]
+
+	if len(rest) == 0 {
+		// No name: the profile is picked interactively
+		opts.Action = ProjectSwitch
+		return opts, nil
+	}
+
+	switch rest[0] {
+	case "-":
+		opts.Action = ProjectToggle
+	case "-c", "--current":
+		opts.Action = ProjectCurrent
+	case "sync":
+		opts.Action = ProjectSync
+		if len(rest) > 1 {
+			opts.ProfileName = rest[1]
+			rest = rest[1:]
+		}
+	default:
+		if strings.HasPrefix(rest[0], "-") {
+			return ProjectOptions{}, fmt.Errorf("unknown project flag %q", rest[0])
+		}
+		opts.Action = ProjectSwitch
+		opts.ProfileName = rest[0]
+	}
+
+	if len(rest) > 1 {
+		return ProjectOptions{}, fmt.Errorf("unexpected argument %q", rest[1])
+	}
+	return opts, nil
+}
+
+// RunProject performs a parsed --project command
+func RunProject(s *store.Store, opts ProjectOptions) error {
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return fmt.Errorf("invalid project directory: %w", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("project directory %q does not exist", dir)
+	}
+
+	switch opts.Action {
+	case ProjectToggle:
+		return ToggleProjectPrevious(s, dir)
+	case ProjectCurrent:
+		return ShowProjectCurrent(s, dir)
+	case ProjectSync:
+		return SyncProjectProfile(s, dir, opts.ProfileName)
+	default:
+		name, ok, err := chooseProfile(s, opts.ProfileName, fmt.Sprintf("Apply which profile to %s?", dir))
+		if err != nil || !ok {
+			return err
+		}
+		return SwitchProjectProfile(s, dir, name)
+	}
+}
+
+// SwitchProjectProfile applies a profile's project part to a project's
+// .claude/settings.local.json, CLAUDE.local.md and .mcp.json, with the
+// same backup, auto-sync and rollback behaviour as SwitchProfile
+func SwitchProjectProfile(s *store.Store, dir, name string) error {
+	if err := profile.ValidateProfileName(name); err != nil {
+		return fmt.Errorf("invalid profile name: %w", err)
+	}
+
+	if !s.Exists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	prof, err := s.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	if prof.Project.IsEmpty() {
+		return fmt.Errorf("profile %q has no project configuration (capture one with: claudectx --project %s sync %s)", name, dir, name)
+	}
+
+	if prof.Project.Settings != nil {
+		if err := validator.ValidateSettings(prof.Project.Settings); err != nil {
+			return fmt.Errorf("profile project settings are invalid: %w", err)
+		}
+	}
+	if err := validator.ValidateClaudeMD(prof.Project.ClaudeMD); err != nil {
+		return fmt.Errorf("profile CLAUDE.local.md is invalid: %w", err)
+	}
+
+	backupMgr, err := backup.NewProjectManager(dir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize backup manager: %w", err)
+	}
+
+	backupID, err := backupMgr.Create(fmt.Sprintf("switch %s to %s", dir, name))
+	if err != nil {
+		printer.Warning("Warning: Failed to create backup: %v", err)
+		printer.Warning("Continuing without backup...")
+	} else {
+		printer.Info("Created backup: %s", backupID)
+	}
+
+	currentName, err := s.GetProjectCurrent(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get current project profile: %w", err)
+	}
+
+	// Auto-sync: save local changes back to the project's current profile
+	if currentName != "" && currentName != name && s.Exists(currentName) {
+		changed, err := projectConfigChanged(s, dir, currentName)
+		if err != nil {
+			printer.Warning("Warning: Could not detect project config changes: %v", err)
+		} else if changed {
+			// Keep the pre-sync profile so a rollback also undoes the sync
+			if backupID != "" {
+				if err := backupMgr.SnapshotProfile(backupID, currentName); err != nil {
+					printer.Warning("Warning: Failed to back up profile %q: %v", currentName, err)
+				}
+			}
+			printer.Info("Auto-syncing project changes to profile %q...", currentName)
+			if err := syncProjectConfig(s, dir, currentName); err != nil {
+				printer.Warning("Warning: Failed to auto-sync profile: %v", err)
+				printer.Warning("Continuing with switch anyway...")
+			} else {
+				printer.Success("Auto-synced project changes to profile %q", currentName)
+			}
+		}
+	}
+
+	if err := applyProjectConfig(dir, prof.Project); err != nil {
+		rollback(backupMgr, backupID)
+		return err
+	}
+
+	if currentName != "" {
+		if err := s.SetProjectPrevious(dir, currentName); err != nil {
+			rollback(backupMgr, backupID)
+			return fmt.Errorf("failed to set previous project profile: %w", err)
+		}
+	}
+
+	if err := s.SetProjectCurrent(dir, name); err != nil {
+		rollback(backupMgr, backupID)
+		return fmt.Errorf("failed to set current project profile: %w", err)
+	}
+
+	pruneBackups(backupMgr)
+
+	if err := s.MarkUsed(name); err != nil {
+		printer.Warning("Warning: Failed to update profile metadata: %v", err)
+	}
+
+	printer.Success("Switched %s to profile %q", dir, name)
+	return nil
+}
+
+// ToggleProjectPrevious switches a project back to its previous profile
+func ToggleProjectPrevious(s *store.Store, dir string) error {
+	prev, err := s.GetProjectPrevious(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get previous project profile: %w", err)
+	}
+
+	if prev == "" {
+		return fmt.Errorf("no previous profile to switch to in %s", dir)
+	}
+
+	if !s.Exists(prev) {
+		return fmt.Errorf("previous profile %q no longer exists", prev)
+	}
+
+	return SwitchProjectProfile(s, dir, prev)
+}
+
+// ShowProjectCurrent prints the profile applied to a project
+func ShowProjectCurrent(s *store.Store, dir string) error {
+	current, err := s.GetProjectCurrent(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get current project profile: %w", err)
+	}
+
+	if current == "" {
+		printer.Info("No profile is currently active in %s", dir)
+		return nil
+	}
+
+	fmt.Println(printer.Colorize(current, printer.Cyan))
+	return nil
+}
+
+// SyncProjectProfile saves a project's local configuration files as the
+// project part of a profile (the project's current profile if name is empty)
+func SyncProjectProfile(s *store.Store, dir, name string) error {
+	if name == "" {
+		current, err := s.GetProjectCurrent(dir)
+		if err != nil {
+			return fmt.Errorf("failed to get current project profile: %w", err)
+		}
+		if current == "" {
+			return fmt.Errorf("no current profile in %s to sync to", dir)
+		}
+		name = current
+	}
+
+	if !s.Exists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	if err := syncProjectConfig(s, dir, name); err != nil {
+		return err
+	}
+
+	printer.Success("Synced project configuration from %s to profile %q", dir, name)
+	return nil
+}
+
+// syncProjectConfig replaces a profile's project part with the project's
+// local configuration files
+func syncProjectConfig(s *store.Store, dir, name string) error {
+	live, err := readProjectConfig(dir)
+	if err != nil {
+		return err
+	}
+
+	prof, err := s.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	prof.Project = live
+	if live.IsEmpty() {
+		prof.Project = nil
+	}
+	prof.Touch()
+
+	if err := s.Save(prof); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return nil
+}
+
+// projectConfigChanged reports whether a project's local configuration
+// differs from the project part stored in the profile
+func projectConfigChanged(s *store.Store, dir, name string) (bool, error) {
+	stored, err := s.Load(name)
+	if err != nil {
+		return false, fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	live, err := readProjectConfig(dir)
+	if err != nil {
+		// If the files can't be read, consider them changed
+		return true, nil
+	}
+
+	project := stored.Project
+	if project == nil {
+		project = &profile.ProjectConfig{}
+	}
+	return !profilesEqual(live.Settings, live.ClaudeMD, live.MCPServers, project.Settings, project.ClaudeMD, project.MCPServers), nil
+}
+
+// readProjectConfig reads a project's .claude/settings.local.json,
+// CLAUDE.local.md and .mcp.json servers. Missing files are left empty.
+func readProjectConfig(dir string) (*profile.ProjectConfig, error) {
+	project := &profile.ProjectConfig{}
+
+	settingsPath := paths.ProjectSettingsLocalFile(dir)
+	if config.FileExists(settingsPath) {
+		settings, err := config.LoadSettings(settingsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", settingsPath, err)
+		}
+		project.Settings = settings
+	}
+
+	claudeMDPath := paths.ProjectClaudeMDLocalFile(dir)
+	if config.FileExists(claudeMDPath) {
+		content, err := os.ReadFile(claudeMDPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", claudeMDPath, err)
+		}
+		project.ClaudeMD = string(content)
+	}
+
+	servers, err := mcpconfig.LoadMCPServers(paths.ProjectMCPFile(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", paths.ProjectMCPFile(dir), err)
+	}
+	if len(servers) > 0 {
+		project.MCPServers = servers
+	}
+
+	return project, nil
+}
+
+// applyProjectConfig writes a profile's project part into a project. Files
+// the profile does not define are removed; .mcp.json keeps any keys other
+// than mcpServers. Callers are responsible for backup/rollback.
+func applyProjectConfig(dir string, project *profile.ProjectConfig) error {
+	settingsPath := paths.ProjectSettingsLocalFile(dir)
+	if project.Settings != nil {
+		if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+			return fmt.Errorf("failed to create .claude directory: %w", err)
+		}
+		if err := config.SaveSettings(settingsPath, project.Settings); err != nil {
+			return fmt.Errorf("failed to save settings.local.json: %w", err)
+		}
+	} else if config.FileExists(settingsPath) {
+		os.Remove(settingsPath)
+	}
+
+	claudeMDPath := paths.ProjectClaudeMDLocalFile(dir)
+	if project.ClaudeMD != "" {
+		if err := os.WriteFile(claudeMDPath, []byte(project.ClaudeMD), 0644); err != nil {
+			return fmt.Errorf("failed to write CLAUDE.local.md: %w", err)
+		}
+	} else if config.FileExists(claudeMDPath) {
+		os.Remove(claudeMDPath)
+	}
+
+	mcpPath := paths.ProjectMCPFile(dir)
+	if len(project.MCPServers) > 0 || config.FileExists(mcpPath) {
+		if err := mcpconfig.SaveMCPServers(mcpPath, project.MCPServers); err != nil {
+			return fmt.Errorf("failed to save .mcp.json: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+func TestParseProjectArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    ProjectOptions
+		wantErr bool
+	}{
+		{args: []string{".", "work"}, want: ProjectOptions{Dir: ".", Action: ProjectSwitch, ProfileName: "work"}},
+		{args: []string{".", "-"}, want: ProjectOptions{Dir: ".", Action: ProjectToggle}},
+		{args: []string{"~/src/app", "-c"}, want: ProjectOptions{Dir: "~/src/app", Action: ProjectCurrent}},
+		{args: []string{".", "sync"}, want: ProjectOptions{Dir: ".", Action: ProjectSync}},
+		{args: []string{".", "sync", "work"}, want: ProjectOptions{Dir: ".", Action: ProjectSync, ProfileName: "work"}},
+		{args: nil, wantErr: true},
+		{args: []string{"."}, want: ProjectOptions{Dir: ".", Action: ProjectSwitch}},
+		{args: []string{".", "--bogus"}, wantErr: true},
+		{args: []string{".", "work", "extra"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseProjectArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseProjectArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseProjectArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func projectProfile(name, model, claudeMD string) *profile.Profile {
+	prof := profile.NewProfile(name)
+	prof.Project = &profile.ProjectConfig{
+		Settings: &config.Settings{Model: model},
+		ClaudeMD: claudeMD,
+	}
+	return prof
+}
+
+func TestSwitchProjectProfile(t *testing.T) {
+	s, _ := setupRunTest(t)
+	project := t.TempDir()
+
+	work := projectProfile("work", "opus", "# Work notes")
+	work.Project.MCPServers = mcpconfig.MCPServers{"db": {Command: "db-mcp"}}
+	saveProfile(t, s, work)
+	saveProfile(t, s, projectProfile("review", "haiku", ""))
+
+	// .mcp.json keys other than mcpServers are preserved
+	writeSettings(t, paths.ProjectMCPFile(project), `{"$schema": "x"}`)
+
+	if err := SwitchProjectProfile(s, project, "work"); err != nil {
+		t.Fatalf("SwitchProjectProfile() failed: %v", err)
+	}
+
+	settings, err := config.LoadSettings(paths.ProjectSettingsLocalFile(project))
+	if err != nil || settings.Model != "opus" {
+		t.Errorf("settings.local.json model = %v (err %v), want opus", settings, err)
+	}
+	if data, _ := os.ReadFile(paths.ProjectClaudeMDLocalFile(project)); string(data) != "# Work notes" {
+		t.Errorf("CLAUDE.local.md = %q", data)
+	}
+	mcpData, _ := os.ReadFile(paths.ProjectMCPFile(project))
+	if !strings.Contains(string(mcpData), "db-mcp") || !strings.Contains(string(mcpData), "$schema") {
+		t.Errorf(".mcp.json = %s", mcpData)
+	}
+
+	if err := SwitchProjectProfile(s, project, "review"); err != nil {
+		t.Fatalf("SwitchProjectProfile() failed: %v", err)
+	}
+	if config.FileExists(paths.ProjectClaudeMDLocalFile(project)) {
+		t.Error("CLAUDE.local.md should be removed for a profile without one")
+	}
+	if current, _ := s.GetProjectCurrent(project); current != "review" {
+		t.Errorf("project current = %q, want review", current)
+	}
+	if previous, _ := s.GetProjectPrevious(project); previous != "work" {
+		t.Errorf("project previous = %q, want work", previous)
+	}
+	if current, _ := s.GetCurrent(); current != "" {
+		t.Errorf("global current profile should be untouched, got %q", current)
+	}
+
+	if err := ToggleProjectPrevious(s, project); err != nil {
+		t.Fatalf("ToggleProjectPrevious() failed: %v", err)
+	}
+	if current, _ := s.GetProjectCurrent(project); current != "work" {
+		t.Errorf("project current after toggle = %q, want work", current)
+	}
+}
+
+func TestSwitchProjectProfile_NoProjectPart(t *testing.T) {
+	s, _ := setupRunTest(t)
+	project := t.TempDir()
+	saveProfile(t, s, profile.NewProfile("plain"))
+
+	err := SwitchProjectProfile(s, project, "plain")
+	if err == nil || !strings.Contains(err.Error(), "no project configuration") {
+		t.Fatalf("expected no project configuration error, got %v", err)
+	}
+	if config.FileExists(filepath.Join(project, ".claude")) {
+		t.Error("project should not be modified")
+	}
+}
+
+func TestSwitchProjectProfile_AutoSyncsCurrent(t *testing.T) {
+	s, _ := setupRunTest(t)
+	project := t.TempDir()
+	saveProfile(t, s, projectProfile("work", "opus", ""))
+	saveProfile(t, s, projectProfile("review", "haiku", ""))
+
+	if err := SwitchProjectProfile(s, project, "work"); err != nil {
+		t.Fatalf("SwitchProjectProfile() failed: %v", err)
+	}
+
+	// Edit the local settings, then switch away
+	writeSettings(t, paths.ProjectSettingsLocalFile(project), `{"model": "sonnet"}`)
+	if err := SwitchProjectProfile(s, project, "review"); err != nil {
+		t.Fatalf("SwitchProjectProfile() failed: %v", err)
+	}
+
+	work, err := s.Load("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.Project.Settings.Model != "sonnet" {
+		t.Errorf("auto-synced model = %q, want sonnet", work.Project.Settings.Model)
+	}
+}
+
+func TestSyncProjectProfile(t *testing.T) {
+	s, _ := setupRunTest(t)
+	project := t.TempDir()
+	saveProfile(t, s, profile.NewProfile("work"))
+
+	writeSettings(t, paths.ProjectSettingsLocalFile(project), `{"permissions": {"allow": ["Bash(make *)"]}}`)
+	writeSettings(t, paths.ProjectClaudeMDLocalFile(project), "# Local")
+
+	if err := SyncProjectProfile(s, project, ""); err == nil {
+		t.Error("expected error syncing without a current project profile")
+	}
+
+	if err := SyncProjectProfile(s, project, "work"); err != nil {
+		t.Fatalf("SyncProjectProfile() failed: %v", err)
+	}
+
+	work, err := s.Load("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.Project == nil || work.Project.ClaudeMD != "# Local" || len(work.Project.Settings.Permissions.Allow) != 1 {
+		t.Errorf("project part = %+v", work.Project)
+	}
+	if work.Project.MCPServers != nil {
+		t.Errorf("expected no project MCP servers, got %v", work.Project.MCPServers)
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// promptInput is where interactive answers are read from (replaced in tests)
+var promptInput io.Reader = os.Stdin
+
+// promptReader buffers promptInput across successive prompts
+var promptReader *bufio.Reader
+
+// readLine prints a prompt and returns the trimmed line entered by the user.
+// io.EOF is returned when there is no more input.
+func readLine(prompt string) (string, error) {
+	if promptReader == nil {
+		promptReader = bufio.NewReader(promptInput)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := promptReader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// confirm asks a yes/no question. An empty answer returns defaultYes, and
+// a closed input returns false.
+func confirm(question string, defaultYes bool) bool {
+	hint := "[y/N]"
+	if defaultYes {
+		hint = "[Y/n]"
+	}
+
+	answer, err := readLine(fmt.Sprintf("%s %s ", question, hint))
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(answer) {
+	case "":
+		return defaultYes
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// readSecret is like readLine but does not echo the answer when reading
+// from a terminal
+func readSecret(prompt string) (string, error) {
+	if f, ok := promptInput.(*os.File); ok && (promptReader == nil || promptReader.Buffered() == 0) && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(os.Stderr, prompt)
+		secret, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(secret)), nil
+	}
+	return readLine(prompt)
+}
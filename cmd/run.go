@@ -22,6 +22,11 @@ type RunOptions struct {
 	ProfileName string
 	ClaudeArgs  []string
 	DryRun      bool
+	// MCPOnly limits the session to these MCP servers; it may name
+	// disabled servers to use them for this session
+	MCPOnly []string
+	// MCPExclude leaves these MCP servers out of the session
+	MCPExclude []string
 }
 
 // RunResult holds output from a RunProfile call.
@@ -39,13 +44,15 @@ type RunResult struct {
 //	run --dry-run <profile>
 //	run <profile> -- <claude args...>
 //	run --dry-run <profile> -- <claude args...>
+//	run <profile> --mcp-only a,b --mcp-exclude c
 func ParseRunArgs(args []string) (RunOptions, error) {
 	var opts RunOptions
 	remaining := make([]string, 0, len(args))
 
-	// First pass: extract --dry-run and find the -- separator.
+	// First pass: extract claudectx flags and find the -- separator.
 	separatorIdx := -1
-	for i, a := range args {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
 		if a == "--dry-run" {
 			opts.DryRun = true
 			continue
@@ -54,6 +61,22 @@ func ParseRunArgs(args []string) (RunOptions, error) {
 			separatorIdx = i
 			break
 		}
+		if flag, value, hasValue := strings.Cut(a, "="); flag == "--mcp-only" || flag == "--mcp-exclude" {
+			if !hasValue {
+				if i+1 >= len(args) || args[i+1] == "--" {
+					return RunOptions{}, fmt.Errorf("%s requires a comma-separated list of MCP servers", flag)
+				}
+				i++
+				value = args[i]
+			}
+			names := splitServerList(value)
+			if flag == "--mcp-only" {
+				opts.MCPOnly = append(opts.MCPOnly, names...)
+			} else {
+				opts.MCPExclude = append(opts.MCPExclude, names...)
+			}
+			continue
+		}
 		remaining = append(remaining, a)
 	}
 
@@ -84,7 +107,8 @@ func ParseRunArgs(args []string) (RunOptions, error) {
 
 // RunProfile launches claude with the named profile's settings without
 // modifying global claudectx state (settings.json, CLAUDE.md, claude.json,
-// current/previous profile trackers).
+// current/previous profile trackers). Only the profile's usage stats in
+// profile.json are updated.
 //
 // When opts.DryRun is true the function returns the generated command args
 // without executing claude and without creating any temp files.
@@ -133,10 +157,17 @@ func RunProfile(s *store.Store, opts RunOptions) (RunResult, error) {
 		claudeArgs = append(claudeArgs, "--append-system-prompt-file", claudeMDPath)
 	}
 
-	// --mcp-config + --strict-mcp-config only when profile has MCP servers.
+	servers, err := sessionMCPServers(prof, opts)
+	if err != nil {
+		return result, err
+	}
+
+	// --mcp-config + --strict-mcp-config only when profile has MCP servers,
+	// including disabled ones, so a profile whose servers are all disabled or
+	// excluded still keeps the global servers out of the session.
 	// In dry-run mode we compute the would-be path but do not create any files.
 	var tempDir string
-	if len(prof.MCPServers) > 0 {
+	if len(prof.MCPServers) > 0 || len(prof.DisabledMCPServers) > 0 {
 		base, pathErr := paths.RunTempDir()
 		if pathErr != nil {
 			return result, fmt.Errorf("failed to resolve run temp dir: %w", pathErr)
@@ -150,7 +181,7 @@ func RunProfile(s *store.Store, opts RunOptions) (RunResult, error) {
 				return result, fmt.Errorf("failed to create temp dir for MCP config: %w", err)
 			}
 			result.TempDir = tempDir
-			if err := mcpconfig.SaveClaudeMCPConfig(mcpPath, prof.MCPServers); err != nil {
+			if err := mcpconfig.SaveClaudeMCPConfig(mcpPath, servers); err != nil {
 				_ = os.RemoveAll(tempDir)
 				return result, fmt.Errorf("failed to write MCP config: %w", err)
 			}
@@ -171,6 +202,11 @@ func RunProfile(s *store.Store, opts RunOptions) (RunResult, error) {
 
 	printer.Info("Running Claude with profile %q for this session only", opts.ProfileName)
 
+	// Record usage in profile.json (best-effort)
+	if err := s.MarkUsed(opts.ProfileName); err != nil {
+		printer.Warning("Warning: Failed to update profile metadata: %v", err)
+	}
+
 	exitCode, err := execClaude(claudeArgs)
 	result.ExitCode = exitCode
 
@@ -188,6 +224,55 @@ func RunProfile(s *store.Store, opts RunOptions) (RunResult, error) {
 	return result, nil
 }
 
+// sessionMCPServers returns the profile's MCP servers for a run session:
+// the enabled servers, or those named by --mcp-only, minus --mcp-exclude
+func sessionMCPServers(prof *profile.Profile, opts RunOptions) (mcpconfig.MCPServers, error) {
+	lookup := func(name string) (mcpconfig.MCPServer, error) {
+		if server, ok := prof.MCPServers[name]; ok {
+			return server, nil
+		}
+		if server, ok := prof.DisabledMCPServers[name]; ok {
+			return server, nil
+		}
+		return mcpconfig.MCPServer{}, fmt.Errorf("profile %q has no MCP server %q", prof.Name, name)
+	}
+
+	servers := make(mcpconfig.MCPServers)
+	if len(opts.MCPOnly) > 0 {
+		for _, name := range opts.MCPOnly {
+			server, err := lookup(name)
+			if err != nil {
+				return nil, err
+			}
+			servers[name] = server
+		}
+	} else {
+		for name, server := range prof.MCPServers {
+			servers[name] = server
+		}
+	}
+
+	for _, name := range opts.MCPExclude {
+		if _, err := lookup(name); err != nil {
+			return nil, err
+		}
+		delete(servers, name)
+	}
+
+	return servers, nil
+}
+
+// splitServerList splits a comma-separated list of MCP server names
+func splitServerList(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // execClaude runs the claude binary with the given args, inheriting stdio.
 // Returns the child exit code and any exec-level error (e.g. binary not found).
 func execClaude(args []string) (int, error) {
@@ -133,3 +133,25 @@ func TestParseRunArgs_PassThroughPreservesOrder(t *testing.T) {
 		t.Errorf("ClaudeArgs = %v, want %v", opts.ClaudeArgs, want)
 	}
 }
+
+// TestParseRunArgs_MCPFilters verifies --mcp-only and --mcp-exclude in both
+// the separate and = forms.
+func TestParseRunArgs_MCPFilters(t *testing.T) {
+	opts, err := ParseRunArgs([]string{"work", "--mcp-only", "github, linear", "--mcp-exclude=playwright", "--", "--mcp-only", "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(opts.MCPOnly, []string{"github", "linear"}) {
+		t.Errorf("MCPOnly = %v", opts.MCPOnly)
+	}
+	if !reflect.DeepEqual(opts.MCPExclude, []string{"playwright"}) {
+		t.Errorf("MCPExclude = %v", opts.MCPExclude)
+	}
+	if !reflect.DeepEqual(opts.ClaudeArgs, []string{"--mcp-only", "x"}) {
+		t.Errorf("ClaudeArgs = %v, flags after -- belong to claude", opts.ClaudeArgs)
+	}
+
+	if _, err := ParseRunArgs([]string{"work", "--mcp-only"}); err == nil {
+		t.Error("expected error for --mcp-only without a value")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -599,3 +601,53 @@ func indexOf(args []string, target string) int {
 	}
 	return -1
 }
+
+func TestRunProfile_MCPFilters(t *testing.T) {
+	s, _ := setupRunTest(t)
+	p := profile.NewProfile("work")
+	p.MCPServers = mcpconfig.MCPServers{
+		"github": {Command: "gh-mcp"},
+		"linear": {URL: "https://mcp.linear.app/mcp"},
+	}
+	p.DisabledMCPServers = mcpconfig.MCPServers{"playwright": {Command: "pw-mcp"}}
+	saveProfile(t, s, p)
+
+	names := func(opts RunOptions) []string {
+		t.Helper()
+		prof, _ := s.Load("work")
+		servers, err := sessionMCPServers(prof, opts)
+		if err != nil {
+			t.Fatalf("sessionMCPServers(%+v) failed: %v", opts, err)
+		}
+		var list []string
+		for name := range servers {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		return list
+	}
+
+	if got := names(RunOptions{}); !reflect.DeepEqual(got, []string{"github", "linear"}) {
+		t.Errorf("default servers = %v, disabled servers should be left out", got)
+	}
+	if got := names(RunOptions{MCPExclude: []string{"linear"}}); !reflect.DeepEqual(got, []string{"github"}) {
+		t.Errorf("--mcp-exclude linear = %v", got)
+	}
+	if got := names(RunOptions{MCPOnly: []string{"playwright"}}); !reflect.DeepEqual(got, []string{"playwright"}) {
+		t.Errorf("--mcp-only playwright = %v, should enable a disabled server", got)
+	}
+
+	_, err := RunProfile(s, RunOptions{ProfileName: "work", DryRun: true, MCPOnly: []string{"missing"}})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected error for unknown server, got %v", err)
+	}
+
+	// Excluding every server still keeps global servers out of the session
+	result, err := RunProfile(s, RunOptions{ProfileName: "work", DryRun: true, MCPExclude: []string{"github", "linear"}})
+	if err != nil {
+		t.Fatalf("RunProfile failed: %v", err)
+	}
+	if !containsString(result.GeneratedArgs, "--strict-mcp-config") {
+		t.Errorf("args = %v, want --strict-mcp-config", result.GeneratedArgs)
+	}
+}
@@ -0,0 +1,731 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/health"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/printer"
+	"github.com/johnfox/claudectx/internal/profile"
+	"github.com/johnfox/claudectx/internal/store"
+)
+
+// showSections lists the sections accepted by --section, in display order
+var showSections = []string{"settings", "permissions", "mcp", "claude-md", "files", "health"}
+
+// ShowOptions holds the parsed arguments for the show command.
+type ShowOptions struct {
+	ProfileName string
+	Reveal      bool
+	JSON        bool
+	Sections    []string
+}
+
+// ProfileDetails is the inspected view of a profile, used for both the
+// human-readable and --json output of the show command.
+type ProfileDetails struct {
+	Name        string                     `json:"name"`
+	Current     bool                       `json:"current"`
+	Metadata    profile.Metadata           `json:"metadata"`
+	Model       string                     `json:"model,omitempty"`
+	Env         map[string]string          `json:"env,omitempty"`
+	Extras      map[string]json.RawMessage `json:"extras,omitempty"`
+	Permissions *PermissionDetails         `json:"permissions,omitempty"`
+	MCPServers  []MCPServerDetails         `json:"mcpServers,omitempty"`
+	ClaudeMD    *ClaudeMDDetails           `json:"claudeMd,omitempty"`
+	Files       []FileDetails              `json:"files,omitempty"`
+	Health      *HealthDetails             `json:"health,omitempty"`
+
+	// masked is set when secret values were hidden
+	masked bool
+}
+
+// PermissionDetails describes the permissions section of a profile
+type PermissionDetails struct {
+	Allow  []string                   `json:"allow,omitempty"`
+	Deny   []string                   `json:"deny,omitempty"`
+	Ask    []string                   `json:"ask,omitempty"`
+	Extras map[string]json.RawMessage `json:"extras,omitempty"`
+}
+
+// MCPServerDetails describes a single MCP server in a profile
+type MCPServerDetails struct {
+	Name      string            `json:"name"`
+	Transport string            `json:"transport"`
+	Command   string            `json:"command,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Disabled  bool              `json:"disabled,omitempty"`
+
+	Cwd           string                 `json:"cwd,omitempty"`
+	Timeout       int                    `json:"timeout,omitempty"`
+	HeadersHelper string                 `json:"headersHelper,omitempty"`
+	OAuth         *mcpconfig.OAuthConfig `json:"oauth,omitempty"`
+
+	// masked is set when secret values were hidden
+	masked bool
+}
+
+// ClaudeMDDetails summarises a profile's CLAUDE.md
+type ClaudeMDDetails struct {
+	Bytes   int      `json:"bytes"`
+	Lines   int      `json:"lines"`
+	Outline []string `json:"outline,omitempty"`
+}
+
+// FileDetails describes a file stored in a profile directory
+type FileDetails struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// HealthDetails summarises a profile health check
+type HealthDetails struct {
+	Summary  string           `json:"summary"`
+	Warnings []string         `json:"warnings,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	Findings []health.Finding `json:"findings,omitempty"`
+}
+
+// ParseShowArgs parses the arguments following "claudectx show".
+// Valid forms:
+//
+//	show [profile] [--reveal] [--json] [--section NAME[,NAME...]]
+func ParseShowArgs(args []string) (ShowOptions, error) {
+	var opts ShowOptions
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--reveal":
+			opts.Reveal = true
+		case a == "--json":
+			opts.JSON = true
+		case a == "--section" || a == "-s":
+			if i+1 >= len(args) {
+				return ShowOptions{}, fmt.Errorf("%s requires a section name", a)
+			}
+			i++
+			opts.Sections = append(opts.Sections, strings.Split(args[i], ",")...)
+		case strings.HasPrefix(a, "--section="):
+			opts.Sections = append(opts.Sections, strings.Split(strings.TrimPrefix(a, "--section="), ",")...)
+		case strings.HasPrefix(a, "-"):
+			return ShowOptions{}, fmt.Errorf("unknown show flag %q", a)
+		default:
+			if opts.ProfileName != "" {
+				return ShowOptions{}, errors.New("only one profile name may be given")
+			}
+			opts.ProfileName = a
+		}
+	}
+
+	for _, section := range opts.Sections {
+		if !containsString(showSections, section) {
+			return ShowOptions{}, fmt.Errorf("unknown section %q (valid: %s)", section, strings.Join(showSections, ", "))
+		}
+	}
+
+	return opts, nil
+}
+
+// ShowProfile prints a detailed view of a stored profile
+func ShowProfile(s *store.Store, opts ShowOptions) error {
+	name := opts.ProfileName
+	if name == "" && isInteractive() {
+		picked, ok, err := chooseProfile(s, "", "Show which profile?")
+		if err != nil || !ok {
+			return err
+		}
+		name = picked
+	}
+	if name == "" {
+		current, err := s.GetCurrent()
+		if err != nil {
+			return fmt.Errorf("failed to get current profile: %w", err)
+		}
+		if current == "" {
+			return fmt.Errorf("no profile specified and no current profile set")
+		}
+		name = current
+	}
+
+	if err := profile.ValidateProfileName(name); err != nil {
+		return fmt.Errorf("invalid profile name: %w", err)
+	}
+
+	if !s.Exists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	prof, err := s.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	details := BuildProfileDetails(s, prof, opts)
+
+	if opts.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(details); err != nil {
+			return fmt.Errorf("failed to encode profile details: %w", err)
+		}
+		return nil
+	}
+
+	printProfileDetails(details, opts)
+	return nil
+}
+
+// BuildProfileDetails collects the inspected view of a profile. Secret values
+// are masked unless opts.Reveal is set, and only the requested sections are
+// populated.
+func BuildProfileDetails(s *store.Store, prof *profile.Profile, opts ShowOptions) *ProfileDetails {
+	want := func(section string) bool {
+		return len(opts.Sections) == 0 || containsString(opts.Sections, section)
+	}
+
+	details := &ProfileDetails{Name: prof.Name}
+
+	if current, err := s.GetCurrent(); err == nil {
+		details.Current = current == prof.Name
+	}
+
+	details.Metadata = prof.Metadata()
+
+	if want("settings") && prof.Settings != nil {
+		details.Model = prof.Settings.Model
+		if len(prof.Settings.Env) > 0 {
+			details.Env = maskValues(prof.Settings.Env, opts.Reveal)
+			details.masked = details.masked || !maps.Equal(details.Env, prof.Settings.Env)
+		}
+		if extras := prof.Settings.Extras(); len(extras) > 0 {
+			details.Extras = maskExtras(extras, opts.Reveal)
+			details.masked = details.masked || !rawMapsEqual(details.Extras, extras)
+		}
+	}
+
+	if want("permissions") && prof.Settings != nil && prof.Settings.Permissions != nil {
+		perms := prof.Settings.Permissions
+		details.Permissions = &PermissionDetails{
+			Allow: perms.Allow,
+			Deny:  perms.Deny,
+			Ask:   perms.Ask,
+		}
+		if extras := perms.Extras(); len(extras) > 0 {
+			details.Permissions.Extras = maskExtras(extras, opts.Reveal)
+			details.masked = details.masked || !rawMapsEqual(details.Permissions.Extras, extras)
+		}
+	}
+
+	if want("mcp") {
+		details.MCPServers = profileMCPServerDetails(prof, opts.Reveal)
+		for _, server := range details.MCPServers {
+			details.masked = details.masked || server.masked
+		}
+	}
+
+	if want("claude-md") && strings.TrimSpace(prof.ClaudeMD) != "" {
+		details.ClaudeMD = &ClaudeMDDetails{
+			Bytes:   len(prof.ClaudeMD),
+			Lines:   strings.Count(strings.TrimRight(prof.ClaudeMD, "\n"), "\n") + 1,
+			Outline: markdownOutline(prof.ClaudeMD),
+		}
+	}
+
+	if want("files") {
+		details.Files = profileFiles(prof.Name)
+	}
+
+	if want("health") {
+		// The same checks as "claudectx health", so both agree
+		rules, rulesErr := loadHealthRules("")
+		if rulesErr != nil {
+			rules = health.NewRuleSet()
+		}
+		report := checkProfileHealth(prof, HealthOptions{}, rules)
+		details.Health = &HealthDetails{
+			Summary:  report.Summary(),
+			Warnings: report.Overall.Warnings,
+			Findings: report.Overall.Findings,
+		}
+		if rulesErr != nil {
+			details.Health.Warnings = append(details.Health.Warnings, fmt.Sprintf("Custom health rules not loaded: %v", rulesErr))
+		}
+		if report.Overall.Error != nil {
+			details.Health.Error = report.Overall.Error.Error()
+		}
+	}
+
+	return details
+}
+
+// printProfileDetails renders ProfileDetails for a terminal
+func printProfileDetails(d *ProfileDetails, opts ShowOptions) {
+	want := func(section string) bool {
+		return len(opts.Sections) == 0 || containsString(opts.Sections, section)
+	}
+
+	title := printer.Colorize(d.Name, printer.Cyan)
+	if d.Current {
+		title += " " + printer.Dim("(current)")
+	}
+	fmt.Printf("Profile: %s\n", title)
+	meta := d.Metadata
+	if meta.Description != "" {
+		fmt.Printf("Description: %s\n", meta.Description)
+	}
+	if len(meta.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(meta.Tags, ", "))
+	}
+	if meta.Owner != "" {
+		fmt.Printf("Owner: %s\n", meta.Owner)
+	}
+	if len(meta.Suppress) > 0 {
+		fmt.Printf("Suppressed rules: %s\n", strings.Join(meta.Suppress, ", "))
+	}
+	if !meta.CreatedAt.IsZero() {
+		fmt.Printf("Created: %s\n", meta.CreatedAt.Local().Format(time.RFC1123))
+	}
+	if !meta.UpdatedAt.IsZero() {
+		fmt.Printf("Updated: %s\n", meta.UpdatedAt.Local().Format(time.RFC1123))
+	}
+	if meta.LastUsedAt != nil {
+		fmt.Printf("Last used: %s (%d uses)\n", meta.LastUsedAt.Local().Format(time.RFC1123), meta.UseCount)
+	} else {
+		fmt.Printf("Last used: %s\n", printer.Dim("never"))
+	}
+
+	if want("settings") {
+		fmt.Println()
+		fmt.Println(printer.Bold("Settings"))
+		if d.Model != "" {
+			fmt.Printf("  model: %s\n", d.Model)
+		} else {
+			fmt.Printf("  model: %s\n", printer.Dim("(default)"))
+		}
+		if len(d.Env) > 0 {
+			fmt.Println("  env:")
+			for _, k := range sortedKeys(d.Env) {
+				fmt.Printf("    %s=%s\n", k, d.Env[k])
+			}
+		}
+		printExtras("  ", d.Extras)
+	}
+
+	if want("permissions") {
+		fmt.Println()
+		fmt.Println(printer.Bold("Permissions"))
+		if d.Permissions == nil {
+			fmt.Printf("  %s\n", printer.Dim("(none)"))
+		} else {
+			printRuleList("allow", d.Permissions.Allow)
+			printRuleList("deny", d.Permissions.Deny)
+			printRuleList("ask", d.Permissions.Ask)
+			printExtras("  ", d.Permissions.Extras)
+		}
+	}
+
+	if want("mcp") {
+		fmt.Println()
+		fmt.Println(printer.Bold("MCP Servers"))
+		if len(d.MCPServers) == 0 {
+			fmt.Printf("  %s\n", printer.Dim("(none)"))
+		}
+		for _, server := range d.MCPServers {
+			printMCPServerDetails("  ", server)
+		}
+	}
+
+	if want("claude-md") {
+		fmt.Println()
+		fmt.Println(printer.Bold("CLAUDE.md"))
+		if d.ClaudeMD == nil {
+			fmt.Printf("  %s\n", printer.Dim("(none)"))
+		} else {
+			fmt.Printf("  %d bytes, %d lines\n", d.ClaudeMD.Bytes, d.ClaudeMD.Lines)
+			for _, heading := range d.ClaudeMD.Outline {
+				fmt.Printf("  %s\n", heading)
+			}
+		}
+	}
+
+	if want("files") {
+		fmt.Println()
+		fmt.Println(printer.Bold("Files"))
+		for _, f := range d.Files {
+			fmt.Printf("  %s %s\n", f.Path, printer.Dim(fmt.Sprintf("(%d bytes, %s)", f.Size, f.Modified.Local().Format(time.RFC3339))))
+		}
+	}
+
+	if want("health") && d.Health != nil {
+		fmt.Println()
+		fmt.Println(printer.Bold("Health"))
+		if d.Health.Error != "" {
+			printer.Error("  ✗ %s: %s", d.Health.Summary, d.Health.Error)
+		} else if len(d.Health.Warnings) > 0 {
+			printer.Warning("  ⚠ %s", d.Health.Summary)
+			for _, warning := range d.Health.Warnings {
+				fmt.Printf("    - %s\n", warning)
+			}
+		} else {
+			printer.Success("  ✓ %s", d.Health.Summary)
+		}
+	}
+
+	if d.masked {
+		fmt.Println()
+		fmt.Println(printer.Dim("Secret values are masked; use --reveal to show them."))
+	}
+}
+
+// printRuleList prints a labelled list of permission rules
+func printRuleList(label string, rules []string) {
+	if len(rules) == 0 {
+		return
+	}
+	fmt.Printf("  %s:\n", label)
+	for _, rule := range rules {
+		fmt.Printf("    - %s\n", rule)
+	}
+}
+
+// printExtras prints preserved JSON keys in sorted order
+func printExtras(indent string, extras map[string]json.RawMessage) {
+	keys := make([]string, 0, len(extras))
+	for k := range extras {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("%s%s: %s\n", indent, k, compactJSON(extras[k]))
+	}
+}
+
+// compactJSON renders a raw JSON value on a single line
+func compactJSON(raw json.RawMessage) string {
+	var out bytes.Buffer
+	if err := json.Compact(&out, raw); err != nil {
+		return string(raw)
+	}
+	return out.String()
+}
+
+// profileFiles lists the files stored in a profile directory
+func profileFiles(name string) []FileDetails {
+	dir, err := paths.ProfileDir(name)
+	if err != nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var files []FileDetails
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path, err := paths.ProfileFile(name, entry.Name())
+		if err != nil {
+			continue
+		}
+		files = append(files, FileDetails{
+			Path:     path,
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+	}
+	return files
+}
+
+// markdownOutline extracts the ATX headings of a markdown document, indented
+// by level. Headings inside fenced code blocks are ignored.
+func markdownOutline(content string) []string {
+	var outline []string
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || !strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		if level > 6 {
+			continue
+		}
+		text := strings.TrimSpace(trimmed[level:])
+		if text == "" || (len(trimmed) > level && trimmed[level] != ' ' && trimmed[level] != '\t') {
+			continue
+		}
+		outline = append(outline, strings.Repeat("  ", level-1)+strings.Repeat("#", level)+" "+text)
+	}
+	return outline
+}
+
+// profileMCPServerDetails describes a profile's enabled and disabled MCP
+// servers, sorted by name
+func profileMCPServerDetails(prof *profile.Profile, reveal bool) []MCPServerDetails {
+	var details []MCPServerDetails
+	add := func(servers mcpconfig.MCPServers, disabled bool) {
+		for serverName, server := range servers {
+			details = append(details, mcpServerDetails(serverName, server, disabled, reveal))
+		}
+	}
+	add(prof.MCPServers, false)
+	add(prof.DisabledMCPServers, true)
+
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].Name < details[j].Name
+	})
+	return details
+}
+
+// mcpServerDetails describes a single MCP server, masking secret env and
+// header values, secret flag arguments and URL query values unless reveal
+// is set
+func mcpServerDetails(name string, server mcpconfig.MCPServer, disabled, reveal bool) MCPServerDetails {
+	details := MCPServerDetails{
+		Name:      name,
+		Transport: server.Transport(),
+		Command:   server.Command,
+		Args:      maskArgs(server.Args, reveal),
+		URL:       maskURL(server.URL, reveal),
+		Env:       maskValues(server.Env, reveal),
+		Headers:   maskValues(server.Headers, reveal),
+		Disabled:  disabled,
+	}
+	details.masked = !slices.Equal(details.Args, server.Args) || details.URL != server.URL ||
+		!maps.Equal(details.Env, server.Env) || !maps.Equal(details.Headers, server.Headers)
+	// Malformed fields are reported by health, not here
+	details.Cwd, _ = server.Cwd()
+	details.Timeout, _ = server.Timeout()
+	details.HeadersHelper, _ = server.HeadersHelper()
+	details.OAuth, _ = server.OAuth()
+	return details
+}
+
+// printMCPServerDetails prints an MCP server's name, transport and
+// configuration
+func printMCPServerDetails(indent string, server MCPServerDetails) {
+	status := ""
+	if server.Disabled {
+		status = " " + printer.Colorize("(disabled)", printer.Yellow)
+	}
+	fmt.Printf("%s%s %s%s\n", indent, server.Name, printer.Dim("["+server.Transport+"]"), status)
+	if server.Command != "" {
+		fmt.Printf("%s  command: %s\n", indent, strings.TrimSpace(server.Command+" "+strings.Join(server.Args, " ")))
+	}
+	if server.URL != "" {
+		fmt.Printf("%s  url: %s\n", indent, server.URL)
+	}
+	for _, k := range sortedKeys(server.Env) {
+		fmt.Printf("%s  env %s=%s\n", indent, k, server.Env[k])
+	}
+	for _, k := range sortedKeys(server.Headers) {
+		fmt.Printf("%s  header %s: %s\n", indent, k, server.Headers[k])
+	}
+	if server.Cwd != "" {
+		fmt.Printf("%s  cwd: %s\n", indent, server.Cwd)
+	}
+	if server.Timeout > 0 {
+		fmt.Printf("%s  timeout: %dms\n", indent, server.Timeout)
+	}
+	if server.HeadersHelper != "" {
+		fmt.Printf("%s  headersHelper: %s\n", indent, server.HeadersHelper)
+	}
+	if server.OAuth != nil {
+		fmt.Printf("%s  oauth: client %s", indent, server.OAuth.ClientID)
+		if server.OAuth.CallbackPort > 0 {
+			fmt.Printf(", callback port %d", server.OAuth.CallbackPort)
+		}
+		fmt.Println()
+	}
+}
+
+// maskValues returns a copy of values with secret-looking entries masked,
+// along with URLs that carry a password, such as a proxy in HTTPS_PROXY
+func maskValues(values map[string]string, reveal bool) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		switch {
+		case reveal:
+		case config.IsSecretKey(k):
+			v = config.MaskSecret(v)
+		case hasURLPassword(v):
+			v = maskURL(v, false)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// hasURLPassword reports whether value is a URL with a password in it
+func hasURLPassword(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return false
+	}
+	_, ok := u.User.Password()
+	return ok
+}
+
+// maskExtras returns a copy of preserved JSON keys with the values of
+// secret-looking keys masked, at any depth
+func maskExtras(extras map[string]json.RawMessage, reveal bool) map[string]json.RawMessage {
+	if reveal || len(extras) == 0 {
+		return extras
+	}
+	out := make(map[string]json.RawMessage, len(extras))
+	for k, raw := range extras {
+		out[k] = raw
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			continue
+		}
+		if masked, changed := maskJSONValue(k, value); changed {
+			if data, err := json.Marshal(masked); err == nil {
+				out[k] = data
+			}
+		}
+	}
+	return out
+}
+
+// maskJSONValue masks a decoded JSON value stored under key: all of it when
+// the key looks secret, otherwise any secret-looking keys inside it
+func maskJSONValue(key string, value any) (any, bool) {
+	if config.IsSecretKey(key) {
+		switch v := value.(type) {
+		case nil:
+			return nil, false
+		case string:
+			return config.MaskSecret(v), v != ""
+		default:
+			return "****", true
+		}
+	}
+
+	changed := false
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			masked, c := maskJSONValue(k, item)
+			v[k] = masked
+			changed = changed || c
+		}
+	case []any:
+		for i, item := range v {
+			masked, c := maskJSONValue("", item)
+			v[i] = masked
+			changed = changed || c
+		}
+	}
+	return value, changed
+}
+
+// maskArgs returns a copy of command arguments with the values of
+// secret-looking flags masked, as in --api-key=VALUE or --token VALUE
+func maskArgs(args []string, reveal bool) []string {
+	if reveal || len(args) == 0 {
+		return args
+	}
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = arg
+		if flag, value, ok := strings.Cut(arg, "="); ok && isSecretFlag(flag) {
+			out[i] = flag + "=" + config.MaskSecret(value)
+		} else if i > 0 && isSecretFlag(args[i-1]) && !strings.HasPrefix(arg, "-") {
+			out[i] = config.MaskSecret(arg)
+		}
+	}
+	return out
+}
+
+// isSecretFlag reports whether a command-line flag such as --api-key names
+// a credential
+func isSecretFlag(flag string) bool {
+	name := strings.TrimLeft(flag, "-")
+	if name == "" || name == flag || strings.Contains(name, "=") {
+		return false
+	}
+	return config.IsSecretKey(name)
+}
+
+// maskURL hides the query parameter values and any password in a URL, as
+// remote MCP servers are often given a token that way
+func maskURL(raw string, reveal bool) string {
+	if reveal || raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	_, hasPassword := u.User.Password()
+	if u.RawQuery == "" && !hasPassword {
+		return raw
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		if key, value, ok := strings.Cut(param, "="); ok && value != "" {
+			params[i] = key + "=****"
+		}
+	}
+	u.RawQuery = strings.Join(params, "&")
+	return u.Redacted()
+}
+
+// rawMapsEqual reports whether two maps of raw JSON values are identical
+func rawMapsEqual(a, b map[string]json.RawMessage) bool {
+	return maps.EqualFunc(a, b, func(x, y json.RawMessage) bool { return bytes.Equal(x, y) })
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -54,7 +54,7 @@ func SwitchProfile(s *store.Store, name string) error {
 	}
 
 	// Create backup before switching
-	backupID, err := backupMgr.Create()
+	backupID, err := backupMgr.Create(fmt.Sprintf("switch to %s", name))
 	if err != nil {
 		printer.Warning("Warning: Failed to create backup: %v", err)
 		printer.Warning("Continuing without backup...")
@@ -74,6 +74,12 @@ func SwitchProfile(s *store.Store, name string) error {
 		if err != nil {
 			printer.Warning("Warning: Could not detect config changes: %v", err)
 		} else if changed {
+			// Keep the pre-sync profile so a rollback also undoes the sync
+			if backupID != "" {
+				if err := backupMgr.SnapshotProfile(backupID, currentName); err != nil {
+					printer.Warning("Warning: Failed to back up profile %q: %v", currentName, err)
+				}
+			}
 			printer.Info("Auto-syncing changes to profile %q...", currentName)
 			err := syncCurrentProfile(s, currentName)
 			if err != nil {
@@ -85,23 +91,66 @@ func SwitchProfile(s *store.Store, name string) error {
 		}
 	}
 
+	// Per-project MCP servers of the outgoing profile, removed on apply
+	var outgoingProjectMCP mcpconfig.ProjectServers
+	if currentName != "" && s.Exists(currentName) {
+		if current, err := s.Load(currentName); err == nil {
+			outgoingProjectMCP = current.ProjectMCPServers
+		}
+	}
+
+	// Write the profile into the active configuration
+	if err := applyProfile(prof, outgoingProjectMCP); err != nil {
+		rollback(backupMgr, backupID)
+		return err
+	}
+
+	// Update previous profile (if there was a current one)
+	if currentName != "" {
+		err = s.SetPrevious(currentName)
+		if err != nil {
+			rollback(backupMgr, backupID)
+			return fmt.Errorf("failed to set previous profile: %w", err)
+		}
+	}
+
+	// Update current profile
+	err = s.SetCurrent(name)
+	if err != nil {
+		rollback(backupMgr, backupID)
+		return fmt.Errorf("failed to set current profile: %w", err)
+	}
+
+	// Prune old backups
+	pruneBackups(backupMgr)
+
+	// Record usage in profile.json (best-effort)
+	if err := s.MarkUsed(name); err != nil {
+		printer.Warning("Warning: Failed to update profile metadata: %v", err)
+	}
+
+	printer.Success("Switched to profile %q", name)
+	return nil
+}
+
+// applyProfile writes a profile's settings, CLAUDE.md and MCP servers to the
+// active Claude configuration. previous is the per-project MCP declaration
+// being replaced. Callers are responsible for backup/rollback.
+func applyProfile(prof *profile.Profile, previous mcpconfig.ProjectServers) error {
 	// Save settings to active location
 	settingsPath, err := paths.SettingsFile()
 	if err != nil {
-		rollback(backupMgr, backupID)
 		return fmt.Errorf("failed to get settings path: %w", err)
 	}
 
 	err = config.SaveSettings(settingsPath, prof.Settings)
 	if err != nil {
-		rollback(backupMgr, backupID)
 		return fmt.Errorf("failed to save settings: %w", err)
 	}
 
 	// Handle CLAUDE.md
 	claudeMDPath, err := paths.ClaudeMDFile()
 	if err != nil {
-		rollback(backupMgr, backupID)
 		return fmt.Errorf("failed to get CLAUDE.md path: %w", err)
 	}
 
@@ -109,7 +158,6 @@ func SwitchProfile(s *store.Store, name string) error {
 		// Write CLAUDE.md
 		err = os.WriteFile(claudeMDPath, []byte(prof.ClaudeMD), 0644)
 		if err != nil {
-			rollback(backupMgr, backupID)
 			return fmt.Errorf("failed to write CLAUDE.md: %w", err)
 		}
 	} else {
@@ -122,41 +170,103 @@ func SwitchProfile(s *store.Store, name string) error {
 	// Handle MCP servers in ~/.claude.json
 	claudeJSONPath, err := paths.ClaudeJSONFile()
 	if err != nil {
-		rollback(backupMgr, backupID)
 		return fmt.Errorf("failed to get claude.json path: %w", err)
 	}
 
 	err = mcpconfig.SaveMCPServers(claudeJSONPath, prof.MCPServers)
 	if err != nil {
-		rollback(backupMgr, backupID)
 		return fmt.Errorf("failed to save MCP servers: %w", err)
 	}
 
-	// Update previous profile (if there was a current one)
-	if currentName != "" {
-		err = s.SetPrevious(currentName)
-		if err != nil {
-			rollback(backupMgr, backupID)
-			return fmt.Errorf("failed to set previous profile: %w", err)
+	// Handle per-project MCP servers in ~/.claude.json
+	return applyProjectMCPServers(claudeJSONPath, previous, prof.ProjectMCPServers)
+}
+
+// applyProjectMCPServers replaces the per-project MCP servers previously
+// declared with the ones declared now, by name, leaving servers neither
+// declares (such as local-scope ones from "claude mcp add") in place
+func applyProjectMCPServers(claudeJSONPath string, previous, declared mcpconfig.ProjectServers) error {
+	if len(previous) == 0 && len(declared) == 0 {
+		return nil
+	}
+
+	known, err := knownProjects(claudeJSONPath)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string][]string)
+	for projectPath, servers := range previous.Resolve(known) {
+		for name := range servers {
+			remove[projectPath] = append(remove[projectPath], name)
 		}
 	}
 
-	// Update current profile
-	err = s.SetCurrent(name)
+	if err := mcpconfig.UpdateProjectMCPServers(claudeJSONPath, remove, declared.Resolve(known)); err != nil {
+		return fmt.Errorf("failed to save project MCP servers: %w", err)
+	}
+	return nil
+}
+
+// knownProjects returns the project paths Claude Code has entries for in
+// ~/.claude.json
+func knownProjects(claudeJSONPath string) ([]string, error) {
+	projects, err := mcpconfig.LoadProjectMCPServers(claudeJSONPath)
 	if err != nil {
-		rollback(backupMgr, backupID)
-		return fmt.Errorf("failed to set current profile: %w", err)
+		return nil, fmt.Errorf("failed to load project MCP servers: %w", err)
+	}
+	known := make([]string, 0, len(projects))
+	for projectPath := range projects {
+		known = append(known, projectPath)
 	}
+	return known, nil
+}
 
-	// Prune old backups (keep last 10)
-	if err := backupMgr.Prune(10); err != nil {
-		printer.Warning("Warning: Failed to prune old backups: %v", err)
+// reapplyProfile writes an updated copy of the current profile to the active
+// configuration, backing up first and rolling back if any write fails.
+// previous is the profile's per-project MCP declaration before the update,
+// whose servers are removed where the update no longer declares them.
+func reapplyProfile(prof *profile.Profile, previous mcpconfig.ProjectServers) error {
+	backupMgr, err := backup.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize backup manager: %w", err)
+	}
+
+	backupID, err := backupMgr.Create(fmt.Sprintf("update %s", prof.Name))
+	if err != nil {
+		printer.Warning("Warning: Failed to create backup: %v", err)
+		printer.Warning("Continuing without backup...")
+	}
+
+	if err := applyProfile(prof, previous); err != nil {
+		rollback(backupMgr, backupID)
+		return err
 	}
 
-	printer.Success("Switched to profile %q", name)
 	return nil
 }
 
+// restoreProfile saves a profile as it was before a change whose
+// re-application to the live configuration failed, so the stored profile
+// matches the live configuration again and the next sync does not drop it
+func restoreProfile(s *store.Store, original *profile.Profile) {
+	if err := s.Save(original); err != nil {
+		printer.Warning("Warning: Failed to restore profile %q: %v", original.Name, err)
+	}
+}
+
+// pruneBackups removes the backups the retention policy in claudectx.json
+// does not keep
+func pruneBackups(backupMgr *backup.Manager) {
+	policy, err := backup.LoadPolicy()
+	if err != nil {
+		printer.Warning("Warning: %v (using the default backup policy)", err)
+	}
+	if _, err := backupMgr.PruneWithPolicy(policy, false); err != nil {
+		printer.Warning("Warning: Failed to prune old backups: %v", err)
+	}
+}
+
 // rollback attempts to restore from backup
 func rollback(backupMgr *backup.Manager, backupID string) {
 	if backupID == "" {
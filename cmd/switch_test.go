@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/johnfox/claudectx/internal/config"
 	"github.com/johnfox/claudectx/internal/mcpconfig"
 	"github.com/johnfox/claudectx/internal/paths"
 	"github.com/johnfox/claudectx/internal/profile"
@@ -300,3 +302,104 @@ func TestSwitchProfile_AutoSync_PreservesUnknownFieldsInProfile(t *testing.T) {
 		t.Error("plugins key was stripped from stored profile during auto-sync — issue #16")
 	}
 }
+
+// TestSwitchProfile_RecordsUsage verifies that switching updates the target
+// profile's last-used time and use count in profile.json.
+func TestSwitchProfile_RecordsUsage(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	s, err := store.NewStore()
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	if err := s.Save(profile.NewProfile("work")); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	if err := SwitchProfile(s, "work"); err != nil {
+		t.Fatalf("SwitchProfile failed: %v", err)
+	}
+
+	meta, err := s.LoadMetadata("work")
+	if err != nil {
+		t.Fatalf("failed to load metadata: %v", err)
+	}
+	if meta.UseCount != 1 || meta.LastUsedAt == nil {
+		t.Errorf("expected usage to be recorded, got %+v", meta)
+	}
+}
+
+func TestSwitchProfile_InvalidPermissionRuleBlocked(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	s, err := store.NewStore()
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	prof := profile.NewProfile("work")
+	prof.Settings.Permissions = &config.Permissions{Allow: []string{"Bash(git diff:*"}}
+	if err := s.Save(prof); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	err = SwitchProfile(s, "work")
+	if err == nil || !strings.Contains(err.Error(), "invalid permission rule") {
+		t.Fatalf("expected an invalid permission rule error, got %v", err)
+	}
+	if cur, _ := s.GetCurrent(); cur == "work" {
+		t.Error("switch should not have happened")
+	}
+}
+
+func TestSwitchProfile_ProjectMCPServers(t *testing.T) {
+	s, home := setupRunTest(t)
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+	claudeJSON := filepath.Join(home, ".claude.json")
+	writeSettings(t, claudeJSON, `{"projects": {"/src/app": {"allowedTools": []}, "/src/lib": {"mcpServers": {"local": {"command": "local-mcp"}}}}}`)
+
+	work := profile.NewProfile("work")
+	work.ProjectMCPServers = mcpconfig.ProjectServers{
+		"/src/*":   {"db": {Command: "db-mcp"}},
+		"/src/api": {"api": {Command: "api-mcp"}},
+	}
+	saveProfile(t, s, work)
+	saveProfile(t, s, profile.NewProfile("personal"))
+
+	if err := SwitchProfile(s, "work"); err != nil {
+		t.Fatalf("SwitchProfile() failed: %v", err)
+	}
+
+	projects, _ := mcpconfig.LoadProjectMCPServers(claudeJSON)
+	for _, p := range []string{"/src/app", "/src/lib"} {
+		if projects[p]["db"].Command != "db-mcp" {
+			t.Errorf("%s servers = %+v, want db", p, projects[p])
+		}
+	}
+	if projects["/src/api"]["api"].Command != "api-mcp" {
+		t.Errorf("/src/api servers = %+v, want api", projects["/src/api"])
+	}
+
+	if err := SwitchProfile(s, "personal"); err != nil {
+		t.Fatalf("SwitchProfile() failed: %v", err)
+	}
+
+	// Only the servers work declared are removed; local-scope ones stay
+	projects, _ = mcpconfig.LoadProjectMCPServers(claudeJSON)
+	for p, servers := range projects {
+		if p == "/src/lib" {
+			if len(servers) != 1 || servers["local"].Command != "local-mcp" {
+				t.Errorf("%s should keep only its local server, got %+v", p, servers)
+			}
+		} else if len(servers) != 0 {
+			t.Errorf("%s servers should be cleared, got %+v", p, servers)
+		}
+	}
+	data, _ := os.ReadFile(claudeJSON)
+	if !strings.Contains(string(data), "allowedTools") {
+		t.Errorf("other project keys should be preserved: %s", data)
+	}
+}
@@ -61,10 +61,67 @@ func hasConfigChanged(s *store.Store, profileName string) (bool, error) {
 		return true, nil
 	}
 
+	// Compare the exact-path project servers the profile declares
+	activeProjectMCP, err := syncedProjectMCPServers(claudeJSONPath, stored.ProjectMCPServers)
+	if err != nil {
+		return true, nil
+	}
+	if hashProjectServers(activeProjectMCP) != hashProjectServers(stored.ProjectMCPServers) {
+		return true, nil
+	}
+
 	// Compare by hashing (more efficient than deep comparison)
 	return !profilesEqual(activeSettings, activeClaudeMD, activeMCPServers, stored.Settings, stored.ClaudeMD, stored.MCPServers), nil
 }
 
+// syncedProjectMCPServers returns a copy of the profile's per-project
+// servers with the servers declared for each exact project path replaced by
+// their live config, dropping those removed from the live entry. Servers
+// the profile does not declare, such as local-scope ones from "claude mcp
+// add", are not captured. Globs cannot be split back out of the live
+// entries, so they are kept as declared.
+func syncedProjectMCPServers(claudeJSONPath string, declared mcpconfig.ProjectServers) (mcpconfig.ProjectServers, error) {
+	if len(declared) == 0 {
+		return declared, nil
+	}
+
+	live, err := mcpconfig.LoadProjectMCPServers(claudeJSONPath)
+	if err != nil {
+		return nil, err
+	}
+
+	synced := make(mcpconfig.ProjectServers, len(declared))
+	for pattern, servers := range declared {
+		if !mcpconfig.IsProjectGlob(pattern) {
+			if liveServers, ok := live[mcpconfig.ExpandProjectPath(pattern)]; ok {
+				kept := make(mcpconfig.MCPServers, len(servers))
+				for name := range servers {
+					if server, ok := liveServers[name]; ok {
+						kept[name] = server
+					}
+				}
+				servers = kept
+			}
+		}
+		synced[pattern] = servers
+	}
+	return synced, nil
+}
+
+// hashProjectServers creates a hash of per-project servers for comparison
+func hashProjectServers(servers mcpconfig.ProjectServers) string {
+	if len(servers) == 0 {
+		return "empty"
+	}
+
+	data, err := json.Marshal(servers)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%x", md5.Sum(data))
+}
+
 // profilesEqual compares two profile configurations by content hash
 func profilesEqual(settings1 *config.Settings, claudeMD1 string, mcp1 mcpconfig.MCPServers, settings2 *config.Settings, claudeMD2 string, mcp2 mcpconfig.MCPServers) bool {
 	// Hash settings
@@ -171,10 +228,16 @@ func syncCurrentProfile(s *store.Store, profileName string) error {
 		return fmt.Errorf("failed to load profile: %w", err)
 	}
 
+	activeProjectMCP, err := syncedProjectMCPServers(claudeJSONPath, prof.ProjectMCPServers)
+	if err != nil {
+		return fmt.Errorf("failed to load project MCP servers: %w", err)
+	}
+
 	// Update the profile with active configuration
 	prof.Settings = activeSettings
 	prof.ClaudeMD = activeClaudeMD
 	prof.MCPServers = activeMCPServers
+	prof.ProjectMCPServers = activeProjectMCP
 	prof.Touch()
 
 	// Save the updated profile
@@ -0,0 +1,421 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// archiveHeaderFile is the first entry of every backup archive
+const archiveHeaderFile = "claudectx-archive.json"
+
+// archiveFormat identifies claudectx backup archives
+const archiveFormat = "claudectx-backups"
+
+// archiveVersion is the current archive format version. Archives with a
+// newer version are rejected.
+const archiveVersion = 1
+
+// maxArchiveEntry limits the size of a single archive entry, so a corrupt
+// or hostile archive cannot fill the disk
+const maxArchiveEntry = 64 << 20
+
+// ArchiveHeader describes a backup archive. Objects are checked against
+// their names; every other entry is listed in Checksums.
+type ArchiveHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Backups   []string  `json:"backups"`
+	// Checksums maps each entry under backups/ to its SHA-256
+	Checksums map[string]string `json:"checksums"`
+}
+
+// ImportResult reports what Import added
+type ImportResult struct {
+	Imported []string
+	// Skipped lists backups that already exist and were left unchanged
+	Skipped []string
+}
+
+// Export writes the given backups, their manifests and the objects they
+// use to w as a gzip-compressed tar archive
+func (m *Manager) Export(w io.Writer, backupIDs []string) error {
+	if len(backupIDs) == 0 {
+		return errors.New("no backups to export")
+	}
+
+	header := ArchiveHeader{
+		Format:    archiveFormat,
+		Version:   archiveVersion,
+		CreatedAt: time.Now(),
+		Checksums: make(map[string]string),
+	}
+
+	// Gather the backup directory entries and the objects they use
+	entries := make(map[string][]byte)
+	objects := make(map[string]File)
+	for _, id := range backupIDs {
+		if !m.Exists(id) {
+			return fmt.Errorf("backup %q does not exist", id)
+		}
+		header.Backups = append(header.Backups, id)
+		backupPath := filepath.Join(m.backupDir, id)
+
+		manifest, err := readManifest(backupPath)
+		if err != nil {
+			return fmt.Errorf("backup %s: %w", id, err)
+		}
+		if manifest != nil && manifest.Version >= 2 {
+			for _, f := range manifest.Files {
+				objects[f.SHA256] = f
+			}
+		}
+
+		// The manifest, plus the files of backups that keep them in place
+		err = filepath.WalkDir(backupPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(backupPath, p)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			name := path.Join("backups", id, filepath.ToSlash(rel))
+			sum := sha256.Sum256(data)
+			entries[name] = data
+			header.Checksums[name] = hex.EncodeToString(sum[:])
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read backup %s: %w", id, err)
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	headerData, err := json.MarshalIndent(header, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive header: %w", err)
+	}
+	if err := writeTarEntry(tw, archiveHeaderFile, headerData); err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(entries) {
+		if err := writeTarEntry(tw, name, entries[name]); err != nil {
+			return err
+		}
+	}
+
+	for _, hash := range sortedKeys(objects) {
+		data, err := m.readObject(objects[hash])
+		if err != nil {
+			return fmt.Errorf("cannot export %s: %w", objects[hash].Path, err)
+		}
+		if err := writeTarEntry(tw, path.Join(objectsDir, hash), data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// Import adds the backups in an archive written by Export. Every entry is
+// checked against its checksum and only known paths are extracted. Backups
+// that already exist are skipped.
+func (m *Manager) Import(r io.Reader) (ImportResult, error) {
+	var result ImportResult
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return result, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	header, err := readArchiveHeader(tr)
+	if err != nil {
+		return result, err
+	}
+	wanted := make(map[string]bool, len(header.Backups))
+	for _, id := range header.Backups {
+		if !validBackupID(id) {
+			return result, fmt.Errorf("invalid backup ID %q in archive", id)
+		}
+		wanted[id] = true
+	}
+
+	// Backups and objects are assembled in a staging directory and moved
+	// into place once the whole archive has been checked, so a rejected
+	// archive leaves nothing behind
+	staging, err := os.MkdirTemp(m.backupDir, ".import-")
+	if err != nil {
+		return result, fmt.Errorf("failed to create import directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	stagedObjects := filepath.Join(staging, objectsDir)
+	if err := os.Mkdir(stagedObjects, 0755); err != nil {
+		return result, fmt.Errorf("failed to create import directory: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var objects []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return result, fmt.Errorf("archive entry %q is not a regular file", hdr.Name)
+		}
+
+		name, err := archiveEntryName(hdr.Name)
+		if err != nil {
+			return result, err
+		}
+		if seen[name] {
+			return result, fmt.Errorf("archive entry %q appears twice", name)
+		}
+		seen[name] = true
+
+		data, err := readTarEntry(tr, hdr)
+		if err != nil {
+			return result, err
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+
+		if rest, ok := strings.CutPrefix(name, objectsDir+"/"); ok {
+			if !validHash(rest) {
+				return result, fmt.Errorf("invalid object name %q in archive", name)
+			}
+			if hash != rest {
+				return result, fmt.Errorf("checksum mismatch for %s", name)
+			}
+			if err := os.WriteFile(filepath.Join(stagedObjects, hash), data, 0600); err != nil {
+				return result, fmt.Errorf("failed to extract %s: %w", name, err)
+			}
+			objects = append(objects, hash)
+			continue
+		}
+
+		want, ok := header.Checksums[name]
+		if !ok {
+			return result, fmt.Errorf("archive entry %q is not listed in the header", name)
+		}
+		if hash != want {
+			return result, fmt.Errorf("checksum mismatch for %s", name)
+		}
+		id := strings.SplitN(name, "/", 3)[1]
+		if !wanted[id] {
+			return result, fmt.Errorf("archive entry %q belongs to a backup not listed in the header", name)
+		}
+
+		target, err := filesPath(staging, strings.TrimPrefix(name, "backups/"))
+		if err != nil {
+			return result, err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return result, err
+		}
+		if err := os.WriteFile(target, data, 0600); err != nil {
+			return result, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+	}
+
+	for name := range header.Checksums {
+		if !seen[name] {
+			return result, fmt.Errorf("archive is missing %s", name)
+		}
+	}
+
+	// Check every backup is complete before adding any of them
+	for _, id := range header.Backups {
+		manifest, err := readManifest(filepath.Join(staging, id))
+		if err != nil {
+			return result, fmt.Errorf("backup %s: %w", id, err)
+		}
+		if manifest == nil {
+			continue
+		}
+		if manifest.ID != id {
+			return result, fmt.Errorf("backup %s: manifest is for backup %q", id, manifest.ID)
+		}
+		if err := manifest.checkNames(); err != nil {
+			return result, fmt.Errorf("backup %s: %w", id, err)
+		}
+		if manifest.Version < 2 {
+			continue
+		}
+		for _, f := range manifest.Files {
+			if _, err := filesPath(staging, f.Path); err != nil {
+				return result, fmt.Errorf("backup %s: %w", id, err)
+			}
+			if validHash(f.SHA256) && config.FileExists(filepath.Join(stagedObjects, f.SHA256)) {
+				continue
+			}
+			if _, err := m.readObject(f); err != nil {
+				return result, fmt.Errorf("backup %s: %s: %w", id, f.Path, err)
+			}
+		}
+	}
+
+	// Objects go in first, so no imported backup refers to a missing one
+	for _, hash := range objects {
+		if err := m.moveObject(hash, filepath.Join(stagedObjects, hash)); err != nil {
+			return result, fmt.Errorf("failed to import object %s: %w", hash[:12], err)
+		}
+	}
+
+	for _, id := range header.Backups {
+		if m.Exists(id) {
+			result.Skipped = append(result.Skipped, id)
+			continue
+		}
+		src := filepath.Join(staging, id)
+		if err := os.MkdirAll(src, 0755); err != nil {
+			return result, err
+		}
+		if err := os.Rename(src, filepath.Join(m.backupDir, id)); err != nil {
+			return result, fmt.Errorf("failed to import backup %s: %w", id, err)
+		}
+		result.Imported = append(result.Imported, id)
+	}
+
+	return result, nil
+}
+
+// readArchiveHeader reads and checks the header entry that starts an archive
+func readArchiveHeader(tr *tar.Reader) (*ArchiveHeader, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	if hdr.Name != archiveHeaderFile || hdr.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("not a backup archive: first entry is %q, want %s", hdr.Name, archiveHeaderFile)
+	}
+
+	data, err := readTarEntry(tr, hdr)
+	if err != nil {
+		return nil, err
+	}
+	var header ArchiveHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("invalid archive header: %w", err)
+	}
+	if header.Format != archiveFormat {
+		return nil, fmt.Errorf("not a backup archive: format %q", header.Format)
+	}
+	if header.Version < 1 || header.Version > archiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d (this claudectx reads up to %d)", header.Version, archiveVersion)
+	}
+	return &header, nil
+}
+
+// archiveEntryName checks that an archive entry is an object or a file in
+// a backup directory, with no absolute or parent path components
+func archiveEntryName(name string) (string, error) {
+	clean := path.Clean(name)
+	if name == "" || strings.Contains(name, "\\") || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("unsafe path %q in archive", name)
+	}
+
+	parts := strings.Split(clean, "/")
+	switch {
+	case len(parts) == 2 && parts[0] == objectsDir:
+	case len(parts) >= 3 && parts[0] == "backups" && validBackupID(parts[1]):
+	default:
+		return "", fmt.Errorf("unexpected path %q in archive", name)
+	}
+	return clean, nil
+}
+
+// readTarEntry reads an archive entry, refusing oversized ones
+func readTarEntry(tr *tar.Reader, hdr *tar.Header) ([]byte, error) {
+	if hdr.Size > maxArchiveEntry {
+		return nil, fmt.Errorf("archive entry %q is too large (%d bytes)", hdr.Name, hdr.Size)
+	}
+	data, err := io.ReadAll(io.LimitReader(tr, maxArchiveEntry+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+	}
+	if len(data) > maxArchiveEntry {
+		return nil, fmt.Errorf("archive entry %q is too large", hdr.Name)
+	}
+	return data, nil
+}
+
+// writeTarEntry adds a regular file to an archive
+func writeTarEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Typeflag: tar.TypeReg,
+		Mode:     0600,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// validBackupID reports whether id is a plain backup directory name
+func validBackupID(id string) bool {
+	return strings.HasPrefix(id, backupPrefix) && id == filepath.Base(id) && id != "." && id != ".."
+}
+
+// validHash reports whether s is a lowercase hex SHA-256
+func validHash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.ToLower(s) == s
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -5,22 +5,32 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/johnfox/claudectx/internal/config"
 	"github.com/johnfox/claudectx/internal/mcpconfig"
 	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
 )
 
 // Backup represents a single backup snapshot
 type Backup struct {
 	ID        string
 	CreatedAt time.Time
+	// Pinned backups are never removed by pruning
+	Pinned bool
 }
 
+// backupPrefix starts the directory name of every backup
+const backupPrefix = "backup-"
+
 // Manager handles backup operations
 type Manager struct {
 	backupDir string
+	// projectDir is set for managers that back up a project's local
+	// configuration instead of the user configuration
+	projectDir string
 }
 
 // NewManager creates a new backup manager
@@ -43,10 +53,16 @@ func NewManager() (*Manager, error) {
 	}, nil
 }
 
-// Create creates a new backup of current configuration
-func (m *Manager) Create() (string, error) {
+// Create creates a new backup of current configuration. The reason is
+// recorded in the backup's manifest along with the current/previous
+// profile trackers.
+func (m *Manager) Create(reason string) (string, error) {
+	if m.projectDir != "" {
+		return m.createProject(reason)
+	}
+
 	// Generate backup ID (timestamp-based)
-	backupID := fmt.Sprintf("backup-%d", time.Now().UnixNano())
+	backupID := fmt.Sprintf("%s%d", backupPrefix, time.Now().UnixNano())
 	backupPath := filepath.Join(m.backupDir, backupID)
 
 	// Create backup directory
@@ -101,18 +117,106 @@ func (m *Manager) Create() (string, error) {
 				return "", fmt.Errorf("failed to backup MCP servers: %w", err)
 			}
 		}
+
+		// Always record the per-project servers, even when there are none,
+		// so restoring can tell them apart from backups that predate them
+		if err := backupProjectMCPServers(claudeJSONPath, filepath.Join(backupPath, projectMCPFile)); err != nil {
+			os.RemoveAll(backupPath) // Clean up on failure
+			return "", err
+		}
+	}
+
+	manifest, err := m.newManifest(backupID, reason)
+	if err == nil {
+		err = m.commit(backupPath, manifest)
+	}
+	if err != nil {
+		os.RemoveAll(backupPath) // Clean up on failure
+		return "", err
 	}
 
 	return backupID, nil
 }
 
-// Restore restores configuration from a backup
-func (m *Manager) Restore(backupID string) error {
+// CreateFromProfile creates a backup holding a stored profile's
+// configuration, in the same layout as a backup of the live configuration.
+// It can be restored with "claudectx cp <backup-id> <name>".
+func (m *Manager) CreateFromProfile(prof *profile.Profile) (string, error) {
+	backupID := fmt.Sprintf("%s%d", backupPrefix, time.Now().UnixNano())
 	backupPath := filepath.Join(m.backupDir, backupID)
 
-	// Verify backup exists
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
-		return fmt.Errorf("backup %q does not exist", backupID)
+	err := os.MkdirAll(backupPath, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	if prof.Settings != nil {
+		err = config.SaveSettings(filepath.Join(backupPath, "settings.json"), prof.Settings)
+		if err != nil {
+			os.RemoveAll(backupPath) // Clean up on failure
+			return "", fmt.Errorf("failed to backup settings.json: %w", err)
+		}
+	}
+
+	if prof.ClaudeMD != "" {
+		err = config.WriteFileAtomic(filepath.Join(backupPath, "CLAUDE.md"), []byte(prof.ClaudeMD), 0644)
+		if err != nil {
+			os.RemoveAll(backupPath) // Clean up on failure
+			return "", fmt.Errorf("failed to backup CLAUDE.md: %w", err)
+		}
+	}
+
+	if len(prof.MCPServers) > 0 {
+		err = mcpconfig.SaveToFile(filepath.Join(backupPath, "mcp.json"), prof.MCPServers)
+		if err != nil {
+			os.RemoveAll(backupPath) // Clean up on failure
+			return "", fmt.Errorf("failed to backup MCP servers: %w", err)
+		}
+	}
+
+	if len(prof.ProjectMCPServers) > 0 {
+		err = saveProjectServers(filepath.Join(backupPath, projectMCPFile), prof.ProjectMCPServers)
+		if err != nil {
+			os.RemoveAll(backupPath) // Clean up on failure
+			return "", err
+		}
+	}
+
+	err = m.commit(backupPath, &Manifest{
+		Version:   manifestVersion,
+		ID:        backupID,
+		CreatedAt: time.Now(),
+		Reason:    fmt.Sprintf("snapshot of profile %s", prof.Name),
+		Profile:   prof.Name,
+	})
+	if err != nil {
+		os.RemoveAll(backupPath) // Clean up on failure
+		return "", err
+	}
+
+	return backupID, nil
+}
+
+// Restore restores configuration from a backup, along with the profile
+// trackers and stored profiles recorded in its manifest
+func (m *Manager) Restore(backupID string) error {
+	// Write the backup's files out of the object store, checking their
+	// hashes before anything live is touched
+	backupPath, manifest, cleanup, err := m.checkout(backupID)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := checkRestorable(backupID, manifest); err != nil {
+		return err
+	}
+
+	if m.projectDir != "" {
+		if err := m.restoreProject(backupPath); err != nil {
+			return err
+		}
+		return m.restoreManifest(backupPath, manifest)
 	}
 
 	// Restore settings.json
@@ -174,7 +278,73 @@ func (m *Manager) Restore(backupID string) error {
 		}
 	}
 
-	return nil
+	// Restore per-project MCP servers; backups that predate them leave the
+	// project entries alone
+	backupProjectMCP := filepath.Join(backupPath, projectMCPFile)
+	if config.FileExists(backupProjectMCP) {
+		if err := restoreProjectMCPServers(backupProjectMCP, claudeJSONPath); err != nil {
+			return err
+		}
+	}
+
+	// Restore the trackers and any profiles auto-sync changed, so the
+	// current profile matches the restored configuration
+	return m.restoreManifest(backupPath, manifest)
+}
+
+// LoadProfile reads a backup snapshot as a profile with the given name,
+// without touching the active configuration
+func (m *Manager) LoadProfile(backupID, name string) (*profile.Profile, error) {
+	backupPath, _, cleanup, err := m.checkout(backupID)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	prof := profile.NewProfile(name)
+
+	backupSettings := filepath.Join(backupPath, "settings.json")
+	if config.FileExists(backupSettings) {
+		settings, err := config.LoadSettings(backupSettings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load backup settings: %w", err)
+		}
+		prof.Settings = settings
+	}
+
+	backupClaudeMD := filepath.Join(backupPath, "CLAUDE.md")
+	if config.FileExists(backupClaudeMD) {
+		content, err := os.ReadFile(backupClaudeMD)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup CLAUDE.md: %w", err)
+		}
+		prof.ClaudeMD = string(content)
+	}
+
+	mcpServers, err := mcpconfig.LoadFromFile(filepath.Join(backupPath, "mcp.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load backup MCP servers: %w", err)
+	}
+	prof.MCPServers = mcpServers
+
+	projectServers, err := loadProjectServers(filepath.Join(backupPath, projectMCPFile))
+	if err != nil {
+		return nil, err
+	}
+	if len(projectServers) > 0 {
+		prof.ProjectMCPServers = projectServers
+	}
+
+	return prof, nil
+}
+
+// Exists reports whether a backup with the given ID exists
+func (m *Manager) Exists(backupID string) bool {
+	if !validBackupID(backupID) {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(m.backupDir, backupID))
+	return err == nil && info.IsDir()
 }
 
 // RestoreLatest restores the most recent backup
@@ -199,22 +369,31 @@ func (m *Manager) List() ([]Backup, error) {
 
 	var backups []Backup
 	for _, entry := range entries {
-		if entry.IsDir() {
-			info, err := entry.Info()
-			if err != nil {
+		// Skip the object store and anything else that is not a backup
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), backupPrefix) {
+			backupPath := filepath.Join(m.backupDir, entry.Name())
+			// An unreadable manifest still lists the backup, dated by its ID
+			manifest, err := readManifest(backupPath)
+			if manifest == nil && err == nil && isEmptyDir(backupPath) {
+				// Left by a backup that failed part-way; restoring it
+				// would clear the live configuration
 				continue
 			}
-
 			backups = append(backups, Backup{
 				ID:        entry.Name(),
-				CreatedAt: info.ModTime(),
+				CreatedAt: m.createdAt(entry.Name(), manifest),
+				Pinned:    manifest != nil && manifest.Pinned,
 			})
 		}
 	}
 
-	// Sort by creation time, newest first
+	// Sort by creation time, newest first. Directory times are not used, as
+	// they change when a backup is touched.
 	sort.Slice(backups, func(i, j int) bool {
-		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+		if !backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].CreatedAt.After(backups[j].CreatedAt)
+		}
+		return backups[i].ID > backups[j].ID
 	})
 
 	return backups, nil
@@ -230,13 +409,12 @@ func (m *Manager) GetLatest() string {
 	return backups[0].ID
 }
 
-// Delete removes a backup
+// Delete removes a backup. Its objects are left for Prune or GC.
 func (m *Manager) Delete(backupID string) error {
-	backupPath := filepath.Join(m.backupDir, backupID)
-
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+	if !m.Exists(backupID) {
 		return fmt.Errorf("backup %q does not exist", backupID)
 	}
+	backupPath := filepath.Join(m.backupDir, backupID)
 
 	err := os.RemoveAll(backupPath)
 	if err != nil {
@@ -246,25 +424,15 @@ func (m *Manager) Delete(backupID string) error {
 	return nil
 }
 
-// Prune removes old backups, keeping only the specified number
+// Prune removes old backups, keeping only the specified number and any
+// pinned backups, and then removes the objects only they used
 func (m *Manager) Prune(keep int) error {
-	backups, err := m.List()
-	if err != nil {
-		return err
-	}
-
-	if len(backups) <= keep {
-		return nil // Nothing to prune
-	}
-
-	// Delete oldest backups
-	toDelete := backups[keep:]
-	for _, backup := range toDelete {
-		err := m.Delete(backup.ID)
-		if err != nil {
-			return err
-		}
-	}
+	_, err := m.PruneWithPolicy(Policy{KeepLast: keep}, false)
+	return err
+}
 
-	return nil
+// isEmptyDir reports whether dir contains no entries
+func isEmptyDir(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) == 0
 }
@@ -1,13 +1,22 @@
 package backup
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
 	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
 )
 
 func setupTestEnv(t *testing.T) string {
@@ -63,7 +72,7 @@ func TestCreateBackup(t *testing.T) {
 	config.SaveSettings(settingsPath, settings)
 
 	// Create backup
-	backupID, err := mgr.Create()
+	backupID, err := mgr.Create("test")
 	if err != nil {
 		t.Fatalf("Create() failed: %v", err)
 	}
@@ -99,21 +108,26 @@ func TestCreateBackupWithClaudeMD(t *testing.T) {
 	os.WriteFile(claudeMDPath, []byte("# Instructions"), 0644)
 
 	// Create backup
-	backupID, err := mgr.Create()
+	backupID, err := mgr.Create("test")
 	if err != nil {
 		t.Fatalf("Create() failed: %v", err)
 	}
 
 	// Verify both files were backed up
-	backupDir := filepath.Join(mgr.backupDir, backupID)
-	settingsBackup := filepath.Join(backupDir, "settings.json")
-	claudeMDBackup := filepath.Join(backupDir, "CLAUDE.md")
+	manifest, err := mgr.ReadManifest(backupID)
+	if err != nil {
+		t.Fatalf("ReadManifest() failed: %v", err)
+	}
+	backedUp := map[string]bool{}
+	for _, f := range manifest.Files {
+		backedUp[f.Path] = true
+	}
 
-	if !config.FileExists(settingsBackup) {
+	if !backedUp["settings.json"] {
 		t.Error("settings.json not backed up")
 	}
 
-	if !config.FileExists(claudeMDBackup) {
+	if !backedUp["CLAUDE.md"] {
 		t.Error("CLAUDE.md not backed up")
 	}
 }
@@ -128,7 +142,7 @@ func TestRestoreBackup(t *testing.T) {
 	config.SaveSettings(settingsPath, originalSettings)
 
 	// Create backup
-	backupID, err := mgr.Create()
+	backupID, err := mgr.Create("test")
 	if err != nil {
 		t.Fatalf("Create() failed: %v", err)
 	}
@@ -166,7 +180,7 @@ func TestRestoreWithClaudeMD(t *testing.T) {
 	os.WriteFile(claudeMDPath, []byte("# Original"), 0644)
 
 	// Create backup
-	backupID, _ := mgr.Create()
+	backupID, _ := mgr.Create("test")
 
 	// Modify files
 	config.SaveSettings(settingsPath, &config.Settings{Model: "haiku"})
@@ -214,11 +228,11 @@ func TestListBackups(t *testing.T) {
 	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
 
 	// Create some backups
-	mgr.Create()
+	mgr.Create("test")
 	time.Sleep(10 * time.Millisecond) // Ensure different timestamps
-	mgr.Create()
+	mgr.Create("test")
 	time.Sleep(10 * time.Millisecond)
-	mgr.Create()
+	mgr.Create("test")
 
 	backups, err = mgr.List()
 	if err != nil {
@@ -237,6 +251,50 @@ func TestListBackups(t *testing.T) {
 	}
 }
 
+func TestCommitKeepsFilesWhenManifestWriteFails(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	backupID := "backup-1000000000000000000"
+	backupPath := filepath.Join(mgr.backupDir, backupID)
+	if err := os.MkdirAll(backupPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(backupPath, "CLAUDE.md"), []byte("# Rules\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A non-empty directory where the manifest goes makes its write fail
+	if err := os.MkdirAll(filepath.Join(backupPath, manifestFile, "blocker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := mgr.commit(backupPath, &Manifest{Version: manifestVersion, ID: backupID, CreatedAt: time.Now()})
+	if err == nil {
+		t.Fatal("commit() should fail when the manifest cannot be written")
+	}
+	if !config.FileExists(filepath.Join(backupPath, "CLAUDE.md")) {
+		t.Error("commit() removed the backup's files without writing its manifest")
+	}
+}
+
+func TestListSkipsEmptyBackupDirectories(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	settingsPath, _ := paths.SettingsFile()
+	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
+	backupID, _ := mgr.Create("test")
+	os.MkdirAll(filepath.Join(mgr.backupDir, "backup-1000000000000000000"), 0755)
+
+	backups, err := mgr.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(backups) != 1 || backups[0].ID != backupID {
+		t.Errorf("List() = %+v, want only %s", backups, backupID)
+	}
+}
+
 func TestPruneOldBackups(t *testing.T) {
 	setupTestEnv(t)
 	mgr, _ := NewManager()
@@ -247,7 +305,7 @@ func TestPruneOldBackups(t *testing.T) {
 
 	// Create more backups than the limit (default 10)
 	for i := 0; i < 12; i++ {
-		mgr.Create()
+		mgr.Create("test")
 		time.Sleep(5 * time.Millisecond)
 	}
 
@@ -273,7 +331,7 @@ func TestDeleteBackup(t *testing.T) {
 	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
 
 	// Create backup
-	backupID, _ := mgr.Create()
+	backupID, _ := mgr.Create("test")
 
 	// Delete it
 	err := mgr.Delete(backupID)
@@ -303,11 +361,11 @@ func TestGetLatestBackup(t *testing.T) {
 	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
 
 	// Create backups
-	mgr.Create()
+	mgr.Create("test")
 	time.Sleep(10 * time.Millisecond)
-	mgr.Create()
+	mgr.Create("test")
 	time.Sleep(10 * time.Millisecond)
-	latestID, _ := mgr.Create()
+	latestID, _ := mgr.Create("test")
 
 	// Get latest
 	latest = mgr.GetLatest()
@@ -324,7 +382,7 @@ func TestRestoreLatest(t *testing.T) {
 
 	// Create original
 	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
-	mgr.Create()
+	mgr.Create("test")
 
 	// Modify
 	config.SaveSettings(settingsPath, &config.Settings{Model: "haiku"})
@@ -351,3 +409,743 @@ func TestRestoreLatestWhenNoBackups(t *testing.T) {
 		t.Error("RestoreLatest() should fail when no backups exist")
 	}
 }
+
+func TestCreateFromProfile(t *testing.T) {
+	setupTestEnv(t)
+
+	mgr, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+
+	prof := profile.NewProfile("work")
+	prof.Settings.Model = "opus"
+	prof.ClaudeMD = "# Work"
+	prof.MCPServers = mcpconfig.MCPServers{"github": {Command: "npx"}}
+
+	backupID, err := mgr.CreateFromProfile(prof)
+	if err != nil {
+		t.Fatalf("CreateFromProfile() failed: %v", err)
+	}
+
+	restored, err := mgr.LoadProfile(backupID, "restored")
+	if err != nil {
+		t.Fatalf("LoadProfile() failed: %v", err)
+	}
+	if restored.Settings.Model != "opus" || restored.ClaudeMD != "# Work" || restored.MCPServers["github"].Command != "npx" {
+		t.Errorf("restored profile = %+v", restored)
+	}
+}
+
+func TestProjectBackupRestore(t *testing.T) {
+	setupTestEnv(t)
+	project := t.TempDir()
+
+	mgr, err := NewProjectManager(project)
+	if err != nil {
+		t.Fatalf("NewProjectManager() failed: %v", err)
+	}
+
+	settingsPath := paths.ProjectSettingsLocalFile(project)
+	os.MkdirAll(filepath.Dir(settingsPath), 0755)
+	os.WriteFile(settingsPath, []byte(`{"model": "opus"}`), 0644)
+	mcpPath := paths.ProjectMCPFile(project)
+	os.WriteFile(mcpPath, []byte(`{"mcpServers": {}}`), 0644)
+	stateDir, _ := paths.ProjectStateDir(project)
+	os.WriteFile(filepath.Join(stateDir, "current"), []byte("work"), 0644)
+
+	backupID, err := mgr.Create("test")
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	// Change the project files after the backup
+	os.WriteFile(settingsPath, []byte(`{"model": "haiku"}`), 0644)
+	os.Remove(mcpPath)
+	claudeMDPath := paths.ProjectClaudeMDLocalFile(project)
+	os.WriteFile(claudeMDPath, []byte("# New"), 0644)
+	os.WriteFile(filepath.Join(stateDir, "current"), []byte("personal"), 0644)
+
+	if err := mgr.Restore(backupID); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	if data, _ := os.ReadFile(settingsPath); string(data) != `{"model": "opus"}` {
+		t.Errorf("settings.local.json = %s", data)
+	}
+	if data, _ := os.ReadFile(mcpPath); string(data) != `{"mcpServers": {}}` {
+		t.Errorf(".mcp.json = %s", data)
+	}
+	if config.FileExists(claudeMDPath) {
+		t.Error("CLAUDE.local.md was not in the backup and should be removed")
+	}
+	if data, _ := os.ReadFile(filepath.Join(stateDir, "current")); string(data) != "work" {
+		t.Errorf("project current tracker = %q, want work", data)
+	}
+
+	// Project backups are kept apart from the user backups
+	userMgr, _ := NewManager()
+	if userMgr.Exists(backupID) {
+		t.Error("project backup should not be listed with user backups")
+	}
+}
+
+func TestBackupRestoreProjectMCPServers(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	claudeJSON, _ := paths.ClaudeJSONFile()
+	mcpconfig.SaveProjectMCPServers(claudeJSON, map[string]mcpconfig.MCPServers{
+		"/src/app": {"db": {Command: "db-mcp"}},
+	})
+
+	backupID, err := mgr.Create("test")
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	mcpconfig.SaveProjectMCPServers(claudeJSON, map[string]mcpconfig.MCPServers{
+		"/src/app":   {"other": {Command: "other"}},
+		"/src/added": {"fs": {Command: "fs-mcp"}},
+	})
+
+	if err := mgr.Restore(backupID); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	projects, _ := mcpconfig.LoadProjectMCPServers(claudeJSON)
+	if len(projects["/src/app"]) != 1 || projects["/src/app"]["db"].Command != "db-mcp" {
+		t.Errorf("/src/app servers = %+v", projects["/src/app"])
+	}
+	if len(projects["/src/added"]) != 0 {
+		t.Errorf("servers added after the backup should be cleared, got %+v", projects["/src/added"])
+	}
+
+	prof, err := mgr.LoadProfile(backupID, "restored")
+	if err != nil {
+		t.Fatalf("LoadProfile() failed: %v", err)
+	}
+	if prof.ProjectMCPServers["/src/app"]["db"].Command != "db-mcp" {
+		t.Errorf("LoadProfile() project servers = %+v", prof.ProjectMCPServers)
+	}
+}
+
+func TestCreateWritesManifest(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	settingsPath, _ := paths.SettingsFile()
+	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
+	currentFile, _ := paths.CurrentProfileFile()
+	os.WriteFile(currentFile, []byte("work"), 0644)
+
+	backupID, err := mgr.Create("switch to personal")
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	manifest, err := mgr.ReadManifest(backupID)
+	if err != nil || manifest == nil {
+		t.Fatalf("ReadManifest() = %v, %v", manifest, err)
+	}
+	if manifest.ID != backupID || manifest.Reason != "switch to personal" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if manifest.Trackers == nil || manifest.Trackers.Current != "work" || manifest.Trackers.Previous != "" {
+		t.Errorf("trackers = %+v", manifest.Trackers)
+	}
+	if len(manifest.Files) != 1 || manifest.Files[0].Path != "settings.json" {
+		t.Errorf("files = %v", manifest.Files)
+	}
+
+	// Profile snapshots record the profile instead of the trackers
+	snapshotID, err := mgr.CreateFromProfile(profile.NewProfile("work"))
+	if err != nil {
+		t.Fatalf("CreateFromProfile() failed: %v", err)
+	}
+	manifest, _ = mgr.ReadManifest(snapshotID)
+	if manifest == nil || manifest.Profile != "work" || manifest.Trackers != nil {
+		t.Errorf("snapshot manifest = %+v", manifest)
+	}
+}
+
+func TestRestoreTrackersAndProfiles(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	currentFile, _ := paths.CurrentProfileFile()
+	previousFile, _ := paths.PreviousProfileFile()
+	os.WriteFile(currentFile, []byte("work"), 0644)
+
+	// A stored profile, as the store lays it out
+	profileDir, _ := paths.ProfileDir("work")
+	os.MkdirAll(filepath.Join(profileDir, "project"), 0755)
+	os.WriteFile(filepath.Join(profileDir, "settings.json"), []byte(`{"model": "opus"}`), 0644)
+	os.WriteFile(filepath.Join(profileDir, "project", "CLAUDE.local.md"), []byte("# Local"), 0644)
+
+	backupID, err := mgr.Create("test")
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if err := mgr.SnapshotProfile(backupID, "work"); err != nil {
+		t.Fatalf("SnapshotProfile() failed: %v", err)
+	}
+
+	// Simulate auto-sync and a half-finished switch
+	os.WriteFile(filepath.Join(profileDir, "settings.json"), []byte(`{"model": "haiku"}`), 0644)
+	os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# Synced"), 0644)
+	os.WriteFile(currentFile, []byte("personal"), 0644)
+	os.WriteFile(previousFile, []byte("work"), 0644)
+
+	if err := mgr.Restore(backupID); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	if data, _ := os.ReadFile(currentFile); string(data) != "work" {
+		t.Errorf("current tracker = %q, want work", data)
+	}
+	if config.FileExists(previousFile) {
+		t.Error("previous tracker was not set when the backup was taken and should be removed")
+	}
+	if data, _ := os.ReadFile(filepath.Join(profileDir, "settings.json")); string(data) != `{"model": "opus"}` {
+		t.Errorf("profile settings = %s", data)
+	}
+	if config.FileExists(filepath.Join(profileDir, "CLAUDE.md")) {
+		t.Error("file added by the sync should be removed")
+	}
+	if data, _ := os.ReadFile(filepath.Join(profileDir, "project", "CLAUDE.local.md")); string(data) != "# Local" {
+		t.Errorf("project CLAUDE.local.md = %s", data)
+	}
+}
+
+func TestRestoreLegacyBackup(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	// Backups taken before manifests and the object store keep their files
+	// in the backup directory
+	backupID := "backup-1"
+	os.MkdirAll(filepath.Join(mgr.backupDir, backupID), 0755)
+	os.WriteFile(filepath.Join(mgr.backupDir, backupID, "settings.json"), []byte(`{"model": "opus"}`), 0644)
+
+	currentFile, _ := paths.CurrentProfileFile()
+	os.WriteFile(currentFile, []byte("work"), 0644)
+
+	if err := mgr.Restore(backupID); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	settingsPath, _ := paths.SettingsFile()
+	if settings, _ := config.LoadSettings(settingsPath); settings == nil || settings.Model != "opus" {
+		t.Errorf("settings = %+v", settings)
+	}
+	if data, _ := os.ReadFile(currentFile); string(data) != "work" {
+		t.Errorf("current tracker = %q, want it left alone", data)
+	}
+}
+
+func TestObjectStoreDeduplicates(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	settingsPath, _ := paths.SettingsFile()
+	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
+
+	first, _ := mgr.Create("test")
+	second, _ := mgr.Create("test")
+
+	// Backup directories hold only their manifest
+	for _, id := range []string{first, second} {
+		entries, _ := os.ReadDir(filepath.Join(mgr.backupDir, id))
+		if len(entries) != 1 || entries[0].Name() != manifestFile {
+			t.Errorf("%s contains %v, want only %s", id, entries, manifestFile)
+		}
+	}
+
+	if n := countObjects(t, mgr); n != 1 {
+		t.Errorf("object store has %d objects, want 1 shared by both backups", n)
+	}
+
+	// The object store is not listed as a backup
+	backups, _ := mgr.List()
+	if len(backups) != 2 {
+		t.Errorf("List() = %v, want 2 backups", backups)
+	}
+	if mgr.Exists(objectsDir) {
+		t.Error("Exists() should not report the object store")
+	}
+}
+
+func TestGC(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	settingsPath, _ := paths.SettingsFile()
+	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
+	kept, _ := mgr.Create("test")
+	config.SaveSettings(settingsPath, &config.Settings{Model: "haiku"})
+	deleted, _ := mgr.Create("test")
+
+	if err := mgr.Delete(deleted); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	// Recently written objects survive the grace period
+	result, err := mgr.GC()
+	if err != nil || result.Objects != 0 {
+		t.Fatalf("GC() = %+v, %v, want nothing removed yet", result, err)
+	}
+
+	backdateObjects(t, mgr)
+	result, err = mgr.GC()
+	if err != nil {
+		t.Fatalf("GC() failed: %v", err)
+	}
+	if result.Objects != 1 || result.Bytes == 0 {
+		t.Errorf("GC() = %+v, want one object removed", result)
+	}
+
+	if err := mgr.Restore(kept); err != nil {
+		t.Fatalf("Restore() of the kept backup failed: %v", err)
+	}
+	if settings, _ := config.LoadSettings(settingsPath); settings.Model != "opus" {
+		t.Errorf("restored model = %q", settings.Model)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	settingsPath, _ := paths.SettingsFile()
+	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
+	claudeMDPath, _ := paths.ClaudeMDFile()
+	os.WriteFile(claudeMDPath, []byte("# Instructions"), 0644)
+	backupID, _ := mgr.Create("test")
+
+	if problems, err := mgr.Verify(); err != nil || len(problems) != 0 {
+		t.Fatalf("Verify() = %v, %v, want no problems", problems, err)
+	}
+
+	manifest, _ := mgr.ReadManifest(backupID)
+	for _, f := range manifest.Files {
+		switch f.Path {
+		case "settings.json":
+			os.WriteFile(mgr.objectPath(f.SHA256), []byte(`{"model": "haiku"}`), 0600)
+		case "CLAUDE.md":
+			os.Remove(mgr.objectPath(f.SHA256))
+		}
+	}
+
+	problems, err := mgr.Verify(backupID)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("Verify() = %v, want 2 problems", problems)
+	}
+	for _, p := range problems {
+		want := map[string]string{"CLAUDE.md": "missing", "settings.json": "corrupt"}[p.Path]
+		if want == "" || !strings.Contains(p.Err.Error(), want) {
+			t.Errorf("problem %s, want %q", p, want)
+		}
+	}
+
+	// A corrupt backup is not restored
+	config.SaveSettings(settingsPath, &config.Settings{Model: "sonnet"})
+	if err := mgr.Restore(backupID); err == nil {
+		t.Error("Restore() should fail for a corrupt backup")
+	}
+	if settings, _ := config.LoadSettings(settingsPath); settings.Model != "sonnet" {
+		t.Errorf("live settings changed to %q by a failed restore", settings.Model)
+	}
+
+	if _, err := mgr.Verify("backup-missing"); err == nil {
+		t.Error("Verify() should fail for an unknown backup")
+	}
+}
+
+func countObjects(t *testing.T, mgr *Manager) int {
+	t.Helper()
+	count := 0
+	filepath.WalkDir(filepath.Join(mgr.backupDir, objectsDir), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+func backdateObjects(t *testing.T, mgr *Manager) {
+	t.Helper()
+	old := time.Now().Add(-2 * gcGracePeriod)
+	filepath.WalkDir(filepath.Join(mgr.backupDir, objectsDir), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			os.Chtimes(path, old, old)
+		}
+		return nil
+	})
+}
+
+func TestPolicySelect(t *testing.T) {
+	now := time.Date(2026, 3, 18, 12, 0, 0, 0, time.Local) // a Wednesday
+	at := func(days, hours int) time.Time {
+		return now.AddDate(0, 0, -days).Add(-time.Duration(hours) * time.Hour)
+	}
+
+	// Newest first, as List returns them
+	backups := []Backup{
+		{ID: "a", CreatedAt: at(0, 1)},
+		{ID: "b", CreatedAt: at(0, 2)},
+		{ID: "c", CreatedAt: at(0, 3)},
+		{ID: "d", CreatedAt: at(1, 0)},
+		{ID: "e", CreatedAt: at(1, 1)},
+		{ID: "f", CreatedAt: at(9, 0)},
+		{ID: "g", CreatedAt: at(10, 0), Pinned: true},
+		{ID: "h", CreatedAt: at(30, 0)},
+	}
+
+	ids := func(backups []Backup) string {
+		var out []string
+		for _, b := range backups {
+			out = append(out, b.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		keep   string
+	}{
+		{"last", Policy{KeepLast: 2}, "a,b,g"},
+		{"daily", Policy{KeepDaily: 2}, "a,d,g"},
+		{"weekly", Policy{KeepWeekly: 2}, "a,f,g"},
+		{"combined", Policy{KeepLast: 1, KeepDaily: 7, KeepWeekly: 6}, "a,d,f,g,h"},
+		{"nothing", Policy{}, "g"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep, remove := tt.policy.Select(backups, now)
+			if got := ids(keep); got != tt.keep {
+				t.Errorf("keep = %s, want %s", got, tt.keep)
+			}
+			if len(keep)+len(remove) != len(backups) {
+				t.Errorf("keep %d + remove %d != %d", len(keep), len(remove), len(backups))
+			}
+		})
+	}
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "claudectx.json")
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+
+	policy, err := LoadPolicyFile(filepath.Join(dir, "missing.json"))
+	if err != nil || policy != DefaultPolicy {
+		t.Errorf("missing file: %+v, %v", policy, err)
+	}
+
+	// Keys that are not set keep their defaults
+	policy, err = LoadPolicyFile(write(`{"backups": {"keepLast": 5, "keepWeekly": 0}, "other": true}`))
+	if err != nil || policy != (Policy{KeepLast: 5, KeepDaily: DefaultPolicy.KeepDaily}) {
+		t.Errorf("partial policy: %+v, %v", policy, err)
+	}
+
+	if _, err := LoadPolicyFile(write(`{"backups": {"keepLast": -1}}`)); err == nil {
+		t.Error("expected error for a negative count")
+	}
+	if _, err := LoadPolicyFile(write(`{"backups": {"keepLast": "5"}}`)); err == nil {
+		t.Error("expected error for a wrongly typed count")
+	}
+}
+
+func TestPinnedBackupSurvivesPrune(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	pinned, _ := mgr.Create("test")
+	for i := 0; i < 3; i++ {
+		mgr.Create("test")
+	}
+
+	if err := mgr.Pin(pinned, true); err != nil {
+		t.Fatalf("Pin() failed: %v", err)
+	}
+
+	removed, err := mgr.PruneWithPolicy(Policy{KeepLast: 1}, true)
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("dry run removed %v, %v, want 2", removed, err)
+	}
+	if backups, _ := mgr.List(); len(backups) != 4 {
+		t.Errorf("dry run deleted backups, %d left", len(backups))
+	}
+
+	if err := mgr.Prune(1); err != nil {
+		t.Fatalf("Prune() failed: %v", err)
+	}
+	if !mgr.Exists(pinned) {
+		t.Error("pinned backup was pruned")
+	}
+	if backups, _ := mgr.List(); len(backups) != 2 || !backups[1].Pinned {
+		t.Errorf("List() after prune = %+v", backups)
+	}
+
+	if err := mgr.Pin(pinned, false); err != nil {
+		t.Fatalf("Pin(false) failed: %v", err)
+	}
+	mgr.Prune(1)
+	if mgr.Exists(pinned) {
+		t.Error("unpinned backup should be pruned")
+	}
+}
+
+func TestListIgnoresDirectoryTimes(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	older, _ := mgr.Create("test")
+	newer, _ := mgr.Create("test")
+
+	// Touching the older backup does not make it the latest
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(mgr.backupDir, older), future, future)
+
+	if latest := mgr.GetLatest(); latest != newer {
+		t.Errorf("GetLatest() = %s, want %s", latest, newer)
+	}
+
+	// Backups without a manifest are dated by their ID, and can be pinned
+	legacy := "backup-1000000000000000000"
+	os.MkdirAll(filepath.Join(mgr.backupDir, legacy), 0755)
+	if err := mgr.Pin(legacy, true); err != nil {
+		t.Fatalf("Pin() of a legacy backup failed: %v", err)
+	}
+	backups, _ := mgr.List()
+	last := backups[len(backups)-1]
+	if last.ID != legacy || !last.Pinned || last.CreatedAt.Year() != 2001 {
+		t.Errorf("legacy backup = %+v", last)
+	}
+	if err := mgr.Restore(legacy); err != nil {
+		t.Errorf("Restore() of a pinned legacy backup failed: %v", err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	settingsPath, _ := paths.SettingsFile()
+	config.SaveSettings(settingsPath, &config.Settings{Model: "opus"})
+	first, _ := mgr.Create("switch to work")
+	mgr.Pin(first, true)
+	config.SaveSettings(settingsPath, &config.Settings{Model: "haiku"})
+	second, _ := mgr.Create("switch to personal")
+
+	var archive bytes.Buffer
+	if err := mgr.Export(&archive, []string{first, second}); err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+
+	// Import on a "new laptop"
+	setupTestEnv(t)
+	other, _ := NewManager()
+	result, err := other.Import(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if len(result.Imported) != 2 || len(result.Skipped) != 0 {
+		t.Errorf("Import() = %+v", result)
+	}
+
+	if problems, err := other.Verify(); err != nil || len(problems) != 0 {
+		t.Errorf("Verify() after import = %v, %v", problems, err)
+	}
+	backups, _ := other.List()
+	if len(backups) != 2 || backups[0].ID != second || !backups[1].Pinned {
+		t.Errorf("List() after import = %+v", backups)
+	}
+	prof, err := other.LoadProfile(first, "restored")
+	if err != nil || prof.Settings.Model != "opus" {
+		t.Errorf("LoadProfile() = %+v, %v", prof, err)
+	}
+
+	// Importing again skips the existing backups
+	result, err = other.Import(bytes.NewReader(archive.Bytes()))
+	if err != nil || len(result.Imported) != 0 || len(result.Skipped) != 2 {
+		t.Errorf("second Import() = %+v, %v", result, err)
+	}
+
+	if err := mgr.Export(&archive, nil); err == nil {
+		t.Error("Export() with no backups should fail")
+	}
+}
+
+func TestImportRejectsBadArchives(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	manifest := []byte(`{"version": 1, "id": "backup-1"}`)
+	sum := sha256.Sum256(manifest)
+	header := func(version int, checksums map[string]string) []byte {
+		data, _ := json.Marshal(ArchiveHeader{
+			Format:    archiveFormat,
+			Version:   version,
+			Backups:   []string{"backup-1"},
+			Checksums: checksums,
+		})
+		return data
+	}
+	valid := map[string]string{"backups/backup-1/backup.json": hex.EncodeToString(sum[:])}
+	object := []byte(`{"model": "opus"}`)
+	objectSum := sha256.Sum256(object)
+
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		want    string
+	}{
+		{"no header", []tarEntry{{name: "backups/backup-1/backup.json", data: manifest}}, "not a backup archive"},
+		{"newer version", []tarEntry{{name: archiveHeaderFile, data: header(archiveVersion+1, valid)}}, "unsupported archive version"},
+		{"traversal", []tarEntry{
+			{name: archiveHeaderFile, data: header(1, valid)},
+			{name: "backups/backup-1/../../../../evil", data: manifest},
+		}, "unsafe path"},
+		{"absolute", []tarEntry{
+			{name: archiveHeaderFile, data: header(1, valid)},
+			{name: "/etc/evil", data: manifest},
+		}, "unsafe path"},
+		{"unknown path", []tarEntry{
+			{name: archiveHeaderFile, data: header(1, valid)},
+			{name: "profiles/work/settings.json", data: manifest},
+		}, "unexpected path"},
+		{"symlink", []tarEntry{
+			{name: archiveHeaderFile, data: header(1, valid)},
+			{name: "backups/backup-1/backup.json", link: "/etc/passwd"},
+		}, "not a regular file"},
+		{"tampered", []tarEntry{
+			{name: archiveHeaderFile, data: header(1, valid)},
+			{name: "backups/backup-1/backup.json", data: []byte(`{"version": 1, "id": "backup-2"}`)},
+		}, "checksum mismatch"},
+		{"bad object", []tarEntry{
+			{name: archiveHeaderFile, data: header(1, valid)},
+			{name: "objects/" + hex.EncodeToString(sum[:]), data: []byte("other")},
+		}, "checksum mismatch"},
+		{"missing entry", []tarEntry{{name: archiveHeaderFile, data: header(1, valid)}}, "archive is missing"},
+		{"object then tampered", []tarEntry{
+			{name: archiveHeaderFile, data: header(1, valid)},
+			{name: "objects/" + hex.EncodeToString(objectSum[:]), data: object},
+			{name: "backups/backup-1/backup.json", data: []byte(`{"version": 1, "id": "backup-2"}`)},
+		}, "checksum mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mgr.Import(bytes.NewReader(buildArchive(t, tt.entries)))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Import() error = %v, want %q", err, tt.want)
+			}
+			if backups, _ := mgr.List(); len(backups) != 0 {
+				t.Errorf("a rejected archive added backups: %v", backups)
+			}
+			if entries, _ := os.ReadDir(filepath.Join(mgr.backupDir, objectsDir)); len(entries) != 0 {
+				t.Errorf("a rejected archive added objects: %v", entries)
+			}
+		})
+	}
+
+	// The valid archive is accepted
+	_, err := mgr.Import(bytes.NewReader(buildArchive(t, []tarEntry{
+		{name: archiveHeaderFile, data: header(1, valid)},
+		{name: "backups/backup-1/backup.json", data: manifest},
+	})))
+	if err != nil || !mgr.Exists("backup-1") {
+		t.Errorf("Import() of a valid archive failed: %v", err)
+	}
+}
+
+type tarEntry struct {
+	name string
+	data []byte
+	link string
+}
+
+func buildArchive(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0600, Size: int64(len(e.data)), Typeflag: tar.TypeReg}
+		if e.link != "" {
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.link, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(e.data)
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestImportRejectsMaliciousManifests(t *testing.T) {
+	setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{"mismatched ID", `{"version": 1, "id": "backup-2"}`, "manifest is for backup"},
+		{"profile traversal", `{"version": 1, "id": "backup-1", "profiles": ["../.."]}`, "invalid profile name"},
+		{"snapshot profile", `{"version": 1, "id": "backup-1", "profile": "../work"}`, "invalid profile name"},
+		{"tracker", `{"version": 1, "id": "backup-1", "trackers": {"current": "work\nevil"}}`, "invalid profile name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := []byte(tt.manifest)
+			sum := sha256.Sum256(manifest)
+			header, _ := json.Marshal(ArchiveHeader{
+				Format:    archiveFormat,
+				Version:   archiveVersion,
+				Backups:   []string{"backup-1"},
+				Checksums: map[string]string{"backups/backup-1/backup.json": hex.EncodeToString(sum[:])},
+			})
+
+			_, err := mgr.Import(bytes.NewReader(buildArchive(t, []tarEntry{
+				{name: archiveHeaderFile, data: header},
+				{name: "backups/backup-1/backup.json", data: manifest},
+			})))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Import() error = %v, want %q", err, tt.want)
+			}
+			if backups, _ := mgr.List(); len(backups) != 0 {
+				t.Errorf("a rejected archive added backups: %v", backups)
+			}
+		})
+	}
+}
+
+func TestRestoreManifestRejectsInvalidProfileNames(t *testing.T) {
+	tmpHome := setupTestEnv(t)
+	mgr, _ := NewManager()
+
+	keep := filepath.Join(tmpHome, ".claude", "keep")
+	if err := os.MkdirAll(keep, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := mgr.restoreManifest(t.TempDir(), &Manifest{Version: manifestVersion, ID: "backup-1", Profiles: []string{"../keep"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid profile name") {
+		t.Fatalf("restoreManifest() error = %v, want an invalid profile name error", err)
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Errorf("restoreManifest removed a directory outside the profile store: %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+// manifestFile is the name of the manifest in each backup directory
+const manifestFile = "backup.json"
+
+// profilesDir is the directory in a backup holding copies of stored profiles
+const profilesDir = "profiles"
+
+// manifestVersion is the current manifest format version. Version 1
+// manifests list files kept in the backup directory; version 2 manifests
+// list objects in the object store.
+const manifestVersion = 2
+
+// Manifest describes what a backup contains and why it was taken. It is
+// written to backup.json in the backup directory.
+type Manifest struct {
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Reason    string    `json:"reason,omitempty"`
+	// ProjectDir is set for backups of a project's local configuration
+	ProjectDir string `json:"projectDir,omitempty"`
+	// Profile is set for backups holding a stored profile rather than the
+	// live configuration
+	Profile string `json:"profile,omitempty"`
+	// Trackers holds the current/previous profiles when the backup was
+	// taken. It is nil for profile snapshots, whose restore leaves the
+	// trackers alone.
+	Trackers *Trackers `json:"trackers,omitempty"`
+	// Pinned backups are never removed by pruning
+	Pinned bool `json:"pinned,omitempty"`
+	// Files lists the files in the backup, including the files of copied
+	// profiles
+	Files []File `json:"files"`
+	// Profiles lists the stored profiles copied into the backup before
+	// auto-sync changed them
+	Profiles []string `json:"profiles,omitempty"`
+}
+
+// Trackers holds the current and previous profile names
+type Trackers struct {
+	Current  string `json:"current,omitempty"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// ReadManifest returns a backup's manifest, or nil for backups taken before
+// manifests were written
+func (m *Manager) ReadManifest(backupID string) (*Manifest, error) {
+	if !m.Exists(backupID) {
+		return nil, fmt.Errorf("backup %q does not exist", backupID)
+	}
+	return readManifest(filepath.Join(m.backupDir, backupID))
+}
+
+// IsProfileSnapshot reports whether the manifest belongs to a backup of a
+// stored profile, such as the ones "health --fix" takes, rather than of the
+// live configuration
+func (mf *Manifest) IsProfileSnapshot() bool {
+	return mf != nil && mf.Profile != ""
+}
+
+// CheckRestorable returns an error if a backup cannot be restored over the
+// live configuration. Profile snapshots hold no trackers, MCP servers or
+// CLAUDE.md for the live configuration, so they are copied to a profile
+// with "claudectx cp" instead.
+func (m *Manager) CheckRestorable(backupID string) error {
+	manifest, err := m.ReadManifest(backupID)
+	if err != nil {
+		return err
+	}
+	return checkRestorable(backupID, manifest)
+}
+
+// checkRestorable refuses profile snapshots
+func checkRestorable(backupID string, manifest *Manifest) error {
+	if manifest.IsProfileSnapshot() {
+		return fmt.Errorf("backup %s is a snapshot of profile %q, not of the live configuration (copy it to a profile with: claudectx cp %s <name>)", backupID, manifest.Profile, backupID)
+	}
+	return nil
+}
+
+// SnapshotProfile copies a stored profile into a backup, so that restoring
+// the backup also undoes changes made to the profile afterwards. Switching
+// calls it before auto-sync writes the live configuration into the outgoing
+// profile.
+func (m *Manager) SnapshotProfile(backupID, name string) error {
+	if !m.Exists(backupID) {
+		return fmt.Errorf("backup %q does not exist", backupID)
+	}
+	backupPath := filepath.Join(m.backupDir, backupID)
+
+	manifest, err := readManifest(backupPath)
+	if err != nil {
+		return err
+	}
+	if manifest == nil || manifest.Version < 2 {
+		return fmt.Errorf("backup %q predates profile snapshots", backupID)
+	}
+
+	profileDir, err := paths.ProfileDir(name)
+	if err != nil {
+		return err
+	}
+	stagingDir := filepath.Join(backupPath, profilesDir)
+	defer os.RemoveAll(stagingDir)
+	if err := copyDir(profileDir, filepath.Join(stagingDir, name)); err != nil {
+		return fmt.Errorf("failed to backup profile %q: %w", name, err)
+	}
+	files, err := m.storeFiles(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to backup profile %q: %w", name, err)
+	}
+
+	// Replace an earlier snapshot of the same profile
+	prefix := profilesDir + "/" + name + "/"
+	kept := manifest.Files[:0]
+	for _, f := range manifest.Files {
+		if !strings.HasPrefix(f.Path, prefix) {
+			kept = append(kept, f)
+		}
+	}
+	manifest.Files = append(kept, files...)
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	if !slices.Contains(manifest.Profiles, name) {
+		manifest.Profiles = append(manifest.Profiles, name)
+		sort.Strings(manifest.Profiles)
+	}
+	return writeManifest(backupPath, manifest)
+}
+
+// newManifest builds the manifest for a backup of the live configuration,
+// recording the trackers. commit adds the files.
+func (m *Manager) newManifest(backupID, reason string) (*Manifest, error) {
+	currentFile, previousFile, err := m.trackerFiles()
+	if err != nil {
+		return nil, err
+	}
+	current, err := readTracker(currentFile)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := readTracker(previousFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manifest{
+		Version:    manifestVersion,
+		ID:         backupID,
+		CreatedAt:  time.Now(),
+		Reason:     reason,
+		ProjectDir: m.projectDir,
+		Trackers:   &Trackers{Current: current, Previous: previous},
+	}, nil
+}
+
+// trackerFiles returns the paths of the current and previous trackers that
+// the manager's backups record
+func (m *Manager) trackerFiles() (string, string, error) {
+	if m.projectDir != "" {
+		// Project backups live in the project's state directory, next to
+		// its trackers
+		stateDir := filepath.Dir(m.backupDir)
+		return filepath.Join(stateDir, "current"), filepath.Join(stateDir, "previous"), nil
+	}
+
+	currentFile, err := paths.CurrentProfileFile()
+	if err != nil {
+		return "", "", err
+	}
+	previousFile, err := paths.PreviousProfileFile()
+	if err != nil {
+		return "", "", err
+	}
+	return currentFile, previousFile, nil
+}
+
+// restoreManifest restores the trackers and stored profiles recorded in a
+// backup's manifest from a checked-out backup in dir. Backups without a
+// manifest leave both alone.
+func (m *Manager) restoreManifest(dir string, manifest *Manifest) error {
+	if manifest == nil {
+		return nil
+	}
+	// Names become tracker contents and profile paths, so a tampered
+	// manifest must not reach outside the profile store
+	if err := manifest.checkNames(); err != nil {
+		return err
+	}
+
+	if manifest.Trackers != nil {
+		currentFile, previousFile, err := m.trackerFiles()
+		if err != nil {
+			return err
+		}
+		if err := writeTracker(currentFile, manifest.Trackers.Current); err != nil {
+			return err
+		}
+		if err := writeTracker(previousFile, manifest.Trackers.Previous); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range manifest.Profiles {
+		profileDir, err := paths.ProfileDir(name)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(profileDir); err != nil {
+			return fmt.Errorf("failed to restore profile %q: %w", name, err)
+		}
+		if err := copyDir(filepath.Join(dir, profilesDir, name), profileDir); err != nil {
+			return fmt.Errorf("failed to restore profile %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkNames checks that every profile name in the manifest is valid
+func (mf *Manifest) checkNames() error {
+	names := slices.Clone(mf.Profiles)
+	if mf.Profile != "" {
+		names = append(names, mf.Profile)
+	}
+	if mf.Trackers != nil {
+		for _, name := range []string{mf.Trackers.Current, mf.Trackers.Previous} {
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if err := profile.ValidateProfileName(name); err != nil {
+			return fmt.Errorf("invalid profile name %q in manifest: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readManifest reads backup.json from a backup directory, returning nil if
+// there is none
+func readManifest(backupPath string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(backupPath, manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// writeManifest writes backup.json to a backup directory
+func writeManifest(backupPath string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+	if err := config.WriteFileAtomic(filepath.Join(backupPath, manifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return nil
+}
+
+// readTracker returns the profile name in a tracker file, or "" if it does
+// not exist
+func readTracker(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// writeTracker writes a profile name to a tracker file, removing the file
+// when name is empty
+func writeTracker(path, name string) error {
+	if name == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to restore %s: %w", filepath.Base(path), err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// copyDir copies a directory tree, keeping file permissions
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return config.CopyFile(path, target)
+	})
+}
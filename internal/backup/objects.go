@@ -0,0 +1,373 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// objectsDir is the directory in the backup directory holding file contents,
+// stored once per distinct content and named by SHA-256
+const objectsDir = "objects"
+
+// gcGracePeriod protects recently written objects from garbage collection,
+// so a backup being created while another process collects keeps its files
+const gcGracePeriod = 10 * time.Minute
+
+// File is a file in a backup, stored as an object
+type File struct {
+	// Path is the file's path in the backup layout, such as settings.json
+	// or profiles/work/CLAUDE.md
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256,omitempty"`
+	Size   int64  `json:"size"`
+}
+
+// UnmarshalJSON also accepts a plain path, as written by version 1
+// manifests for files kept in the backup directory
+func (f *File) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*f = File{Path: path}
+		return nil
+	}
+
+	type fileFields File
+	return json.Unmarshal(data, (*fileFields)(f))
+}
+
+// GCResult reports what garbage collection removed
+type GCResult struct {
+	Objects int
+	Bytes   int64
+}
+
+// Problem is a file in a backup that failed verification
+type Problem struct {
+	BackupID string
+	Path     string
+	Err      error
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %v", p.BackupID, p.Path, p.Err)
+}
+
+// objectPath returns the path of the object with the given hash
+func (m *Manager) objectPath(hash string) string {
+	return filepath.Join(m.backupDir, objectsDir, hash[:2], hash[2:])
+}
+
+// storeObject adds a file's content to the object store. Content already
+// stored is not written again.
+func (m *Manager) storeObject(path string) (string, int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if err := m.writeObject(hash, data); err != nil {
+		return "", 0, err
+	}
+	return hash, int64(len(data)), nil
+}
+
+// writeObject adds content with a known hash to the object store
+func (m *Manager) writeObject(hash string, data []byte) error {
+	objectPath := m.objectPath(hash)
+	if config.FileExists(objectPath) {
+		// Refresh the time so garbage collection running now keeps it
+		now := time.Now()
+		os.Chtimes(objectPath, now, now)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return err
+	}
+	return config.WriteFileAtomic(objectPath, data, 0600)
+}
+
+// moveObject adds a file already checked against hash to the object store
+// by renaming it, so it must be on the same filesystem
+func (m *Manager) moveObject(hash, path string) error {
+	objectPath := m.objectPath(hash)
+	if config.FileExists(objectPath) {
+		now := time.Now()
+		os.Chtimes(objectPath, now, now)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return err
+	}
+	return os.Rename(path, objectPath)
+}
+
+// readObject returns a file's content from the object store, checking it
+// against the file's hash
+func (m *Manager) readObject(f File) ([]byte, error) {
+	if !validHash(f.SHA256) {
+		return nil, fmt.Errorf("invalid object hash %q", f.SHA256)
+	}
+
+	data, err := os.ReadFile(m.objectPath(f.SHA256))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("object %s is missing", f.SHA256[:12])
+		}
+		return nil, fmt.Errorf("failed to read object %s: %w", f.SHA256[:12], err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != f.SHA256 {
+		return nil, fmt.Errorf("object %s is corrupt (hash mismatch)", f.SHA256[:12])
+	}
+	return data, nil
+}
+
+// storeFiles adds the files written under dir to the object store and
+// returns them, sorted by path. The files are left in dir.
+func (m *Manager) storeFiles(dir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == manifestFile {
+			return nil
+		}
+
+		hash, size, err := m.storeObject(path)
+		if err != nil {
+			return fmt.Errorf("failed to store %s: %w", rel, err)
+		}
+		files = append(files, File{Path: rel, SHA256: hash, Size: size})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// commit stores the files written to a new backup directory as objects and
+// writes the manifest listing them. The files are removed only once the
+// manifest is in place, so the directory never ends up with neither, which
+// would read as an empty legacy backup.
+func (m *Manager) commit(backupPath string, manifest *Manifest) error {
+	files, err := m.storeFiles(backupPath)
+	if err != nil {
+		return err
+	}
+	manifest.Files = append(manifest.Files, files...)
+	if err := writeManifest(backupPath, manifest); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		top, _, _ := strings.Cut(f.Path, "/")
+		if err := os.RemoveAll(filepath.Join(backupPath, top)); err != nil {
+			return fmt.Errorf("failed to clean up backup directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkout writes a backup's files to a temporary directory in the backup
+// layout, checking each against its hash. Backups that keep their files in
+// the backup directory are used in place. The returned function removes the
+// temporary directory.
+func (m *Manager) checkout(backupID string) (string, *Manifest, func(), error) {
+	noop := func() {}
+	if !m.Exists(backupID) {
+		return "", nil, noop, fmt.Errorf("backup %q does not exist", backupID)
+	}
+	backupPath := filepath.Join(m.backupDir, backupID)
+
+	manifest, err := readManifest(backupPath)
+	if err != nil {
+		return "", nil, noop, err
+	}
+	if manifest == nil || manifest.Version < 2 {
+		return backupPath, manifest, noop, nil
+	}
+
+	dir, err := os.MkdirTemp("", "claudectx-restore-")
+	if err != nil {
+		return "", nil, noop, fmt.Errorf("failed to create restore directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	for _, f := range manifest.Files {
+		target, err := filesPath(dir, f.Path)
+		if err != nil {
+			cleanup()
+			return "", nil, noop, err
+		}
+		data, err := m.readObject(f)
+		if err != nil {
+			cleanup()
+			return "", nil, noop, fmt.Errorf("backup %s: %s: %w", backupID, f.Path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			cleanup()
+			return "", nil, noop, err
+		}
+		if err := os.WriteFile(target, data, 0600); err != nil {
+			cleanup()
+			return "", nil, noop, fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+
+	return dir, manifest, cleanup, nil
+}
+
+// filesPath joins a backup file path onto dir, rejecting paths that would
+// escape it
+func filesPath(dir, path string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(path))
+	if path == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid backup file path %q", path)
+	}
+	return filepath.Join(dir, clean), nil
+}
+
+// Verify checks every file of the given backups (all backups if none are
+// given) against its hash and returns the files that are missing or corrupt
+func (m *Manager) Verify(backupIDs ...string) ([]Problem, error) {
+	if len(backupIDs) == 0 {
+		backups, err := m.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range backups {
+			backupIDs = append(backupIDs, b.ID)
+		}
+	}
+
+	var problems []Problem
+	for _, id := range backupIDs {
+		if !m.Exists(id) {
+			return nil, fmt.Errorf("backup %q does not exist", id)
+		}
+		manifest, err := readManifest(filepath.Join(m.backupDir, id))
+		if err != nil {
+			problems = append(problems, Problem{BackupID: id, Path: manifestFile, Err: err})
+			continue
+		}
+		if manifest == nil || manifest.Version < 2 {
+			// Files kept in the backup directory have no hashes to check
+			continue
+		}
+		for _, f := range manifest.Files {
+			if _, err := filesPath(m.backupDir, f.Path); err != nil {
+				problems = append(problems, Problem{BackupID: id, Path: f.Path, Err: err})
+				continue
+			}
+			if _, err := m.readObject(f); err != nil {
+				problems = append(problems, Problem{BackupID: id, Path: f.Path, Err: err})
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+// GC removes objects no backup refers to. Objects written in the last few
+// minutes are kept, as they may belong to a backup still being created.
+func (m *Manager) GC() (GCResult, error) {
+	var result GCResult
+
+	backups, err := m.List()
+	if err != nil {
+		return result, err
+	}
+	referenced := make(map[string]bool)
+	for _, b := range backups {
+		manifest, err := readManifest(filepath.Join(m.backupDir, b.ID))
+		if err != nil {
+			// Without the manifest it is unknown which objects the backup
+			// uses, so keep everything
+			return result, fmt.Errorf("backup %s: %w", b.ID, err)
+		}
+		if manifest == nil {
+			continue
+		}
+		for _, f := range manifest.Files {
+			referenced[f.SHA256] = true
+		}
+	}
+
+	root := filepath.Join(m.backupDir, objectsDir)
+	cutoff := time.Now().Add(-gcGracePeriod)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if referenced[strings.ReplaceAll(filepath.ToSlash(rel), "/", "")] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove object: %w", err)
+		}
+		result.Objects++
+		result.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to collect unused objects: %w", err)
+	}
+
+	// Remove fan-out directories left empty
+	if entries, err := os.ReadDir(root); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				os.Remove(filepath.Join(root, entry.Name())) // fails unless empty
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/paths"
+)
+
+// NewProjectManager creates a backup manager for a project's local
+// configuration (.claude/settings.local.json, CLAUDE.local.md and
+// .mcp.json). Its backups are kept in the project's claudectx state
+// directory, separate from the user configuration backups.
+func NewProjectManager(projectDir string) (*Manager, error) {
+	abs, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project directory: %w", err)
+	}
+
+	stateDir, err := paths.ProjectStateDir(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project state directory: %w", err)
+	}
+
+	backupDir := filepath.Join(stateDir, "backups")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	return &Manager{
+		backupDir:  backupDir,
+		projectDir: abs,
+	}, nil
+}
+
+// projectFiles maps the name of each file in a project backup to its live
+// path in the project
+func (m *Manager) projectFiles() map[string]string {
+	return map[string]string{
+		"settings.local.json": paths.ProjectSettingsLocalFile(m.projectDir),
+		"CLAUDE.local.md":     paths.ProjectClaudeMDLocalFile(m.projectDir),
+		".mcp.json":           paths.ProjectMCPFile(m.projectDir),
+	}
+}
+
+// createProject backs up the project's local configuration files as they
+// are, so that restoring them is byte-for-byte
+func (m *Manager) createProject(reason string) (string, error) {
+	backupID := fmt.Sprintf("%s%d", backupPrefix, time.Now().UnixNano())
+	backupPath := filepath.Join(m.backupDir, backupID)
+
+	if err := os.MkdirAll(backupPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	for name, livePath := range m.projectFiles() {
+		if !config.FileExists(livePath) {
+			continue
+		}
+		if err := config.CopyFile(livePath, filepath.Join(backupPath, name)); err != nil {
+			os.RemoveAll(backupPath) // Clean up on failure
+			return "", fmt.Errorf("failed to backup %s: %w", name, err)
+		}
+	}
+
+	manifest, err := m.newManifest(backupID, reason)
+	if err == nil {
+		err = m.commit(backupPath, manifest)
+	}
+	if err != nil {
+		os.RemoveAll(backupPath) // Clean up on failure
+		return "", err
+	}
+
+	return backupID, nil
+}
+
+// restoreProject restores the project's local configuration files from a
+// backup, removing any that did not exist when the backup was taken
+func (m *Manager) restoreProject(backupPath string) error {
+	for name, livePath := range m.projectFiles() {
+		backupFile := filepath.Join(backupPath, name)
+		if !config.FileExists(backupFile) {
+			if config.FileExists(livePath) {
+				if err := os.Remove(livePath); err != nil {
+					return fmt.Errorf("failed to remove %s: %w", livePath, err)
+				}
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(livePath), 0755); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		if err := config.CopyFile(backupFile, livePath); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
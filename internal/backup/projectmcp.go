@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+)
+
+// projectMCPFile holds the per-project MCP servers from ~/.claude.json in a
+// backup, keyed by project path
+const projectMCPFile = "project-mcp.json"
+
+// backupProjectMCPServers copies every project's non-empty mcpServers from
+// ~/.claude.json into a backup file
+func backupProjectMCPServers(claudeJSONPath, dest string) error {
+	projects, err := mcpconfig.LoadProjectMCPServers(claudeJSONPath)
+	if err != nil {
+		return fmt.Errorf("failed to backup project MCP servers: %w", err)
+	}
+
+	servers := make(mcpconfig.ProjectServers)
+	for projectPath, projectServers := range projects {
+		if len(projectServers) > 0 {
+			servers[projectPath] = projectServers
+		}
+	}
+	return saveProjectServers(dest, servers)
+}
+
+// restoreProjectMCPServers writes the backed-up per-project servers to
+// ~/.claude.json and clears the servers of projects that had none when the
+// backup was taken
+func restoreProjectMCPServers(backupFile, claudeJSONPath string) error {
+	backedUp, err := loadProjectServers(backupFile)
+	if err != nil {
+		return err
+	}
+
+	live, err := mcpconfig.LoadProjectMCPServers(claudeJSONPath)
+	if err != nil {
+		return fmt.Errorf("failed to load project MCP servers: %w", err)
+	}
+	known := make([]string, 0, len(live))
+	for projectPath := range live {
+		known = append(known, projectPath)
+	}
+
+	updates := backedUp.Resolve(known)
+	for projectPath, servers := range live {
+		if _, ok := updates[projectPath]; !ok && len(servers) > 0 {
+			updates[projectPath] = make(mcpconfig.MCPServers)
+		}
+	}
+
+	if err := mcpconfig.SaveProjectMCPServers(claudeJSONPath, updates); err != nil {
+		return fmt.Errorf("failed to restore project MCP servers: %w", err)
+	}
+	return nil
+}
+
+// saveProjectServers writes per-project servers to a backup file
+func saveProjectServers(path string, servers mcpconfig.ProjectServers) error {
+	data, err := json.MarshalIndent(servers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project MCP servers: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to backup project MCP servers: %w", err)
+	}
+	return nil
+}
+
+// loadProjectServers reads per-project servers from a backup file. A
+// missing file yields no servers.
+func loadProjectServers(path string) (mcpconfig.ProjectServers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup project MCP servers: %w", err)
+	}
+
+	var servers mcpconfig.ProjectServers
+	if err := json.Unmarshal(data, &servers); err != nil {
+		return nil, fmt.Errorf("failed to parse backup project MCP servers: %w", err)
+	}
+	return servers, nil
+}
@@ -0,0 +1,181 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/paths"
+)
+
+// Policy decides which backups pruning keeps. A backup is kept if any rule
+// keeps it; pinned backups are always kept.
+type Policy struct {
+	// KeepLast keeps the newest N backups
+	KeepLast int `json:"keepLast"`
+	// KeepDaily keeps the newest backup of each of the last D days
+	KeepDaily int `json:"keepDaily"`
+	// KeepWeekly keeps the newest backup of each of the last W weeks
+	KeepWeekly int `json:"keepWeekly"`
+}
+
+// DefaultPolicy is used when claudectx.json does not set a policy. Keys
+// missing from the file keep these values.
+var DefaultPolicy = Policy{KeepLast: 50, KeepDaily: 14, KeepWeekly: 8}
+
+// LoadPolicy reads the backup retention policy from the "backups" key of
+// ~/.claude/claudectx.json, falling back to DefaultPolicy
+func LoadPolicy() (Policy, error) {
+	configPath, err := paths.ConfigFile()
+	if err != nil {
+		return DefaultPolicy, err
+	}
+	return LoadPolicyFile(configPath)
+}
+
+// LoadPolicyFile reads the backup retention policy from a claudectx config
+// file. A missing file or key yields DefaultPolicy.
+func LoadPolicyFile(path string) (Policy, error) {
+	policy := DefaultPolicy
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return policy, nil
+		}
+		return policy, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	var file struct {
+		Backups *Policy `json:"backups"`
+	}
+	file.Backups = &policy
+	if err := json.Unmarshal(data, &file); err != nil {
+		return DefaultPolicy, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	if file.Backups == nil {
+		// "backups": null
+		return DefaultPolicy, nil
+	}
+	if err := policy.Validate(); err != nil {
+		return DefaultPolicy, fmt.Errorf("invalid backup policy in %s: %w", filepath.Base(path), err)
+	}
+	return policy, nil
+}
+
+// Validate checks that the policy's counts are not negative
+func (p Policy) Validate() error {
+	if p.KeepLast < 0 || p.KeepDaily < 0 || p.KeepWeekly < 0 {
+		return fmt.Errorf("keepLast, keepDaily and keepWeekly must not be negative")
+	}
+	return nil
+}
+
+// Select splits backups, sorted newest first as List returns them, into
+// those the policy keeps and those it removes. Days and weeks are calendar
+// days and ISO weeks in local time, counted back from now.
+func (p Policy) Select(backups []Backup, now time.Time) (keep, remove []Backup) {
+	now = now.Local()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	firstDay := today.AddDate(0, 0, -(p.KeepDaily - 1))
+	// Weeks start on Monday, as ISO weeks do
+	thisWeek := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	firstWeek := thisWeek.AddDate(0, 0, -7*(p.KeepWeekly-1))
+
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for i, b := range backups {
+		created := b.CreatedAt.Local()
+		kept := b.Pinned || i < p.KeepLast
+
+		if day := created.Format("2006-01-02"); p.KeepDaily > 0 && !created.Before(firstDay) && !days[day] {
+			days[day] = true
+			kept = true
+		}
+
+		year, week := created.ISOWeek()
+		if key := fmt.Sprintf("%d-%02d", year, week); p.KeepWeekly > 0 && !created.Before(firstWeek) && !weeks[key] {
+			weeks[key] = true
+			kept = true
+		}
+
+		if kept {
+			keep = append(keep, b)
+		} else {
+			remove = append(remove, b)
+		}
+	}
+	return keep, remove
+}
+
+// PruneWithPolicy removes the backups the policy does not keep, then the
+// objects only they used, and returns the removed backups. With dryRun set
+// nothing is removed.
+func (m *Manager) PruneWithPolicy(policy Policy, dryRun bool) ([]Backup, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	backups, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	_, remove := policy.Select(backups, time.Now())
+	if dryRun || len(remove) == 0 {
+		return remove, nil
+	}
+
+	for _, b := range remove {
+		if err := m.Delete(b.ID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := m.GC(); err != nil {
+		return remove, err
+	}
+	return remove, nil
+}
+
+// Pin marks a backup as pinned, so pruning never removes it, or unpins it
+func (m *Manager) Pin(backupID string, pinned bool) error {
+	if !m.Exists(backupID) {
+		return fmt.Errorf("backup %q does not exist", backupID)
+	}
+	backupPath := filepath.Join(m.backupDir, backupID)
+
+	manifest, err := readManifest(backupPath)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		if !pinned {
+			return nil
+		}
+		// Backups from before manifests get one that only records the pin;
+		// their files stay in the backup directory
+		manifest = &Manifest{Version: 1, ID: backupID, CreatedAt: m.createdAt(backupID, nil)}
+	}
+
+	manifest.Pinned = pinned
+	return writeManifest(backupPath, manifest)
+}
+
+// createdAt returns when a backup was taken: the manifest time, else the
+// time in its ID, else the directory's modification time
+func (m *Manager) createdAt(backupID string, manifest *Manifest) time.Time {
+	if manifest != nil && !manifest.CreatedAt.IsZero() {
+		return manifest.CreatedAt
+	}
+	if nanos, err := strconv.ParseInt(strings.TrimPrefix(backupID, backupPrefix), 10, 64); err == nil {
+		return time.Unix(0, nanos)
+	}
+	if info, err := os.Stat(filepath.Join(m.backupDir, backupID)); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
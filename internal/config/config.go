@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+
+	"github.com/johnfox/claudectx/internal/jsonedit"
 )
 
 // Settings represents the structure of settings.json.
@@ -93,12 +96,38 @@ func (s *Settings) MarshalJSON() ([]byte, error) {
 	return json.Marshal(out)
 }
 
+// Extras returns a copy of the settings keys that are not modelled as typed
+// fields. The returned map is safe to modify.
+func (s *Settings) Extras() map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage, len(s.extras))
+	for k, v := range s.extras {
+		out[k] = v
+	}
+	return out
+}
+
+// SetExtra stores a raw JSON value for a key that is not modelled as a
+// typed field
+func (s *Settings) SetExtra(key string, value json.RawMessage) {
+	if s.extras == nil {
+		s.extras = make(map[string]json.RawMessage)
+	}
+	s.extras[key] = value
+}
+
+// DeleteExtra removes a key that is not modelled as a typed field
+func (s *Settings) DeleteExtra(key string) {
+	delete(s.extras, key)
+}
+
 // Permissions represents the permissions section of settings.
 // Unknown sub-fields (e.g. defaultMode) are preserved via the same extras
 // mechanism used by Settings.
 type Permissions struct {
 	Allow []string `json:"allow,omitempty"`
 	Deny  []string `json:"deny,omitempty"`
+	// Ask lists rules that always prompt for confirmation
+	Ask []string `json:"ask,omitempty"`
 
 	extras map[string]json.RawMessage
 }
@@ -110,7 +139,7 @@ func (p *Permissions) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	known := map[string]bool{"allow": true, "deny": true}
+	known := map[string]bool{"allow": true, "deny": true, "ask": true}
 	p.extras = make(map[string]json.RawMessage)
 	for k, v := range raw {
 		if !known[k] {
@@ -128,12 +157,17 @@ func (p *Permissions) UnmarshalJSON(data []byte) error {
 			return fmt.Errorf("parsing deny: %w", err)
 		}
 	}
+	if v, ok := raw["ask"]; ok {
+		if err := json.Unmarshal(v, &p.Ask); err != nil {
+			return fmt.Errorf("parsing ask: %w", err)
+		}
+	}
 	return nil
 }
 
 // MarshalJSON implements json.Marshaler for Permissions.
 func (p *Permissions) MarshalJSON() ([]byte, error) {
-	out := make(map[string]json.RawMessage, len(p.extras)+2)
+	out := make(map[string]json.RawMessage, len(p.extras)+3)
 	for k, v := range p.extras {
 		out[k] = v
 	}
@@ -151,9 +185,26 @@ func (p *Permissions) MarshalJSON() ([]byte, error) {
 		}
 		out["deny"] = b
 	}
+	if len(p.Ask) > 0 {
+		b, err := json.Marshal(p.Ask)
+		if err != nil {
+			return nil, err
+		}
+		out["ask"] = b
+	}
 	return json.Marshal(out)
 }
 
+// Extras returns a copy of the permissions keys that are not modelled as
+// typed fields (e.g. defaultMode).
+func (p *Permissions) Extras() map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage, len(p.extras))
+	for k, v := range p.extras {
+		out[k] = v
+	}
+	return out
+}
+
 // LoadSettings reads and parses a settings.json file
 func LoadSettings(path string) (*Settings, error) {
 	data, err := os.ReadFile(path)
@@ -182,19 +233,25 @@ func LoadSettingsOrEmpty(path string) *Settings {
 	return settings
 }
 
-// SaveSettings writes settings to a JSON file with formatting
+// SaveSettings writes settings to a JSON file with formatting. An existing
+// file is edited in place, so only the keys that changed are rewritten.
 func SaveSettings(path string, settings *Settings) error {
-	// Marshal with indentation for readability
-	data, err := json.MarshalIndent(settings, "", "  ")
+	// Invalid files are replaced rather than edited
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read settings file: %w", err)
+	}
+	doc, err := jsonedit.Parse(data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal settings: %w", err)
+		doc, _ = jsonedit.Parse(nil)
 	}
 
-	// Add newline at end of file (common convention)
-	data = append(data, '\n')
+	if err := doc.Set(settings); err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
 
 	// Write to file
-	err = os.WriteFile(path, data, 0644)
+	err = os.WriteFile(path, doc.Bytes(), 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write settings file: %w", err)
 	}
@@ -230,6 +287,41 @@ func CopyFile(src, dst string) error {
 	return nil
 }
 
+// WriteFileAtomic writes data to a temporary file in the same directory as
+// path and renames it into place, so readers never observe a partial write
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	return nil
+}
+
 // FileExists checks if a file exists
 func FileExists(path string) bool {
 	_, err := os.Stat(path)
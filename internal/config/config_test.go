@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -520,3 +521,44 @@ func TestSaveSettings_NilPermissions_PreservesExistingPermissionsUnknownFields(t
 		t.Error("permissions.defaultMode was stripped when Permissions has no Allow/Deny")
 	}
 }
+
+func TestSettings_SetDeleteExtra(t *testing.T) {
+	s := &Settings{Model: "opus"}
+	s.SetExtra("attribution", json.RawMessage(`{"commit":""}`))
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"attribution":{"commit":""},"model":"opus"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+
+	s.DeleteExtra("attribution")
+	if _, ok := s.Extras()["attribution"]; ok {
+		t.Error("DeleteExtra should remove the key")
+	}
+}
+
+func TestSaveSettings_PreservesKeyOrderAndFormatting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	initial := "{\n    \"permissions\": {\"allow\": [\"Read\"], \"defaultMode\": \"plan\"},\n    \"model\": \"opus\",\n    \"effortLevel\": \"high\"\n}\n"
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.Model = "sonnet"
+	if err := SaveSettings(path, settings); err != nil {
+		t.Fatalf("SaveSettings failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	want := strings.Replace(initial, `"opus"`, `"sonnet"`, 1)
+	if string(got) != want {
+		t.Errorf("file =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The accessors below give typed access to settings.json fields that are
+// kept in extras. Getters decode the stored JSON on each call and never
+// modify it, so the fields round-trip losslessly until a setter replaces
+// them. Setters with a zero value remove the key.
+
+// HookEvents are the hook events Claude Code runs hooks for
+var HookEvents = []string{
+	"PreToolUse", "PostToolUse", "Notification", "UserPromptSubmit", "Stop",
+	"SubagentStop", "PreCompact", "SessionStart", "SessionEnd",
+}
+
+// HookTypes are the hook types whose fields claudectx checks. Claude Code
+// supports others (agent, http), which are kept but not checked.
+var HookTypes = []string{"command", "prompt"}
+
+// PermissionModes are the valid values of permissions.defaultMode
+var PermissionModes = []string{"default", "acceptEdits", "plan", "dontAsk", "bypassPermissions"}
+
+// Hooks maps a hook event (e.g. PreToolUse) to its matchers
+type Hooks map[string][]HookMatcher
+
+// HookMatcher runs its hooks for tools matching Matcher (all tools when
+// empty)
+type HookMatcher struct {
+	Matcher string `json:"matcher,omitempty"`
+	Hooks   []Hook `json:"hooks"`
+}
+
+// Hook is a single hook: a shell command, or a prompt for the model
+type Hook struct {
+	Type    string `json:"type"`
+	Command string `json:"command,omitempty"`
+	Prompt  string `json:"prompt,omitempty"`
+	// Timeout is in seconds
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// StatusLine configures the command that renders Claude Code's status line
+type StatusLine struct {
+	Type    string `json:"type"`
+	Command string `json:"command,omitempty"`
+	Padding int    `json:"padding,omitempty"`
+}
+
+// Hooks returns the hooks setting
+func (s *Settings) Hooks() (Hooks, error) {
+	var hooks Hooks
+	return hooks, getField(s.extras, "hooks", &hooks)
+}
+
+// SetHooks replaces the hooks setting
+func (s *Settings) SetHooks(hooks Hooks) error {
+	return setField(&s.extras, "hooks", hooks, len(hooks) == 0)
+}
+
+// StatusLine returns the statusLine setting, or nil if unset
+func (s *Settings) StatusLine() (*StatusLine, error) {
+	var line *StatusLine
+	return line, getField(s.extras, "statusLine", &line)
+}
+
+// SetStatusLine replaces the statusLine setting
+func (s *Settings) SetStatusLine(line *StatusLine) error {
+	return setField(&s.extras, "statusLine", line, line == nil)
+}
+
+// APIKeyHelper returns the apiKeyHelper script, which prints an API key
+func (s *Settings) APIKeyHelper() (string, error) {
+	var helper string
+	return helper, getField(s.extras, "apiKeyHelper", &helper)
+}
+
+// SetAPIKeyHelper replaces the apiKeyHelper setting
+func (s *Settings) SetAPIKeyHelper(helper string) error {
+	return setField(&s.extras, "apiKeyHelper", helper, helper == "")
+}
+
+// OutputStyle returns the outputStyle setting
+func (s *Settings) OutputStyle() (string, error) {
+	var style string
+	return style, getField(s.extras, "outputStyle", &style)
+}
+
+// SetOutputStyle replaces the outputStyle setting
+func (s *Settings) SetOutputStyle(style string) error {
+	return setField(&s.extras, "outputStyle", style, style == "")
+}
+
+// EnabledPlugins returns the enabledPlugins setting, keyed by
+// plugin@marketplace
+func (s *Settings) EnabledPlugins() (map[string]bool, error) {
+	var plugins map[string]bool
+	return plugins, getField(s.extras, "enabledPlugins", &plugins)
+}
+
+// SetEnabledPlugins replaces the enabledPlugins setting
+func (s *Settings) SetEnabledPlugins(plugins map[string]bool) error {
+	return setField(&s.extras, "enabledPlugins", plugins, len(plugins) == 0)
+}
+
+// IncludeCoAuthoredBy returns the deprecated includeCoAuthoredBy setting,
+// or nil if unset
+func (s *Settings) IncludeCoAuthoredBy() (*bool, error) {
+	var include *bool
+	return include, getField(s.extras, "includeCoAuthoredBy", &include)
+}
+
+// SetIncludeCoAuthoredBy replaces the includeCoAuthoredBy setting
+func (s *Settings) SetIncludeCoAuthoredBy(include *bool) error {
+	return setField(&s.extras, "includeCoAuthoredBy", include, include == nil)
+}
+
+// DefaultMode returns permissions.defaultMode, or "" if unset
+func (p *Permissions) DefaultMode() (string, error) {
+	var mode string
+	return mode, getField(p.extras, "defaultMode", &mode)
+}
+
+// SetDefaultMode replaces permissions.defaultMode
+func (p *Permissions) SetDefaultMode(mode string) error {
+	return setField(&p.extras, "defaultMode", mode, mode == "")
+}
+
+// AdditionalDirectories returns permissions.additionalDirectories
+func (p *Permissions) AdditionalDirectories() ([]string, error) {
+	var dirs []string
+	return dirs, getField(p.extras, "additionalDirectories", &dirs)
+}
+
+// SetAdditionalDirectories replaces permissions.additionalDirectories
+func (p *Permissions) SetAdditionalDirectories(dirs []string) error {
+	return setField(&p.extras, "additionalDirectories", dirs, len(dirs) == 0)
+}
+
+// getField decodes extras[key] into v, leaving v unchanged if the key is
+// absent or null
+func getField(extras map[string]json.RawMessage, key string, v any) error {
+	raw, ok := extras[key]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", key, err)
+	}
+	return nil
+}
+
+// setField stores v as extras[key], or removes the key when empty is true
+func setField(extras *map[string]json.RawMessage, key string, v any, empty bool) error {
+	if empty {
+		delete(*extras, key)
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", key, err)
+	}
+	if *extras == nil {
+		*extras = make(map[string]json.RawMessage)
+	}
+	(*extras)[key] = b
+	return nil
+}
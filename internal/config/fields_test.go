@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const fieldsJSON = `{
+  "hooks": {
+    "PreToolUse": [
+      {"matcher": "Bash", "hooks": [{"type": "command", "command": "~/bin/check.sh", "timeout": 30, "futureKey": true}]}
+    ]
+  },
+  "statusLine": {"type": "command", "command": "~/.claude/statusline.sh", "padding": 0},
+  "apiKeyHelper": "/usr/local/bin/get-key",
+  "outputStyle": "Explanatory",
+  "enabledPlugins": {"formatter@tools": true, "linter@tools": false},
+  "includeCoAuthoredBy": false,
+  "permissions": {"allow": ["Read"], "defaultMode": "acceptEdits", "additionalDirectories": ["../docs"]}
+}`
+
+func TestSettingsFields_Get(t *testing.T) {
+	var s Settings
+	if err := json.Unmarshal([]byte(fieldsJSON), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks, err := s.Hooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Hooks{"PreToolUse": {{Matcher: "Bash", Hooks: []Hook{{Type: "command", Command: "~/bin/check.sh", Timeout: 30}}}}}
+	if !reflect.DeepEqual(hooks, want) {
+		t.Errorf("Hooks() = %+v", hooks)
+	}
+
+	line, err := s.StatusLine()
+	if err != nil || line == nil || line.Command != "~/.claude/statusline.sh" {
+		t.Errorf("StatusLine() = %+v, %v", line, err)
+	}
+	if helper, _ := s.APIKeyHelper(); helper != "/usr/local/bin/get-key" {
+		t.Errorf("APIKeyHelper() = %q", helper)
+	}
+	if style, _ := s.OutputStyle(); style != "Explanatory" {
+		t.Errorf("OutputStyle() = %q", style)
+	}
+	if plugins, _ := s.EnabledPlugins(); len(plugins) != 2 || !plugins["formatter@tools"] {
+		t.Errorf("EnabledPlugins() = %v", plugins)
+	}
+	if include, _ := s.IncludeCoAuthoredBy(); include == nil || *include {
+		t.Errorf("IncludeCoAuthoredBy() = %v", include)
+	}
+	if mode, _ := s.Permissions.DefaultMode(); mode != "acceptEdits" {
+		t.Errorf("DefaultMode() = %q", mode)
+	}
+	if dirs, _ := s.Permissions.AdditionalDirectories(); !reflect.DeepEqual(dirs, []string{"../docs"}) {
+		t.Errorf("AdditionalDirectories() = %v", dirs)
+	}
+}
+
+func TestSettingsFields_Unset(t *testing.T) {
+	s := &Settings{Permissions: &Permissions{}}
+	if hooks, err := s.Hooks(); hooks != nil || err != nil {
+		t.Errorf("Hooks() = %v, %v", hooks, err)
+	}
+	if line, err := s.StatusLine(); line != nil || err != nil {
+		t.Errorf("StatusLine() = %v, %v", line, err)
+	}
+	if include, err := s.IncludeCoAuthoredBy(); include != nil || err != nil {
+		t.Errorf("IncludeCoAuthoredBy() = %v, %v", include, err)
+	}
+	if mode, err := s.Permissions.DefaultMode(); mode != "" || err != nil {
+		t.Errorf("DefaultMode() = %q, %v", mode, err)
+	}
+}
+
+func TestSettingsFields_RoundTripIsLossless(t *testing.T) {
+	var s Settings
+	if err := json.Unmarshal([]byte(fieldsJSON), &s); err != nil {
+		t.Fatal(err)
+	}
+	// Reading typed values must not drop keys the types don't model
+	if _, err := s.Hooks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetOutputStyle("Learning"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"futureKey":true`) || !strings.Contains(string(data), `"outputStyle":"Learning"`) {
+		t.Errorf("Marshal() = %s", data)
+	}
+}
+
+func TestSettingsFields_Set(t *testing.T) {
+	s := &Settings{}
+	hooks := Hooks{"Stop": {{Hooks: []Hook{{Type: "command", Command: "say done"}}}}}
+	if err := s.SetHooks(hooks); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetStatusLine(&StatusLine{Type: "command", Command: "status"}); err != nil {
+		t.Fatal(err)
+	}
+	include := true
+	if err := s.SetIncludeCoAuthoredBy(&include); err != nil {
+		t.Fatal(err)
+	}
+	perms := &Permissions{}
+	if err := perms.SetDefaultMode("plan"); err != nil {
+		t.Fatal(err)
+	}
+	if err := perms.SetAdditionalDirectories([]string{"/tmp"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Permissions = perms
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"hooks":{"Stop":[{"hooks":[{"type":"command","command":"say done"}]}]},"includeCoAuthoredBy":true,` +
+		`"permissions":{"additionalDirectories":["/tmp"],"defaultMode":"plan"},"statusLine":{"type":"command","command":"status"}}`
+	if string(data) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", data, want)
+	}
+
+	// Zero values remove the keys
+	s.SetHooks(nil)
+	s.SetStatusLine(nil)
+	s.SetIncludeCoAuthoredBy(nil)
+	perms.SetDefaultMode("")
+	if extras := s.Extras(); len(extras) != 0 {
+		t.Errorf("expected no extras, got %v", extras)
+	}
+	if _, ok := perms.Extras()["defaultMode"]; ok {
+		t.Error("SetDefaultMode(\"\") should remove defaultMode")
+	}
+}
+
+func TestSettingsFields_WrongType(t *testing.T) {
+	var s Settings
+	if err := json.Unmarshal([]byte(`{"hooks":["not","a","map"],"apiKeyHelper":42,"permissions":{"defaultMode":true}}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Hooks(); err == nil || !strings.Contains(err.Error(), "parsing hooks") {
+		t.Errorf("Hooks() error = %v", err)
+	}
+	if _, err := s.APIKeyHelper(); err == nil {
+		t.Error("expected an error for a non-string apiKeyHelper")
+	}
+	if _, err := s.Permissions.DefaultMode(); err == nil {
+		t.Error("expected an error for a non-string defaultMode")
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import "strings"
+
+// secretMarkers are substrings that identify keys holding credentials
+var secretMarkers = []string{
+	"TOKEN",
+	"SECRET",
+	"PASSWORD",
+	"PASSWD",
+	"API_KEY",
+	"APIKEY",
+	"ACCESS_KEY",
+	"PRIVATE_KEY",
+	"CREDENTIAL",
+	"AUTHORIZATION",
+}
+
+// secretKeys are keys that hold credentials without naming them, such as
+// ANTHROPIC_CUSTOM_HEADERS, which usually carries auth headers
+var secretKeys = map[string]bool{
+	"ANTHROPIC_CUSTOM_HEADERS": true,
+}
+
+// IsSecretKey reports whether an env var or header name looks like it holds
+// a credential, e.g. ANTHROPIC_AUTH_TOKEN, Authorization or X-Api-Key.
+// Hyphens are treated as underscores so header names match too.
+func IsSecretKey(key string) bool {
+	upper := strings.ReplaceAll(strings.ToUpper(key), "-", "_")
+	if secretKeys[upper] {
+		return true
+	}
+	for _, marker := range secretMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskSecret hides all but the last four characters of a secret value.
+// Short values are masked completely.
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
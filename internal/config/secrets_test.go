@@ -0,0 +1,47 @@
+package config
+
+import "testing"
+
+func TestIsSecretKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"ANTHROPIC_AUTH_TOKEN", true},
+		{"ANTHROPIC_API_KEY", true},
+		{"Authorization", true},
+		{"AWS_SECRET_ACCESS_KEY", true},
+		{"DB_PASSWORD", true},
+		{"X-Api-Key", true},
+		{"x-auth-token", true},
+		{"ANTHROPIC_CUSTOM_HEADERS", true},
+		{"ANTHROPIC_BASE_URL", false},
+		{"AWS_REGION", false},
+		{"DEBUG", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := IsSecretKey(tt.key); got != tt.want {
+				t.Errorf("IsSecretKey(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"short", "****"},
+		{"sk-ant-1234567890abcd", "****abcd"},
+	}
+
+	for _, tt := range tests {
+		if got := MaskSecret(tt.value); got != tt.want {
+			t.Errorf("MaskSecret(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified_Equal(t *testing.T) {
+	if got := Unified("a", "b", "same\n", "same\n", DefaultContext); got != "" {
+		t.Errorf("Unified() = %q, want empty", got)
+	}
+}
+
+func TestUnified_SingleChange(t *testing.T) {
+	a := "{\n  \"model\": \"claude-3-opus\",\n  \"env\": {}\n}\n"
+	b := "{\n  \"model\": \"opus\",\n  \"env\": {}\n}\n"
+
+	want := `--- old
++++ new
+@@ -1,4 +1,4 @@
+ {
+-  "model": "claude-3-opus",
++  "model": "opus",
+   "env": {}
+ }
+`
+	if got := Unified("old", "new", a, b, DefaultContext); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20; i++ {
+		line := strings.Repeat("x", i+1)
+		a = append(a, line)
+		switch i {
+		case 1:
+			b = append(b, "changed")
+		case 17:
+			// deleted
+		default:
+			b = append(b, line)
+		}
+	}
+
+	got := Unified("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"), 1)
+	if strings.Count(got, "@@ -") != 2 {
+		t.Fatalf("expected two hunks, got:\n%s", got)
+	}
+	if !strings.Contains(got, "@@ -1,3 +1,3 @@") || !strings.Contains(got, "@@ -17,3 +17,2 @@") {
+		t.Errorf("unexpected hunk headers:\n%s", got)
+	}
+}
+
+func TestUnified_AddToEmpty(t *testing.T) {
+	got := Unified("a", "b", "", "one\ntwo\n", DefaultContext)
+	if !strings.Contains(got, "@@ -0,0 +1,2 @@\n+one\n+two\n") {
+		t.Errorf("Unified() =\n%s", got)
+	}
+}
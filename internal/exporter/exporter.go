@@ -18,12 +18,23 @@ const ExportVersion = "1.0.0"
 
 // ExportedProfile represents a profile in export format
 type ExportedProfile struct {
-	Version    string               `json:"version"`
-	Name       string               `json:"name"`
-	Settings   *config.Settings     `json:"settings"`
+	Version            string                   `json:"version"`
+	Name               string                   `json:"name"`
+	Settings           *config.Settings         `json:"settings"`
+	ClaudeMD           string                   `json:"claude_md,omitempty"`
+	MCPServers         mcpconfig.MCPServers     `json:"mcp_servers,omitempty"`
+	DisabledMCPServers mcpconfig.MCPServers     `json:"disabled_mcp_servers,omitempty"`
+	ProjectMCPServers  mcpconfig.ProjectServers `json:"project_mcp_servers,omitempty"`
+	Project            *ExportedProject         `json:"project,omitempty"`
+	Metadata           *profile.Metadata        `json:"metadata,omitempty"`
+	ExportedAt         string                   `json:"exported_at"`
+}
+
+// ExportedProject is the project-scoped configuration of an exported profile
+type ExportedProject struct {
+	Settings   *config.Settings     `json:"settings,omitempty"`
 	ClaudeMD   string               `json:"claude_md,omitempty"`
 	MCPServers mcpconfig.MCPServers `json:"mcp_servers,omitempty"`
-	ExportedAt string               `json:"exported_at"`
 }
 
 // ExportProfile exports a profile to JSON format
@@ -40,13 +51,25 @@ func ExportProfile(s *store.Store, profileName string, w io.Writer) error {
 	}
 
 	// Create export structure
+	meta := prof.Metadata()
 	exported := ExportedProfile{
-		Version:    ExportVersion,
-		Name:       profileName,
-		Settings:   prof.Settings,
-		ClaudeMD:   prof.ClaudeMD,
-		MCPServers: prof.MCPServers,
-		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Version:            ExportVersion,
+		Name:               profileName,
+		Settings:           prof.Settings,
+		ClaudeMD:           prof.ClaudeMD,
+		MCPServers:         prof.MCPServers,
+		DisabledMCPServers: prof.DisabledMCPServers,
+		ProjectMCPServers:  prof.ProjectMCPServers,
+		Metadata:           &meta,
+		ExportedAt:         time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if prof.Project != nil {
+		exported.Project = &ExportedProject{
+			Settings:   prof.Project.Settings,
+			ClaudeMD:   prof.Project.ClaudeMD,
+			MCPServers: prof.Project.MCPServers,
+		}
 	}
 
 	// Marshal to pretty-printed JSON
@@ -60,19 +83,67 @@ func ExportProfile(s *store.Store, profileName string, w io.Writer) error {
 	return nil
 }
 
-// ImportProfile imports a profile from JSON format
-func ImportProfile(s *store.Store, r io.Reader, newName string) error {
+// ReadExport decodes and version-checks an exported profile
+func ReadExport(r io.Reader) (*ExportedProfile, error) {
 	// Decode JSON
 	var exported ExportedProfile
 	decoder := json.NewDecoder(r)
 	err := decoder.Decode(&exported)
 	if err != nil {
-		return fmt.Errorf("failed to decode profile: %w", err)
+		return nil, fmt.Errorf("failed to decode profile: %w", err)
 	}
 
 	// Validate version compatibility
 	if exported.Version != ExportVersion {
-		return fmt.Errorf("incompatible export version %q (expected %q)", exported.Version, ExportVersion)
+		return nil, fmt.Errorf("incompatible export version %q (expected %q)", exported.Version, ExportVersion)
+	}
+
+	return &exported, nil
+}
+
+// Profile converts an exported profile into a profile with the given name
+func (e *ExportedProfile) Profile(name string) *profile.Profile {
+	// Handle nil MCPServers from old exports
+	mcpServers := e.MCPServers
+	if mcpServers == nil {
+		mcpServers = make(mcpconfig.MCPServers)
+	}
+
+	settings := e.Settings
+	if settings == nil {
+		settings = &config.Settings{Env: make(map[string]string)}
+	}
+
+	prof := profile.ProfileFromCurrent(name, settings, e.ClaudeMD, mcpServers)
+	prof.DisabledMCPServers = e.DisabledMCPServers
+	prof.ProjectMCPServers = e.ProjectMCPServers
+	if e.Project != nil {
+		prof.Project = &profile.ProjectConfig{
+			Settings:   e.Project.Settings,
+			ClaudeMD:   e.Project.ClaudeMD,
+			MCPServers: e.Project.MCPServers,
+		}
+	}
+
+	// Restore metadata from exports that carry it
+	if e.Metadata != nil {
+		prof.SetMetadata(*e.Metadata)
+		if prof.CreatedAt.IsZero() {
+			prof.CreatedAt = time.Now()
+		}
+		if prof.UpdatedAt.IsZero() {
+			prof.UpdatedAt = prof.CreatedAt
+		}
+	}
+
+	return prof
+}
+
+// ImportProfile imports a profile from JSON format
+func ImportProfile(s *store.Store, r io.Reader, newName string) error {
+	exported, err := ReadExport(r)
+	if err != nil {
+		return err
 	}
 
 	// Use new name if provided, otherwise use exported name
@@ -96,14 +167,15 @@ func ImportProfile(s *store.Store, r io.Reader, newName string) error {
 		return fmt.Errorf("imported CLAUDE.md is invalid: %w", err)
 	}
 
-	// Handle nil MCPServers from old exports
-	mcpServers := exported.MCPServers
-	if mcpServers == nil {
-		mcpServers = make(mcpconfig.MCPServers)
+	// Validate project settings
+	if exported.Project != nil && exported.Project.Settings != nil {
+		if err := validator.ValidateSettings(exported.Project.Settings); err != nil {
+			return fmt.Errorf("imported project settings are invalid: %w", err)
+		}
 	}
 
 	// Create profile from imported data
-	prof := profile.ProfileFromCurrent(profileName, exported.Settings, exported.ClaudeMD, mcpServers)
+	prof := exported.Profile(profileName)
 
 	// Save the profile
 	err = s.Save(prof)
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
 	"github.com/johnfox/claudectx/internal/profile"
 	"github.com/johnfox/claudectx/internal/store"
 )
@@ -390,3 +391,125 @@ func TestExportIncludesMetadata(t *testing.T) {
 		t.Error("Export should include ExportedAt timestamp")
 	}
 }
+
+func TestExportImport_PreservesProfileMetadata(t *testing.T) {
+	setupTestEnv(t)
+	s, _ := store.NewStore()
+
+	prof := profile.NewProfile("tagged")
+	prof.Description = "Shared team setup"
+	prof.Tags = []string{"team"}
+	if err := s.Save(prof); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportProfile(s, "tagged", &buf); err != nil {
+		t.Fatalf("ExportProfile failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"metadata"`) {
+		t.Fatalf("export should include metadata, got %s", buf.String())
+	}
+
+	if err := ImportProfile(s, &buf, "imported"); err != nil {
+		t.Fatalf("ImportProfile failed: %v", err)
+	}
+	imported, err := s.Load("imported")
+	if err != nil {
+		t.Fatalf("failed to load imported profile: %v", err)
+	}
+	if imported.Description != "Shared team setup" || len(imported.Tags) != 1 {
+		t.Errorf("metadata not imported: %+v", imported.Metadata())
+	}
+}
+
+func TestExportImport_PreservesProjectConfig(t *testing.T) {
+	s, _ := setupTestEnv(t)
+
+	prof := profile.NewProfile("repo")
+	prof.Project = &profile.ProjectConfig{
+		Settings:   &config.Settings{Model: "opus"},
+		ClaudeMD:   "# Repo\n",
+		MCPServers: mcpconfig.MCPServers{"db": {Command: "db-mcp"}},
+	}
+	if err := s.Save(prof); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportProfile(s, "repo", &buf); err != nil {
+		t.Fatalf("ExportProfile failed: %v", err)
+	}
+	if err := ImportProfile(s, &buf, "imported"); err != nil {
+		t.Fatalf("ImportProfile failed: %v", err)
+	}
+
+	imported, err := s.Load("imported")
+	if err != nil {
+		t.Fatalf("failed to load imported profile: %v", err)
+	}
+	project := imported.Project
+	if project == nil || project.Settings == nil || project.Settings.Model != "opus" {
+		t.Fatalf("project settings not imported: %+v", project)
+	}
+	if project.ClaudeMD != "# Repo\n" || project.MCPServers["db"].Command != "db-mcp" {
+		t.Errorf("project CLAUDE.md or MCP servers not imported: %+v", project)
+	}
+}
+
+func TestExportImport_PreservesProjectMCPServers(t *testing.T) {
+	s, _ := setupTestEnv(t)
+
+	prof := profile.NewProfile("repos")
+	prof.ProjectMCPServers = mcpconfig.ProjectServers{
+		"~/src/*": {"db": {Command: "db-mcp"}},
+	}
+	if err := s.Save(prof); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportProfile(s, "repos", &buf); err != nil {
+		t.Fatalf("ExportProfile failed: %v", err)
+	}
+	if err := ImportProfile(s, &buf, "imported"); err != nil {
+		t.Fatalf("ImportProfile failed: %v", err)
+	}
+
+	imported, err := s.Load("imported")
+	if err != nil {
+		t.Fatalf("failed to load imported profile: %v", err)
+	}
+	if imported.ProjectMCPServers["~/src/*"]["db"].Command != "db-mcp" {
+		t.Errorf("project MCP servers not imported: %+v", imported.ProjectMCPServers)
+	}
+}
+
+func TestExportImport_PreservesDisabledMCPServers(t *testing.T) {
+	s, _ := setupTestEnv(t)
+
+	prof := profile.NewProfile("work")
+	prof.DisabledMCPServers = mcpconfig.MCPServers{"github": {Command: "npx"}}
+	if err := s.Save(prof); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportProfile(s, "work", &buf); err != nil {
+		t.Fatalf("ExportProfile failed: %v", err)
+	}
+	if err := ImportProfile(s, &buf, "imported"); err != nil {
+		t.Fatalf("ImportProfile failed: %v", err)
+	}
+
+	imported, err := s.Load("imported")
+	if err != nil {
+		t.Fatalf("failed to load imported profile: %v", err)
+	}
+	if imported.DisabledMCPServers["github"].Command != "npx" {
+		t.Errorf("disabled MCP servers not imported: %+v", imported.DisabledMCPServers)
+	}
+	if _, ok := imported.MCPServers["github"]; ok {
+		t.Error("a disabled server must not be imported as enabled")
+	}
+}
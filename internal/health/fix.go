@@ -0,0 +1,223 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Fix is one change made to resolve a finding
+type Fix struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+}
+
+// Fixer changes a profile to resolve its findings for one rule, returning
+// a description of each change made. Fixers modify in.Settings and
+// in.MCPServers in place.
+type Fixer func(in *RuleInput) []string
+
+// FixableRule is a Rule that can also fix its own findings
+type FixableRule interface {
+	Rule
+	Fix(in *RuleInput) []string
+}
+
+// fixers holds the fixers for built-in rules
+var fixers = map[string]Fixer{
+	RuleEmptyEnv:            fixEmptyEnv,
+	RuleDeprecatedEnv:       fixDeprecatedEnv,
+	RuleDuplicatePermission: fixDuplicatePermissions,
+	RuleRetiredModel:        fixRetiredModel,
+	RuleDeprecatedSetting:   fixDeprecatedSettings,
+	RuleMCPCommand:          fixMCPCommands,
+}
+
+// FixerFor returns the fixer for a rule, looking at the registry's rules
+// before the built-in ones. registry may be nil.
+func FixerFor(rule string, registry *Registry) (Fixer, bool) {
+	if registry != nil {
+		for _, r := range registry.rules {
+			if r.ID() != rule {
+				continue
+			}
+			if fixable, ok := r.(FixableRule); ok {
+				return fixable.Fix, true
+			}
+		}
+	}
+	fixer, ok := fixers[rule]
+	return fixer, ok
+}
+
+// Fixable reports whether any finding in the report has a fixer
+func (r *ProfileHealthReport) Fixable(registry *Registry) bool {
+	for _, f := range r.Overall.Findings {
+		if _, ok := FixerFor(f.Rule, registry); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyFixes runs the fixer of every rule with findings in the report
+// against in, in the order the findings were reported, and returns the
+// changes made. Suppressed and disabled findings are not in the report, so
+// they are not fixed.
+func ApplyFixes(report *ProfileHealthReport, in *RuleInput, registry *Registry) []Fix {
+	var fixes []Fix
+	done := map[string]bool{}
+	for _, f := range report.Overall.Findings {
+		if done[f.Rule] {
+			continue
+		}
+		done[f.Rule] = true
+
+		fixer, ok := FixerFor(f.Rule, registry)
+		if !ok {
+			continue
+		}
+		for _, description := range fixer(in) {
+			fixes = append(fixes, Fix{Rule: f.Rule, Description: description})
+		}
+	}
+	return fixes
+}
+
+// fixEmptyEnv removes environment variables with empty values, keeping the
+// deliberately blank ANTHROPIC_API_KEY of custom-endpoint setups
+func fixEmptyEnv(in *RuleInput) []string {
+	if in.Settings == nil {
+		return nil
+	}
+	provider := DetectProvider(in.Settings.Env)
+
+	var changes []string
+	for _, key := range sortedKeys(in.Settings.Env) {
+		if in.Settings.Env[key] != "" || (key == "ANTHROPIC_API_KEY" && provider == ProviderCustom) {
+			continue
+		}
+		delete(in.Settings.Env, key)
+		changes = append(changes, fmt.Sprintf("Removed empty env.%s", key))
+	}
+	return changes
+}
+
+// fixDeprecatedEnv renames ANTHROPIC_SMALL_FAST_MODEL to
+// ANTHROPIC_DEFAULT_HAIKU_MODEL
+func fixDeprecatedEnv(in *RuleInput) []string {
+	if in.Settings == nil {
+		return nil
+	}
+	env := in.Settings.Env
+	value, ok := env["ANTHROPIC_SMALL_FAST_MODEL"]
+	if !ok {
+		return nil
+	}
+	delete(env, "ANTHROPIC_SMALL_FAST_MODEL")
+	if _, exists := env["ANTHROPIC_DEFAULT_HAIKU_MODEL"]; exists {
+		return []string{"Removed env.ANTHROPIC_SMALL_FAST_MODEL (ANTHROPIC_DEFAULT_HAIKU_MODEL is already set)"}
+	}
+	env["ANTHROPIC_DEFAULT_HAIKU_MODEL"] = value
+	return []string{"Renamed env.ANTHROPIC_SMALL_FAST_MODEL to env.ANTHROPIC_DEFAULT_HAIKU_MODEL"}
+}
+
+// fixDuplicatePermissions drops repeated allow, deny and ask rules, keeping
+// the first occurrence
+func fixDuplicatePermissions(in *RuleInput) []string {
+	if in.Settings == nil || in.Settings.Permissions == nil {
+		return nil
+	}
+	perms := in.Settings.Permissions
+
+	var changes []string
+	dedupe := func(name string, rules []string) []string {
+		seen := map[string]bool{}
+		out := rules[:0:0]
+		for _, rule := range rules {
+			if seen[rule] {
+				changes = append(changes, fmt.Sprintf("Removed duplicate %q from permissions.%s", rule, name))
+				continue
+			}
+			seen[rule] = true
+			out = append(out, rule)
+		}
+		return out
+	}
+	perms.Allow = dedupe("allow", perms.Allow)
+	perms.Deny = dedupe("deny", perms.Deny)
+	perms.Ask = dedupe("ask", perms.Ask)
+	return changes
+}
+
+// fixRetiredModel replaces a retired model ID with its alias
+func fixRetiredModel(in *RuleInput) []string {
+	if in.Settings == nil {
+		return nil
+	}
+
+	if alias, ok := retiredModelAlias(in.Settings.Model); ok {
+		old := in.Settings.Model
+		in.Settings.Model = alias
+		return []string{fmt.Sprintf("Replaced retired model %q with %q", old, alias)}
+	}
+	if alias, ok := retiredModelAlias(in.Settings.Env["ANTHROPIC_MODEL"]); ok {
+		old := in.Settings.Env["ANTHROPIC_MODEL"]
+		in.Settings.Env["ANTHROPIC_MODEL"] = alias
+		return []string{fmt.Sprintf("Replaced retired env.ANTHROPIC_MODEL %q with %q", old, alias)}
+	}
+	return nil
+}
+
+// fixDeprecatedSettings migrates deprecated settings keys to their
+// replacements
+func fixDeprecatedSettings(in *RuleInput) []string {
+	if in.Settings == nil {
+		return nil
+	}
+	if _, ok := in.Settings.Extras()["includeCoAuthoredBy"]; !ok {
+		return nil
+	}
+
+	include, err := in.Settings.IncludeCoAuthoredBy()
+	in.Settings.DeleteExtra("includeCoAuthoredBy")
+	if err == nil && include != nil && !*include {
+		if _, exists := in.Settings.Extras()["attribution"]; !exists {
+			in.Settings.SetExtra("attribution", json.RawMessage(`{"commit":"","pr":""}`))
+			return []string{`Replaced includeCoAuthoredBy: false with attribution {"commit": "", "pr": ""}`}
+		}
+	}
+	return []string{"Removed deprecated includeCoAuthoredBy"}
+}
+
+// fixMCPCommands points stdio servers whose command path no longer exists
+// at the same program found on PATH
+func fixMCPCommands(in *RuleInput) []string {
+	names := make([]string, 0, len(in.MCPServers))
+	for name := range in.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []string
+	for _, name := range names {
+		server := in.MCPServers[name]
+		if server.Transport() != "stdio" || !strings.Contains(server.Command, "/") {
+			continue
+		}
+		if _, err := exec.LookPath(expandHome(server.Command)); err == nil {
+			continue
+		}
+		found, err := exec.LookPath(filepath.Base(server.Command))
+		if err != nil {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("Changed MCP server %q command from %s to %s", name, server.Command, found))
+		server.Command = found
+		in.MCPServers[name] = server
+	}
+	return changes
+}
@@ -0,0 +1,178 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+)
+
+// checkAndFix runs the built-in checks and applies every available fixer
+func checkAndFix(settings *config.Settings, servers mcpconfig.MCPServers, registry *Registry) ([]Fix, *ProfileHealthReport) {
+	report := CheckProfile("test", settings, "")
+	if len(servers) > 0 {
+		report.AddMCP(CheckMCPServers(servers, MCPCheckOptions{}))
+	}
+	in := RuleInput{Profile: "test", Settings: settings, MCPServers: servers}
+	fixes := ApplyFixes(report, &in, registry)
+
+	after := CheckProfile("test", settings, "")
+	if len(servers) > 0 {
+		after.AddMCP(CheckMCPServers(servers, MCPCheckOptions{}))
+	}
+	return fixes, after
+}
+
+func TestApplyFixes_BuiltinRules(t *testing.T) {
+	settings := &config.Settings{
+		Model: "claude-3-5-sonnet-20241022",
+		Env: map[string]string{
+			"EMPTY":                      "",
+			"ANTHROPIC_SMALL_FAST_MODEL": "claude-haiku-4-5",
+		},
+		Permissions: &config.Permissions{
+			Allow: []string{"Read", "Bash(ls)", "Read"},
+			Deny:  []string{"WebFetch", "WebFetch"},
+		},
+	}
+	settings.SetExtra("includeCoAuthoredBy", []byte("false"))
+
+	fixes, after := checkAndFix(settings, nil, nil)
+
+	rules := map[string]bool{}
+	for _, fix := range fixes {
+		rules[fix.Rule] = true
+	}
+	for _, rule := range []string{RuleEmptyEnv, RuleDeprecatedEnv, RuleDuplicatePermission, RuleRetiredModel, RuleDeprecatedSetting} {
+		if !rules[rule] {
+			t.Errorf("expected a fix for %s, got %+v", rule, fixes)
+		}
+	}
+
+	if settings.Model != "sonnet" {
+		t.Errorf("Model = %q, want sonnet", settings.Model)
+	}
+	wantEnv := map[string]string{"ANTHROPIC_DEFAULT_HAIKU_MODEL": "claude-haiku-4-5"}
+	if !reflect.DeepEqual(settings.Env, wantEnv) {
+		t.Errorf("Env = %v, want %v", settings.Env, wantEnv)
+	}
+	if !reflect.DeepEqual(settings.Permissions.Allow, []string{"Read", "Bash(ls)"}) || len(settings.Permissions.Deny) != 1 {
+		t.Errorf("Permissions = %+v", settings.Permissions)
+	}
+	extras := settings.Extras()
+	if _, ok := extras["includeCoAuthoredBy"]; ok || string(extras["attribution"]) != `{"commit":"","pr":""}` {
+		t.Errorf("extras = %s", extras)
+	}
+
+	if got := ruleSeverities(after.Overall); len(got) != 1 || got["allow-and-deny"] != SeverityWarning {
+		t.Errorf("only the unfixable finding should remain, got %v", got)
+	}
+}
+
+func TestApplyFixes_DuplicateAskRules(t *testing.T) {
+	settings := &config.Settings{
+		Model:       "opus",
+		Env:         map[string]string{"X": "1"},
+		Permissions: &config.Permissions{Ask: []string{"Bash(git push:*)", "WebFetch", "Bash(git push:*)"}},
+	}
+
+	fixes, after := checkAndFix(settings, nil, nil)
+	if len(fixes) != 1 || fixes[0].Rule != RuleDuplicatePermission {
+		t.Fatalf("expected a duplicate permission fix, got %+v", fixes)
+	}
+	if want := []string{"Bash(git push:*)", "WebFetch"}; !reflect.DeepEqual(settings.Permissions.Ask, want) {
+		t.Errorf("Ask = %v, want %v", settings.Permissions.Ask, want)
+	}
+	if _, ok := ruleSeverities(after.Overall)[RuleDuplicatePermission]; ok {
+		t.Error("duplicate ask rules should be fixed")
+	}
+}
+
+func TestApplyFixes_NothingToFix(t *testing.T) {
+	settings := &config.Settings{Model: "opus", Env: map[string]string{"X": "1"}}
+	if fixes, _ := checkAndFix(settings, nil, nil); len(fixes) != 0 {
+		t.Errorf("expected no fixes, got %+v", fixes)
+	}
+
+	// A blank API key is intentional for custom endpoints and is kept
+	settings = &config.Settings{Model: "opus", Env: map[string]string{
+		"ANTHROPIC_BASE_URL":   "https://openrouter.ai/api",
+		"ANTHROPIC_AUTH_TOKEN": "tok",
+		"ANTHROPIC_API_KEY":    "",
+	}}
+	checkAndFix(settings, nil, nil)
+	if _, ok := settings.Env["ANTHROPIC_API_KEY"]; !ok {
+		t.Error("blank ANTHROPIC_API_KEY should be kept")
+	}
+}
+
+func TestApplyFixes_SuppressedNotFixed(t *testing.T) {
+	settings := &config.Settings{Model: "opus", Env: map[string]string{"EMPTY": ""}}
+	report := CheckProfile("test", settings, "")
+	report.Suppress([]string{RuleEmptyEnv})
+
+	in := RuleInput{Settings: settings}
+	if fixes := ApplyFixes(report, &in, nil); len(fixes) != 0 {
+		t.Errorf("suppressed findings should not be fixed, got %+v", fixes)
+	}
+}
+
+func TestFixMCPCommands(t *testing.T) {
+	bin := t.TempDir()
+	tool := filepath.Join(bin, "mcp-tool")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	servers := mcpconfig.MCPServers{
+		"moved":   {Command: "/old/location/mcp-tool", Args: []string{"--stdio"}},
+		"missing": {Command: "/old/location/not-installed"},
+	}
+	settings := &config.Settings{Model: "opus", Env: map[string]string{"X": "1"}}
+
+	fixes, _ := checkAndFix(settings, servers, nil)
+	if len(fixes) != 1 || servers["moved"].Command != tool || servers["moved"].Args[0] != "--stdio" {
+		t.Errorf("fixes = %+v, servers = %+v", fixes, servers)
+	}
+	if servers["missing"].Command != "/old/location/not-installed" {
+		t.Error("commands not found on PATH should be left alone")
+	}
+}
+
+// fixableRule requires env.TEAM and fixes it by setting a default
+type fixableRule struct{}
+
+func (fixableRule) ID() string         { return "team-env" }
+func (fixableRule) Severity() Severity { return SeverityWarning }
+func (fixableRule) Check(in RuleInput) []Finding {
+	if in.Settings.Env["TEAM"] == "" {
+		return []Finding{{Severity: SeverityWarning, Message: "TEAM is not set"}}
+	}
+	return nil
+}
+func (fixableRule) Fix(in *RuleInput) []string {
+	in.Settings.Env["TEAM"] = "platform"
+	return []string{"Set env.TEAM"}
+}
+
+func TestApplyFixes_CustomRule(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(fixableRule{})
+
+	settings := &config.Settings{Model: "opus", Env: map[string]string{"X": "1"}}
+	in := RuleInput{Settings: settings}
+	report := CheckProfile("test", settings, "")
+	report.AddRules(registry.Run(in))
+
+	if !report.Fixable(registry) || report.Fixable(nil) {
+		t.Error("custom fixers should only be found through the registry")
+	}
+	fixes := ApplyFixes(report, &in, registry)
+	if len(fixes) != 1 || fixes[0].Rule != "team-env" || settings.Env["TEAM"] != "platform" {
+		t.Errorf("fixes = %+v, env = %v", fixes, settings.Env)
+	}
+}
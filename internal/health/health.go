@@ -2,9 +2,28 @@ package health
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/permissions"
+)
+
+// Rule names for settings, model and permission findings that have fixers
+const (
+	RuleDuplicatePermission = "permission-duplicate"
+	RuleRetiredModel        = "model-retired"
+	RuleDeprecatedSetting   = "settings-deprecated"
+)
+
+// Rule names for permission rule syntax and reachability findings
+const (
+	RulePermissionSyntax      = permissions.IssueSyntax
+	RulePermissionUnknownTool = permissions.IssueUnknownTool
+	RulePermissionSpecifier   = permissions.IssueSpecifier
+	RulePermissionShadowed    = permissions.IssueShadowed
+	RulePermissionUnreachable = permissions.IssueUnreachable
 )
 
 // HealthError represents a health check error
@@ -20,11 +39,119 @@ func (e *HealthError) Error() string {
 	return e.Message
 }
 
+// Severity ranks how serious a finding is
+type Severity int
+
+const (
+	// SeverityInfo is advice that needs no action
+	SeverityInfo Severity = iota
+	// SeverityWarning is a likely problem that still allows Claude Code to start
+	SeverityWarning
+	// SeverityError is a configuration Claude Code cannot use
+	SeverityError
+)
+
+// String returns the lower-case severity name
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// MarshalText encodes the severity by name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// ParseSeverity parses "error", "warning" or "info" (case-insensitive)
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToLower(name) {
+	case "error":
+		return SeverityError, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "info":
+		return SeverityInfo, nil
+	}
+	return SeverityInfo, fmt.Errorf("unknown severity %q (use error, warning or info)", name)
+}
+
+// Finding is a single issue reported by a health check
+type Finding struct {
+	// Rule identifies the check that produced the finding (e.g. "bedrock-region")
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Hint suggests how to fix the issue
+	Hint string `json:"hint,omitempty"`
+}
+
 // HealthResult represents the result of a health check
 type HealthResult struct {
 	IsValid  bool
 	Warnings []string
 	Error    *HealthError
+	// Findings holds every issue found, including those summarised in
+	// Warnings and Error
+	Findings []Finding
+}
+
+// newResult returns a valid result with no findings
+func newResult() HealthResult {
+	return HealthResult{IsValid: true, Warnings: []string{}}
+}
+
+// add records a finding. Warnings are also listed in Warnings, and the
+// first error becomes the result's Error.
+func (r *HealthResult) add(severity Severity, rule, message, hint string) {
+	r.Findings = append(r.Findings, Finding{Rule: rule, Severity: severity, Message: message, Hint: hint})
+	switch severity {
+	case SeverityWarning:
+		r.Warnings = append(r.Warnings, message)
+	case SeverityError:
+		r.IsValid = false
+		if r.Error == nil {
+			r.Error = &HealthError{Message: message}
+		}
+	}
+}
+
+// without returns a copy of the result with the given rule's findings removed
+func (r HealthResult) without(rule string) HealthResult {
+	return r.rewrite(func(f Finding) (Finding, bool) {
+		return f, f.Rule != rule
+	})
+}
+
+// rewrite returns a copy of the result with fn applied to each finding;
+// findings for which fn returns false are dropped. Results without findings
+// are returned unchanged.
+func (r HealthResult) rewrite(fn func(Finding) (Finding, bool)) HealthResult {
+	if len(r.Findings) == 0 {
+		return r
+	}
+	out := newResult()
+	for _, f := range r.Findings {
+		if f, keep := fn(f); keep {
+			out.add(f.Severity, f.Rule, f.Message, f.Hint)
+		}
+	}
+	return out
 }
 
 // IsHealthy returns true if there are no errors
@@ -45,6 +172,14 @@ type ProfileHealthReport struct {
 	Model       HealthResult
 	Permissions HealthResult
 	EnvVars     HealthResult
+	// Online is set when a live API probe was run
+	Online *OnlineResult
+	// MCP holds per-server results when MCP servers were checked
+	MCP []MCPServerResult
+	// Rules holds findings from custom rules
+	Rules HealthResult
+	// Suppressed counts findings dropped by the profile's suppressions
+	Suppressed int
 }
 
 // IsHealthy returns true if the overall health is good
@@ -54,15 +189,96 @@ func (r *ProfileHealthReport) IsHealthy() bool {
 
 // TotalWarnings returns the total number of warnings across all checks
 func (r *ProfileHealthReport) TotalWarnings() int {
+	// Overall repeats the sub-check warnings, so it is not counted
 	total := 0
-	total += len(r.Overall.Warnings)
 	total += len(r.Settings.Warnings)
 	total += len(r.Model.Warnings)
 	total += len(r.Permissions.Warnings)
 	total += len(r.EnvVars.Warnings)
+	if r.Online != nil {
+		total += len(r.Online.Warnings)
+	}
+	for _, server := range r.MCP {
+		total += len(server.Warnings)
+	}
+	total += len(r.Rules.Warnings)
 	return total
 }
 
+// AddRules attaches the findings of custom rules, folding them into the
+// overall result
+func (r *ProfileHealthReport) AddRules(result HealthResult) {
+	r.Rules = result
+	for _, f := range result.Findings {
+		r.Overall.add(f.Severity, f.Rule, f.Message, f.Hint)
+	}
+}
+
+// Suppress drops every finding whose rule is listed in rules
+func (r *ProfileHealthReport) Suppress(rules []string) {
+	if len(rules) == 0 {
+		return
+	}
+	r.Rewrite(func(f Finding) (Finding, bool) {
+		if containsKey(rules, f.Rule) {
+			r.Suppressed++
+			return f, false
+		}
+		return f, true
+	})
+}
+
+// Rewrite applies fn to every finding in the report, dropping those for
+// which it returns false, and recomputes the overall result
+func (r *ProfileHealthReport) Rewrite(fn func(Finding) (Finding, bool)) {
+	r.Settings = r.Settings.rewrite(fn)
+	r.Model = r.Model.rewrite(fn)
+	r.Permissions = r.Permissions.rewrite(fn)
+	r.EnvVars = r.EnvVars.rewrite(fn)
+	if r.Online != nil {
+		r.Online.HealthResult = r.Online.rewrite(fn)
+	}
+	for i := range r.MCP {
+		r.MCP[i].HealthResult = r.MCP[i].rewrite(fn)
+	}
+	r.Rules = r.Rules.rewrite(fn)
+
+	r.Overall = newResult()
+	sections := []HealthResult{r.Settings, r.Model, r.Permissions, r.EnvVars}
+	if r.Online != nil {
+		sections = append(sections, r.Online.HealthResult)
+	}
+	for _, server := range r.MCP {
+		sections = append(sections, server.HealthResult)
+	}
+	sections = append(sections, r.Rules)
+	for _, result := range sections {
+		for _, f := range result.Findings {
+			r.Overall.add(f.Severity, f.Rule, f.Message, f.Hint)
+		}
+	}
+}
+
+// AddMCP attaches per-server MCP results, folding their findings into the
+// overall result
+func (r *ProfileHealthReport) AddMCP(results []MCPServerResult) {
+	r.MCP = results
+	for _, server := range results {
+		for _, f := range server.Findings {
+			r.Overall.add(f.Severity, f.Rule, f.Message, f.Hint)
+		}
+	}
+}
+
+// AddOnline attaches a live API probe result, folding its findings into
+// the overall result
+func (r *ProfileHealthReport) AddOnline(online OnlineResult) {
+	r.Online = &online
+	for _, f := range online.Findings {
+		r.Overall.add(f.Severity, f.Rule, f.Message, f.Hint)
+	}
+}
+
 // Summary returns a brief summary of the health status
 func (r *ProfileHealthReport) Summary() string {
 	if !r.IsHealthy() {
@@ -86,117 +302,176 @@ func CheckProfile(profileName string, settings *config.Settings, claudeMD string
 	// If settings check failed, overall health fails
 	if !report.Settings.IsHealthy() {
 		report.Overall = HealthResult{
-			IsValid: false,
-			Error:   report.Settings.Error,
+			IsValid:  false,
+			Error:    report.Settings.Error,
+			Findings: report.Settings.Findings,
 		}
 		return report
 	}
 
-	// Check model
-	report.Model = CheckModel(settings.Model)
+	provider := DetectProvider(settings.Env)
+
+	// Check model (provider model IDs and custom endpoints use their own names)
+	model := settings.Model
+	if model == "" {
+		model = settings.Env["ANTHROPIC_MODEL"]
+	}
+	report.Model = checkModel(model, provider != ProviderAnthropic)
 
 	// Check permissions
 	report.Permissions = CheckPermissions(settings.Permissions)
 
 	// Check environment variables
 	report.EnvVars = CheckEnvVars(settings.Env)
+	if helper, _ := settings.APIKeyHelper(); helper != "" {
+		// apiKeyHelper supplies the token for custom endpoints
+		report.EnvVars = report.EnvVars.without(RuleMissingAuthToken)
+	}
 
 	// Determine overall health
-	allHealthy := report.Settings.IsHealthy() &&
-		report.Model.IsHealthy() &&
-		report.Permissions.IsHealthy() &&
-		report.EnvVars.IsHealthy()
-
-	report.Overall = HealthResult{
-		IsValid: allHealthy,
+	report.Overall = newResult()
+	for _, result := range []HealthResult{report.Settings, report.Model, report.Permissions, report.EnvVars} {
+		for _, f := range result.Findings {
+			report.Overall.add(f.Severity, f.Rule, f.Message, f.Hint)
+		}
 	}
 
-	// Collect all warnings
-	var allWarnings []string
-	allWarnings = append(allWarnings, report.Settings.Warnings...)
-	allWarnings = append(allWarnings, report.Model.Warnings...)
-	allWarnings = append(allWarnings, report.Permissions.Warnings...)
-	allWarnings = append(allWarnings, report.EnvVars.Warnings...)
-
-	report.Overall.Warnings = allWarnings
-
 	return report
 }
 
 // CheckSettings validates the settings structure
 func CheckSettings(settings *config.Settings) HealthResult {
+	result := newResult()
 	if settings == nil {
-		return HealthResult{
-			IsValid: false,
-			Error: &HealthError{
-				Message: "Settings cannot be nil",
-			},
-		}
+		result.add(SeverityError, "no-settings", "Settings cannot be nil", "")
+		return result
 	}
 
-	warnings := []string{}
-
-	// Warn if no model is set
-	if settings.Model == "" {
-		warnings = append(warnings, "No model specified (will use Claude Code default)")
+	// Warn if no model is set (providers often set it with ANTHROPIC_MODEL)
+	if settings.Model == "" && settings.Env["ANTHROPIC_MODEL"] == "" {
+		result.add(SeverityWarning, "no-model", "No model specified (will use Claude Code default)",
+			"Set one with: claudectx set <profile> model sonnet")
 	}
 
 	// Warn if no environment variables
 	if len(settings.Env) == 0 {
-		warnings = append(warnings, "No environment variables set")
+		result.add(SeverityWarning, "no-env", "No environment variables set", "")
 	}
 
-	return HealthResult{
-		IsValid:  true,
-		Warnings: warnings,
+	extras := settings.Extras()
+	for _, key := range sortedKeys(deprecatedSettings) {
+		if _, ok := extras[key]; ok {
+			result.add(SeverityWarning, RuleDeprecatedSetting,
+				fmt.Sprintf("Setting %q is deprecated", key), deprecatedSettings[key])
+		}
 	}
+
+	checkSettingsFields(&result, settings)
+
+	return result
 }
 
-// Known Claude models
+// Model aliases accepted by Claude Code
 var knownModels = []string{
+	"default",
 	"opus",
 	"sonnet",
 	"haiku",
-	"claude-3-opus",
-	"claude-3-sonnet",
-	"claude-3-haiku",
-	"claude-3-opus-20240229",
-	"claude-3-sonnet-20240229",
-	"claude-3-haiku-20240307",
+	"opusplan",
+	"opus[1m]",
+	"sonnet[1m]",
 }
 
-// isKnownModel checks if a model name is in the known list
+// knownModelPattern matches Claude model IDs in both the current
+// (claude-sonnet-4-5-20250929) and legacy (claude-3-5-sonnet-20241022)
+// naming schemes, including Bedrock and Vertex forms that embed them
+var knownModelPattern = regexp.MustCompile(`claude-((opus|sonnet|haiku)-\d+|\d+(-\d+)?-(opus|sonnet|haiku))`)
+
+// retiredModels maps retired Claude model ID prefixes to the alias that
+// replaces them
+var retiredModels = map[string]string{
+	"claude-2":          "sonnet",
+	"claude-instant":    "haiku",
+	"claude-3-opus":     "opus",
+	"claude-3-sonnet":   "sonnet",
+	"claude-3-5-sonnet": "sonnet",
+	"claude-3-7-sonnet": "sonnet",
+	"claude-3-5-haiku":  "haiku",
+}
+
+// deprecatedSettings maps deprecated settings.json keys to advice on
+// replacing them
+var deprecatedSettings = map[string]string{
+	"includeCoAuthoredBy": `Use "attribution" instead`,
+}
+
+// retiredModelAlias returns the alias replacing model if it is a retired
+// first-party model ID
+func retiredModelAlias(model string) (string, bool) {
+	modelLower := strings.ToLower(model)
+	for _, prefix := range sortedKeys(retiredModels) {
+		if strings.HasPrefix(modelLower, prefix) {
+			return retiredModels[prefix], true
+		}
+	}
+	return "", false
+}
+
+// sortedKeys returns a map's keys in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isKnownModel checks if a model name is a known alias or Claude model ID
 func isKnownModel(model string) bool {
 	modelLower := strings.ToLower(model)
 	for _, known := range knownModels {
-		if strings.Contains(modelLower, known) {
+		if modelLower == known {
 			return true
 		}
 	}
-	return false
+	return knownModelPattern.MatchString(modelLower)
 }
 
 // CheckModel validates the model configuration
 func CheckModel(model string) HealthResult {
-	warnings := []string{}
+	return checkModel(model, false)
+}
+
+// checkModel validates the model; unknown names are only informational when
+// the profile targets a non-Anthropic provider or endpoint
+func checkModel(model string, customProvider bool) HealthResult {
+	result := newResult()
 
 	if model == "" {
-		warnings = append(warnings, "No model specified")
-		return HealthResult{
-			IsValid:  true,
-			Warnings: warnings,
-		}
+		result.add(SeverityWarning, "no-model", "No model specified", "")
+		return result
+	}
+
+	if alias, retired := retiredModelAlias(model); retired && !customProvider {
+		result.add(SeverityWarning, RuleRetiredModel,
+			fmt.Sprintf("Model %q has been retired", model),
+			fmt.Sprintf("Use the %q alias instead", alias))
 	}
 
 	// Check if it's a known model
 	if !isKnownModel(model) {
-		warnings = append(warnings, fmt.Sprintf("Unknown model %q (custom models are allowed but may not work)", model))
+		if customProvider {
+			result.add(SeverityInfo, "unknown-model",
+				fmt.Sprintf("Model %q is not a Claude model ID (expected for custom endpoints)", model), "")
+		} else {
+			result.add(SeverityWarning, "unknown-model",
+				fmt.Sprintf("Unknown model %q (custom models are allowed but may not work)", model),
+				"Use an alias (opus, sonnet, haiku) or a full ID such as claude-sonnet-4-5")
+		}
 	}
 
-	return HealthResult{
-		IsValid:  true,
-		Warnings: warnings,
-	}
+	return result
 }
 
 // CheckPermissions validates the permissions configuration
@@ -207,40 +482,46 @@ func CheckPermissions(perms *config.Permissions) HealthResult {
 		}
 	}
 
-	warnings := []string{}
+	result := newResult()
+
+	checkPermissionFields(&result, perms)
 
 	// Check for wildcard
 	for _, allow := range perms.Allow {
 		if allow == "*" {
-			warnings = append(warnings, "Wildcard (*) in allow list grants access to all tools")
+			result.add(SeverityWarning, "allow-wildcard", "Wildcard (*) in allow list grants access to all tools",
+				"List the specific tools to allow instead")
 			break
 		}
 	}
 
-	// Warn if both allow and deny are used
-	if len(perms.Allow) > 0 && len(perms.Deny) > 0 {
-		warnings = append(warnings, "Both allow and deny lists are specified (deny takes precedence)")
-	}
-
-	return HealthResult{
-		IsValid:  true,
-		Warnings: warnings,
+	for _, list := range []struct {
+		name  string
+		rules []string
+	}{{"allow", perms.Allow}, {"deny", perms.Deny}, {"ask", perms.Ask}} {
+		seen := map[string]int{}
+		for _, rule := range list.rules {
+			seen[rule]++
+			if seen[rule] == 2 {
+				result.add(SeverityWarning, RuleDuplicatePermission,
+					fmt.Sprintf("Permission rule %q is listed more than once in %s", rule, list.name),
+					"Remove the duplicate entry")
+			}
+		}
 	}
-}
-
-// CheckEnvVars validates environment variables
-func CheckEnvVars(env map[string]string) HealthResult {
-	warnings := []string{}
 
-	// Check for empty values
-	for key, value := range env {
-		if value == "" {
-			warnings = append(warnings, fmt.Sprintf("Environment variable %q has empty value", key))
+	for _, issue := range permissions.Check(perms) {
+		severity := SeverityWarning
+		if issue.Error {
+			severity = SeverityError
 		}
+		result.add(severity, issue.Kind, issue.Message, issue.Hint)
 	}
 
-	return HealthResult{
-		IsValid:  true,
-		Warnings: warnings,
+	// Warn if both allow and deny are used
+	if len(perms.Allow) > 0 && len(perms.Deny) > 0 {
+		result.add(SeverityWarning, "allow-and-deny", "Both allow and deny lists are specified (deny takes precedence)", "")
 	}
+
+	return result
 }
@@ -171,6 +171,29 @@ func TestCheckPermissions(t *testing.T) {
 	}
 }
 
+func TestCheckPermissions_RuleFindings(t *testing.T) {
+	result := CheckPermissions(&config.Permissions{
+		Allow: []string{"Bash(git:*)", "Bash(git log)", "Raed", "mcp__github__create_issue", "Bash(ls"},
+		Deny:  []string{"mcp__github"},
+	})
+
+	got := ruleSeverities(result)
+	want := map[string]Severity{
+		RulePermissionSyntax:      SeverityError,
+		RulePermissionUnknownTool: SeverityWarning,
+		RulePermissionShadowed:    SeverityWarning,
+		RulePermissionUnreachable: SeverityWarning,
+	}
+	for rule, severity := range want {
+		if sev, ok := got[rule]; !ok || sev != severity {
+			t.Errorf("rule %s: severity = %v (present %v), want %v", rule, sev, ok, severity)
+		}
+	}
+	if result.IsValid {
+		t.Error("an unparseable rule should make the result invalid")
+	}
+}
+
 func TestCheckEnvVars(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -0,0 +1,673 @@
+package health
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/validator"
+)
+
+// mcpProtocolVersion is the MCP revision claudectx offers in initialize
+const mcpProtocolVersion = "2025-06-18"
+
+// stdioShutdownGrace is how long a stdio server gets to exit after its
+// stdin is closed before it is killed
+const stdioShutdownGrace = 500 * time.Millisecond
+
+// maxToolPages bounds tools/list pagination for misbehaving servers
+const maxToolPages = 50
+
+// Rule names for MCP findings
+const (
+	RuleMCPCommand     = "mcp-command"
+	RuleMCPFile        = "mcp-file"
+	RuleMCPURL         = "mcp-url"
+	RuleMCPField       = "mcp-field"
+	RuleMCPTransport   = "mcp-transport"
+	RuleMCPHandshake   = "mcp-handshake"
+	RuleMCPAuth        = "mcp-auth"
+	RuleMCPTimeout     = "mcp-timeout"
+	RuleMCPOK          = "mcp-ok"
+	RuleMCPUnreachable = "mcp-unreachable"
+)
+
+// MCPCheckOptions controls how MCP servers are checked
+type MCPCheckOptions struct {
+	// Probe starts stdio servers and contacts remote ones; without it only
+	// static checks run
+	Probe bool
+	// Timeout bounds each server's probe
+	Timeout time.Duration
+	// Client is used for http and sse servers (nil for http.DefaultClient)
+	Client *http.Client
+}
+
+// MCPServerResult is the health of a single MCP server
+type MCPServerResult struct {
+	HealthResult
+	Name      string
+	Transport string
+	// Probed is true when the server was started or contacted
+	Probed bool
+	// ServerName and ServerVersion come from the initialize response
+	ServerName      string
+	ServerVersion   string
+	ProtocolVersion string
+	// ToolCount is the number of tools listed, or -1 if unknown
+	ToolCount int
+	Latency   time.Duration
+}
+
+// CheckMCPServers checks every server, probing them in parallel, and
+// returns the results sorted by server name
+func CheckMCPServers(servers mcpconfig.MCPServers, opts MCPCheckOptions) []MCPServerResult {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]MCPServerResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = CheckMCPServer(name, servers[name], opts)
+		}(i, name)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CheckMCPServer validates one server's configuration and, when opts.Probe
+// is set, performs an MCP initialize handshake and tools/list
+func CheckMCPServer(name string, server mcpconfig.MCPServer, opts MCPCheckOptions) MCPServerResult {
+	result := MCPServerResult{
+		HealthResult: newResult(),
+		Name:         name,
+		Transport:    server.Transport(),
+		ToolCount:    -1,
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultOnlineTimeout
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	switch result.Transport {
+	case "stdio":
+		checkStdioConfig(&result, server)
+	case "http", "sse":
+		checkRemoteConfig(&result, server)
+	default:
+		result.add(SeverityError, RuleMCPTransport,
+			fmt.Sprintf("MCP server %q has unknown type %q", name, server.Type),
+			"Use type stdio, http or sse")
+	}
+
+	checkServerFields(&result, server)
+
+	if !opts.Probe || !result.IsValid {
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	result.Probed = true
+	start := time.Now()
+
+	var err error
+	switch result.Transport {
+	case "stdio":
+		err = probeStdio(ctx, &result, server)
+	case "http":
+		err = withConn(ctx, &result, newHTTPConn(opts.Client, server))
+	case "sse":
+		err = probeSSE(ctx, opts.Client, server)
+	}
+	result.Latency = time.Since(start)
+
+	if err != nil {
+		reportProbeError(ctx, &result, err, opts.Timeout)
+		return result
+	}
+
+	message := fmt.Sprintf("MCP server %q responded in %s", name, result.Latency.Round(time.Millisecond))
+	if result.ServerName != "" {
+		message = fmt.Sprintf("MCP server %q is %s", name, strings.TrimSpace(result.ServerName+" "+result.ServerVersion))
+	}
+	if result.ToolCount >= 0 {
+		message += fmt.Sprintf(" with %d tools", result.ToolCount)
+	}
+	result.add(SeverityInfo, RuleMCPOK, message, "")
+	return result
+}
+
+// checkStdioConfig verifies the command resolves and referenced files exist
+func checkStdioConfig(result *MCPServerResult, server mcpconfig.MCPServer) {
+	if server.Command == "" {
+		result.add(SeverityError, RuleMCPCommand,
+			fmt.Sprintf("MCP server %q has no command", result.Name),
+			"Set the command to run, e.g. npx")
+		return
+	}
+
+	if _, err := exec.LookPath(expandHome(server.Command)); err != nil {
+		hint := fmt.Sprintf("Install %s or use its full path", server.Command)
+		if strings.Contains(server.Command, "/") {
+			hint = "Check the path exists and is executable"
+		}
+		result.add(SeverityError, RuleMCPCommand,
+			fmt.Sprintf("MCP server %q command %q not found", result.Name, server.Command), hint)
+	}
+
+	for _, arg := range server.Args {
+		if !looksLikePath(arg) {
+			continue
+		}
+		if _, err := os.Stat(expandHome(arg)); err != nil {
+			result.add(SeverityWarning, RuleMCPFile,
+				fmt.Sprintf("MCP server %q references missing file %q", result.Name, arg),
+				"Fix the path in the server's args")
+		}
+	}
+}
+
+// checkServerFields checks cwd, timeout, headersHelper and oauth: malformed
+// values are errors, and a missing cwd or helper command is a warning
+func checkServerFields(result *MCPServerResult, server mcpconfig.MCPServer) {
+	if err := validator.ValidateMCPServerFields(server); err != nil {
+		result.add(SeverityError, RuleMCPField,
+			fmt.Sprintf("MCP server %q is invalid: %v", result.Name, err),
+			fmt.Sprintf("Fix it with: claudectx edit <profile> mcp, or remove the field with: claudectx unset <profile> mcp.%s.<field>", result.Name))
+		return
+	}
+
+	if cwd, _ := server.Cwd(); cwd != "" && (filepath.IsAbs(cwd) || strings.HasPrefix(cwd, "~/")) {
+		if info, err := os.Stat(expandHome(cwd)); err != nil || !info.IsDir() {
+			result.add(SeverityWarning, RuleMCPFile,
+				fmt.Sprintf("MCP server %q cwd %q does not exist", result.Name, cwd),
+				"Create the directory or fix the server's cwd")
+		}
+	}
+
+	if helper, _ := server.HeadersHelper(); helper != "" {
+		if program, ok := missingCommand(helper); ok {
+			result.add(SeverityWarning, RuleHelperCommand,
+				fmt.Sprintf("MCP server %q headersHelper command %q not found", result.Name, program),
+				"Fix the path in the server's headersHelper")
+		}
+	}
+}
+
+// checkRemoteConfig validates an http or sse server's URL
+func checkRemoteConfig(result *MCPServerResult, server mcpconfig.MCPServer) {
+	if server.URL == "" {
+		result.add(SeverityError, RuleMCPURL,
+			fmt.Sprintf("MCP server %q (%s) has no url", result.Name, result.Transport),
+			"Set the server's url")
+		return
+	}
+
+	u, err := url.Parse(server.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		result.add(SeverityError, RuleMCPURL,
+			fmt.Sprintf("MCP server %q url is not a valid http(s) URL: %q", result.Name, server.URL),
+			"Use a full URL such as https://mcp.example.com/mcp")
+		return
+	}
+
+	if u.Scheme == "http" && !isLoopback(u.Hostname()) {
+		result.add(SeverityWarning, RuleInsecureURL,
+			fmt.Sprintf("MCP server %q uses plain http", result.Name),
+			"Use https unless the server runs locally")
+	}
+}
+
+// looksLikePath reports whether an argument is meant to be a file path
+func looksLikePath(arg string) bool {
+	return filepath.IsAbs(arg) || strings.HasPrefix(arg, "./") || strings.HasPrefix(arg, "../") || strings.HasPrefix(arg, "~/")
+}
+
+// expandHome replaces a leading ~/ with the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// errMCPAuth marks a remote server that rejected the request as unauthenticated
+var errMCPAuth = errors.New("authentication required")
+
+// reportProbeError records a failed probe as a finding
+func reportProbeError(ctx context.Context, result *MCPServerResult, err error, timeout time.Duration) {
+	switch {
+	case errors.Is(err, errMCPAuth):
+		result.add(SeverityWarning, RuleMCPAuth,
+			fmt.Sprintf("MCP server %q requires authentication", result.Name),
+			"Authenticate with /mcp in Claude Code, or add an Authorization header")
+	case ctx.Err() == context.DeadlineExceeded:
+		result.add(SeverityError, RuleMCPTimeout,
+			fmt.Sprintf("MCP server %q did not complete the handshake within %s", result.Name, timeout),
+			"Start the server by hand to check it works, or retry with a longer --timeout")
+	case result.Transport == "stdio":
+		result.add(SeverityError, RuleMCPHandshake,
+			fmt.Sprintf("MCP server %q failed: %v", result.Name, err),
+			"Run the server's command by hand to see why it fails")
+	default:
+		rule := RuleMCPHandshake
+		var reqErr *url.Error
+		if errors.As(err, &reqErr) {
+			rule = RuleMCPUnreachable
+		}
+		result.add(SeverityError, rule,
+			fmt.Sprintf("MCP server %q failed: %v", result.Name, err),
+			"Check the server's url and that it is running")
+	}
+}
+
+// mcpConn is a JSON-RPC connection to an MCP server
+type mcpConn interface {
+	call(ctx context.Context, id int, method string, params interface{}) (json.RawMessage, error)
+	notify(ctx context.Context, method string, params interface{}) error
+}
+
+// rpcMessage is a JSON-RPC request, notification or response
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// withConn performs the initialize handshake and lists tools over conn
+func withConn(ctx context.Context, result *MCPServerResult, conn mcpConn) error {
+	raw, err := conn.call(ctx, 1, "initialize", map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "claudectx", "version": "health"},
+	})
+	if err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+	}
+	if err := json.Unmarshal(raw, &init); err != nil {
+		return fmt.Errorf("initialize: invalid result: %w", err)
+	}
+	result.ServerName = init.ServerInfo.Name
+	result.ServerVersion = init.ServerInfo.Version
+	result.ProtocolVersion = init.ProtocolVersion
+
+	if err := conn.notify(ctx, "notifications/initialized", nil); err != nil {
+		return fmt.Errorf("initialized notification: %w", err)
+	}
+
+	result.ToolCount = 0
+	if _, ok := init.Capabilities["tools"]; !ok {
+		return nil
+	}
+
+	cursor := ""
+	for page := 0; page < maxToolPages; page++ {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		raw, err := conn.call(ctx, 2+page, "tools/list", params)
+		if err != nil {
+			return fmt.Errorf("tools/list: %w", err)
+		}
+
+		var list struct {
+			Tools      []json.RawMessage `json:"tools"`
+			NextCursor string            `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return fmt.Errorf("tools/list: invalid result: %w", err)
+		}
+		result.ToolCount += len(list.Tools)
+
+		if list.NextCursor == "" {
+			break
+		}
+		cursor = list.NextCursor
+	}
+	return nil
+}
+
+// probeStdio starts a stdio server and runs the handshake over its
+// stdin/stdout. The process and any children it started are stopped
+// afterwards.
+func probeStdio(ctx context.Context, result *MCPServerResult, server mcpconfig.MCPServer) error {
+	cmd := exec.Command(expandHome(server.Command), server.Args...)
+	if cwd, _ := server.Cwd(); cwd != "" {
+		cmd.Dir = expandHome(cwd)
+	}
+	cmd.Env = os.Environ()
+	for k, v := range server.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var stderr tailBuffer
+	cmd.Stderr = &stderr
+	// Don't wait on pipes held open by the server's own children
+	cmd.WaitDelay = time.Second
+	startInProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	conn := &stdioConn{in: stdin, out: bufio.NewReader(stdout)}
+	err = withConn(ctx, result, conn)
+
+	// MCP servers exit when stdin closes; kill any that don't, along with
+	// whatever a wrapper such as npx left running in the process group
+	stdin.Close()
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+		killProcessGroup(cmd)
+	case <-time.After(stdioShutdownGrace):
+		killProcessGroup(cmd)
+		<-exited
+	}
+
+	if err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("%w; stderr: %s", err, stderr.String())
+	}
+	return err
+}
+
+// errServerExited is returned when a stdio server stops before answering
+var errServerExited = errors.New("server exited before responding")
+
+// stdioConn speaks newline-delimited JSON-RPC over a process's pipes
+type stdioConn struct {
+	in  io.Writer
+	out *bufio.Reader
+}
+
+func (c *stdioConn) send(msg rpcMessage) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := c.in.Write(append(data, '\n')); err != nil {
+		if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+			return errServerExited
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *stdioConn) notify(ctx context.Context, method string, params interface{}) error {
+	return c.send(rpcMessage{Method: method, Params: params})
+}
+
+func (c *stdioConn) call(ctx context.Context, id int, method string, params interface{}) (json.RawMessage, error) {
+	if err := c.send(rpcMessage{ID: &id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	type readResult struct {
+		raw json.RawMessage
+		err error
+	}
+	done := make(chan readResult, 1)
+	go func() {
+		for {
+			line, err := c.out.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				var msg rpcMessage
+				// Skip log lines, notifications and server requests
+				if json.Unmarshal(line, &msg) == nil && msg.ID != nil && *msg.ID == id && msg.Method == "" {
+					if msg.Error != nil {
+						done <- readResult{err: msg.Error}
+					} else {
+						done <- readResult{raw: msg.Result}
+					}
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = errServerExited
+				}
+				done <- readResult{err: err}
+				return
+			}
+		}
+	}()
+
+	select {
+	case r := <-done:
+		return r.raw, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// httpConn speaks MCP's streamable HTTP transport
+type httpConn struct {
+	client  *http.Client
+	server  mcpconfig.MCPServer
+	session string
+}
+
+func newHTTPConn(client *http.Client, server mcpconfig.MCPServer) *httpConn {
+	return &httpConn{client: client, server: server}
+}
+
+// post sends one JSON-RPC message and returns the response
+func (c *httpConn) post(ctx context.Context, msg rpcMessage) (*http.Response, error) {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.server.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.server.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("MCP-Protocol-Version", mcpProtocolVersion)
+	if c.session != "" {
+		req.Header.Set("Mcp-Session-Id", c.session)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		return nil, errMCPAuth
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		c.session = id
+	}
+	return resp, nil
+}
+
+func (c *httpConn) notify(ctx context.Context, method string, params interface{}) error {
+	resp, err := c.post(ctx, rpcMessage{Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *httpConn) call(ctx context.Context, id int, method string, params interface{}) (json.RawMessage, error) {
+	resp, err := c.post(ctx, rpcMessage{ID: &id, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The response is either a single JSON body or an SSE stream that
+	// carries it in a data: line
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data:"); ok {
+				if msg, ok := matchResponse([]byte(strings.TrimSpace(data)), id); ok {
+					return msg.Result, responseError(msg)
+				}
+			}
+		}
+	} else {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if msg, ok := matchResponse(body, id); ok {
+			return msg.Result, responseError(msg)
+		}
+	}
+
+	return nil, errors.New("no response in server reply")
+}
+
+// matchResponse decodes data as the JSON-RPC response with the given id
+func matchResponse(data []byte, id int) (rpcMessage, bool) {
+	var msg rpcMessage
+	if json.Unmarshal(data, &msg) != nil || msg.ID == nil || *msg.ID != id || msg.Method != "" {
+		return rpcMessage{}, false
+	}
+	return msg, true
+}
+
+// responseError returns the response's JSON-RPC error, if any
+func responseError(msg rpcMessage) error {
+	if msg.Error != nil {
+		return msg.Error
+	}
+	return nil
+}
+
+// probeSSE checks a legacy SSE server accepts an event stream connection
+func probeSSE(ctx context.Context, client *http.Client, server mcpconfig.MCPServer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range server.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return errMCPAuth
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	case !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		return fmt.Errorf("expected an event stream, got %q", resp.Header.Get("Content-Type"))
+	}
+	return nil
+}
+
+// tailBuffer keeps the last few hundred bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+const tailBufferSize = 512
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > tailBufferSize {
+		b.buf = b.buf[len(b.buf)-tailBufferSize:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(bytes.TrimSpace(b.buf))
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
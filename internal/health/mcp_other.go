@@ -0,0 +1,13 @@
+//go:build !unix
+
+package health
+
+import "os/exec"
+
+// startInProcessGroup is a no-op where process groups are not available
+func startInProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills cmd's process; its children are not tracked
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,285 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+)
+
+// fakeStdioServer writes a shell script that answers initialize and
+// tools/list with canned responses, logging noise along the way
+func fakeStdioServer(t *testing.T) string {
+	t.Helper()
+	script := `#!/bin/sh
+read init
+echo "starting up" >&2
+echo 'not json'
+echo '{"jsonrpc":"2.0","method":"notifications/message","params":{}}'
+echo '{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"fake","version":"1.2.3"},"capabilities":{"tools":{}}}}'
+read initialized
+read list
+echo '{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"a"},{"name":"b"}],"nextCursor":"p2"}}'
+read list2
+echo '{"jsonrpc":"2.0","id":3,"result":{"tools":[{"name":"c"}]}}'
+read eof
+`
+	path := filepath.Join(t.TempDir(), "fake-mcp")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// parseServer decodes a server, including fields kept in extras
+func parseServer(t *testing.T, data string) mcpconfig.MCPServer {
+	t.Helper()
+	var server mcpconfig.MCPServer
+	if err := json.Unmarshal([]byte(data), &server); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestCheckMCPServer_Static(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "server.js")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		server    mcpconfig.MCPServer
+		wantRules map[string]Severity
+	}{
+		{
+			name:      "resolvable command and existing file",
+			server:    mcpconfig.MCPServer{Command: "sh", Args: []string{existing, "--flag"}},
+			wantRules: map[string]Severity{},
+		},
+		{
+			name:      "missing command",
+			server:    mcpconfig.MCPServer{Command: "definitely-not-a-real-mcp-binary"},
+			wantRules: map[string]Severity{RuleMCPCommand: SeverityError},
+		},
+		{
+			name:      "empty command",
+			server:    mcpconfig.MCPServer{Type: "stdio"},
+			wantRules: map[string]Severity{RuleMCPCommand: SeverityError},
+		},
+		{
+			name:      "missing referenced file",
+			server:    mcpconfig.MCPServer{Command: "sh", Args: []string{"/no/such/server.js"}},
+			wantRules: map[string]Severity{RuleMCPFile: SeverityWarning},
+		},
+		{
+			name:      "valid http",
+			server:    mcpconfig.MCPServer{Type: "http", URL: "https://mcp.example.com/mcp"},
+			wantRules: map[string]Severity{},
+		},
+		{
+			name:      "malformed url",
+			server:    mcpconfig.MCPServer{Type: "sse", URL: "mcp.example.com"},
+			wantRules: map[string]Severity{RuleMCPURL: SeverityError},
+		},
+		{
+			name:      "plain http remote",
+			server:    mcpconfig.MCPServer{URL: "http://mcp.example.com/mcp"},
+			wantRules: map[string]Severity{RuleInsecureURL: SeverityWarning},
+		},
+		{
+			name:      "unknown transport",
+			server:    mcpconfig.MCPServer{Type: "websocket", URL: "wss://x"},
+			wantRules: map[string]Severity{RuleMCPTransport: SeverityError},
+		},
+		{
+			name:      "missing cwd",
+			server:    parseServer(t, `{"command":"sh","cwd":"/no/such/dir"}`),
+			wantRules: map[string]Severity{RuleMCPFile: SeverityWarning},
+		},
+		{
+			name:      "missing headersHelper command",
+			server:    parseServer(t, `{"type":"http","url":"https://mcp.example.com/mcp","headersHelper":"no-such-mcp-helper --json"}`),
+			wantRules: map[string]Severity{RuleHelperCommand: SeverityWarning},
+		},
+		{
+			name:      "oauth on a stdio server",
+			server:    parseServer(t, `{"command":"sh","oauth":{"clientId":"abc"}}`),
+			wantRules: map[string]Severity{RuleMCPField: SeverityError},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CheckMCPServer("srv", tt.server, MCPCheckOptions{})
+
+			got := ruleSeverities(result.HealthResult)
+			if len(got) != len(tt.wantRules) {
+				t.Fatalf("rules = %v, want %v", got, tt.wantRules)
+			}
+			for rule, severity := range tt.wantRules {
+				if got[rule] != severity {
+					t.Errorf("rule %s severity = %s, want %s", rule, got[rule], severity)
+				}
+			}
+			if result.Probed {
+				t.Error("static check should not probe")
+			}
+		})
+	}
+}
+
+func TestCheckMCPServer_StdioHandshake(t *testing.T) {
+	server := mcpconfig.MCPServer{Command: fakeStdioServer(t)}
+
+	result := CheckMCPServer("fake", server, MCPCheckOptions{Probe: true, Timeout: 5 * time.Second})
+
+	if !result.IsHealthy() || !result.Probed {
+		t.Fatalf("expected healthy probe, got %+v", result)
+	}
+	if result.ServerName != "fake" || result.ServerVersion != "1.2.3" || result.ProtocolVersion != "2025-06-18" {
+		t.Errorf("unexpected server info: %+v", result)
+	}
+	if result.ToolCount != 3 {
+		t.Errorf("ToolCount = %d, want 3", result.ToolCount)
+	}
+}
+
+func TestCheckMCPServer_StdioFailures(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("exits early", func(t *testing.T) {
+		server := mcpconfig.MCPServer{Command: write("crash", "echo 'missing API key' >&2; exit 1")}
+		result := CheckMCPServer("crash", server, MCPCheckOptions{Probe: true, Timeout: 5 * time.Second})
+		if rules := ruleSeverities(result.HealthResult); rules[RuleMCPHandshake] != SeverityError {
+			t.Fatalf("expected handshake error, got %v", rules)
+		}
+		if msg := result.Error.Message; !strings.Contains(msg, "server exited") || !strings.Contains(msg, "missing API key") {
+			t.Errorf("error should include exit and stderr, got %q", msg)
+		}
+	})
+
+	t.Run("never answers", func(t *testing.T) {
+		server := mcpconfig.MCPServer{Command: write("hang", "sleep 5")}
+		start := time.Now()
+		result := CheckMCPServer("hang", server, MCPCheckOptions{Probe: true, Timeout: 100 * time.Millisecond})
+		if rules := ruleSeverities(result.HealthResult); rules[RuleMCPTimeout] != SeverityError {
+			t.Fatalf("expected timeout error, got %v", rules)
+		}
+		if time.Since(start) > 3*time.Second {
+			t.Error("probe should stop the server after the timeout")
+		}
+	})
+}
+
+// fakeHTTPServer implements MCP's streamable HTTP transport. initialize is
+// answered with JSON and tools/list with an SSE stream.
+func fakeHTTPServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var msg struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+		}
+		json.Unmarshal(body, &msg)
+
+		switch msg.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "session-1")
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"remote","version":"0.9"},"capabilities":{"tools":{}}}}`, *msg.ID)
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			if r.Header.Get("Mcp-Session-Id") != "session-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":%d,\"result\":{\"tools\":[{\"name\":\"x\"}]}}\n\n", *msg.ID)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCheckMCPServer_HTTPHandshake(t *testing.T) {
+	server := fakeHTTPServer(t, "secret")
+	defer server.Close()
+
+	srv := mcpconfig.MCPServer{Type: "http", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}}
+	result := CheckMCPServer("remote", srv, MCPCheckOptions{Probe: true, Timeout: 5 * time.Second, Client: server.Client()})
+
+	if !result.IsHealthy() || result.ServerName != "remote" || result.ToolCount != 1 {
+		t.Fatalf("expected healthy probe with 1 tool, got %+v", result)
+	}
+
+	// Without the header the server asks for authentication
+	srv.Headers = nil
+	result = CheckMCPServer("remote", srv, MCPCheckOptions{Probe: true, Timeout: 5 * time.Second, Client: server.Client()})
+	if rules := ruleSeverities(result.HealthResult); rules[RuleMCPAuth] != SeverityWarning {
+		t.Errorf("expected auth warning, got %v", rules)
+	}
+}
+
+func TestCheckMCPServer_SSEProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: endpoint\ndata: /messages?session=1\n\n")
+	}))
+	defer server.Close()
+
+	result := CheckMCPServer("legacy", mcpconfig.MCPServer{Type: "sse", URL: server.URL},
+		MCPCheckOptions{Probe: true, Timeout: 5 * time.Second, Client: server.Client()})
+	if !result.IsHealthy() || !result.Probed {
+		t.Errorf("expected reachable sse server, got %+v", result)
+	}
+
+	url := server.URL
+	server.Close()
+	result = CheckMCPServer("legacy", mcpconfig.MCPServer{Type: "sse", URL: url},
+		MCPCheckOptions{Probe: true, Timeout: 5 * time.Second})
+	if rules := ruleSeverities(result.HealthResult); rules[RuleMCPUnreachable] != SeverityError {
+		t.Errorf("expected unreachable error, got %v", rules)
+	}
+}
+
+func TestCheckMCPServers_SortedAndFolded(t *testing.T) {
+	servers := mcpconfig.MCPServers{
+		"zeta":  {Command: "sh"},
+		"alpha": {Command: "definitely-not-a-real-mcp-binary"},
+	}
+
+	results := CheckMCPServers(servers, MCPCheckOptions{})
+	if len(results) != 2 || results[0].Name != "alpha" || results[1].Name != "zeta" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	report := CheckProfile("test", &config.Settings{Model: "opus", Env: map[string]string{"X": "1"}}, "")
+	report.AddMCP(results)
+	if report.IsHealthy() || len(report.MCP) != 2 {
+		t.Errorf("MCP errors should make the report unhealthy, got %+v", report.Overall)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package health
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startInProcessGroup makes cmd lead a new process group, so the servers
+// that wrappers like npx, uvx or sh -c start can be stopped with it
+func startInProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills every process left in cmd's process group
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
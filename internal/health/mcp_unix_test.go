@@ -0,0 +1,73 @@
+//go:build unix
+
+package health
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+)
+
+// processGone reports whether pid has exited. A killed orphan may linger as
+// a zombie until init reaps it, which counts as gone.
+func processGone(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return true
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+func TestCheckMCPServer_StdioStopsChildProcesses(t *testing.T) {
+	tests := []struct {
+		name string
+		// wait keeps the wrapper running once the child has started
+		wait string
+	}{
+		{"wrapper exits on EOF", "cat >/dev/null"},
+		{"wrapper ignores EOF", "wait"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			pidFile := filepath.Join(dir, "child.pid")
+			script := "#!/bin/sh\nsleep 300 &\necho $! > " + pidFile + "\n" + tt.wait + "\n"
+			path := filepath.Join(dir, "wrapper")
+			if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			CheckMCPServer("wrapped", mcpconfig.MCPServer{Command: path}, MCPCheckOptions{Probe: true, Timeout: 200 * time.Millisecond})
+
+			data, err := os.ReadFile(pidFile)
+			if err != nil {
+				t.Fatalf("wrapper did not start its child: %v", err)
+			}
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatalf("invalid child pid %q", data)
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			for !processGone(pid) {
+				if time.Now().After(deadline) {
+					syscall.Kill(pid, syscall.SIGKILL)
+					t.Fatalf("child process %d is still running after the probe", pid)
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// DefaultBaseURL is the Anthropic API used when ANTHROPIC_BASE_URL is unset
+const DefaultBaseURL = "https://api.anthropic.com"
+
+// DefaultOnlineTimeout bounds the whole online probe
+const DefaultOnlineTimeout = 10 * time.Second
+
+// anthropicVersion is sent with every probe request
+const anthropicVersion = "2023-06-01"
+
+// probeModel is used for count_tokens when the profile names no model ID
+const probeModel = "claude-haiku-4-5"
+
+// Rule names for online findings
+const (
+	RuleOnlineOK          = "online-ok"
+	RuleOnlineSkipped     = "online-skipped"
+	RuleOnlineNoAuth      = "online-no-credentials"
+	RuleOnlineAuth        = "online-auth"
+	RuleOnlineUnreachable = "online-unreachable"
+	RuleOnlineTimeout     = "online-timeout"
+	RuleOnlineTLS         = "online-tls"
+	RuleOnlineStatus      = "online-status"
+	RuleOnlineRedirect    = "online-redirect"
+)
+
+// OnlineResult is the outcome of a live API connectivity probe
+type OnlineResult struct {
+	HealthResult
+	// Endpoint is the last URL requested
+	Endpoint string
+	// Status is the HTTP status of the last response (0 if none arrived)
+	Status  int
+	Latency time.Duration
+	// AuthSource names the variable the credentials came from
+	AuthSource string
+}
+
+// CheckOnline makes a minimal authenticated request to the API the settings
+// point at: GET /v1/models, falling back to POST /v1/messages/count_tokens
+// for endpoints that don't list models. The base URL and credentials come
+// only from the profile's env, so a shell credential is never sent to a
+// host the profile names. Redirects are never followed, so the credentials
+// only reach that host. client may be nil to use http.DefaultClient;
+// timeout bounds the whole probe.
+func CheckOnline(client *http.Client, settings *config.Settings, timeout time.Duration) OnlineResult {
+	result := OnlineResult{HealthResult: newResult()}
+	client = noRedirectClient(client)
+	if timeout <= 0 {
+		timeout = DefaultOnlineTimeout
+	}
+
+	env := map[string]string{}
+	if settings != nil && settings.Env != nil {
+		env = settings.Env
+	}
+	lookup := func(key string) (string, string) {
+		if v := env[key]; v != "" {
+			return v, "env." + key
+		}
+		return "", ""
+	}
+
+	switch provider := DetectProvider(env); provider {
+	case ProviderBedrock, ProviderVertex:
+		result.add(SeverityInfo, RuleOnlineSkipped,
+			fmt.Sprintf("Online check is not supported for %s (it uses cloud credentials)", provider), "")
+		return result
+	}
+
+	baseURL, _ := lookup("ANTHROPIC_BASE_URL")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	headers := http.Header{}
+	headers.Set("anthropic-version", anthropicVersion)
+	if token, source := lookup("ANTHROPIC_AUTH_TOKEN"); token != "" {
+		headers.Set("Authorization", "Bearer "+token)
+		result.AuthSource = source
+	} else if key, source := lookup("ANTHROPIC_API_KEY"); key != "" {
+		headers.Set("x-api-key", key)
+		result.AuthSource = source
+	} else {
+		result.add(SeverityWarning, RuleOnlineNoAuth,
+			"No ANTHROPIC_AUTH_TOKEN or ANTHROPIC_API_KEY found; cannot make an authenticated request",
+			"Set env.ANTHROPIC_AUTH_TOKEN or env.ANTHROPIC_API_KEY in the profile (apiKeyHelper is not run by health)")
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+
+	resp, err := probe(ctx, client, http.MethodGet, baseURL+"/v1/models?limit=1", headers, nil, &result)
+	if err == nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed) {
+		// Many Anthropic-compatible gateways only implement the messages API
+		body, _ := json.Marshal(map[string]interface{}{
+			"model":    onlineProbeModel(settings),
+			"messages": []map[string]string{{"role": "user", "content": "ping"}},
+		})
+		resp, err = probe(ctx, client, http.MethodPost, baseURL+"/v1/messages/count_tokens", headers, body, &result)
+	}
+	result.Latency = time.Since(start)
+
+	if err != nil {
+		classifyTransportError(&result, err, timeout)
+		return result
+	}
+
+	result.Status = resp.StatusCode
+	latency := result.Latency.Round(time.Millisecond)
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		result.add(SeverityInfo, RuleOnlineOK,
+			fmt.Sprintf("Reached %s (HTTP %d) in %s", result.Endpoint, resp.StatusCode, latency), "")
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		target := resp.Header.Get("Location")
+		if target == "" {
+			target = "another location"
+		}
+		result.add(SeverityWarning, RuleOnlineRedirect,
+			fmt.Sprintf("%s redirected (HTTP %d) to %s; the redirect was not followed", result.Endpoint, resp.StatusCode, target),
+			"Point ANTHROPIC_BASE_URL at the final API root so credentials are not sent through a redirect")
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		result.add(SeverityError, RuleOnlineAuth,
+			fmt.Sprintf("Authentication failed: %s returned HTTP %d", result.Endpoint, resp.StatusCode),
+			fmt.Sprintf("Check the credentials in %s are valid for %s", result.AuthSource, baseURL))
+	case resp.StatusCode == http.StatusTooManyRequests:
+		result.add(SeverityWarning, RuleOnlineStatus,
+			fmt.Sprintf("Reached %s but it is rate limiting requests (HTTP 429)", result.Endpoint),
+			"Try again later or check your plan's rate limits")
+	case resp.StatusCode >= 500:
+		result.add(SeverityWarning, RuleOnlineStatus,
+			fmt.Sprintf("Reached %s but the server returned HTTP %d", result.Endpoint, resp.StatusCode),
+			"The service may be having problems; try again later")
+	default:
+		result.add(SeverityWarning, RuleOnlineStatus,
+			fmt.Sprintf("Reached %s but it returned HTTP %d", result.Endpoint, resp.StatusCode),
+			"Check ANTHROPIC_BASE_URL points at an Anthropic-compatible API root (without /v1)")
+	}
+
+	return result
+}
+
+// noRedirectClient returns a copy of client that returns redirect responses
+// instead of following them. Go only drops Authorization and Cookie on a
+// cross-host redirect, so following one would leak an x-api-key header.
+func noRedirectClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	copied := *client
+	copied.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &copied
+}
+
+// probe sends one request, recording the endpoint on result. Only the
+// status matters, so the response body is closed unread.
+func probe(ctx context.Context, client *http.Client, method, url string, headers http.Header, body []byte, result *OnlineResult) (*http.Response, error) {
+	result.Endpoint = url
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers.Clone()
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// classifyTransportError turns a failed request into a TLS, timeout or
+// unreachable-host finding
+func classifyTransportError(result *OnlineResult, err error, timeout time.Duration) {
+	var (
+		certErr     *tls.CertificateVerificationError
+		unknownAuth x509.UnknownAuthorityError
+		hostErr     x509.HostnameError
+		invalidErr  x509.CertificateInvalidError
+		recordErr   tls.RecordHeaderError
+		dnsErr      *net.DNSError
+		netErr      net.Error
+	)
+
+	switch {
+	case errors.As(err, &certErr), errors.As(err, &unknownAuth), errors.As(err, &hostErr),
+		errors.As(err, &invalidErr), errors.As(err, &recordErr):
+		result.add(SeverityError, RuleOnlineTLS,
+			fmt.Sprintf("TLS error connecting to %s: %v", result.Endpoint, unwrapURLError(err)),
+			"Check the endpoint's certificate, or set NODE_EXTRA_CA_CERTS/SSL_CERT_FILE for a corporate CA")
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		result.add(SeverityError, RuleOnlineTimeout,
+			fmt.Sprintf("No response from %s within %s", result.Endpoint, timeout),
+			"Check network access or proxy settings (HTTPS_PROXY), or retry with a longer --timeout")
+	case errors.As(err, &dnsErr):
+		result.add(SeverityError, RuleOnlineUnreachable,
+			fmt.Sprintf("Cannot resolve host for %s: %v", result.Endpoint, unwrapURLError(err)),
+			"Check the host name in ANTHROPIC_BASE_URL and your DNS settings")
+	default:
+		result.add(SeverityError, RuleOnlineUnreachable,
+			fmt.Sprintf("Cannot reach %s: %v", result.Endpoint, unwrapURLError(err)),
+			"Check ANTHROPIC_BASE_URL, network access and proxy settings (HTTPS_PROXY)")
+	}
+}
+
+// unwrapURLError drops the "Get \"url\":" prefix net/http adds to errors
+func unwrapURLError(err error) error {
+	if inner := errors.Unwrap(err); inner != nil {
+		return inner
+	}
+	return err
+}
+
+// onlineProbeModel picks a model ID for count_tokens from the profile,
+// skipping aliases the API does not accept
+func onlineProbeModel(settings *config.Settings) string {
+	if settings == nil {
+		return probeModel
+	}
+	for _, model := range []string{
+		settings.Env["ANTHROPIC_DEFAULT_HAIKU_MODEL"],
+		settings.Env["ANTHROPIC_MODEL"],
+		settings.Model,
+	} {
+		if model != "" && !isModelAlias(model) {
+			return model
+		}
+	}
+	return probeModel
+}
+
+// isModelAlias reports whether model is one of Claude Code's model aliases
+func isModelAlias(model string) bool {
+	for _, alias := range knownModels {
+		if strings.EqualFold(model, alias) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// clearAuthEnv keeps the process environment from supplying credentials
+func clearAuthEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"ANTHROPIC_BASE_URL", "ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY"} {
+		t.Setenv(key, "")
+	}
+}
+
+// onlineSettings returns settings pointing at baseURL with the given env
+func onlineSettings(baseURL string, env map[string]string) *config.Settings {
+	merged := map[string]string{"ANTHROPIC_BASE_URL": baseURL}
+	for k, v := range env {
+		merged[k] = v
+	}
+	return &config.Settings{Env: merged}
+}
+
+func TestCheckOnline_Success(t *testing.T) {
+	clearAuthEnv(t)
+
+	var gotAuth, gotVersion, gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotVersion = r.Header.Get("anthropic-version")
+		gotPath = r.URL.Path
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	result := CheckOnline(server.Client(), onlineSettings(server.URL+"/", map[string]string{"ANTHROPIC_AUTH_TOKEN": "tok"}), time.Second)
+
+	if !result.IsHealthy() || result.Status != http.StatusOK {
+		t.Fatalf("expected success, got %+v", result)
+	}
+	if gotAuth != "Bearer tok" || gotVersion == "" || gotPath != "/v1/models" {
+		t.Errorf("unexpected request: auth=%q version=%q path=%q", gotAuth, gotVersion, gotPath)
+	}
+	if result.AuthSource != "env.ANTHROPIC_AUTH_TOKEN" {
+		t.Errorf("AuthSource = %q", result.AuthSource)
+	}
+	if result.Latency <= 0 {
+		t.Error("expected latency to be recorded")
+	}
+}
+
+func TestCheckOnline_FallsBackToCountTokens(t *testing.T) {
+	clearAuthEnv(t)
+
+	var gotKey, gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages/count_tokens" {
+			http.NotFound(w, r)
+			return
+		}
+		gotKey = r.Header.Get("x-api-key")
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body.Model
+		w.Write([]byte(`{"input_tokens": 1}`))
+	}))
+	defer server.Close()
+
+	settings := onlineSettings(server.URL, map[string]string{
+		"ANTHROPIC_API_KEY":             "key",
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL": "glm-4.5-air",
+	})
+	settings.Model = "opus"
+	result := CheckOnline(server.Client(), settings, time.Second)
+
+	if !result.IsHealthy() || result.Endpoint != server.URL+"/v1/messages/count_tokens" {
+		t.Fatalf("expected count_tokens success, got %+v", result)
+	}
+	if gotKey != "key" || gotModel != "glm-4.5-air" {
+		t.Errorf("unexpected request: key=%q model=%q", gotKey, gotModel)
+	}
+}
+
+func TestCheckOnline_Failures(t *testing.T) {
+	clearAuthEnv(t)
+	creds := map[string]string{"ANTHROPIC_AUTH_TOKEN": "tok"}
+
+	statusServer := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+	}
+
+	t.Run("auth failure", func(t *testing.T) {
+		server := statusServer(http.StatusUnauthorized)
+		defer server.Close()
+
+		result := CheckOnline(server.Client(), onlineSettings(server.URL, creds), time.Second)
+		if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineAuth] != SeverityError {
+			t.Errorf("expected auth error, got %v", rules)
+		}
+		if result.Status != http.StatusUnauthorized {
+			t.Errorf("Status = %d", result.Status)
+		}
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		server := statusServer(http.StatusBadGateway)
+		defer server.Close()
+
+		result := CheckOnline(server.Client(), onlineSettings(server.URL, creds), time.Second)
+		if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineStatus] != SeverityWarning {
+			t.Errorf("expected status warning, got %v", rules)
+		}
+	})
+
+	t.Run("unreachable host", func(t *testing.T) {
+		server := statusServer(http.StatusOK)
+		url := server.URL
+		server.Close()
+
+		result := CheckOnline(http.DefaultClient, onlineSettings(url, creds), time.Second)
+		if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineUnreachable] != SeverityError {
+			t.Errorf("expected unreachable error, got %v", rules)
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		// A plain client does not trust the test server's certificate
+		result := CheckOnline(&http.Client{}, onlineSettings(server.URL, creds), time.Second)
+		if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineTLS] != SeverityError {
+			t.Errorf("expected TLS error, got %v", rules)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(done)
+
+		result := CheckOnline(server.Client(), onlineSettings(server.URL, creds), 50*time.Millisecond)
+		if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineTimeout] != SeverityError {
+			t.Errorf("expected timeout error, got %v", rules)
+		}
+	})
+}
+
+func TestCheckOnline_DoesNotFollowRedirects(t *testing.T) {
+	clearAuthEnv(t)
+
+	var leaked bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "" || r.Header.Get("Authorization") != "" {
+			leaked = true
+		}
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	// http.DefaultClient follows redirects; CheckOnline must not
+	result := CheckOnline(http.DefaultClient, onlineSettings(server.URL, map[string]string{"ANTHROPIC_API_KEY": "sk-ant-secret"}), time.Second)
+
+	if leaked {
+		t.Fatal("credentials were sent to the redirect target")
+	}
+	if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineRedirect] != SeverityWarning {
+		t.Errorf("expected redirect warning, got %v", rules)
+	}
+	if result.Status != http.StatusTemporaryRedirect {
+		t.Errorf("Status = %d, want %d", result.Status, http.StatusTemporaryRedirect)
+	}
+}
+
+func TestCheckOnline_Skipped(t *testing.T) {
+	clearAuthEnv(t)
+
+	result := CheckOnline(nil, &config.Settings{Env: map[string]string{"CLAUDE_CODE_USE_BEDROCK": "1"}}, time.Second)
+	if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineSkipped] != SeverityInfo {
+		t.Errorf("expected bedrock to be skipped, got %v", rules)
+	}
+
+	result = CheckOnline(nil, &config.Settings{}, time.Second)
+	if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineNoAuth] != SeverityWarning {
+		t.Errorf("expected missing credentials warning, got %v", rules)
+	}
+}
+
+func TestCheckOnline_IgnoresShellEnv(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-shell")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "shell-token")
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	// A profile naming a gateway without credentials must not get the shell's
+	result := CheckOnline(server.Client(), onlineSettings(server.URL, nil), time.Second)
+	if rules := ruleSeverities(result.HealthResult); rules[RuleOnlineNoAuth] != SeverityWarning {
+		t.Errorf("expected missing credentials warning, got %v", rules)
+	}
+	if requests != 0 {
+		t.Errorf("shell credentials were sent to the profile's base URL (%d requests)", requests)
+	}
+
+	// Nor is the shell's base URL used for a profile without one
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	var host string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		host = r.URL.Host
+		return nil, errors.New("offline")
+	})}
+	CheckOnline(client, &config.Settings{Env: map[string]string{"ANTHROPIC_API_KEY": "sk-profile"}}, time.Second)
+	if want := strings.TrimPrefix(DefaultBaseURL, "https://"); host != want {
+		t.Errorf("request went to %q, want %q", host, want)
+	}
+}
+
+// roundTripFunc lets a function act as an http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestAddOnline(t *testing.T) {
+	report := CheckProfile("test", &config.Settings{Model: "opus", Env: map[string]string{"X": "1"}}, "")
+	report.AddOnline(OnlineResult{HealthResult: func() HealthResult {
+		r := newResult()
+		r.add(SeverityError, RuleOnlineAuth, "Authentication failed", "")
+		return r
+	}()})
+
+	if report.IsHealthy() || report.Online == nil {
+		t.Errorf("online errors should make the report unhealthy, got %+v", report.Overall)
+	}
+}
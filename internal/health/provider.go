@@ -0,0 +1,202 @@
+package health
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Provider identifies which API backend a profile's environment targets
+type Provider string
+
+const (
+	// ProviderAnthropic is the default Anthropic API
+	ProviderAnthropic Provider = "anthropic"
+	// ProviderBedrock is Amazon Bedrock (CLAUDE_CODE_USE_BEDROCK)
+	ProviderBedrock Provider = "bedrock"
+	// ProviderVertex is Google Vertex AI (CLAUDE_CODE_USE_VERTEX)
+	ProviderVertex Provider = "vertex"
+	// ProviderCustom is an Anthropic-compatible endpoint set by ANTHROPIC_BASE_URL
+	ProviderCustom Provider = "custom"
+)
+
+// Rule names for provider findings
+const (
+	RuleEmptyEnv            = "env-empty"
+	RuleConflictingProvider = "provider-conflict"
+	RuleBedrockRegion       = "bedrock-region"
+	RuleBedrockBaseURL      = "bedrock-base-url"
+	RuleVertexRegion        = "vertex-region"
+	RuleVertexProject       = "vertex-project"
+	RuleMalformedURL        = "url-malformed"
+	RuleInsecureURL         = "url-insecure"
+	RuleMissingAuthToken    = "auth-token-missing"
+	RuleUnknownModelKey     = "model-key-unknown"
+	RuleDeprecatedEnv       = "env-deprecated"
+)
+
+// modelMappingKeys are the ANTHROPIC_DEFAULT_*_MODEL variables Claude Code reads
+var modelMappingKeys = []string{
+	"ANTHROPIC_DEFAULT_OPUS_MODEL",
+	"ANTHROPIC_DEFAULT_SONNET_MODEL",
+	"ANTHROPIC_DEFAULT_HAIKU_MODEL",
+}
+
+// urlEnvVars are environment variables that must hold an http(s) URL
+var urlEnvVars = []string{
+	"ANTHROPIC_BASE_URL",
+	"ANTHROPIC_BEDROCK_BASE_URL",
+	"ANTHROPIC_VERTEX_BASE_URL",
+}
+
+// isEnabled reports whether a flag variable such as CLAUDE_CODE_USE_BEDROCK
+// is switched on
+func isEnabled(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "0", "false", "no", "off":
+		return false
+	}
+	return true
+}
+
+// DetectProvider returns the API provider selected by env. Bedrock wins over
+// Vertex when both are set, matching Claude Code.
+func DetectProvider(env map[string]string) Provider {
+	switch {
+	case isEnabled(env["CLAUDE_CODE_USE_BEDROCK"]):
+		return ProviderBedrock
+	case isEnabled(env["CLAUDE_CODE_USE_VERTEX"]):
+		return ProviderVertex
+	case env["ANTHROPIC_BASE_URL"] != "":
+		return ProviderCustom
+	}
+	return ProviderAnthropic
+}
+
+// CheckEnvVars validates environment variables, including the variables
+// each API provider requires
+func CheckEnvVars(env map[string]string) HealthResult {
+	result := newResult()
+
+	// Iterate in a stable order so findings are reproducible
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	provider := DetectProvider(env)
+
+	for _, key := range keys {
+		value := env[key]
+
+		// Check for empty values. OpenRouter-style setups blank
+		// ANTHROPIC_API_KEY on purpose so it can't override the auth token.
+		if value == "" && !(key == "ANTHROPIC_API_KEY" && provider == ProviderCustom) {
+			result.add(SeverityWarning, RuleEmptyEnv,
+				fmt.Sprintf("Environment variable %q has empty value", key),
+				fmt.Sprintf("Give it a value or remove it with: claudectx unset <profile> env.%s", key))
+		}
+
+		if strings.HasPrefix(key, "ANTHROPIC_DEFAULT_") && !containsKey(modelMappingKeys, key) {
+			result.add(SeverityWarning, RuleUnknownModelKey,
+				fmt.Sprintf("Unknown model mapping %q is ignored by Claude Code", key),
+				"Valid keys are "+strings.Join(modelMappingKeys, ", "))
+		}
+	}
+
+	if _, ok := env["ANTHROPIC_SMALL_FAST_MODEL"]; ok {
+		result.add(SeverityInfo, RuleDeprecatedEnv,
+			"ANTHROPIC_SMALL_FAST_MODEL is deprecated",
+			"Use ANTHROPIC_DEFAULT_HAIKU_MODEL instead")
+	}
+
+	checkURLs(&result, env)
+
+	bedrock := isEnabled(env["CLAUDE_CODE_USE_BEDROCK"])
+	vertex := isEnabled(env["CLAUDE_CODE_USE_VERTEX"])
+	if bedrock && vertex {
+		result.add(SeverityError, RuleConflictingProvider,
+			"Both CLAUDE_CODE_USE_BEDROCK and CLAUDE_CODE_USE_VERTEX are set",
+			"Remove one of them so the profile targets a single provider")
+	}
+
+	if bedrock {
+		if env["AWS_REGION"] == "" {
+			result.add(SeverityError, RuleBedrockRegion,
+				"Bedrock is enabled but AWS_REGION is not set",
+				"Set env.AWS_REGION (e.g. us-east-1); Claude Code does not read the region from ~/.aws/config")
+		}
+		if env["ANTHROPIC_BASE_URL"] != "" {
+			result.add(SeverityWarning, RuleBedrockBaseURL,
+				"ANTHROPIC_BASE_URL is ignored when Bedrock is enabled",
+				"Use ANTHROPIC_BEDROCK_BASE_URL for a Bedrock gateway")
+		}
+	}
+
+	if vertex {
+		if env["CLOUD_ML_REGION"] == "" {
+			result.add(SeverityError, RuleVertexRegion,
+				"Vertex AI is enabled but CLOUD_ML_REGION is not set",
+				"Set env.CLOUD_ML_REGION (e.g. us-east5 or global)")
+		}
+		if env["ANTHROPIC_VERTEX_PROJECT_ID"] == "" {
+			result.add(SeverityError, RuleVertexProject,
+				"Vertex AI is enabled but ANTHROPIC_VERTEX_PROJECT_ID is not set",
+				"Set env.ANTHROPIC_VERTEX_PROJECT_ID to your GCP project ID")
+		}
+	}
+
+	if provider == ProviderCustom && env["ANTHROPIC_AUTH_TOKEN"] == "" && env["ANTHROPIC_API_KEY"] == "" {
+		result.add(SeverityWarning, RuleMissingAuthToken,
+			"ANTHROPIC_BASE_URL is set without ANTHROPIC_AUTH_TOKEN or ANTHROPIC_API_KEY",
+			"Set env.ANTHROPIC_AUTH_TOKEN for the endpoint, or configure apiKeyHelper")
+	}
+
+	return result
+}
+
+// checkURLs reports base URL variables that are not valid http(s) URLs
+func checkURLs(result *HealthResult, env map[string]string) {
+	for _, key := range urlEnvVars {
+		raw := env[key]
+		if raw == "" {
+			continue
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			result.add(SeverityError, RuleMalformedURL,
+				fmt.Sprintf("%s is not a valid URL: %q", key, raw),
+				"Use a full URL including the scheme, e.g. https://api.example.com")
+			continue
+		}
+
+		if u.Scheme == "http" && !isLoopback(u.Hostname()) {
+			result.add(SeverityWarning, RuleInsecureURL,
+				fmt.Sprintf("%s uses plain http; credentials will be sent unencrypted", key),
+				"Use https unless the endpoint is a local proxy")
+		}
+	}
+}
+
+// isLoopback reports whether host refers to the local machine
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// containsKey reports whether keys contains key
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package health
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// ruleSeverities returns the severity of each rule reported in result
+func ruleSeverities(result HealthResult) map[string]Severity {
+	rules := make(map[string]Severity)
+	for _, f := range result.Findings {
+		rules[f.Rule] = f.Severity
+	}
+	return rules
+}
+
+func TestDetectProvider(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want Provider
+	}{
+		{map[string]string{}, ProviderAnthropic},
+		{map[string]string{"CLAUDE_CODE_USE_BEDROCK": "1"}, ProviderBedrock},
+		{map[string]string{"CLAUDE_CODE_USE_BEDROCK": "0"}, ProviderAnthropic},
+		{map[string]string{"CLAUDE_CODE_USE_VERTEX": "true"}, ProviderVertex},
+		{map[string]string{"ANTHROPIC_BASE_URL": "https://api.z.ai/api/anthropic"}, ProviderCustom},
+	}
+
+	for _, tt := range tests {
+		if got := DetectProvider(tt.env); got != tt.want {
+			t.Errorf("DetectProvider(%v) = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestCheckEnvVars_Providers(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantRules map[string]Severity
+		wantValid bool
+	}{
+		{
+			name: "complete bedrock",
+			env: map[string]string{
+				"CLAUDE_CODE_USE_BEDROCK": "1",
+				"AWS_REGION":              "us-east-1",
+			},
+			wantRules: map[string]Severity{},
+			wantValid: true,
+		},
+		{
+			name:      "bedrock without region",
+			env:       map[string]string{"CLAUDE_CODE_USE_BEDROCK": "1"},
+			wantRules: map[string]Severity{RuleBedrockRegion: SeverityError},
+		},
+		{
+			name: "bedrock with anthropic base url",
+			env: map[string]string{
+				"CLAUDE_CODE_USE_BEDROCK": "1",
+				"AWS_REGION":              "us-east-1",
+				"ANTHROPIC_BASE_URL":      "https://gateway.example.com",
+			},
+			wantRules: map[string]Severity{RuleBedrockBaseURL: SeverityWarning},
+			wantValid: true,
+		},
+		{
+			name:      "vertex without region or project",
+			env:       map[string]string{"CLAUDE_CODE_USE_VERTEX": "1"},
+			wantRules: map[string]Severity{RuleVertexRegion: SeverityError, RuleVertexProject: SeverityError},
+		},
+		{
+			name: "bedrock and vertex",
+			env: map[string]string{
+				"CLAUDE_CODE_USE_BEDROCK":     "1",
+				"AWS_REGION":                  "us-east-1",
+				"CLAUDE_CODE_USE_VERTEX":      "1",
+				"CLOUD_ML_REGION":             "us-east5",
+				"ANTHROPIC_VERTEX_PROJECT_ID": "my-project",
+			},
+			wantRules: map[string]Severity{RuleConflictingProvider: SeverityError},
+		},
+		{
+			name:      "base url without token",
+			env:       map[string]string{"ANTHROPIC_BASE_URL": "https://api.z.ai/api/anthropic"},
+			wantRules: map[string]Severity{RuleMissingAuthToken: SeverityWarning},
+			wantValid: true,
+		},
+		{
+			name: "openrouter style blank api key",
+			env: map[string]string{
+				"ANTHROPIC_BASE_URL":   "https://openrouter.ai/api",
+				"ANTHROPIC_AUTH_TOKEN": "sk-or-123",
+				"ANTHROPIC_API_KEY":    "",
+			},
+			wantRules: map[string]Severity{},
+			wantValid: true,
+		},
+		{
+			name: "malformed url",
+			env: map[string]string{
+				"ANTHROPIC_BASE_URL":   "api.z.ai/api/anthropic",
+				"ANTHROPIC_AUTH_TOKEN": "token",
+			},
+			wantRules: map[string]Severity{RuleMalformedURL: SeverityError},
+		},
+		{
+			name: "plain http to remote host",
+			env: map[string]string{
+				"ANTHROPIC_BASE_URL":   "http://proxy.example.com",
+				"ANTHROPIC_AUTH_TOKEN": "token",
+			},
+			wantRules: map[string]Severity{RuleInsecureURL: SeverityWarning},
+			wantValid: true,
+		},
+		{
+			name: "plain http to localhost",
+			env: map[string]string{
+				"ANTHROPIC_BASE_URL":   "http://127.0.0.1:4000",
+				"ANTHROPIC_AUTH_TOKEN": "token",
+			},
+			wantRules: map[string]Severity{},
+			wantValid: true,
+		},
+		{
+			name: "unknown and deprecated model keys",
+			env: map[string]string{
+				"ANTHROPIC_DEFAULT_SONET_MODEL": "glm-4.7",
+				"ANTHROPIC_DEFAULT_OPUS_MODEL":  "glm-4.7",
+				"ANTHROPIC_SMALL_FAST_MODEL":    "claude-haiku-4-5",
+			},
+			wantRules: map[string]Severity{RuleUnknownModelKey: SeverityWarning, RuleDeprecatedEnv: SeverityInfo},
+			wantValid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CheckEnvVars(tt.env)
+
+			if result.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v (findings: %+v)", result.IsValid, tt.wantValid, result.Findings)
+			}
+			if got := ruleSeverities(result); len(got) != len(tt.wantRules) {
+				t.Errorf("rules = %v, want %v", got, tt.wantRules)
+			} else {
+				for rule, severity := range tt.wantRules {
+					if got[rule] != severity {
+						t.Errorf("rule %s severity = %s, want %s", rule, got[rule], severity)
+					}
+				}
+			}
+			for _, f := range result.Findings {
+				if f.Severity > SeverityInfo && f.Hint == "" {
+					t.Errorf("finding %s has no fix-it hint", f.Rule)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckProfile_ProviderFindings(t *testing.T) {
+	settings := &config.Settings{
+		Model: "glm-4.7",
+		Env:   map[string]string{"ANTHROPIC_BASE_URL": "https://api.z.ai/api/anthropic"},
+	}
+
+	report := CheckProfile("zai", settings, "")
+	if !report.IsHealthy() {
+		t.Fatalf("expected healthy report, got %+v", report.Overall)
+	}
+	rules := ruleSeverities(report.Overall)
+	if rules["unknown-model"] != SeverityInfo {
+		t.Errorf("custom endpoint model should be informational, got %v", rules)
+	}
+	if _, ok := rules[RuleMissingAuthToken]; !ok {
+		t.Errorf("expected missing auth token finding, got %v", rules)
+	}
+
+	// apiKeyHelper supplies the token
+	var withHelper config.Settings
+	if err := json.Unmarshal([]byte(`{"apiKeyHelper": "~/bin/get-key", "env": {"ANTHROPIC_BASE_URL": "https://api.z.ai/api/anthropic"}}`), &withHelper); err != nil {
+		t.Fatal(err)
+	}
+	report = CheckProfile("zai", &withHelper, "")
+	if _, ok := ruleSeverities(report.Overall)[RuleMissingAuthToken]; ok {
+		t.Error("apiKeyHelper should satisfy the auth token check")
+	}
+
+	// A model set through ANTHROPIC_MODEL counts as a model
+	report = CheckProfile("bedrock", &config.Settings{Env: map[string]string{
+		"CLAUDE_CODE_USE_BEDROCK": "1",
+		"AWS_REGION":              "eu-west-1",
+		"ANTHROPIC_MODEL":         "eu.anthropic.claude-sonnet-4-5-20250929-v1:0",
+	}}, "")
+	if report.TotalWarnings() != 0 {
+		t.Errorf("expected no warnings, got %v", report.Overall.Warnings)
+	}
+
+	// Provider errors make the whole profile unhealthy
+	report = CheckProfile("bedrock", &config.Settings{Env: map[string]string{"CLAUDE_CODE_USE_BEDROCK": "1"}}, "")
+	if report.IsHealthy() || report.Overall.Error == nil {
+		t.Errorf("expected unhealthy report with error, got %+v", report.Overall)
+	}
+}
+
+func TestIsKnownModel_CurrentModels(t *testing.T) {
+	known := []string{
+		"opusplan",
+		"sonnet[1m]",
+		"claude-sonnet-4-5-20250929",
+		"claude-opus-4-1",
+		"claude-haiku-4-5",
+		"claude-3-5-sonnet-20241022",
+		"us.anthropic.claude-sonnet-4-5-20250929-v1:0",
+		"claude-sonnet-4-5@20250929",
+	}
+	for _, model := range known {
+		if !isKnownModel(model) {
+			t.Errorf("isKnownModel(%q) should be true", model)
+		}
+	}
+
+	for _, model := range []string{"gpt-4o", "glm-4.7", "opus-custom"} {
+		if isKnownModel(model) {
+			t.Errorf("isKnownModel(%q) should be false", model)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/keypath"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+)
+
+// RepoRulesFileName is the per-repository rule file, looked up from the
+// working directory towards the filesystem root
+const RepoRulesFileName = ".claudectx-rules.json"
+
+// RuleInput is the profile data a rule inspects
+type RuleInput struct {
+	Profile    string
+	Tags       []string
+	Settings   *config.Settings
+	ClaudeMD   string
+	MCPServers mcpconfig.MCPServers
+}
+
+// Rule is a pluggable health check
+type Rule interface {
+	// ID identifies the rule in findings, overrides and suppressions
+	ID() string
+	// Severity is the severity of the rule's findings. Findings left at the
+	// zero severity (info) are given it.
+	Severity() Severity
+	// Check returns the rule's findings for a profile
+	Check(in RuleInput) []Finding
+}
+
+// Registry holds the rules run against each profile
+type Registry struct {
+	rules []Rule
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a rule, replacing any registered rule with the same ID
+func (r *Registry) Register(rule Rule) {
+	for i, existing := range r.rules {
+		if existing.ID() == rule.ID() {
+			r.rules[i] = rule
+			return
+		}
+	}
+	r.rules = append(r.rules, rule)
+}
+
+// Rules returns the registered rules in registration order
+func (r *Registry) Rules() []Rule {
+	return append([]Rule(nil), r.rules...)
+}
+
+// Run checks a profile against every registered rule. Findings without a
+// rule ID or severity take those of the rule that produced them.
+func (r *Registry) Run(in RuleInput) HealthResult {
+	result := newResult()
+	for _, rule := range r.rules {
+		for _, f := range rule.Check(in) {
+			if f.Rule == "" {
+				f.Rule = rule.ID()
+			}
+			if f.Severity == SeverityInfo {
+				f.Severity = rule.Severity()
+			}
+			result.add(f.Severity, f.Rule, f.Message, f.Hint)
+		}
+	}
+	return result
+}
+
+// DeclarativeRule is a rule defined in a rule file. It asserts something
+// about the value at a key path, using the same paths as "claudectx get"
+// (e.g. "permissions.allow", "env.ANTHROPIC_BASE_URL", "mcp.github.command").
+type DeclarativeRule struct {
+	RuleID string   `json:"id"`
+	Level  Severity `json:"severity"`
+	// Message and Hint override the generated finding text
+	Message string `json:"message,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+
+	// Profiles and Tags limit the rule to profiles whose name matches one
+	// of the globs and that carry one of the tags
+	Profiles []string `json:"profiles,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+
+	Path string `json:"path"`
+	// Exists requires the path to be set (true) or unset (false)
+	Exists *bool `json:"exists,omitempty"`
+	// Equals requires the value to equal the given JSON value
+	Equals any `json:"equals,omitempty"`
+	// Matches requires the value to be a string matching the regexp
+	Matches string `json:"matches,omitempty"`
+	// Contains requires a list (or string) value to contain every item
+	Contains []string `json:"contains,omitempty"`
+	// NotContains forbids a list (or string) value from containing any item
+	NotContains []string `json:"notContains,omitempty"`
+
+	pattern  *regexp.Regexp
+	segments []string
+}
+
+// UnmarshalJSON decodes a rule, defaulting its severity to warning
+func (d *DeclarativeRule) UnmarshalJSON(data []byte) error {
+	type plain DeclarativeRule
+	rule := plain{Level: SeverityWarning}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rule); err != nil {
+		return err
+	}
+	*d = DeclarativeRule(rule)
+	return nil
+}
+
+// ID returns the rule's ID
+func (d *DeclarativeRule) ID() string { return d.RuleID }
+
+// Severity returns the rule's severity
+func (d *DeclarativeRule) Severity() Severity { return d.Level }
+
+// compile validates the rule and prepares its path and pattern
+func (d *DeclarativeRule) compile() error {
+	if strings.TrimSpace(d.RuleID) == "" || strings.ContainsAny(d.RuleID, ", \t\n") {
+		return fmt.Errorf("invalid rule ID %q", d.RuleID)
+	}
+	segments, err := keypath.Parse(d.Path)
+	if err != nil {
+		return fmt.Errorf("rule %s: %w", d.RuleID, err)
+	}
+	d.segments = segments
+
+	if d.Exists == nil && d.Equals == nil && d.Matches == "" && len(d.Contains) == 0 && len(d.NotContains) == 0 {
+		return fmt.Errorf("rule %s: no condition (use exists, equals, matches, contains or notContains)", d.RuleID)
+	}
+	if d.Matches != "" {
+		if d.pattern, err = regexp.Compile(d.Matches); err != nil {
+			return fmt.Errorf("rule %s: invalid matches pattern: %w", d.RuleID, err)
+		}
+	}
+	for _, glob := range d.Profiles {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("rule %s: invalid profile pattern %q", d.RuleID, glob)
+		}
+	}
+	return nil
+}
+
+// appliesTo reports whether the rule's profile and tag filters select in
+func (d *DeclarativeRule) appliesTo(in RuleInput) bool {
+	if len(d.Profiles) > 0 {
+		matched := false
+		for _, glob := range d.Profiles {
+			if ok, _ := path.Match(glob, in.Profile); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(d.Tags) > 0 {
+		for _, tag := range d.Tags {
+			for _, t := range in.Tags {
+				if strings.EqualFold(t, tag) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Check evaluates the rule's conditions against the profile
+func (d *DeclarativeRule) Check(in RuleInput) []Finding {
+	if !d.appliesTo(in) {
+		return nil
+	}
+
+	value, found := ruleValue(in, d.segments)
+	var problems []string
+
+	if d.Exists != nil {
+		if *d.Exists && !found {
+			problems = append(problems, fmt.Sprintf("%s is not set", d.Path))
+		} else if !*d.Exists && found {
+			problems = append(problems, fmt.Sprintf("%s must not be set", d.Path))
+		}
+	}
+	if d.Equals != nil && (!found || !jsonEqual(value, d.Equals)) {
+		problems = append(problems, fmt.Sprintf("%s must be %s", d.Path, formatRuleValue(d.Equals)))
+	}
+	if d.pattern != nil {
+		s, ok := value.(string)
+		if !found || !ok || !d.pattern.MatchString(s) {
+			problems = append(problems, fmt.Sprintf("%s must match %s", d.Path, d.Matches))
+		}
+	}
+	for _, item := range d.Contains {
+		if !found || !valueContains(value, item) {
+			problems = append(problems, fmt.Sprintf("%s must include %q", d.Path, item))
+		}
+	}
+	for _, item := range d.NotContains {
+		if found && valueContains(value, item) {
+			problems = append(problems, fmt.Sprintf("%s must not include %q", d.Path, item))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	message := d.Message
+	if message == "" {
+		message = strings.Join(problems, "; ")
+	}
+	return []Finding{{Rule: d.RuleID, Severity: d.Level, Message: message, Hint: d.Hint}}
+}
+
+// ruleValue looks up a key path in the profile. Paths starting with "mcp"
+// address the MCP servers; anything else addresses settings.json.
+func ruleValue(in RuleInput, segments []string) (any, bool) {
+	var source any = in.Settings
+	if segments[0] == "mcp" {
+		source = in.MCPServers
+		segments = segments[1:]
+	}
+
+	data, err := json.Marshal(source)
+	if err != nil {
+		return nil, false
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil || doc == nil {
+		return nil, false
+	}
+	if len(segments) == 0 {
+		return doc, true
+	}
+	return keypath.Get(doc, segments)
+}
+
+// valueContains reports whether a list holds item, or a string contains it
+func valueContains(value any, item string) bool {
+	switch v := value.(type) {
+	case []any:
+		for _, elem := range v {
+			if s, ok := elem.(string); ok && s == item {
+				return true
+			}
+		}
+	case string:
+		return strings.Contains(v, item)
+	case map[string]any:
+		_, ok := v[item]
+		return ok
+	}
+	return false
+}
+
+// jsonEqual compares two decoded JSON values
+func jsonEqual(a, b any) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	if err1 != nil || err2 != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(ab) == string(bb)
+}
+
+// formatRuleValue renders a rule value for a message
+func formatRuleValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// RuleFile is the on-disk form of a rule file
+type RuleFile struct {
+	Rules []*DeclarativeRule `json:"rules"`
+	// Severity changes the severity of any rule's findings, built-in or
+	// custom; "off" disables the rule
+	Severity map[string]string `json:"severity,omitempty"`
+}
+
+// RuleSet is the combined configuration of one or more rule files
+type RuleSet struct {
+	Registry *Registry
+	// Sources lists the rule files that were loaded
+	Sources []string
+	// overrides maps rule IDs to a new severity; nil disables the rule
+	overrides map[string]*Severity
+}
+
+// NewRuleSet returns a rule set with no rules
+func NewRuleSet() *RuleSet {
+	return &RuleSet{Registry: NewRegistry(), overrides: map[string]*Severity{}}
+}
+
+// LoadRuleSet reads the given rule files in order, skipping any that do
+// not exist. Rules and overrides in later files replace those with the
+// same ID in earlier ones.
+func LoadRuleSet(paths ...string) (*RuleSet, error) {
+	rs := NewRuleSet()
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		data, err := os.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule file: %w", err)
+		}
+		file, err := ParseRuleFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule file %s: %w", p, err)
+		}
+		rs.add(file)
+		rs.Sources = append(rs.Sources, p)
+	}
+	return rs, nil
+}
+
+// ParseRuleFile decodes and validates a rule file
+func ParseRuleFile(data []byte) (*RuleFile, error) {
+	var file RuleFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, rule := range file.Rules {
+		if rule == nil {
+			return nil, errors.New("rules cannot contain null")
+		}
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+		if seen[rule.RuleID] {
+			return nil, fmt.Errorf("duplicate rule ID %q", rule.RuleID)
+		}
+		seen[rule.RuleID] = true
+	}
+	for id, level := range file.Severity {
+		if level == "off" {
+			continue
+		}
+		if _, err := ParseSeverity(level); err != nil {
+			return nil, fmt.Errorf("severity for %s: %w", id, err)
+		}
+	}
+	return &file, nil
+}
+
+// add merges a parsed rule file into the set
+func (rs *RuleSet) add(file *RuleFile) {
+	for _, rule := range file.Rules {
+		rs.Registry.Register(rule)
+	}
+	for id, level := range file.Severity {
+		if level == "off" {
+			rs.overrides[id] = nil
+			continue
+		}
+		severity, _ := ParseSeverity(level)
+		rs.overrides[id] = &severity
+	}
+}
+
+// Apply runs the set's rules against a profile, attaches their findings to
+// the report, and then applies severity overrides to every finding
+func (rs *RuleSet) Apply(report *ProfileHealthReport, in RuleInput) {
+	if len(rs.Registry.rules) > 0 {
+		report.AddRules(rs.Registry.Run(in))
+	}
+	if len(rs.overrides) == 0 {
+		return
+	}
+	report.Rewrite(func(f Finding) (Finding, bool) {
+		override, ok := rs.overrides[f.Rule]
+		if !ok {
+			return f, true
+		}
+		if override == nil {
+			return f, false
+		}
+		f.Severity = *override
+		return f, true
+	})
+}
+
+// FindRepoRulesFile looks for RepoRulesFileName in dir and its parents,
+// returning "" if there is none
+func FindRepoRulesFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		candidate := filepath.Join(dir, RepoRulesFileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,287 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+)
+
+const orgRules = `{
+  "rules": [
+    {
+      "id": "no-bash-wildcard",
+      "severity": "error",
+      "path": "permissions.allow",
+      "notContains": ["Bash(*)"],
+      "hint": "Allow specific commands such as Bash(npm test)"
+    },
+    {
+      "id": "client-deny-webfetch",
+      "tags": ["client"],
+      "path": "permissions.deny",
+      "contains": ["WebFetch"]
+    },
+    {
+      "id": "corp-proxy",
+      "profiles": ["work-*"],
+      "path": "env.ANTHROPIC_BASE_URL",
+      "matches": "^https://llm\\.corp\\.example\\.com",
+      "message": "Work profiles must use the corporate LLM proxy"
+    },
+    {
+      "id": "no-filesystem-mcp",
+      "severity": "info",
+      "path": "mcp.filesystem",
+      "exists": false
+    }
+  ],
+  "severity": {"no-env": "off", "allow-and-deny": "error"}
+}`
+
+func loadRules(t *testing.T, contents ...string) *RuleSet {
+	t.Helper()
+	var files []string
+	for i, c := range contents {
+		path := filepath.Join(t.TempDir(), "rules"+string(rune('a'+i))+".json")
+		if err := os.WriteFile(path, []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	rs, err := LoadRuleSet(files...)
+	if err != nil {
+		t.Fatalf("LoadRuleSet failed: %v", err)
+	}
+	return rs
+}
+
+func TestDeclarativeRules(t *testing.T) {
+	rs := loadRules(t, orgRules)
+
+	tests := []struct {
+		name      string
+		in        RuleInput
+		wantRules map[string]Severity
+	}{
+		{
+			name: "compliant work profile",
+			in: RuleInput{
+				Profile: "work-main",
+				Tags:    []string{"client"},
+				Settings: &config.Settings{
+					Env:         map[string]string{"ANTHROPIC_BASE_URL": "https://llm.corp.example.com/v1"},
+					Permissions: &config.Permissions{Allow: []string{"Bash(npm test)"}, Deny: []string{"WebFetch"}},
+				},
+			},
+			wantRules: map[string]Severity{},
+		},
+		{
+			name: "every rule violated",
+			in: RuleInput{
+				Profile: "work-side",
+				Tags:    []string{"Client"},
+				Settings: &config.Settings{
+					Env:         map[string]string{"ANTHROPIC_BASE_URL": "https://other.example.com"},
+					Permissions: &config.Permissions{Allow: []string{"Bash(*)"}},
+				},
+				MCPServers: mcpconfig.MCPServers{"filesystem": {Command: "npx"}},
+			},
+			wantRules: map[string]Severity{
+				"no-bash-wildcard":     SeverityError,
+				"client-deny-webfetch": SeverityWarning,
+				"corp-proxy":           SeverityWarning,
+				"no-filesystem-mcp":    SeverityInfo,
+			},
+		},
+		{
+			name: "filters skip unrelated profiles",
+			in: RuleInput{
+				Profile:  "personal",
+				Settings: &config.Settings{},
+			},
+			wantRules: map[string]Severity{},
+		},
+		{
+			name: "missing value fails matches",
+			in: RuleInput{
+				Profile:  "work-new",
+				Settings: &config.Settings{},
+			},
+			wantRules: map[string]Severity{"corp-proxy": SeverityWarning},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := rs.Registry.Run(tt.in)
+			got := ruleSeverities(result)
+			if len(got) != len(tt.wantRules) {
+				t.Fatalf("rules = %v, want %v", got, tt.wantRules)
+			}
+			for rule, severity := range tt.wantRules {
+				if got[rule] != severity {
+					t.Errorf("rule %s severity = %s, want %s", rule, got[rule], severity)
+				}
+			}
+		})
+	}
+}
+
+func TestDeclarativeRuleMessages(t *testing.T) {
+	rs := loadRules(t, orgRules)
+	result := rs.Registry.Run(RuleInput{
+		Profile:  "work-x",
+		Settings: &config.Settings{Permissions: &config.Permissions{Allow: []string{"Bash(*)"}}},
+	})
+
+	messages := map[string]Finding{}
+	for _, f := range result.Findings {
+		messages[f.Rule] = f
+	}
+	if f := messages["no-bash-wildcard"]; f.Message != `permissions.allow must not include "Bash(*)"` || f.Hint == "" {
+		t.Errorf("generated finding = %+v", f)
+	}
+	if f := messages["corp-proxy"]; f.Message != "Work profiles must use the corporate LLM proxy" {
+		t.Errorf("custom message not used: %+v", f)
+	}
+}
+
+func TestParseRuleFile_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":     `{"rules": [{"id": "x", "path": "model", "exists": true, "bogus": 1}]}`,
+		"missing id":        `{"rules": [{"path": "model", "exists": true}]}`,
+		"no condition":      `{"rules": [{"id": "x", "path": "model"}]}`,
+		"bad path":          `{"rules": [{"id": "x", "path": "", "exists": true}]}`,
+		"bad regexp":        `{"rules": [{"id": "x", "path": "model", "matches": "("}]}`,
+		"bad severity":      `{"rules": [{"id": "x", "severity": "fatal", "path": "model", "exists": true}]}`,
+		"duplicate id":      `{"rules": [{"id": "x", "path": "model", "exists": true}, {"id": "x", "path": "env", "exists": true}]}`,
+		"bad override":      `{"severity": {"no-env": "loud"}}`,
+		"bad profile glob":  `{"rules": [{"id": "x", "profiles": ["["], "path": "model", "exists": true}]}`,
+		"not a rule object": `{"rules": [null]}`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRuleFile([]byte(data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestLoadRuleSet_LaterFilesOverride(t *testing.T) {
+	repo := `{
+  "rules": [{"id": "no-bash-wildcard", "severity": "warning", "path": "permissions.allow", "notContains": ["Bash(*)"]}],
+  "severity": {"no-env": "warning"}
+}`
+	rs := loadRules(t, orgRules, repo)
+	if len(rs.Sources) != 2 || len(rs.Registry.Rules()) != 4 {
+		t.Fatalf("sources = %v, rules = %d", rs.Sources, len(rs.Registry.Rules()))
+	}
+
+	settings := &config.Settings{Model: "opus", Permissions: &config.Permissions{Allow: []string{"Bash(*)"}}}
+	report := CheckProfile("personal", settings, "")
+	rs.Apply(report, RuleInput{Profile: "personal", Settings: settings})
+
+	got := ruleSeverities(report.Overall)
+	if got["no-bash-wildcard"] != SeverityWarning || got["no-env"] != SeverityWarning {
+		t.Errorf("repo file should override user file, got %v", got)
+	}
+}
+
+func TestLoadRuleSet_MissingFilesSkipped(t *testing.T) {
+	rs, err := LoadRuleSet(filepath.Join(t.TempDir(), "none.json"), "")
+	if err != nil || len(rs.Sources) != 0 {
+		t.Errorf("LoadRuleSet() = %+v, %v", rs, err)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(bad, []byte("{"), 0644)
+	if _, err := LoadRuleSet(bad); err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("expected error naming the file, got %v", err)
+	}
+}
+
+func TestRuleSet_ApplyOverridesAndSuppress(t *testing.T) {
+	rs := loadRules(t, orgRules)
+
+	settings := &config.Settings{
+		Model:       "opus",
+		Permissions: &config.Permissions{Allow: []string{"Bash(*)", "Read"}, Deny: []string{"WebFetch"}},
+	}
+	report := CheckProfile("personal", settings, "")
+	rs.Apply(report, RuleInput{Profile: "personal", Settings: settings})
+
+	got := ruleSeverities(report.Overall)
+	if _, ok := got["no-env"]; ok {
+		t.Error("no-env should be switched off")
+	}
+	if got["allow-and-deny"] != SeverityError || got["no-bash-wildcard"] != SeverityError {
+		t.Errorf("unexpected severities: %v", got)
+	}
+	if report.IsHealthy() || report.Permissions.IsHealthy() {
+		t.Error("overridden error should make the report unhealthy")
+	}
+
+	report.Suppress([]string{"allow-and-deny", "no-bash-wildcard"})
+	if !report.IsHealthy() || report.Suppressed != 2 {
+		t.Errorf("expected healthy report with 2 suppressed, got %+v (%d suppressed)", report.Overall, report.Suppressed)
+	}
+	if len(report.Rules.Findings) != 0 || len(report.Permissions.Findings) != 0 {
+		t.Error("suppressed findings should be removed from their sections")
+	}
+}
+
+// staticRule is a Go-defined rule used to exercise the registry
+type staticRule struct {
+	id       string
+	findings []Finding
+}
+
+func (r staticRule) ID() string                   { return r.id }
+func (r staticRule) Severity() Severity           { return SeverityWarning }
+func (r staticRule) Check(in RuleInput) []Finding { return r.findings }
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(staticRule{id: "a", findings: []Finding{{Severity: SeverityWarning, Message: "first"}}})
+	reg.Register(staticRule{id: "b"})
+	reg.Register(staticRule{id: "a", findings: []Finding{{Severity: SeverityError, Message: "replaced"}}})
+
+	if rules := reg.Rules(); len(rules) != 2 || rules[0].ID() != "a" {
+		t.Fatalf("Rules() = %v", rules)
+	}
+
+	result := reg.Run(RuleInput{})
+	if len(result.Findings) != 1 || result.Findings[0].Rule != "a" || result.Error.Message != "replaced" {
+		t.Errorf("Run() = %+v", result)
+	}
+
+	// A finding without a severity takes the rule's
+	reg.Register(staticRule{id: "b", findings: []Finding{{Message: "unset"}}})
+	result = reg.Run(RuleInput{})
+	if got := ruleSeverities(result); got["b"] != SeverityWarning {
+		t.Errorf("rule b severity = %v, want warning", got["b"])
+	}
+}
+
+func TestFindRepoRulesFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindRepoRulesFile(nested); got != "" && strings.HasPrefix(got, root) {
+		t.Errorf("unexpected rules file %q", got)
+	}
+
+	want := filepath.Join(root, RepoRulesFileName)
+	os.WriteFile(want, []byte(`{}`), 0644)
+	if got := FindRepoRulesFile(nested); got != want {
+		t.Errorf("FindRepoRulesFile() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// Rule names for hooks, helper commands and other typed settings
+const (
+	RuleInvalidSetting      = "settings-invalid"
+	RuleHookEvent           = "hook-event"
+	RuleHookType            = "hook-type"
+	RuleHookCommand         = "hook-command"
+	RuleHelperCommand       = "helper-command"
+	RulePermissionMode      = "permission-mode"
+	RuleAdditionalDirectory = "additional-directory"
+)
+
+// checkSettingsFields checks hooks, statusLine and apiKeyHelper: malformed
+// values are errors, and commands that cannot be found are warnings
+func checkSettingsFields(result *HealthResult, settings *config.Settings) {
+	invalid := func(key string, err error) {
+		result.add(SeverityError, RuleInvalidSetting, fmt.Sprintf("Setting %q is invalid: %v", key, err),
+			fmt.Sprintf("Fix it with: claudectx edit <profile>, or remove it with: claudectx unset <profile> %s", key))
+	}
+
+	hooks, err := settings.Hooks()
+	if err != nil {
+		invalid("hooks", err)
+	}
+	for _, event := range sortedHookEvents(hooks) {
+		if !slices.Contains(config.HookEvents, event) {
+			result.add(SeverityWarning, RuleHookEvent, fmt.Sprintf("Unknown hook event %q", event),
+				"Valid events: "+strings.Join(config.HookEvents, ", "))
+		}
+		for _, matcher := range hooks[event] {
+			for _, hook := range matcher.Hooks {
+				if hook.Type != "" && !slices.Contains(config.HookTypes, hook.Type) {
+					result.add(SeverityWarning, RuleHookType,
+						fmt.Sprintf("%s hook has unrecognised type %q; it is not checked", event, hook.Type),
+						"Check your Claude Code version supports it; known types: "+strings.Join(config.HookTypes, ", "))
+				}
+				if hook.Type != "command" {
+					continue
+				}
+				if program, ok := missingCommand(hook.Command); ok {
+					result.add(SeverityWarning, RuleHookCommand,
+						fmt.Sprintf("%s hook command %q not found", event, program),
+						"Install it or fix the path in the hook")
+				}
+			}
+		}
+	}
+
+	line, err := settings.StatusLine()
+	if err != nil {
+		invalid("statusLine", err)
+	} else if line != nil {
+		if program, ok := missingCommand(line.Command); ok {
+			result.add(SeverityWarning, RuleHelperCommand,
+				fmt.Sprintf("statusLine command %q not found", program), "Fix the path in statusLine.command")
+		}
+	}
+
+	helper, err := settings.APIKeyHelper()
+	if err != nil {
+		invalid("apiKeyHelper", err)
+	} else if program, ok := missingCommand(helper); ok {
+		result.add(SeverityWarning, RuleHelperCommand,
+			fmt.Sprintf("apiKeyHelper command %q not found", program), "Fix the path in apiKeyHelper")
+	}
+
+	if _, err := settings.OutputStyle(); err != nil {
+		invalid("outputStyle", err)
+	}
+	if _, err := settings.EnabledPlugins(); err != nil {
+		invalid("enabledPlugins", err)
+	}
+	if _, err := settings.IncludeCoAuthoredBy(); err != nil {
+		invalid("includeCoAuthoredBy", err)
+	}
+}
+
+// checkPermissionFields checks permissions.defaultMode and
+// additionalDirectories
+func checkPermissionFields(result *HealthResult, perms *config.Permissions) {
+	mode, err := perms.DefaultMode()
+	switch {
+	case err != nil:
+		result.add(SeverityError, RulePermissionMode, fmt.Sprintf("permissions.defaultMode is invalid: %v", err), "")
+	case mode != "" && !slices.Contains(config.PermissionModes, mode):
+		result.add(SeverityError, RulePermissionMode, fmt.Sprintf("Unknown permissions.defaultMode %q", mode),
+			"Valid modes: "+strings.Join(config.PermissionModes, ", "))
+	}
+
+	dirs, err := perms.AdditionalDirectories()
+	if err != nil {
+		result.add(SeverityError, RuleAdditionalDirectory, fmt.Sprintf("permissions.additionalDirectories is invalid: %v", err), "")
+	}
+	for _, dir := range dirs {
+		// Relative directories depend on the project Claude Code runs in
+		if !filepath.IsAbs(dir) && !strings.HasPrefix(dir, "~/") {
+			continue
+		}
+		if info, err := os.Stat(expandHome(dir)); err != nil || !info.IsDir() {
+			result.add(SeverityWarning, RuleAdditionalDirectory,
+				fmt.Sprintf("Additional directory %q does not exist", dir),
+				"Create it or remove it from permissions.additionalDirectories")
+		}
+	}
+}
+
+// missingCommand returns the program a shell command runs if it cannot be
+// found. Commands whose program comes from a variable, such as
+// "$CLAUDE_PROJECT_DIR/.claude/hooks/check.sh", are not checked.
+func missingCommand(command string) (string, bool) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "", false
+	}
+	program := strings.Trim(fields[0], `"'`)
+	if strings.ContainsAny(program, "$`") {
+		return "", false
+	}
+	if _, err := exec.LookPath(expandHome(program)); err != nil {
+		return program, true
+	}
+	return "", false
+}
+
+// sortedHookEvents returns the hook event names in sorted order
+func sortedHookEvents(hooks config.Hooks) []string {
+	events := make([]string, 0, len(hooks))
+	for event := range hooks {
+		events = append(events, event)
+	}
+	slices.Sort(events)
+	return events
+}
@@ -0,0 +1,109 @@
+package health
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// settingsFromJSON parses a settings.json document
+func settingsFromJSON(t *testing.T, data string) *config.Settings {
+	t.Helper()
+	var settings config.Settings
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		t.Fatal(err)
+	}
+	return &settings
+}
+
+func TestCheckSettings_HooksAndHelpers(t *testing.T) {
+	bin := t.TempDir()
+	script := filepath.Join(bin, "check.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := settingsFromJSON(t, `{
+		"model": "opus",
+		"env": {"X": "1"},
+		"hooks": {
+			"PreToolUse": [{"matcher": "Bash", "hooks": [
+				{"type": "command", "command": "`+script+` --strict"},
+				{"type": "command", "command": "\"$CLAUDE_PROJECT_DIR\"/.claude/hooks/fmt.sh"},
+				{"type": "prompt", "prompt": "Is this safe?"},
+				{"type": "http", "url": "https://hooks.example.com/pre"}
+			]}],
+			"PostToolUse": [{"hooks": [{"type": "command", "command": "/nonexistent/lint.sh"}]}],
+			"BeforeEverything": []
+		},
+		"statusLine": {"type": "command", "command": "/nonexistent/status.sh"},
+		"apiKeyHelper": "`+script+`"
+	}`)
+
+	got := ruleSeverities(CheckSettings(settings))
+	want := map[string]Severity{
+		RuleHookCommand:   SeverityWarning,
+		RuleHookEvent:     SeverityWarning,
+		RuleHookType:      SeverityWarning,
+		RuleHelperCommand: SeverityWarning,
+	}
+	if len(got) != len(want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	for rule, severity := range want {
+		if got[rule] != severity {
+			t.Errorf("rule %s: severity = %v, want %v", rule, got[rule], severity)
+		}
+	}
+
+	var hookFindings int
+	for _, f := range CheckSettings(settings).Findings {
+		if f.Rule == RuleHookCommand {
+			hookFindings++
+		}
+	}
+	if hookFindings != 1 {
+		t.Errorf("expected only the missing hook to be reported, got %d findings", hookFindings)
+	}
+}
+
+func TestCheckSettings_InvalidField(t *testing.T) {
+	settings := settingsFromJSON(t, `{"model": "opus", "env": {"X": "1"}, "statusLine": "status.sh"}`)
+
+	result := CheckSettings(settings)
+	if result.IsValid || ruleSeverities(result)[RuleInvalidSetting] != SeverityError {
+		t.Errorf("expected an invalid statusLine error, got %+v", result.Findings)
+	}
+}
+
+func TestCheckPermissions_ModeAndDirectories(t *testing.T) {
+	existing := t.TempDir()
+	perms := settingsFromJSON(t, `{"permissions": {
+		"defaultMode": "yolo",
+		"additionalDirectories": ["`+existing+`", "/nonexistent/shared", "../relative"]
+	}}`).Permissions
+
+	result := CheckPermissions(perms)
+	got := ruleSeverities(result)
+	if got[RulePermissionMode] != SeverityError || got[RuleAdditionalDirectory] != SeverityWarning {
+		t.Errorf("findings = %v", got)
+	}
+
+	var dirFindings int
+	for _, f := range result.Findings {
+		if f.Rule == RuleAdditionalDirectory {
+			dirFindings++
+		}
+	}
+	if dirFindings != 1 {
+		t.Errorf("expected only the missing absolute directory to be reported, got %d", dirFindings)
+	}
+
+	perms = settingsFromJSON(t, `{"permissions": {"defaultMode": "plan"}}`).Permissions
+	if result := CheckPermissions(perms); len(result.Findings) != 0 {
+		t.Errorf("expected no findings, got %+v", result.Findings)
+	}
+}
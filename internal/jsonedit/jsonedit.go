@@ -0,0 +1,470 @@
+// Package jsonedit edits JSON documents in place. Only the values that
+// change are rewritten: key order, indentation, spacing and the trailing
+// newline of everything else are left byte-for-byte as they were, so
+// dotfiles under version control get minimal diffs.
+package jsonedit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// defaultIndent is used when the document gives no indentation to copy
+const defaultIndent = "  "
+
+// Document is a JSON object being edited
+type Document struct {
+	data []byte
+	// indent is one level of the document's indentation
+	indent string
+}
+
+// member is an object member's position in the document
+type member struct {
+	key        string
+	keyStart   int // offset of the key's opening quote
+	keyEnd     int // offset just past the key's closing quote
+	valueStart int
+	valueEnd   int
+}
+
+// object is an object's position in the document
+type object struct {
+	start   int // offset of '{'
+	end     int // offset of '}'
+	members []member
+}
+
+// Parse returns a document for data, which must be a JSON object. Empty
+// data is treated as an empty object.
+func Parse(data []byte) (*Document, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}\n")
+	}
+	if !json.Valid(data) {
+		return nil, errors.New("invalid JSON")
+	}
+
+	d := &Document{data: bytes.Clone(data), indent: defaultIndent}
+	root, err := d.parseObject(d.rootStart())
+	if err != nil {
+		return nil, err
+	}
+	if indent, multiline := d.memberIndent(root); multiline && len(root.members) > 0 && indent != "" {
+		d.indent = indent
+	}
+	return d, nil
+}
+
+// ReadFile parses the JSON object in path. A missing file is an empty
+// document.
+func ReadFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Bytes returns the edited document
+func (d *Document) Bytes() []byte {
+	return bytes.Clone(d.data)
+}
+
+// Get returns the raw value at path, and whether it exists
+func (d *Document) Get(path ...string) (json.RawMessage, bool, error) {
+	start := d.rootStart()
+	for i, key := range path {
+		obj, err := d.parseObject(start)
+		if err != nil {
+			return nil, false, err
+		}
+		m, ok := obj.find(key)
+		if !ok {
+			return nil, false, nil
+		}
+		if i == len(path)-1 {
+			return json.RawMessage(bytes.Clone(d.data[m.valueStart:m.valueEnd])), true, nil
+		}
+		if d.data[m.valueStart] != '{' {
+			return nil, false, nil
+		}
+		start = m.valueStart
+	}
+	return json.RawMessage(bytes.Clone(bytes.TrimSpace(d.data))), true, nil
+}
+
+// Set makes the value at path equal to value, creating missing parent
+// objects. Where the old and new values are both objects the members are
+// updated one by one: unchanged members keep their formatting and
+// position, removed members are deleted and new members are appended.
+// Values that are already equal are left untouched. An empty path sets the
+// whole document, which must then be an object.
+func (d *Document) Set(value any, path ...string) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	if len(path) == 0 {
+		if !isObject(raw) {
+			return errors.New("document root must be an object")
+		}
+		return d.mergeObject(d.rootStart(), raw)
+	}
+
+	start := d.rootStart()
+	for i, key := range path[:len(path)-1] {
+		obj, err := d.parseObject(start)
+		if err != nil {
+			return err
+		}
+		m, ok := obj.find(key)
+		if !ok {
+			// Build the missing objects around the value
+			for j := len(path) - 1; j > i; j-- {
+				raw, err = json.Marshal(map[string]json.RawMessage{path[j]: raw})
+				if err != nil {
+					return err
+				}
+			}
+			return d.setMember(start, key, raw)
+		}
+		if d.data[m.valueStart] != '{' {
+			return fmt.Errorf("%q is not an object", key)
+		}
+		start = m.valueStart
+	}
+	return d.setMember(start, path[len(path)-1], raw)
+}
+
+// Delete removes the member at path. Missing members are ignored.
+func (d *Document) Delete(path ...string) error {
+	if len(path) == 0 {
+		return errors.New("cannot delete the document root")
+	}
+
+	start := d.rootStart()
+	for _, key := range path[:len(path)-1] {
+		obj, err := d.parseObject(start)
+		if err != nil {
+			return err
+		}
+		m, ok := obj.find(key)
+		if !ok || d.data[m.valueStart] != '{' {
+			return nil
+		}
+		start = m.valueStart
+	}
+	return d.deleteMember(start, path[len(path)-1])
+}
+
+// setMember sets one member of the object starting at start
+func (d *Document) setMember(start int, key string, raw json.RawMessage) error {
+	obj, err := d.parseObject(start)
+	if err != nil {
+		return err
+	}
+	indent, multiline := d.memberIndent(obj)
+
+	m, ok := obj.find(key)
+	if !ok {
+		return d.insertMember(obj, key, raw, indent, multiline)
+	}
+
+	existing := d.data[m.valueStart:m.valueEnd]
+	if equalJSON(existing, raw) {
+		return nil
+	}
+	if isObject(existing) && isObject(raw) {
+		return d.mergeObject(m.valueStart, raw)
+	}
+	d.splice(m.valueStart, m.valueEnd, d.format(raw, indent, multiline))
+	return nil
+}
+
+// mergeObject updates the object starting at start to have exactly the
+// members of raw
+func (d *Document) mergeObject(start int, raw json.RawMessage) error {
+	keys, values, err := objectMembers(raw)
+	if err != nil {
+		return err
+	}
+
+	obj, err := d.parseObject(start)
+	if err != nil {
+		return err
+	}
+	for _, m := range obj.members {
+		if _, keep := values[m.key]; !keep {
+			if err := d.deleteMember(start, m.key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if err := d.setMember(start, key, values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertMember appends a member to obj
+func (d *Document) insertMember(obj object, key string, raw json.RawMessage, indent string, multiline bool) error {
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	separator := ": "
+	if len(obj.members) > 0 {
+		first := obj.members[0]
+		separator = string(d.data[first.keyEnd:first.valueStart])
+	}
+	entry := string(keyJSON) + separator + string(d.format(raw, indent, multiline))
+
+	switch {
+	case len(obj.members) == 0:
+		// Replace whatever whitespace is inside the braces
+		closing := lineIndent(d.data, obj.start)
+		d.splice(obj.start+1, obj.end, []byte("\n"+indent+entry+"\n"+closing))
+	case multiline:
+		last := obj.members[len(obj.members)-1]
+		d.splice(last.valueEnd, last.valueEnd, []byte(",\n"+indent+entry))
+	default:
+		last := obj.members[len(obj.members)-1]
+		d.splice(last.valueEnd, last.valueEnd, []byte(", "+entry))
+	}
+	return nil
+}
+
+// deleteMember removes a member, with the comma and whitespace that
+// separate it from its neighbours, from the object starting at start
+func (d *Document) deleteMember(start int, key string) error {
+	obj, err := d.parseObject(start)
+	if err != nil {
+		return err
+	}
+
+	for i, m := range obj.members {
+		if m.key != key {
+			continue
+		}
+		switch {
+		case len(obj.members) == 1:
+			d.splice(obj.start+1, obj.end, nil)
+		case i > 0:
+			d.splice(obj.members[i-1].valueEnd, m.valueEnd, nil)
+		default:
+			d.splice(m.keyStart, obj.members[1].keyStart, nil)
+		}
+		return nil
+	}
+	return nil
+}
+
+// memberIndent returns the indentation of obj's members, and whether they
+// are on their own lines. Empty objects get one level more than the line
+// they start on.
+func (d *Document) memberIndent(obj object) (string, bool) {
+	if len(obj.members) == 0 {
+		return lineIndent(d.data, obj.start) + d.indent, true
+	}
+	first := obj.members[0]
+	if !bytes.Contains(d.data[obj.start:first.keyStart], []byte("\n")) {
+		return "", false
+	}
+	return lineIndent(d.data, first.keyStart), true
+}
+
+// format renders raw for a member at the given indentation
+func (d *Document) format(raw json.RawMessage, indent string, multiline bool) []byte {
+	var buf bytes.Buffer
+	if multiline {
+		if err := json.Indent(&buf, raw, indent, d.indent); err == nil {
+			return buf.Bytes()
+		}
+	} else if err := json.Compact(&buf, raw); err == nil {
+		return buf.Bytes()
+	}
+	return raw
+}
+
+// splice replaces data[from:to] with replacement
+func (d *Document) splice(from, to int, replacement []byte) {
+	out := make([]byte, 0, len(d.data)-(to-from)+len(replacement))
+	out = append(out, d.data[:from]...)
+	out = append(out, replacement...)
+	out = append(out, d.data[to:]...)
+	d.data = out
+}
+
+// rootStart returns the offset of the document's opening brace
+func (d *Document) rootStart() int {
+	return skipSpace(d.data, 0)
+}
+
+// parseObject scans the object starting at start
+func (d *Document) parseObject(start int) (object, error) {
+	data := d.data
+	if start >= len(data) || data[start] != '{' {
+		return object{}, errors.New("not a JSON object")
+	}
+
+	obj := object{start: start}
+	pos := skipSpace(data, start+1)
+	if data[pos] == '}' {
+		obj.end = pos
+		return obj, nil
+	}
+
+	for {
+		m := member{keyStart: pos}
+		m.keyEnd = skipString(data, pos)
+		if err := json.Unmarshal(data[m.keyStart:m.keyEnd], &m.key); err != nil {
+			return object{}, fmt.Errorf("invalid key: %w", err)
+		}
+		pos = skipSpace(data, m.keyEnd) + 1 // past ':'
+		m.valueStart = skipSpace(data, pos)
+		m.valueEnd = skipValue(data, m.valueStart)
+		obj.members = append(obj.members, m)
+
+		pos = skipSpace(data, m.valueEnd)
+		if data[pos] == '}' {
+			obj.end = pos
+			return obj, nil
+		}
+		pos = skipSpace(data, pos+1) // past ','
+	}
+}
+
+// find returns the member with the given key
+func (o object) find(key string) (member, bool) {
+	for _, m := range o.members {
+		if m.key == key {
+			return m, true
+		}
+	}
+	return member{}, false
+}
+
+// objectMembers returns the keys of a JSON object in document order, and
+// their values
+func objectMembers(raw json.RawMessage) ([]string, map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	var keys []string
+	values := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		if _, seen := values[key]; !seen {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	return keys, values, nil
+}
+
+// equalJSON reports whether two JSON values are identical apart from
+// whitespace
+func equalJSON(a, b []byte) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// isObject reports whether raw is a JSON object
+func isObject(raw []byte) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// lineIndent returns the leading whitespace of the line containing pos
+func lineIndent(data []byte, pos int) string {
+	lineStart := bytes.LastIndexByte(data[:pos], '\n') + 1
+	end := lineStart
+	for end < pos && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[lineStart:end])
+}
+
+// skipSpace returns the offset of the first non-whitespace byte at or
+// after pos
+func skipSpace(data []byte, pos int) int {
+	for pos < len(data) {
+		switch data[pos] {
+		case ' ', '\t', '\n', '\r':
+			pos++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// skipString returns the offset just past the string starting at pos
+func skipString(data []byte, pos int) int {
+	for pos++; pos < len(data); pos++ {
+		switch data[pos] {
+		case '\\':
+			pos++
+		case '"':
+			return pos + 1
+		}
+	}
+	return pos
+}
+
+// skipValue returns the offset just past the value starting at pos. The
+// document has already been validated, so values are well formed.
+func skipValue(data []byte, pos int) int {
+	switch data[pos] {
+	case '"':
+		return skipString(data, pos)
+	case '{', '[':
+		depth := 0
+		for pos < len(data) {
+			switch data[pos] {
+			case '"':
+				pos = skipString(data, pos)
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return pos + 1
+				}
+			}
+			pos++
+		}
+		return pos
+	default:
+		for pos < len(data) {
+			switch data[pos] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				return pos
+			}
+			pos++
+		}
+		return pos
+	}
+}
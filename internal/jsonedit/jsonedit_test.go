@@ -0,0 +1,185 @@
+package jsonedit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// claudeJSON mimics ~/.claude.json: unsorted keys, nested objects and a
+// trailing newline
+const claudeJSON = `{
+  "numStartups": 12,
+  "installMethod": "native",
+  "mcpServers": {
+    "github": {
+      "command": "gh-mcp"
+    }
+  },
+  "projects": {
+    "/src/app": {
+      "allowedTools": [],
+      "history": [{"display": "fix the {braces} \"here\""}]
+    }
+  },
+  "autoUpdates": false
+}
+`
+
+func TestSet_ReplacesOnlyTheValue(t *testing.T) {
+	doc, err := Parse([]byte(claudeJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	servers := map[string]any{
+		"github": map[string]any{"command": "gh-mcp"},
+		"linear": map[string]any{"type": "http", "url": "https://mcp.linear.app/mcp"},
+	}
+	if err := doc.Set(servers, "mcpServers"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "numStartups": 12,
+  "installMethod": "native",
+  "mcpServers": {
+    "github": {
+      "command": "gh-mcp"
+    },
+    "linear": {
+      "type": "http",
+      "url": "https://mcp.linear.app/mcp"
+    }
+  },
+  "projects": {
+    "/src/app": {
+      "allowedTools": [],
+      "history": [{"display": "fix the {braces} \"here\""}]
+    }
+  },
+  "autoUpdates": false
+}
+`
+	if got := string(doc.Bytes()); got != want {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSet_UnchangedValueKeepsFormatting(t *testing.T) {
+	input := `{"b": 1,   "a": {"x": [1,2,3]}}`
+	doc, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set(map[string]any{"a": map[string]any{"x": []int{1, 2, 3}}, "b": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(doc.Bytes()); got != input {
+		t.Errorf("Bytes() = %s, want unchanged %s", got, input)
+	}
+}
+
+func TestSet_RootMergeDeletesAndAppends(t *testing.T) {
+	input := "{\n    \"model\": \"opus\",\n    \"env\": {\"A\": \"1\"},\n    \"effortLevel\": \"high\"\n}\n"
+	doc, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = doc.Set(map[string]any{
+		"model":       "sonnet",
+		"effortLevel": "high",
+		"permissions": map[string]any{"allow": []string{"Read"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Four-space indentation is copied, env is removed and permissions added
+	want := "{\n    \"model\": \"sonnet\",\n    \"effortLevel\": \"high\",\n    \"permissions\": {\n        \"allow\": [\n            \"Read\"\n        ]\n    }\n}\n"
+	if got := string(doc.Bytes()); got != want {
+		t.Errorf("Bytes() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSet_CreatesParents(t *testing.T) {
+	doc, err := Parse([]byte("{\n  \"projects\": {}\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set(map[string]any{"db": map[string]any{"command": "db"}}, "projects", "/src/a.b", "mcpServers"); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, ok, err := doc.Get("projects", "/src/a.b", "mcpServers", "db")
+	if err != nil || !ok || string(raw) != "{\n          \"command\": \"db\"\n        }" {
+		t.Errorf("Get() = %s, %v, %v\ndocument:\n%s", raw, ok, err, doc.Bytes())
+	}
+
+	if err := doc.Set(1, "projects", "/src/a.b", "mcpServers", "db", "command", "x"); err == nil {
+		t.Error("expected error setting a member of a non-object")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  []string
+		want  string
+	}{
+		{"first", "{\n  \"a\": 1,\n  \"b\": 2\n}\n", []string{"a"}, "{\n  \"b\": 2\n}\n"},
+		{"middle", "{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3\n}\n", []string{"b"}, "{\n  \"a\": 1,\n  \"c\": 3\n}\n"},
+		{"last", "{\n  \"a\": 1,\n  \"b\": 2\n}\n", []string{"b"}, "{\n  \"a\": 1\n}\n"},
+		{"only", "{\n  \"a\": 1\n}\n", []string{"a"}, "{}\n"},
+		{"nested", `{"a": {"b": 1, "c": 2}}`, []string{"a", "c"}, `{"a": {"b": 1}}`},
+		{"missing", `{"a": 1}`, []string{"x", "y"}, `{"a": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := doc.Delete(tt.path...); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, input := range []string{"not json", "[1, 2]", `{"a": }`} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+}
+
+func TestReadFile_NewDocument(t *testing.T) {
+	doc, err := ReadFile(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set(map[string]string{"K": "v"}, "env"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := doc.Bytes()
+	if string(got) != "{\n  \"env\": {\n    \"K\": \"v\"\n  }\n}\n" {
+		t.Errorf("Bytes() = %q", got)
+	}
+	if !json.Valid(got) {
+		t.Error("result is not valid JSON")
+	}
+
+	// Unreadable paths are errors
+	if _, err := ReadFile(os.DevNull + "/x"); err == nil {
+		t.Error("expected error for an unreadable path")
+	}
+}
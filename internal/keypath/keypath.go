@@ -0,0 +1,210 @@
+package keypath
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Parse splits a dotted key path such as "env.ANTHROPIC_BASE_URL" or
+// "mcp.github.args" into its segments. A backslash escapes a literal dot
+// (e.g. "mcp.my\.server.url").
+func Parse(path string) ([]string, error) {
+	if path == "" {
+		return nil, errors.New("key path cannot be empty")
+	}
+
+	var segments []string
+	var current strings.Builder
+	escaped := false
+	for _, r := range path {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '.':
+			segments = append(segments, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	segments = append(segments, current.String())
+
+	for _, seg := range segments {
+		if seg == "" {
+			return nil, fmt.Errorf("invalid key path %q: empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+// Get returns the value at path within a decoded JSON document
+func Get(doc any, path []string) (any, bool) {
+	node := doc
+	for _, seg := range path {
+		switch v := node.(type) {
+		case map[string]any:
+			next, ok := v[seg]
+			if !ok {
+				return nil, false
+			}
+			node = next
+		case []any:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			node = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// Set stores value at path, creating intermediate objects as needed
+func Set(doc map[string]any, path []string, value any) error {
+	return update(doc, path, func(any, bool) (any, bool, error) {
+		return value, true, nil
+	})
+}
+
+// Delete removes the value at path. It reports whether anything was removed.
+func Delete(doc map[string]any, path []string) (bool, error) {
+	removed := false
+	err := update(doc, path, func(_ any, exists bool) (any, bool, error) {
+		removed = exists
+		return nil, false, nil
+	})
+	return removed, err
+}
+
+// Append adds value to the list at path, creating the list if needed. Values
+// already present are not added twice.
+func Append(doc map[string]any, path []string, value any) error {
+	return update(doc, path, func(current any, exists bool) (any, bool, error) {
+		if !exists || current == nil {
+			return []any{value}, true, nil
+		}
+		list, ok := current.([]any)
+		if !ok {
+			return nil, false, fmt.Errorf("%s is not a list", strings.Join(path, "."))
+		}
+		for _, item := range list {
+			if reflect.DeepEqual(item, value) {
+				return list, true, nil
+			}
+		}
+		return append(list, value), true, nil
+	})
+}
+
+// Remove deletes every occurrence of value from the list at path. It reports
+// whether anything was removed.
+func Remove(doc map[string]any, path []string, value any) (bool, error) {
+	removed := false
+	err := update(doc, path, func(current any, exists bool) (any, bool, error) {
+		if !exists {
+			return nil, false, nil
+		}
+		list, ok := current.([]any)
+		if !ok {
+			return nil, false, fmt.Errorf("%s is not a list", strings.Join(path, "."))
+		}
+		kept := make([]any, 0, len(list))
+		for _, item := range list {
+			if reflect.DeepEqual(item, value) {
+				removed = true
+				continue
+			}
+			kept = append(kept, item)
+		}
+		return kept, true, nil
+	})
+	return removed, err
+}
+
+// updateFunc receives the current value at a path and whether it exists,
+// and returns the replacement value and whether to keep the key at all
+type updateFunc func(current any, exists bool) (any, bool, error)
+
+// update applies fn to the value at path within doc
+func update(doc map[string]any, path []string, fn updateFunc) error {
+	if len(path) == 0 {
+		return errors.New("key path cannot be empty")
+	}
+	_, _, err := updateNode(doc, path, fn, path)
+	return err
+}
+
+// updateNode walks one level of the document, returning the (possibly new)
+// node and whether it should be kept
+func updateNode(node any, path []string, fn updateFunc, full []string) (any, bool, error) {
+	seg := path[0]
+	rest := path[1:]
+
+	switch v := node.(type) {
+	case map[string]any:
+		current, exists := v[seg]
+		var next any
+		var keep bool
+		var err error
+		if len(rest) == 0 {
+			next, keep, err = fn(current, exists)
+		} else {
+			if !exists || current == nil {
+				current = map[string]any{}
+			}
+			next, keep, err = updateNode(current, rest, fn, full)
+			// Don't create empty parents for a no-op delete
+			keep = keep && (exists || !isEmptyMap(next))
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if keep {
+			v[seg] = next
+		} else {
+			delete(v, seg)
+		}
+		return v, true, nil
+
+	case []any:
+		idx, err := strconv.Atoi(seg)
+		if err != nil || idx < 0 || idx >= len(v) {
+			return nil, false, fmt.Errorf("invalid index %q in %s", seg, strings.Join(full, "."))
+		}
+		var next any
+		var keep bool
+		if len(rest) == 0 {
+			next, keep, err = fn(v[idx], true)
+		} else {
+			next, keep, err = updateNode(v[idx], rest, fn, full)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if keep {
+			v[idx] = next
+			return v, true, nil
+		}
+		return append(v[:idx:idx], v[idx+1:]...), true, nil
+
+	default:
+		return nil, false, fmt.Errorf("cannot descend into %s: not an object", strings.Join(full[:len(full)-len(path)], "."))
+	}
+}
+
+// isEmptyMap reports whether v is an object with no keys
+func isEmptyMap(v any) bool {
+	m, ok := v.(map[string]any)
+	return ok && len(m) == 0
+}
@@ -0,0 +1,133 @@
+package keypath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatalf("failed to decode %s: %v", s, err)
+	}
+	return doc
+}
+
+func encode(t *testing.T, doc map[string]any) string {
+	t.Helper()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	return string(data)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "model", want: []string{"model"}},
+		{path: "env.ANTHROPIC_BASE_URL", want: []string{"env", "ANTHROPIC_BASE_URL"}},
+		{path: `mcp.my\.server.url`, want: []string{"mcp", "my.server", "url"}},
+		{path: "", wantErr: true},
+		{path: "env..FOO", wantErr: true},
+		{path: "env.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := Parse(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	doc := decode(t, `{"env":{"FOO":"bar"},"permissions":{"allow":["Read","Edit"]}}`)
+
+	if v, ok := Get(doc, []string{"env", "FOO"}); !ok || v != "bar" {
+		t.Errorf("Get(env.FOO) = %v, %v", v, ok)
+	}
+	if v, ok := Get(doc, []string{"permissions", "allow", "1"}); !ok || v != "Edit" {
+		t.Errorf("Get(permissions.allow.1) = %v, %v", v, ok)
+	}
+	if _, ok := Get(doc, []string{"env", "MISSING"}); ok {
+		t.Error("expected missing key to report not found")
+	}
+	if _, ok := Get(doc, []string{"env", "FOO", "deeper"}); ok {
+		t.Error("expected descending into a string to report not found")
+	}
+}
+
+func TestSet(t *testing.T) {
+	doc := decode(t, `{"model":"sonnet"}`)
+
+	if err := Set(doc, []string{"model"}, "opus"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := Set(doc, []string{"env", "FOO"}, "bar"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, want := encode(t, doc), `{"env":{"FOO":"bar"},"model":"opus"}`; got != want {
+		t.Errorf("doc = %s, want %s", got, want)
+	}
+
+	if err := Set(doc, []string{"model", "nested"}, "x"); err == nil {
+		t.Error("expected error when descending into a string")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	doc := decode(t, `{"env":{"FOO":"bar","BAZ":"qux"},"model":"opus"}`)
+
+	removed, err := Delete(doc, []string{"env", "FOO"})
+	if err != nil || !removed {
+		t.Fatalf("Delete(env.FOO) = %v, %v", removed, err)
+	}
+	removed, err = Delete(doc, []string{"hooks", "PreToolUse"})
+	if err != nil || removed {
+		t.Fatalf("Delete(missing) = %v, %v", removed, err)
+	}
+	if got, want := encode(t, doc), `{"env":{"BAZ":"qux"},"model":"opus"}`; got != want {
+		t.Errorf("doc = %s, want %s", got, want)
+	}
+}
+
+func TestAppendAndRemove(t *testing.T) {
+	doc := decode(t, `{"permissions":{"allow":["Read"]}}`)
+	path := []string{"permissions", "allow"}
+
+	if err := Append(doc, path, "Bash(git *)"); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := Append(doc, path, "Bash(git *)"); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := Append(doc, []string{"permissions", "deny"}, "WebFetch"); err != nil {
+		t.Fatalf("Append to new list failed: %v", err)
+	}
+	if got, want := encode(t, doc), `{"permissions":{"allow":["Read","Bash(git *)"],"deny":["WebFetch"]}}`; got != want {
+		t.Errorf("doc = %s, want %s", got, want)
+	}
+
+	removed, err := Remove(doc, path, "Read")
+	if err != nil || !removed {
+		t.Fatalf("Remove = %v, %v", removed, err)
+	}
+	if got, want := encode(t, doc), `{"permissions":{"allow":["Bash(git *)"],"deny":["WebFetch"]}}`; got != want {
+		t.Errorf("doc = %s, want %s", got, want)
+	}
+
+	if err := Append(doc, []string{"permissions"}, "x"); err == nil {
+		t.Error("expected error appending to an object")
+	}
+}
@@ -0,0 +1,91 @@
+package mcpconfig
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The accessors below give typed access to server fields that are kept in
+// extras. Getters decode the stored JSON on each call and never modify it,
+// so the fields round-trip losslessly until a setter replaces them. Setters
+// with a zero value remove the key.
+
+// OAuthConfig holds the pre-registered OAuth client a remote server
+// authenticates with. The client secret is kept in the system keychain by
+// Claude Code, never in the config file.
+type OAuthConfig struct {
+	ClientID     string `json:"clientId,omitempty"`
+	CallbackPort int    `json:"callbackPort,omitempty"`
+}
+
+// Cwd returns the working directory a stdio server is started in
+func (s MCPServer) Cwd() (string, error) {
+	var cwd string
+	return cwd, getField(s.extras, "cwd", &cwd)
+}
+
+// SetCwd sets the working directory a stdio server is started in
+func (s *MCPServer) SetCwd(cwd string) error {
+	return s.setField("cwd", cwd, cwd == "")
+}
+
+// Timeout returns the server's request timeout in milliseconds (0 if unset)
+func (s MCPServer) Timeout() (int, error) {
+	var timeout int
+	return timeout, getField(s.extras, "timeout", &timeout)
+}
+
+// SetTimeout sets the server's request timeout in milliseconds
+func (s *MCPServer) SetTimeout(timeout int) error {
+	return s.setField("timeout", timeout, timeout == 0)
+}
+
+// HeadersHelper returns the command whose JSON output supplies extra
+// headers for a remote server
+func (s MCPServer) HeadersHelper() (string, error) {
+	var helper string
+	return helper, getField(s.extras, "headersHelper", &helper)
+}
+
+// SetHeadersHelper sets the headersHelper command
+func (s *MCPServer) SetHeadersHelper(helper string) error {
+	return s.setField("headersHelper", helper, helper == "")
+}
+
+// OAuth returns the server's OAuth client settings
+func (s MCPServer) OAuth() (*OAuthConfig, error) {
+	var oauth *OAuthConfig
+	return oauth, getField(s.extras, "oauth", &oauth)
+}
+
+// SetOAuth sets the server's OAuth client settings
+func (s *MCPServer) SetOAuth(oauth *OAuthConfig) error {
+	return s.setField("oauth", oauth, oauth == nil)
+}
+
+// getField decodes extras[key] into v, leaving v unchanged if the key is
+// absent or null
+func getField(extras map[string]json.RawMessage, key string, v any) error {
+	raw, ok := extras[key]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", key, err)
+	}
+	return nil
+}
+
+// setField stores v as extras[key], or removes the key when empty is true
+func (s *MCPServer) setField(key string, v any, empty bool) error {
+	if empty {
+		s.DeleteExtra(key)
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", key, err)
+	}
+	s.SetExtra(key, b)
+	return nil
+}
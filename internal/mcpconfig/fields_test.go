@@ -0,0 +1,174 @@
+package mcpconfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// realWorldJSON is a ~/.claude.json with servers as written by
+// "claude mcp add", including fields claudectx does not model
+const realWorldJSON = `{
+  "numStartups": 12,
+  "mcpServers": {
+    "filesystem": {
+      "type": "stdio",
+      "command": "npx",
+      "args": ["-y", "@modelcontextprotocol/server-filesystem", "/Users/me/src"],
+      "env": {},
+      "cwd": "/Users/me/src",
+      "timeout": 60000
+    },
+    "sentry": {
+      "type": "http",
+      "url": "https://mcp.sentry.dev/mcp",
+      "oauth": {"clientId": "claude-code-123", "callbackPort": 8765, "scopes": ["org:read"]}
+    },
+    "internal-api": {
+      "type": "sse",
+      "url": "https://mcp.internal.example.com/sse",
+      "headers": {"X-Team": "platform"},
+      "headersHelper": "~/bin/mcp-token --json",
+      "alwaysAllow": ["search"],
+      "disabled": false
+    }
+  }
+}`
+
+func TestMCPServer_RoundTripPreservesUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude.json")
+	if err := os.WriteFile(path, []byte(realWorldJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	servers, err := LoadMCPServers(path)
+	if err != nil {
+		t.Fatalf("LoadMCPServers failed: %v", err)
+	}
+	if err := SaveMCPServers(path, servers); err != nil {
+		t.Fatalf("SaveMCPServers failed: %v", err)
+	}
+
+	var before, after map[string]any
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal([]byte(realWorldJSON), &before); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &after); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the empty env is dropped, as before
+	delete(before["mcpServers"].(map[string]any)["filesystem"].(map[string]any), "env")
+	beforeJSON, _ := json.Marshal(before)
+	afterJSON, _ := json.Marshal(after)
+	if string(beforeJSON) != string(afterJSON) {
+		t.Errorf("round trip changed the file:\nbefore %s\nafter  %s", beforeJSON, afterJSON)
+	}
+}
+
+func TestMCPServer_Fields(t *testing.T) {
+	var servers MCPServers
+	if err := json.Unmarshal([]byte(realWorldJSON), &struct {
+		MCPServers *MCPServers `json:"mcpServers"`
+	}{&servers}); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := servers["filesystem"]
+	if cwd, _ := fs.Cwd(); cwd != "/Users/me/src" {
+		t.Errorf("Cwd() = %q", cwd)
+	}
+	if timeout, _ := fs.Timeout(); timeout != 60000 {
+		t.Errorf("Timeout() = %d", timeout)
+	}
+
+	oauth, err := servers["sentry"].OAuth()
+	if err != nil || oauth == nil || oauth.ClientID != "claude-code-123" || oauth.CallbackPort != 8765 {
+		t.Errorf("OAuth() = %+v, %v", oauth, err)
+	}
+
+	if helper, _ := servers["internal-api"].HeadersHelper(); helper != "~/bin/mcp-token --json" {
+		t.Errorf("HeadersHelper() = %q", helper)
+	}
+
+	// Unset fields are zero values
+	if oauth, err := fs.OAuth(); oauth != nil || err != nil {
+		t.Errorf("OAuth() on stdio server = %+v, %v", oauth, err)
+	}
+}
+
+func TestMCPServer_SetFields(t *testing.T) {
+	server := MCPServer{Type: "http", URL: "https://mcp.example.com/mcp"}
+	if err := server.SetTimeout(30000); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetOAuth(&OAuthConfig{ClientID: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetHeadersHelper("get-headers"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"headersHelper":"get-headers","oauth":{"clientId":"abc"},"timeout":30000,"type":"http","url":"https://mcp.example.com/mcp"}`
+	if string(data) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", data, want)
+	}
+
+	// Zero values remove the keys, and the usual field order comes back
+	server.SetTimeout(0)
+	server.SetOAuth(nil)
+	server.SetHeadersHelper("")
+	if extras := server.Extras(); len(extras) != 0 {
+		t.Errorf("expected no extras, got %v", extras)
+	}
+	data, _ = json.Marshal(server)
+	if string(data) != `{"type":"http","url":"https://mcp.example.com/mcp"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+}
+
+func TestMCPServer_SetExtraDoesNotAffectCopies(t *testing.T) {
+	var original MCPServer
+	if err := json.Unmarshal([]byte(`{"command":"srv","cwd":"/a"}`), &original); err != nil {
+		t.Fatal(err)
+	}
+
+	servers := MCPServers{"srv": original}
+	copied := servers["srv"]
+	if err := copied.SetCwd("/b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cwd, _ := servers["srv"].Cwd(); cwd != "/a" {
+		t.Errorf("original cwd = %q, want /a", cwd)
+	}
+	if cwd, _ := copied.Cwd(); cwd != "/b" {
+		t.Errorf("copied cwd = %q, want /b", cwd)
+	}
+}
+
+func TestMCPServer_WrongType(t *testing.T) {
+	var server MCPServer
+	if err := json.Unmarshal([]byte(`{"command":"srv","timeout":"30s","oauth":"yes"}`), &server); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Timeout(); err == nil || !strings.Contains(err.Error(), "parsing timeout") {
+		t.Errorf("Timeout() error = %v", err)
+	}
+	if _, err := server.OAuth(); err == nil {
+		t.Error("expected an error for a non-object oauth")
+	}
+
+	// Fields of the wrong type still round-trip unchanged
+	data, _ := json.Marshal(server)
+	if !strings.Contains(string(data), `"timeout":"30s"`) {
+		t.Errorf("Marshal() = %s", data)
+	}
+}
@@ -0,0 +1,274 @@
+package mcpconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MCP configuration formats understood by Import and Export
+const (
+	FormatAuto    = "auto"
+	FormatClaude  = "claude"
+	FormatDesktop = "desktop"
+	FormatCursor  = "cursor"
+	FormatVSCode  = "vscode"
+)
+
+// Formats lists the concrete formats, for help and error messages
+var Formats = []string{FormatClaude, FormatDesktop, FormatCursor, FormatVSCode}
+
+// ImportResult holds the servers read from another tool's configuration
+type ImportResult struct {
+	Format  string
+	Servers MCPServers
+	// Warnings describe anything that could not be converted exactly
+	Warnings []string
+}
+
+// foreignConfig is the top level of any supported file. VS Code keeps its
+// servers under "servers"; the others use "mcpServers". Fields other tools
+// add, such as envFile, end up in each server's extras.
+type foreignConfig struct {
+	MCPServers MCPServers `json:"mcpServers"`
+	Servers    MCPServers `json:"servers"`
+}
+
+var (
+	// vscodeVariable matches ${input:id} and ${env:NAME} references
+	vscodeVariable = regexp.MustCompile(`\$\{(input|env):([^}]+)\}`)
+	// envVariable matches the ${NAME} references Claude Code expands
+	envVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// DetectFormat works out which tool a configuration file belongs to from
+// its name and top-level keys
+func DetectFormat(path string, data []byte) (string, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if _, ok := top["servers"]; ok {
+		return FormatVSCode, nil
+	}
+	if _, ok := top["mcpServers"]; !ok {
+		return "", fmt.Errorf("%s has no \"mcpServers\" or \"servers\" key", path)
+	}
+
+	slashPath := filepath.ToSlash(path)
+	switch {
+	case filepath.Base(path) == "claude_desktop_config.json":
+		return FormatDesktop, nil
+	case strings.Contains(slashPath, ".cursor/"):
+		return FormatCursor, nil
+	}
+	return FormatClaude, nil
+}
+
+// Import converts another tool's MCP configuration into MCPServers. With
+// FormatAuto (or "") the format is detected from the path and content.
+func Import(path string, data []byte, format string) (*ImportResult, error) {
+	if format == "" || format == FormatAuto {
+		detected, err := DetectFormat(path, data)
+		if err != nil {
+			return nil, err
+		}
+		format = detected
+	}
+	if !isFormat(format) {
+		return nil, fmt.Errorf("unknown MCP format %q (valid: %s)", format, strings.Join(Formats, ", "))
+	}
+
+	var cfg foreignConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	source := cfg.MCPServers
+	if format == FormatVSCode {
+		source = cfg.Servers
+	}
+
+	result := &ImportResult{Format: format, Servers: make(MCPServers)}
+	for _, name := range sortedNames(source) {
+		server, warnings := convertForeignServer(name, source[name], format)
+		result.Servers[name] = server
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+	return result, nil
+}
+
+// convertForeignServer maps one server into Claude Code's form
+func convertForeignServer(name string, in MCPServer, format string) (MCPServer, []string) {
+	var warnings []string
+	warn := func(msg string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf("%s: ", name)+fmt.Sprintf(msg, args...))
+	}
+
+	server := in
+	server.Type = ""
+
+	switch strings.ToLower(in.Type) {
+	case "", "stdio":
+		if in.URL != "" && in.Command == "" {
+			server.Type = "http"
+		}
+	case "http", "streamable-http", "streamablehttp":
+		server.Type = "http"
+	case "sse":
+		server.Type = "sse"
+	default:
+		warn("unknown transport %q, imported as given", in.Type)
+		server.Type = in.Type
+	}
+
+	if envFile, ok := in.Extras()["envFile"]; ok {
+		warn("envFile %s is not supported by Claude Code and was dropped", envFile)
+		server.DeleteExtra("envFile")
+	}
+
+	if format == FormatVSCode {
+		inputs := make(map[string]bool)
+		convert := func(value string) string {
+			return vscodeVariable.ReplaceAllStringFunc(value, func(ref string) string {
+				m := vscodeVariable.FindStringSubmatch(ref)
+				variable := m[2]
+				if m[1] == "input" {
+					variable = inputVariable(m[2])
+					if !inputs[m[2]] {
+						inputs[m[2]] = true
+						warn("input %q is now read from $%s; set it before starting Claude Code", m[2], variable)
+					}
+				}
+				return "${" + variable + "}"
+			})
+		}
+		server.Command = convert(server.Command)
+		server.URL = convert(server.URL)
+		server.Args = convertList(server.Args, convert)
+		server.Env = convertMap(server.Env, convert)
+		server.Headers = convertMap(server.Headers, convert)
+	}
+
+	if strings.Contains(in.Command+strings.Join(in.Args, " "), "${workspaceFolder}") {
+		warn("${workspaceFolder} is not expanded by Claude Code; replace it with the project path")
+	}
+
+	return server, warnings
+}
+
+// Export converts servers into another tool's configuration file. Servers
+// the format cannot express are left out and reported as warnings.
+func Export(servers MCPServers, format string) ([]byte, []string, error) {
+	if !isFormat(format) {
+		return nil, nil, fmt.Errorf("unknown MCP format %q (valid: %s)", format, strings.Join(Formats, ", "))
+	}
+
+	var warnings []string
+	out := make(MCPServers, len(servers))
+	for _, name := range sortedNames(servers) {
+		server := servers[name]
+		remote := server.Transport() != "stdio"
+
+		switch format {
+		case FormatDesktop:
+			if remote {
+				warnings = append(warnings, fmt.Sprintf("%s: Claude Desktop only supports stdio servers in its config file; skipped", name))
+				continue
+			}
+			server.Type = ""
+		case FormatCursor:
+			// Cursor picks the transport from the presence of url
+			if server.Transport() != "sse" {
+				server.Type = ""
+			}
+		case FormatVSCode:
+			server.Type = server.Transport()
+			convert := func(value string) string {
+				return envVariable.ReplaceAllString(value, "$${env:$1}")
+			}
+			server.Command = convert(server.Command)
+			server.URL = convert(server.URL)
+			server.Args = convertList(server.Args, convert)
+			server.Env = convertMap(server.Env, convert)
+			server.Headers = convertMap(server.Headers, convert)
+		}
+		out[name] = server
+	}
+
+	key := "mcpServers"
+	if format == FormatVSCode {
+		key = "servers"
+	}
+	data, err := json.MarshalIndent(map[string]any{key: out}, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal MCP config: %w", err)
+	}
+	return append(data, '\n'), warnings, nil
+}
+
+// inputVariable turns a VS Code input ID such as "github-token" into an
+// environment variable name such as GITHUB_TOKEN
+func inputVariable(id string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(id) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "INPUT_" + name
+	}
+	return name
+}
+
+// convertList applies convert to each value of a list
+func convertList(values []string, convert func(string) string) []string {
+	if values == nil {
+		return nil
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = convert(v)
+	}
+	return out
+}
+
+// convertMap applies convert to each value of a map
+func convertMap(values map[string]string, convert func(string) string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		out[k] = convert(v)
+	}
+	return out
+}
+
+// isFormat reports whether format is one of Formats
+func isFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedNames returns the server names in sorted order
+func sortedNames(servers MCPServers) []string {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,179 @@
+package mcpconfig
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		data string
+		want string
+	}{
+		{"/home/u/Library/Application Support/Claude/claude_desktop_config.json", `{"mcpServers":{}}`, FormatDesktop},
+		{"/src/app/.cursor/mcp.json", `{"mcpServers":{}}`, FormatCursor},
+		{"/src/app/.vscode/mcp.json", `{"servers":{}}`, FormatVSCode},
+		{"/src/app/.mcp.json", `{"mcpServers":{}}`, FormatClaude},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.path, []byte(tt.data))
+		if err != nil {
+			t.Errorf("DetectFormat(%q) error: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := DetectFormat("x.json", []byte(`{"other":{}}`)); err == nil {
+		t.Error("expected error for a file without servers")
+	}
+	if _, err := DetectFormat("x.json", []byte(`not json`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestImport_Transports(t *testing.T) {
+	data := `{"mcpServers": {
+  "fs": {"command": "npx", "args": ["-y", "@modelcontextprotocol/server-filesystem", "/tmp"]},
+  "remote": {"url": "https://mcp.example.com/mcp"},
+  "events": {"type": "sse", "url": "https://mcp.example.com/sse"},
+  "stream": {"type": "streamable-http", "url": "https://mcp.example.com/mcp"},
+  "local": {"command": "srv", "envFile": ".env", "cwd": "/src/app"}
+}}`
+	result, err := Import("/src/app/.cursor/mcp.json", []byte(data), FormatAuto)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Format != FormatCursor {
+		t.Errorf("Format = %q, want cursor", result.Format)
+	}
+
+	wantTypes := map[string]string{"fs": "", "remote": "http", "events": "sse", "stream": "http", "local": ""}
+	for name, want := range wantTypes {
+		if got := result.Servers[name].Type; got != want {
+			t.Errorf("%s type = %q, want %q", name, got, want)
+		}
+	}
+	if len(result.Servers["fs"].Args) != 3 {
+		t.Errorf("fs args = %v", result.Servers["fs"].Args)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "envFile") {
+		t.Errorf("warnings = %v, want one envFile warning", result.Warnings)
+	}
+	local := result.Servers["local"]
+	if _, ok := local.Extras()["envFile"]; ok {
+		t.Error("envFile should be dropped")
+	}
+	if cwd, _ := local.Cwd(); cwd != "/src/app" {
+		t.Errorf("cwd = %q, want it kept", cwd)
+	}
+}
+
+func TestImport_VSCodeVariables(t *testing.T) {
+	data := `{
+  "inputs": [{"type": "promptString", "id": "github-token", "password": true}],
+  "servers": {
+    "github": {
+      "type": "http",
+      "url": "https://api.githubcopilot.com/mcp/",
+      "headers": {"Authorization": "Bearer ${input:github-token}"}
+    },
+    "db": {
+      "type": "stdio",
+      "command": "db-mcp",
+      "env": {"DSN": "${env:DATABASE_URL}", "TOKEN": "${input:github-token}"}
+    }
+  }
+}`
+	result, err := Import("/src/app/.vscode/mcp.json", []byte(data), "")
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	if got := result.Servers["github"].Headers["Authorization"]; got != "Bearer ${GITHUB_TOKEN}" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := result.Servers["db"].Env["DSN"]; got != "${DATABASE_URL}" {
+		t.Errorf("DSN = %q", got)
+	}
+	if result.Servers["db"].Type != "" {
+		t.Errorf("stdio type should be left implicit, got %q", result.Servers["db"].Type)
+	}
+	// One warning per server that uses the input
+	if len(result.Warnings) != 2 {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+}
+
+func TestImport_UnknownFormat(t *testing.T) {
+	if _, err := Import("x.json", []byte(`{"mcpServers":{}}`), "zed"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestExport(t *testing.T) {
+	servers := MCPServers{
+		"fs":     {Command: "fs-mcp", Env: map[string]string{"TOKEN": "${FS_TOKEN}"}},
+		"remote": {Type: "http", URL: "https://mcp.example.com/mcp"},
+		"events": {Type: "sse", URL: "https://mcp.example.com/sse"},
+	}
+
+	parse := func(data []byte, key string) map[string]map[string]any {
+		t.Helper()
+		var top map[string]map[string]map[string]any
+		if err := json.Unmarshal(data, &top); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, data)
+		}
+		return top[key]
+	}
+
+	data, warnings, err := Export(servers, FormatDesktop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := parse(data, "mcpServers")
+	if len(out) != 1 || out["fs"] == nil || len(warnings) != 2 {
+		t.Errorf("desktop: servers = %v, warnings = %v", out, warnings)
+	}
+
+	data, _, err = Export(servers, FormatCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = parse(data, "mcpServers")
+	if out["remote"]["type"] != nil || out["events"]["type"] != "sse" {
+		t.Errorf("cursor: %v", out)
+	}
+
+	data, _, err = Export(servers, FormatVSCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = parse(data, "servers")
+	if out["fs"]["type"] != "stdio" || out["remote"]["type"] != "http" {
+		t.Errorf("vscode types: %v", out)
+	}
+	if env := out["fs"]["env"].(map[string]any); env["TOKEN"] != "${env:FS_TOKEN}" {
+		t.Errorf("vscode env = %v", env)
+	}
+
+	// Exporting to VS Code and importing again gives the same servers back
+	result, err := Import("mcp.json", data, FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Servers["fs"].Env["TOKEN"]; got != "${FS_TOKEN}" {
+		t.Errorf("round trip TOKEN = %q", got)
+	}
+	if result.Servers["remote"].Type != "http" || result.Servers["events"].Type != "sse" {
+		t.Errorf("round trip servers = %+v", result.Servers)
+	}
+
+	if _, _, err := Export(servers, "zed"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"github.com/johnfox/claudectx/internal/jsonedit"
 )
 
-// MCPServer represents a single MCP server configuration
+// MCPServer represents a single MCP server configuration.
+// The core fields are typed; any other fields Claude Code supports (see
+// fields.go) are preserved in extras so that a LoadMCPServers→SaveMCPServers
+// roundtrip never destroys data.
 type MCPServer struct {
 	Type    string            `json:"type,omitempty"`
 	Command string            `json:"command,omitempty"`
@@ -14,6 +19,111 @@ type MCPServer struct {
 	Env     map[string]string `json:"env,omitempty"`
 	URL     string            `json:"url,omitempty"`
 	Headers map[string]string `json:"headers,omitempty"`
+
+	// extras holds any JSON keys not explicitly modelled above, such as
+	// cwd, timeout, headersHelper and oauth
+	extras map[string]json.RawMessage
+}
+
+// mcpServerFields has MCPServer's typed fields without its JSON methods
+type mcpServerFields MCPServer
+
+// serverKeys are the JSON keys of MCPServer's typed fields
+var serverKeys = map[string]bool{
+	"type": true, "command": true, "args": true, "env": true, "url": true, "headers": true,
+}
+
+// UnmarshalJSON implements json.Unmarshaler for MCPServer.
+// It populates the typed fields and stores all unrecognised keys in extras.
+func (s *MCPServer) UnmarshalJSON(data []byte) error {
+	var fields mcpServerFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = MCPServer(fields)
+	s.extras = nil
+	for k, v := range raw {
+		if serverKeys[k] {
+			continue
+		}
+		if s.extras == nil {
+			s.extras = make(map[string]json.RawMessage)
+		}
+		s.extras[k] = v
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler for MCPServer.
+// Without extras the typed fields are written in their usual order;
+// otherwise extras are merged in, with the typed fields taking precedence.
+func (s MCPServer) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(mcpServerFields(s))
+	if err != nil || len(s.extras) == 0 {
+		return data, err
+	}
+
+	out := make(map[string]json.RawMessage, len(s.extras)+len(serverKeys))
+	for k, v := range s.extras {
+		out[k] = v
+	}
+	var typed map[string]json.RawMessage
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return nil, err
+	}
+	for k, v := range typed {
+		out[k] = v
+	}
+	return json.Marshal(out)
+}
+
+// Extras returns a copy of the server keys that are not modelled as typed
+// fields. The returned map is safe to modify.
+func (s MCPServer) Extras() map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage, len(s.extras))
+	for k, v := range s.extras {
+		out[k] = v
+	}
+	return out
+}
+
+// SetExtra stores a raw JSON value for a key that is not modelled as a
+// typed field. Servers are copied by value, so extras are copied rather
+// than changed in place.
+func (s *MCPServer) SetExtra(key string, value json.RawMessage) {
+	s.extras = s.Extras()
+	s.extras[key] = value
+}
+
+// DeleteExtra removes a key that is not modelled as a typed field
+func (s *MCPServer) DeleteExtra(key string) {
+	if _, ok := s.extras[key]; !ok {
+		return
+	}
+	s.extras = s.Extras()
+	delete(s.extras, key)
+	if len(s.extras) == 0 {
+		s.extras = nil
+	}
+}
+
+// Transport returns the server's transport type. An explicit Type wins;
+// otherwise servers with a URL are treated as "http" and everything else
+// as "stdio", matching Claude Code's defaults.
+func (s MCPServer) Transport() string {
+	if s.Type != "" {
+		return s.Type
+	}
+	if s.URL != "" {
+		return "http"
+	}
+	return "stdio"
 }
 
 // MCPServers is a map of server name to server configuration
@@ -49,48 +159,26 @@ func LoadMCPServers(path string) (MCPServers, error) {
 	return config.MCPServers, nil
 }
 
-// SaveMCPServers updates only the mcpServers field in ~/.claude.json
-// preserving all other fields in the file
+// SaveMCPServers updates only the mcpServers field in ~/.claude.json.
+// The file is edited in place, so other fields keep their order and
+// formatting, and servers that did not change are left as they were.
 func SaveMCPServers(path string, servers MCPServers) error {
-	// Read existing file content
-	var existingData map[string]json.RawMessage
-	data, err := os.ReadFile(path)
+	doc, err := jsonedit.ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist, create new one with just mcpServers
-			existingData = make(map[string]json.RawMessage)
-		} else {
-			return fmt.Errorf("failed to read claude.json: %w", err)
-		}
-	} else {
-		err = json.Unmarshal(data, &existingData)
-		if err != nil {
-			return fmt.Errorf("failed to parse claude.json: %w", err)
-		}
+		return fmt.Errorf("failed to read claude.json: %w", err)
 	}
 
-	// Update or remove mcpServers field
 	if len(servers) == 0 {
 		// Remove the field if empty
-		delete(existingData, "mcpServers")
+		err = doc.Delete("mcpServers")
 	} else {
-		// Marshal the new servers
-		serversJSON, err := json.Marshal(servers)
-		if err != nil {
-			return fmt.Errorf("failed to marshal MCP servers: %w", err)
-		}
-		existingData["mcpServers"] = serversJSON
+		err = doc.Set(servers, "mcpServers")
 	}
-
-	// Marshal the complete file
-	output, err := json.MarshalIndent(existingData, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal claude.json: %w", err)
+		return fmt.Errorf("failed to update claude.json: %w", err)
 	}
 
-	// Write back to file
-	err = os.WriteFile(path, output, 0644)
-	if err != nil {
+	if err := os.WriteFile(path, doc.Bytes(), 0644); err != nil {
 		return fmt.Errorf("failed to write claude.json: %w", err)
 	}
 
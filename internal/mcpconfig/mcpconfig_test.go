@@ -150,3 +150,30 @@ func TestFileExists(t *testing.T) {
 		t.Fatal("expected FileExists true for existing file")
 	}
 }
+
+func TestSaveMCPServers_PreservesKeyOrderAndFormatting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude.json")
+	initial := "{\n  \"numStartups\": 3,\n  \"mcpServers\": {\n    \"keep\": {\"command\": \"k\"},\n    \"drop\": {\"command\": \"d\"}\n  },\n  \"autoUpdates\": true\n}\n"
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveMCPServers(path, MCPServers{"keep": {Command: "k"}, "add": {Command: "a"}}); err != nil {
+		t.Fatalf("SaveMCPServers failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	want := "{\n  \"numStartups\": 3,\n  \"mcpServers\": {\n    \"keep\": {\"command\": \"k\"},\n    \"add\": {\n      \"command\": \"a\"\n    }\n  },\n  \"autoUpdates\": true\n}\n"
+	if string(got) != want {
+		t.Errorf("file =\n%s\nwant\n%s", got, want)
+	}
+
+	// Removing every server removes the key and nothing else
+	if err := SaveMCPServers(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = os.ReadFile(path)
+	if string(got) != "{\n  \"numStartups\": 3,\n  \"autoUpdates\": true\n}\n" {
+		t.Errorf("file = %q", got)
+	}
+}
@@ -0,0 +1,256 @@
+package mcpconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/jsonedit"
+)
+
+// ProjectServers maps a project path, or a glob of project paths, to the
+// MCP servers Claude Code should use in that project. Paths may start with
+// "~/"; in globs, * matches within a single path segment.
+type ProjectServers map[string]MCPServers
+
+// LoadProjectMCPServers reads the mcpServers of every project entry in
+// ~/.claude.json (projects.<path>.mcpServers), keyed by project path.
+// Projects without servers are included with an empty map.
+func LoadProjectMCPServers(path string) (map[string]MCPServers, error) {
+	root, err := readJSONObject(path)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := projectEntries(root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]MCPServers, len(projects))
+	for projectPath, raw := range projects {
+		var entry struct {
+			MCPServers MCPServers `json:"mcpServers"`
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse project %q in claude.json: %w", projectPath, err)
+		}
+		if entry.MCPServers == nil {
+			entry.MCPServers = make(MCPServers)
+		}
+		result[projectPath] = entry.MCPServers
+	}
+	return result, nil
+}
+
+// SaveProjectMCPServers replaces projects.<path>.mcpServers for each path in
+// updates, editing ~/.claude.json in place so that every other key keeps
+// its order and formatting. Entries are created for paths that have
+// servers; an empty server map clears the servers of an existing entry.
+func SaveProjectMCPServers(path string, updates map[string]MCPServers) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	doc, err := jsonedit.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read claude.json: %w", err)
+	}
+
+	projectPaths := make([]string, 0, len(updates))
+	for projectPath := range updates {
+		projectPaths = append(projectPaths, projectPath)
+	}
+	sort.Strings(projectPaths)
+
+	for _, projectPath := range projectPaths {
+		servers := updates[projectPath]
+		if _, exists, err := doc.Get("projects", projectPath); err != nil {
+			return fmt.Errorf("failed to parse project %q in claude.json: %w", projectPath, err)
+		} else if !exists && len(servers) == 0 {
+			continue
+		}
+
+		if servers == nil {
+			servers = make(MCPServers)
+		}
+		if err := doc.Set(servers, "projects", projectPath, "mcpServers"); err != nil {
+			return fmt.Errorf("failed to update project %q in claude.json: %w", projectPath, err)
+		}
+	}
+
+	if err := os.WriteFile(path, doc.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write claude.json: %w", err)
+	}
+	return nil
+}
+
+// UpdateProjectMCPServers edits projects.<path>.mcpServers in ~/.claude.json
+// by server name. For each path, the servers named in remove are deleted
+// and the servers in set are added or replaced; servers neither names, such
+// as ones added with "claude mcp add", are kept. Entries are created only
+// for paths that get servers.
+func UpdateProjectMCPServers(path string, remove map[string][]string, set map[string]MCPServers) error {
+	if len(remove) == 0 && len(set) == 0 {
+		return nil
+	}
+
+	doc, err := jsonedit.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read claude.json: %w", err)
+	}
+
+	projectPaths := make([]string, 0, len(remove)+len(set))
+	for projectPath := range remove {
+		projectPaths = append(projectPaths, projectPath)
+	}
+	for projectPath := range set {
+		if _, ok := remove[projectPath]; !ok {
+			projectPaths = append(projectPaths, projectPath)
+		}
+	}
+	sort.Strings(projectPaths)
+
+	for _, projectPath := range projectPaths {
+		servers := set[projectPath]
+		for _, name := range remove[projectPath] {
+			if _, ok := servers[name]; ok {
+				continue // Replaced below, keeping its position
+			}
+			if err := doc.Delete("projects", projectPath, "mcpServers", name); err != nil {
+				return fmt.Errorf("failed to update project %q in claude.json: %w", projectPath, err)
+			}
+		}
+
+		names := make([]string, 0, len(servers))
+		for name := range servers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := doc.Set(servers[name], "projects", projectPath, "mcpServers", name); err != nil {
+				return fmt.Errorf("failed to update project %q in claude.json: %w", projectPath, err)
+			}
+		}
+	}
+
+	if err := os.WriteFile(path, doc.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write claude.json: %w", err)
+	}
+	return nil
+}
+
+// Clone copies the declared paths and their server maps, so adding or
+// removing servers in the copy leaves p unchanged. Server configs are shared.
+func (p ProjectServers) Clone() ProjectServers {
+	if p == nil {
+		return nil
+	}
+	clone := make(ProjectServers, len(p))
+	for pattern, servers := range p {
+		copied := make(MCPServers, len(servers))
+		for name, server := range servers {
+			copied[name] = server
+		}
+		clone[pattern] = copied
+	}
+	return clone
+}
+
+// Resolve expands the declared paths into the concrete project paths they
+// apply to. Exact paths apply whether or not Claude Code knows the project
+// yet; globs apply to the matching paths in known. When a project matches
+// several entries, servers from an exact path override those from globs,
+// and globs are merged in sorted order.
+func (p ProjectServers) Resolve(known []string) map[string]MCPServers {
+	result := make(map[string]MCPServers)
+
+	patterns := make([]string, 0, len(p))
+	for pattern := range p {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	merge := func(projectPath string, servers MCPServers) {
+		if result[projectPath] == nil {
+			result[projectPath] = make(MCPServers)
+		}
+		for name, server := range servers {
+			result[projectPath][name] = server
+		}
+	}
+
+	for _, pattern := range patterns {
+		if !IsProjectGlob(pattern) {
+			continue
+		}
+		expanded := ExpandProjectPath(pattern)
+		for _, projectPath := range known {
+			if ok, _ := filepath.Match(expanded, projectPath); ok {
+				merge(projectPath, p[pattern])
+			}
+		}
+	}
+
+	for _, pattern := range patterns {
+		if IsProjectGlob(pattern) {
+			continue
+		}
+		merge(ExpandProjectPath(pattern), p[pattern])
+	}
+
+	return result
+}
+
+// IsProjectGlob reports whether a declared project path is a glob
+func IsProjectGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// ExpandProjectPath expands a leading "~/" and cleans the path, matching
+// how Claude Code keys its project entries
+func ExpandProjectPath(projectPath string) string {
+	if strings.HasPrefix(projectPath, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			projectPath = filepath.Join(home, projectPath[2:])
+		}
+	}
+	return filepath.Clean(projectPath)
+}
+
+// readJSONObject reads a JSON object as raw top-level keys. A missing file
+// is an empty object.
+func readJSONObject(path string) (map[string]json.RawMessage, error) {
+	root := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return root, nil
+		}
+		return nil, fmt.Errorf("failed to read claude.json: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse claude.json: %w", err)
+	}
+	if root == nil {
+		root = make(map[string]json.RawMessage)
+	}
+	return root, nil
+}
+
+// projectEntries returns the raw entries of the top-level projects object
+func projectEntries(root map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	raw, ok := root["projects"]
+	if !ok || string(raw) == "null" {
+		return nil, nil
+	}
+	var projects map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse projects in claude.json: %w", err)
+	}
+	return projects, nil
+}
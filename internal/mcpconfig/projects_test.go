@@ -0,0 +1,152 @@
+package mcpconfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveProjectMCPServers_PreservesOtherKeys(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "claude.json")
+	initial := `{
+  "numStartups": 3,
+  "mcpServers": {"global": {"command": "g"}},
+  "projects": {
+    "/src/app": {"allowedTools": ["Read"], "mcpServers": {"old": {"command": "o"}}},
+    "/src/other": {"history": []}
+  }
+}`
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := SaveProjectMCPServers(path, map[string]MCPServers{
+		"/src/app":  {"db": {Command: "db-mcp"}},
+		"/src/new":  {"fs": {Command: "fs-mcp"}},
+		"/src/gone": {},
+	})
+	if err != nil {
+		t.Fatalf("SaveProjectMCPServers failed: %v", err)
+	}
+
+	var parsed struct {
+		NumStartups int                                   `json:"numStartups"`
+		MCPServers  MCPServers                            `json:"mcpServers"`
+		Projects    map[string]map[string]json.RawMessage `json:"projects"`
+	}
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("written file is not json: %v", err)
+	}
+
+	if parsed.NumStartups != 3 || parsed.MCPServers["global"].Command != "g" {
+		t.Errorf("top-level keys not preserved: %s", data)
+	}
+	if _, ok := parsed.Projects["/src/app"]["allowedTools"]; !ok {
+		t.Errorf("project keys not preserved: %s", data)
+	}
+	if _, ok := parsed.Projects["/src/other"]["history"]; !ok {
+		t.Errorf("other projects not preserved: %s", data)
+	}
+	if _, ok := parsed.Projects["/src/gone"]; ok {
+		t.Error("empty servers should not create a project entry")
+	}
+
+	projects, err := LoadProjectMCPServers(path)
+	if err != nil {
+		t.Fatalf("LoadProjectMCPServers failed: %v", err)
+	}
+	if len(projects["/src/app"]) != 1 || projects["/src/app"]["db"].Command != "db-mcp" {
+		t.Errorf("/src/app servers = %+v", projects["/src/app"])
+	}
+	if projects["/src/new"]["fs"].Command != "fs-mcp" {
+		t.Errorf("/src/new servers = %+v", projects["/src/new"])
+	}
+	if servers, ok := projects["/src/other"]; !ok || len(servers) != 0 {
+		t.Errorf("/src/other should be listed without servers, got %+v", servers)
+	}
+}
+
+func TestUpdateProjectMCPServers_MergesByName(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "claude.json")
+	initial := `{
+  "projects": {
+    "/src/app": {"mcpServers": {"local": {"command": "l"}, "db": {"command": "old-db"}, "gone": {"command": "x"}}}
+  }
+}`
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := UpdateProjectMCPServers(path,
+		map[string][]string{"/src/app": {"db", "gone"}, "/src/missing": {"db"}},
+		map[string]MCPServers{"/src/app": {"db": {Command: "db-mcp"}}, "/src/new": {"fs": {Command: "fs-mcp"}}},
+	)
+	if err != nil {
+		t.Fatalf("UpdateProjectMCPServers failed: %v", err)
+	}
+
+	projects, err := LoadProjectMCPServers(path)
+	if err != nil {
+		t.Fatalf("LoadProjectMCPServers failed: %v", err)
+	}
+	app := projects["/src/app"]
+	if app["local"].Command != "l" {
+		t.Errorf("servers the caller did not name should be kept, got %+v", app)
+	}
+	if app["db"].Command != "db-mcp" {
+		t.Errorf("db = %+v, want the new config", app["db"])
+	}
+	if _, ok := app["gone"]; ok {
+		t.Error("removed server should be deleted")
+	}
+	if projects["/src/new"]["fs"].Command != "fs-mcp" {
+		t.Errorf("/src/new servers = %+v", projects["/src/new"])
+	}
+	if _, ok := projects["/src/missing"]; ok {
+		t.Error("removing from a missing project should not create it")
+	}
+}
+
+func TestLoadProjectMCPServers_FileNotExists(t *testing.T) {
+	projects, err := LoadProjectMCPServers(filepath.Join(t.TempDir(), "claude.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(projects) != 0 {
+		t.Errorf("expected no projects, got %+v", projects)
+	}
+}
+
+func TestProjectServersResolve(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	declared := ProjectServers{
+		"/src/clients/*": {"shared": {Command: "glob"}, "lint": {Command: "lint"}},
+		"/src/clients/a": {"shared": {Command: "exact"}},
+		"~/notes":        {"notes": {Command: "notes"}},
+	}
+	known := []string{"/src/clients/a", "/src/clients/b", "/src/clients/b/nested", "/src/elsewhere"}
+
+	resolved := declared.Resolve(known)
+
+	if got := resolved["/src/clients/a"]; got["shared"].Command != "exact" || got["lint"].Command != "lint" {
+		t.Errorf("exact path should override the glob and merge with it, got %+v", got)
+	}
+	if got := resolved["/src/clients/b"]; got["shared"].Command != "glob" {
+		t.Errorf("glob should apply to known projects, got %+v", got)
+	}
+	if _, ok := resolved["/src/clients/b/nested"]; ok {
+		t.Error("* should not match across path separators")
+	}
+	if _, ok := resolved["/src/elsewhere"]; ok {
+		t.Error("unmatched project should not be resolved")
+	}
+	if got := resolved[filepath.Join(home, "notes")]; got["notes"].Command != "notes" {
+		t.Errorf("~/ paths should expand and apply even if unknown, got %+v", resolved)
+	}
+}
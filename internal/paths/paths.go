@@ -1,7 +1,9 @@
 package paths
 
 import (
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -117,3 +119,75 @@ func RunTempDir() (string, error) {
 	}
 	return filepath.Join(claudeDir, ".claudectx-run"), nil
 }
+
+// TemplatesDir returns the directory for user profile templates
+// (~/.claude/profile-templates)
+func TemplatesDir() (string, error) {
+	claudeDir, err := ClaudeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(claudeDir, "profile-templates"), nil
+}
+
+// RulesFile returns the path to the user's health rule file
+// (~/.claude/claudectx-rules.json)
+func RulesFile() (string, error) {
+	claudeDir, err := ClaudeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(claudeDir, "claudectx-rules.json"), nil
+}
+
+// ConfigFile returns the path to claudectx's own settings file
+// (~/.claude/claudectx.json)
+func ConfigFile() (string, error) {
+	claudeDir, err := ClaudeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(claudeDir, "claudectx.json"), nil
+}
+
+// ProjectsStateDir returns the directory holding claudectx's per-project
+// state (~/.claude/claudectx-projects)
+func ProjectsStateDir() (string, error) {
+	claudeDir, err := ClaudeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(claudeDir, "claudectx-projects"), nil
+}
+
+// ProjectStateDir returns the directory holding the current/previous
+// trackers and backups for a project. The directory name combines the
+// project's base name with a hash of its absolute path.
+func ProjectStateDir(projectDir string) (string, error) {
+	abs, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", err
+	}
+	stateDir, err := ProjectsStateDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(stateDir, fmt.Sprintf("%s-%x", filepath.Base(abs), sum[:6])), nil
+}
+
+// ProjectSettingsLocalFile returns the path to a project's
+// .claude/settings.local.json
+func ProjectSettingsLocalFile(projectDir string) string {
+	return filepath.Join(projectDir, ".claude", "settings.local.json")
+}
+
+// ProjectClaudeMDLocalFile returns the path to a project's CLAUDE.local.md
+func ProjectClaudeMDLocalFile(projectDir string) string {
+	return filepath.Join(projectDir, "CLAUDE.local.md")
+}
+
+// ProjectMCPFile returns the path to a project's .mcp.json
+func ProjectMCPFile(projectDir string) string {
+	return filepath.Join(projectDir, ".mcp.json")
+}
@@ -99,6 +99,39 @@ func TestClaudeMDFile(t *testing.T) {
 	}
 }
 
+func TestTemplatesDir(t *testing.T) {
+	dir, err := TemplatesDir()
+	if err != nil {
+		t.Fatalf("TemplatesDir() failed: %v", err)
+	}
+
+	if filepath.Base(dir) != "profile-templates" {
+		t.Errorf("TemplatesDir() = %q, want profile-templates", dir)
+	}
+}
+
+func TestRulesFile(t *testing.T) {
+	path, err := RulesFile()
+	if err != nil {
+		t.Fatalf("RulesFile() failed: %v", err)
+	}
+
+	if filepath.Base(path) != "claudectx-rules.json" || filepath.Base(filepath.Dir(path)) != ".claude" {
+		t.Errorf("RulesFile() = %q, want ~/.claude/claudectx-rules.json", path)
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	path, err := ConfigFile()
+	if err != nil {
+		t.Fatalf("ConfigFile() failed: %v", err)
+	}
+
+	if filepath.Base(path) != "claudectx.json" || filepath.Base(filepath.Dir(path)) != ".claude" {
+		t.Errorf("ConfigFile() = %q, want ~/.claude/claudectx.json", path)
+	}
+}
+
 func TestProfileFiles(t *testing.T) {
 	tests := []struct {
 		name     string
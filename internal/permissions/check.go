@@ -0,0 +1,313 @@
+package permissions
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// Issue kinds, used as health rule IDs
+const (
+	IssueSyntax      = "permission-syntax"
+	IssueUnknownTool = "permission-unknown-tool"
+	IssueSpecifier   = "permission-specifier"
+	IssueShadowed    = "permission-shadowed"
+	IssueUnreachable = "permission-unreachable"
+)
+
+// Issue is a problem with a permission rule
+type Issue struct {
+	Kind string
+	// Error marks rules Claude Code cannot use at all; other issues are
+	// warnings
+	Error bool
+	// List is "allow", "deny" or "ask"
+	List    string
+	Rule    string
+	Message string
+	Hint    string
+}
+
+// list is one named permission list
+type list struct {
+	name  string
+	rules []string
+}
+
+// lists returns the permission lists in precedence order (deny wins over
+// ask, which wins over allow)
+func lists(perms *config.Permissions) []list {
+	return []list{{"deny", perms.Deny}, {"ask", perms.Ask}, {"allow", perms.Allow}}
+}
+
+// Validate returns an error for the first rule Claude Code cannot use:
+// one that does not parse or has an invalid path pattern
+func Validate(perms *config.Permissions) error {
+	if perms == nil {
+		return nil
+	}
+	for _, l := range lists(perms) {
+		for _, raw := range l.rules {
+			rule, err := Parse(raw)
+			if err != nil {
+				return fmt.Errorf("invalid permission rule %q in %s: %w", raw, l.name, err)
+			}
+			for _, issue := range checkRule(rule) {
+				if issue.Error {
+					return fmt.Errorf("invalid permission rule %q in %s: %s", raw, l.name, issue.Message)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Check parses every rule and reports syntax errors, unknown tools, invalid
+// specifiers, rules shadowed by a broader rule in the same list, and rules
+// that can never take effect because a higher-precedence list covers them.
+// Exact duplicates are not reported.
+func Check(perms *config.Permissions) []Issue {
+	if perms == nil {
+		return nil
+	}
+
+	var issues []Issue
+	parsed := map[string][]Rule{}
+	for _, l := range lists(perms) {
+		for _, raw := range l.rules {
+			rule, err := Parse(raw)
+			if err != nil {
+				issues = append(issues, Issue{
+					Kind: IssueSyntax, Error: true, List: l.name, Rule: raw,
+					Message: fmt.Sprintf("Invalid %s rule %q: %v", l.name, raw, err),
+					Hint:    `Use Tool or Tool(specifier), e.g. Bash(git diff:*) or Read(./src/**)`,
+				})
+				continue
+			}
+			for _, issue := range checkRule(rule) {
+				issue.List = l.name
+				issue.Message = fmt.Sprintf("%s rule %q: %s", capitalize(l.name), raw, issue.Message)
+				issues = append(issues, issue)
+			}
+			parsed[l.name] = append(parsed[l.name], rule)
+		}
+	}
+
+	// Shadowed rules within a list
+	for _, l := range lists(perms) {
+		rules := parsed[l.name]
+		for i, narrow := range rules {
+			for j, broad := range rules {
+				if i == j || narrow.Raw == broad.Raw || !Covers(broad, narrow) {
+					continue
+				}
+				// Of two rules that cover each other, report only the later
+				if Covers(narrow, broad) && i < j {
+					continue
+				}
+				issues = append(issues, Issue{
+					Kind: IssueShadowed, List: l.name, Rule: narrow.Raw,
+					Message: fmt.Sprintf("%s rule %q is already covered by %q", capitalize(l.name), narrow.Raw, broad.Raw),
+					Hint:    "Remove the narrower rule",
+				})
+				break
+			}
+		}
+	}
+
+	// Rules overridden by a higher-precedence list
+	precedence := []string{"deny", "ask", "allow"}
+	for i, name := range precedence {
+		for _, narrow := range parsed[name] {
+		higher:
+			for _, over := range precedence[:i] {
+				for _, broad := range parsed[over] {
+					if !Covers(broad, narrow) {
+						continue
+					}
+					outcome := "can never apply"
+					if over == "ask" {
+						outcome = "will still prompt"
+					}
+					issues = append(issues, Issue{
+						Kind: IssueUnreachable, List: name, Rule: narrow.Raw,
+						Message: fmt.Sprintf("%s rule %q %s: %s rule %q takes precedence", capitalize(name), narrow.Raw, outcome, over, broad.Raw),
+						Hint:    fmt.Sprintf("Remove one of the rules, or narrow the %s rule", over),
+					})
+					break higher
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+// checkRule validates a parsed rule's tool name and specifier
+func checkRule(rule Rule) []Issue {
+	var issues []Issue
+	warn := func(kind, message, hint string) {
+		issues = append(issues, Issue{Kind: kind, Rule: rule.Raw, Message: message, Hint: hint})
+	}
+	fail := func(kind, message, hint string) {
+		issues = append(issues, Issue{Kind: kind, Error: true, Rule: rule.Raw, Message: message, Hint: hint})
+	}
+
+	switch {
+	case rule.Tool == "*":
+		return nil
+	case rule.IsMCP():
+		if rule.HasSpecifier {
+			warn(IssueSpecifier, "MCP rules do not take a specifier",
+				"Use mcp__<server> for every tool of a server or mcp__<server>__<tool> for one tool")
+		}
+		return issues
+	case !IsKnownTool(rule.Tool):
+		hint := "Check the tool name; built-in tools include Bash, Read, Edit, Write, WebFetch and WebSearch"
+		if suggestion := suggestTool(rule.Tool); suggestion != "" {
+			hint = fmt.Sprintf("Did you mean %s?", suggestion)
+		}
+		warn(IssueUnknownTool, fmt.Sprintf("unknown tool %q", rule.Tool), hint)
+		return issues
+	}
+
+	if !rule.HasSpecifier {
+		return nil
+	}
+	spec := rule.Specifier
+
+	switch {
+	case contains(noSpecifierTools, rule.Tool):
+		warn(IssueSpecifier, fmt.Sprintf("%s does not take a specifier", rule.Tool),
+			fmt.Sprintf("Use %s without parentheses", rule.Tool))
+	case rule.Tool == "Bash":
+		if i := strings.Index(spec, ":*"); i >= 0 && i != len(spec)-2 {
+			warn(IssueSpecifier, `":*" only works at the end of a Bash rule`,
+				`Use a prefix rule such as Bash(git diff:*) or a wildcard such as Bash(git * main)`)
+		}
+	case rule.Tool == "WebFetch":
+		host, ok := strings.CutPrefix(spec, "domain:")
+		if !ok || host == "" || strings.ContainsAny(host, "/ ") {
+			warn(IssueSpecifier, "WebFetch rules take a domain",
+				"Use WebFetch(domain:example.com)")
+		}
+	case contains(pathTools, rule.Tool):
+		if _, err := path.Match(strings.ReplaceAll(spec, "**", "*"), ""); err != nil {
+			fail(IssueSpecifier, fmt.Sprintf("invalid glob %q", spec),
+				"Check for unclosed [ ] brackets or a trailing backslash")
+		} else if strings.Contains(spec, `\`) {
+			warn(IssueSpecifier, "path patterns use forward slashes",
+				"Use / as the path separator, e.g. Read(./src/**)")
+		}
+	}
+	return issues
+}
+
+// Covers reports whether every use matched by narrow is also matched by
+// broad. It is conservative: when in doubt it returns false.
+func Covers(broad, narrow Rule) bool {
+	if broad.Tool == "*" {
+		return true
+	}
+
+	if broad.IsMCP() || narrow.IsMCP() {
+		if !broad.IsMCP() || !narrow.IsMCP() || broad.HasSpecifier || narrow.HasSpecifier {
+			return false
+		}
+		bServer, bTool := broad.MCPParts()
+		nServer, nTool := narrow.MCPParts()
+		return bServer == nServer && (bTool == "" || bTool == "*" || bTool == nTool)
+	}
+
+	if broad.Tool != narrow.Tool {
+		return false
+	}
+	if !broad.HasSpecifier {
+		return true
+	}
+	if !narrow.HasSpecifier {
+		return false
+	}
+	if broad.Specifier == narrow.Specifier {
+		return true
+	}
+
+	switch {
+	case broad.Tool == "Bash":
+		return bashCovers(broad.Specifier, narrow.Specifier)
+	case contains(pathTools, broad.Tool):
+		return globCovers(broad.Specifier, narrow.Specifier)
+	}
+	return false
+}
+
+// bashCovers reports whether the Bash pattern broad matches every command
+// narrow matches. "cmd:*" is a prefix match on whole words, so git:* covers
+// "git status" but not "gitk", and "*" a wildcard.
+func bashCovers(broad, narrow string) bool {
+	if prefix, ok := strings.CutSuffix(broad, ":*"); ok {
+		narrow = strings.TrimSuffix(narrow, ":*")
+		rest, ok := strings.CutPrefix(narrow, prefix)
+		return ok && (rest == "" || strings.HasPrefix(rest, " ") || strings.HasSuffix(prefix, " "))
+	}
+	if !strings.Contains(broad, "*") {
+		return false
+	}
+	// Treat narrow's own wildcards literally: if its text matches broad,
+	// so does anything it expands to, because broad's * spans them
+	narrow = strings.TrimSuffix(narrow, ":*") + strings.Repeat("*", strings.Count(narrow, ":*"))
+	pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(broad), `\*`, ".*") + "$"
+	return regexp.MustCompile(pattern).MatchString(narrow)
+}
+
+// globCovers reports whether the path pattern broad matches every path
+// matched by narrow. Narrow patterns with wildcards are only covered by a
+// broad pattern ending in "/**" over a common directory.
+func globCovers(broad, narrow string) bool {
+	if !strings.ContainsAny(narrow, "*?[") {
+		return MatchPath(broad, narrow)
+	}
+	if dir, ok := strings.CutSuffix(broad, "/**"); ok && !strings.ContainsAny(dir, "*?[") {
+		return strings.HasPrefix(narrow, dir+"/")
+	}
+	return false
+}
+
+// MatchPath matches a path against a gitignore-style pattern where "**"
+// matches any number of directories and "*" matches within one directory
+func MatchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches path segments, expanding "**"
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// capitalize upper-cases the first letter of a list name
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
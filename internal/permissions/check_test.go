@@ -0,0 +1,182 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// issueKinds maps each rule to the kind of its first issue
+func issueKinds(issues []Issue) map[string]string {
+	kinds := map[string]string{}
+	for _, issue := range issues {
+		if _, ok := kinds[issue.Rule]; !ok {
+			kinds[issue.Rule] = issue.Kind
+		}
+	}
+	return kinds
+}
+
+func TestCheck_Clean(t *testing.T) {
+	perms := &config.Permissions{
+		Allow: []string{"Bash(npm test)", "Read(./src/**)", "WebFetch(domain:example.com)", "mcp__github__get_issue"},
+		Deny:  []string{"Read(./.env)", "Bash(rm:*)"},
+		Ask:   []string{"Bash(git push:*)"},
+	}
+	if issues := Check(perms); len(issues) != 0 {
+		t.Errorf("expected no issues, got %+v", issues)
+	}
+	if Check(nil) != nil {
+		t.Error("nil permissions should have no issues")
+	}
+}
+
+func TestCheck_Syntax(t *testing.T) {
+	perms := &config.Permissions{
+		Allow: []string{"Bash(ls", "Raed(./src)", "WebSearch(foo)", "WebFetch(example.com)", "Bash(git:* --force)"},
+		Deny:  []string{"Read([abc)"},
+	}
+	want := map[string]string{
+		"Bash(ls":               IssueSyntax,
+		"Raed(./src)":           IssueUnknownTool,
+		"WebSearch(foo)":        IssueSpecifier,
+		"WebFetch(example.com)": IssueSpecifier,
+		"Bash(git:* --force)":   IssueSpecifier,
+		"Read([abc)":            IssueSpecifier,
+	}
+
+	issues := Check(perms)
+	got := issueKinds(issues)
+	for rule, kind := range want {
+		if got[rule] != kind {
+			t.Errorf("rule %q: kind = %q, want %q", rule, got[rule], kind)
+		}
+	}
+	for _, issue := range issues {
+		wantError := issue.Rule == "Bash(ls" || issue.Rule == "Read([abc)"
+		if issue.Error != wantError {
+			t.Errorf("rule %q: Error = %v, want %v", issue.Rule, issue.Error, wantError)
+		}
+		if issue.Rule == "Raed(./src)" && issue.Hint != "Did you mean Read?" {
+			t.Errorf("unexpected hint %q", issue.Hint)
+		}
+	}
+}
+
+func TestCheck_ShadowedAndUnreachable(t *testing.T) {
+	perms := &config.Permissions{
+		Allow: []string{"Bash(git:*)", "Bash(git status)", "Read(./src/**)", "Read(./src/main.go)", "WebFetch", "mcp__github__create_issue"},
+		Deny:  []string{"WebFetch(domain:evil.com)", "mcp__github"},
+		Ask:   []string{"Bash(git push:*)"},
+	}
+	got := issueKinds(Check(perms))
+	want := map[string]string{
+		"Bash(git status)":          IssueShadowed,
+		"Read(./src/main.go)":       IssueShadowed,
+		"mcp__github__create_issue": IssueUnreachable,
+	}
+	for rule, kind := range want {
+		if got[rule] != kind {
+			t.Errorf("rule %q: kind = %q, want %q", rule, got[rule], kind)
+		}
+	}
+	// A broad allow with a narrower deny is the normal way to carve out
+	// exceptions and is not reported
+	for _, rule := range []string{"WebFetch", "Bash(git:*)", "Read(./src/**)", "Bash(git push:*)"} {
+		if kind, ok := got[rule]; ok {
+			t.Errorf("rule %q should not be reported, got %q", rule, kind)
+		}
+	}
+}
+
+func TestCheck_AskOverridesAllow(t *testing.T) {
+	perms := &config.Permissions{
+		Allow: []string{"Bash(git push origin main)"},
+		Ask:   []string{"Bash(git push:*)"},
+	}
+	issues := Check(perms)
+	if len(issues) != 1 || issues[0].Kind != IssueUnreachable || issues[0].List != "allow" {
+		t.Errorf("expected the allow rule to be reported, got %+v", issues)
+	}
+}
+
+func TestCheck_EquivalentRulesReportedOnce(t *testing.T) {
+	perms := &config.Permissions{Allow: []string{"mcp__github", "mcp__github__*"}}
+	issues := Check(perms)
+	if len(issues) != 1 || issues[0].Rule != "mcp__github__*" {
+		t.Errorf("expected only the later rule to be reported, got %+v", issues)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	tests := []struct {
+		broad, narrow string
+		want          bool
+	}{
+		{"Bash", "Bash(ls)", true},
+		{"Bash(ls)", "Bash", false},
+		{"*", "Read", true},
+		{"Bash(npm run:*)", "Bash(npm run test)", true},
+		{"Bash(npm run:*)", "Bash(npm run test:*)", true},
+		{"Bash(npm run test:*)", "Bash(npm run:*)", false},
+		{"Bash(git:*)", "Bash(git status)", true},
+		{"Bash(git:*)", "Bash(git)", true},
+		{"Bash(git:*)", "Bash(gitk)", false},
+		{"Bash(git:*)", "Bash(github-cli:*)", false},
+		{"Bash(npm *)", "Bash(npm install)", true},
+		{"Bash(npm install)", "Bash(npm *)", false},
+		{"Read(./src/**)", "Read(./src/a/b.go)", true},
+		{"Read(./src/**)", "Read(./src/*.go)", true},
+		{"Read(./src/*.go)", "Read(./src/a/b.go)", false},
+		{"Read(./src/*.go)", "Read(./src/main.go)", true},
+		{"Read(./src/**)", "Edit(./src/main.go)", false},
+		{"mcp__github", "mcp__github__create_issue", true},
+		{"mcp__github__*", "mcp__github__create_issue", true},
+		{"mcp__github__get_issue", "mcp__github__create_issue", false},
+		{"mcp__github", "mcp__gitlab", false},
+		{"WebFetch(domain:a.com)", "WebFetch(domain:b.com)", false},
+	}
+	for _, tt := range tests {
+		broad, err := Parse(tt.broad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		narrow, err := Parse(tt.narrow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Covers(broad, narrow); got != tt.want {
+			t.Errorf("Covers(%q, %q) = %v, want %v", tt.broad, tt.narrow, got, tt.want)
+		}
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"./src/**", "./src/a/b/c.go", true},
+		{"**/*.env", "config/prod.env", true},
+		{"**/*.env", "prod.env", true},
+		{"./src/*.go", "./src/a/b.go", false},
+		{"./src/**/test.go", "./src/test.go", true},
+	}
+	for _, tt := range tests {
+		if got := MatchPath(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(&config.Permissions{Allow: []string{"Bash(ls)", "Raed"}}); err != nil {
+		t.Errorf("warnings should not fail validation: %v", err)
+	}
+	if err := Validate(&config.Permissions{Ask: []string{"Bash(ls"}}); err == nil {
+		t.Error("expected an error for an unparseable rule")
+	}
+	if err := Validate(&config.Permissions{Deny: []string{"Read([abc)"}}); err == nil {
+		t.Error("expected an error for an invalid glob")
+	}
+}
@@ -0,0 +1,183 @@
+package permissions
+
+import (
+	"fmt"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// Settings scopes. A switched-to profile is the user settings file, the
+// lowest-precedence scope; "claudectx run" passes it with --settings, which
+// takes precedence over the project and local scopes.
+const (
+	ScopeUser    = "user"
+	ScopeProject = "project"
+	ScopeLocal   = "local"
+	ScopeProfile = "profile"
+)
+
+// Source is one settings file that contributes permissions
+type Source struct {
+	Scope string
+	// Path is the file the permissions came from ("" for a stored profile)
+	Path        string
+	Permissions *config.Permissions
+	// Found is false when the file does not exist
+	Found bool
+}
+
+// ScopedRule is a merged permission rule and the scopes that list it
+type ScopedRule struct {
+	Rule   string   `json:"rule"`
+	Scopes []string `json:"scopes"`
+}
+
+// Effective is the merged permission set Claude Code will use
+type Effective struct {
+	Allow []ScopedRule `json:"allow"`
+	Deny  []ScopedRule `json:"deny"`
+	Ask   []ScopedRule `json:"ask"`
+	// DefaultMode is the permission mode from the highest-precedence scope
+	// that sets one
+	DefaultMode      string `json:"defaultMode,omitempty"`
+	DefaultModeScope string `json:"defaultModeScope,omitempty"`
+	// Overrides describe where other scopes change what the profile asks for
+	Overrides []string `json:"overrides,omitempty"`
+}
+
+// Merge combines sources given from lowest to highest precedence the way
+// Claude Code does: allow, deny and ask lists are concatenated and
+// deduplicated across scopes, and defaultMode comes from the
+// highest-precedence scope that sets it. The source with scope
+// profileScope is the profile whose intent is checked for overrides.
+func Merge(sources []Source, profileScope string) *Effective {
+	eff := &Effective{}
+	add := func(list *[]ScopedRule, rule, scope string) {
+		for i := range *list {
+			if (*list)[i].Rule == rule {
+				if !contains((*list)[i].Scopes, scope) {
+					(*list)[i].Scopes = append((*list)[i].Scopes, scope)
+				}
+				return
+			}
+		}
+		*list = append(*list, ScopedRule{Rule: rule, Scopes: []string{scope}})
+	}
+
+	var prof *config.Permissions
+	for _, src := range sources {
+		if src.Permissions == nil {
+			continue
+		}
+		if src.Scope == profileScope {
+			prof = src.Permissions
+		}
+		for _, rule := range src.Permissions.Allow {
+			add(&eff.Allow, rule, src.Scope)
+		}
+		for _, rule := range src.Permissions.Deny {
+			add(&eff.Deny, rule, src.Scope)
+		}
+		for _, rule := range src.Permissions.Ask {
+			add(&eff.Ask, rule, src.Scope)
+		}
+		if mode, _ := src.Permissions.DefaultMode(); mode != "" {
+			eff.DefaultMode, eff.DefaultModeScope = mode, src.Scope
+		}
+	}
+
+	if prof != nil {
+		eff.Overrides = overrides(eff, prof, profileScope)
+	}
+	return eff
+}
+
+// overrides lists the ways other scopes widen or block what the profile's
+// own permissions say
+func overrides(eff *Effective, prof *config.Permissions, profileScope string) []string {
+	var out []string
+
+	// Profile allow rules blocked by deny or ask rules from other scopes
+	for _, raw := range prof.Allow {
+		allow, err := Parse(raw)
+		if err != nil {
+			continue
+		}
+		for _, over := range []struct {
+			name  string
+			rules []ScopedRule
+		}{{"deny", eff.Deny}, {"ask", eff.Ask}} {
+			if blocking := coveringRule(over.rules, allow, profileScope); blocking != nil {
+				out = append(out, fmt.Sprintf("Profile allows %q, but %s rule %q from the %s scope takes precedence",
+					raw, over.name, blocking.Rule, blocking.Scopes[0]))
+				break
+			}
+		}
+	}
+
+	// Allow rules from other scopes the profile does not grant itself.
+	// Claude Code merges allow lists, so a profile cannot narrow them.
+	var profAllow []Rule
+	for _, raw := range prof.Allow {
+		if rule, err := Parse(raw); err == nil {
+			profAllow = append(profAllow, rule)
+		}
+	}
+	for _, scoped := range eff.Allow {
+		if contains(scoped.Scopes, profileScope) {
+			continue
+		}
+		rule, err := Parse(scoped.Rule)
+		if err != nil || coveredBy(profAllow, rule) {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s scope allows %q, which the profile does not; the profile cannot remove it",
+			capitalize(scoped.Scopes[0]), scoped.Rule))
+	}
+
+	// A different default mode from a higher-precedence scope
+	if mode, _ := prof.DefaultMode(); mode != "" && eff.DefaultMode != mode {
+		out = append(out, fmt.Sprintf("Profile sets defaultMode %q, but %s scope sets %q and takes precedence",
+			mode, eff.DefaultModeScope, eff.DefaultMode))
+	}
+
+	return out
+}
+
+// coveringRule returns the first rule from a scope other than skipScope
+// that covers rule
+func coveringRule(rules []ScopedRule, rule Rule, skipScope string) *ScopedRule {
+	for i, scoped := range rules {
+		if len(scoped.Scopes) == 1 && scoped.Scopes[0] == skipScope {
+			continue
+		}
+		broad, err := Parse(scoped.Rule)
+		if err == nil && Covers(broad, rule) {
+			s := rules[i]
+			s.Scopes = without(s.Scopes, skipScope)
+			return &s
+		}
+	}
+	return nil
+}
+
+// coveredBy reports whether any of rules covers rule
+func coveredBy(rules []Rule, rule Rule) bool {
+	for _, broad := range rules {
+		if Covers(broad, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns list with s removed
+func without(list []string, s string) []string {
+	var out []string
+	for _, item := range list {
+		if item != s {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package permissions
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/johnfox/claudectx/internal/config"
+)
+
+// permsFromJSON parses a permissions object
+func permsFromJSON(t *testing.T, data string) *config.Permissions {
+	t.Helper()
+	var perms config.Permissions
+	if err := json.Unmarshal([]byte(data), &perms); err != nil {
+		t.Fatal(err)
+	}
+	return &perms
+}
+
+func TestMerge(t *testing.T) {
+	sources := []Source{
+		{Scope: ScopeUser, Permissions: permsFromJSON(t, `{"allow":["Read","Bash(ls)"],"defaultMode":"default"}`)},
+		{Scope: ScopeProject, Permissions: permsFromJSON(t, `{"deny":["Read(./.env)"],"ask":["Bash(git push:*)"]}`)},
+		{Scope: ScopeLocal},
+		{Scope: ScopeProfile, Permissions: permsFromJSON(t, `{"allow":["Bash(ls)","Bash(git push origin main)"],"defaultMode":"acceptEdits"}`)},
+	}
+
+	eff := Merge(sources, ScopeProfile)
+
+	wantAllow := []ScopedRule{
+		{Rule: "Read", Scopes: []string{ScopeUser}},
+		{Rule: "Bash(ls)", Scopes: []string{ScopeUser, ScopeProfile}},
+		{Rule: "Bash(git push origin main)", Scopes: []string{ScopeProfile}},
+	}
+	if !reflect.DeepEqual(eff.Allow, wantAllow) {
+		t.Errorf("Allow = %+v, want %+v", eff.Allow, wantAllow)
+	}
+	if len(eff.Deny) != 1 || len(eff.Ask) != 1 || eff.Ask[0].Scopes[0] != ScopeProject {
+		t.Errorf("Deny = %+v, Ask = %+v", eff.Deny, eff.Ask)
+	}
+	if eff.DefaultMode != "acceptEdits" || eff.DefaultModeScope != ScopeProfile {
+		t.Errorf("DefaultMode = %q from %q", eff.DefaultMode, eff.DefaultModeScope)
+	}
+
+	overrides := strings.Join(eff.Overrides, "\n")
+	if len(eff.Overrides) != 2 ||
+		!strings.Contains(overrides, `Profile allows "Bash(git push origin main)", but ask rule "Bash(git push:*)" from the project scope`) ||
+		!strings.Contains(overrides, `User scope allows "Read"`) {
+		t.Errorf("Overrides =\n%s", overrides)
+	}
+}
+
+func TestMerge_DefaultModeOverridden(t *testing.T) {
+	sources := []Source{
+		{Scope: ScopeProfile, Permissions: permsFromJSON(t, `{"defaultMode":"plan"}`)},
+		{Scope: ScopeLocal, Permissions: permsFromJSON(t, `{"defaultMode":"bypassPermissions"}`)},
+	}
+
+	eff := Merge(sources, ScopeProfile)
+	if eff.DefaultMode != "bypassPermissions" || eff.DefaultModeScope != ScopeLocal {
+		t.Errorf("DefaultMode = %q from %q", eff.DefaultMode, eff.DefaultModeScope)
+	}
+	if len(eff.Overrides) != 1 || !strings.Contains(eff.Overrides[0], `defaultMode "plan"`) {
+		t.Errorf("Overrides = %v", eff.Overrides)
+	}
+}
+
+func TestMerge_NoProfilePermissions(t *testing.T) {
+	sources := []Source{
+		{Scope: ScopeUser, Permissions: permsFromJSON(t, `{"allow":["Read"]}`)},
+		{Scope: ScopeProfile},
+	}
+	if eff := Merge(sources, ScopeProfile); len(eff.Overrides) != 0 || len(eff.Allow) != 1 {
+		t.Errorf("unexpected result %+v", eff)
+	}
+}
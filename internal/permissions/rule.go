@@ -0,0 +1,179 @@
+// Package permissions parses and checks Claude Code permission rules such
+// as "Bash(git diff:*)", "Read(./src/**)" or "mcp__github__create_issue".
+package permissions
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule is a parsed permission rule: a tool name with an optional specifier
+// in parentheses
+type Rule struct {
+	// Raw is the rule as written in settings.json
+	Raw  string
+	Tool string
+	// Specifier is the text between the parentheses, if any
+	Specifier    string
+	HasSpecifier bool
+}
+
+// String returns the rule as written
+func (r Rule) String() string {
+	return r.Raw
+}
+
+// IsMCP reports whether the rule names an MCP server or tool
+func (r Rule) IsMCP() bool {
+	return strings.HasPrefix(r.Tool, mcpPrefix)
+}
+
+// MCPParts splits an MCP rule into its server and tool ("" when the rule
+// covers the whole server)
+func (r Rule) MCPParts() (server, tool string) {
+	name := strings.TrimPrefix(r.Tool, mcpPrefix)
+	server, tool, _ = strings.Cut(name, "__")
+	return server, tool
+}
+
+// mcpPrefix starts every MCP tool name (mcp__<server>__<tool>)
+const mcpPrefix = "mcp__"
+
+// toolNamePattern matches valid tool names
+var toolNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Parse parses a single permission rule. It returns an error for rules
+// Claude Code cannot parse, such as unbalanced parentheses or an empty
+// specifier.
+func Parse(raw string) (Rule, error) {
+	if strings.TrimSpace(raw) == "" {
+		return Rule{}, errors.New("empty rule")
+	}
+	if strings.TrimSpace(raw) != raw {
+		return Rule{}, errors.New("leading or trailing whitespace")
+	}
+
+	rule := Rule{Raw: raw, Tool: raw}
+	open := strings.IndexByte(raw, '(')
+	if open < 0 {
+		if strings.ContainsRune(raw, ')') {
+			return Rule{}, errors.New("unexpected ')' without '('")
+		}
+	} else {
+		rule.Tool = raw[:open]
+		if !strings.HasSuffix(raw, ")") {
+			return Rule{}, fmt.Errorf("missing closing ')' in %q", raw)
+		}
+		rule.Specifier = raw[open+1 : len(raw)-1]
+		rule.HasSpecifier = true
+
+		depth := 0
+		for _, c := range rule.Specifier {
+			switch c {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth < 0 {
+				break
+			}
+		}
+		if depth != 0 {
+			return Rule{}, fmt.Errorf("unbalanced parentheses in %q", raw)
+		}
+		if strings.TrimSpace(rule.Specifier) == "" {
+			return Rule{}, fmt.Errorf("empty specifier; use %q to match every use of the tool", rule.Tool)
+		}
+	}
+
+	if rule.Tool == "" {
+		return Rule{}, errors.New("missing tool name")
+	}
+	// MCP rules may end in "__*" to match every tool of a server
+	name := rule.Tool
+	if rule.IsMCP() {
+		name = strings.TrimSuffix(name, "__*")
+	}
+	if rule.Tool != "*" && !toolNamePattern.MatchString(name) {
+		return Rule{}, fmt.Errorf("invalid tool name %q", rule.Tool)
+	}
+	if rule.IsMCP() {
+		if server, _ := rule.MCPParts(); server == "" {
+			return Rule{}, fmt.Errorf("missing server name in %q (use mcp__<server> or mcp__<server>__<tool>)", raw)
+		}
+	}
+
+	return rule, nil
+}
+
+// knownTools are Claude Code's built-in tools
+var knownTools = []string{
+	"Agent", "Bash", "BashOutput", "Edit", "ExitPlanMode", "Glob", "Grep",
+	"KillBash", "KillShell", "LS", "MultiEdit", "NotebookEdit", "NotebookRead",
+	"Read", "SlashCommand", "Skill", "Task", "TodoWrite", "WebFetch",
+	"WebSearch", "Write",
+}
+
+// pathTools take gitignore-style path patterns as specifiers
+var pathTools = []string{
+	"Edit", "Glob", "Grep", "LS", "MultiEdit", "NotebookEdit", "NotebookRead", "Read", "Write",
+}
+
+// noSpecifierTools do not support specifiers
+var noSpecifierTools = []string{
+	"BashOutput", "ExitPlanMode", "KillBash", "KillShell", "TodoWrite", "WebSearch",
+}
+
+// IsKnownTool reports whether tool is a built-in Claude Code tool
+func IsKnownTool(tool string) bool {
+	return contains(knownTools, tool)
+}
+
+// suggestTool returns the known tool closest to tool, or "" if none is
+// plausibly what was meant
+func suggestTool(tool string) string {
+	best, bestDistance := "", 3
+	for _, known := range knownTools {
+		if strings.EqualFold(known, tool) {
+			return known
+		}
+		if d := editDistance(strings.ToLower(tool), strings.ToLower(known)); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
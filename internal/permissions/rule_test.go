@@ -0,0 +1,69 @@
+package permissions
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw           string
+		wantTool      string
+		wantSpecifier string
+		wantErr       bool
+	}{
+		{raw: "Bash", wantTool: "Bash"},
+		{raw: "Bash(git diff:*)", wantTool: "Bash", wantSpecifier: "git diff:*"},
+		{raw: "Read(./src/**)", wantTool: "Read", wantSpecifier: "./src/**"},
+		{raw: "Bash(echo (hi))", wantTool: "Bash", wantSpecifier: "echo (hi)"},
+		{raw: "mcp__github", wantTool: "mcp__github"},
+		{raw: "mcp__github__create_issue", wantTool: "mcp__github__create_issue"},
+		{raw: "*", wantTool: "*"},
+		{raw: "", wantErr: true},
+		{raw: " Bash", wantErr: true},
+		{raw: "Bash(", wantErr: true},
+		{raw: "Bash)", wantErr: true},
+		{raw: "Bash()", wantErr: true},
+		{raw: "Bash(a)(b)", wantErr: true},
+		{raw: "Bash(a))", wantErr: true},
+		{raw: "(ls)", wantErr: true},
+		{raw: "Web Fetch", wantErr: true},
+		{raw: "mcp__", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			rule, err := Parse(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if rule.Tool != tt.wantTool || rule.Specifier != tt.wantSpecifier || rule.String() != tt.raw {
+				t.Errorf("Parse(%q) = %+v", tt.raw, rule)
+			}
+		})
+	}
+}
+
+func TestRule_MCPParts(t *testing.T) {
+	rule, _ := Parse("mcp__github__create_issue")
+	if server, tool := rule.MCPParts(); !rule.IsMCP() || server != "github" || tool != "create_issue" {
+		t.Errorf("MCPParts() = %q, %q", server, tool)
+	}
+	rule, _ = Parse("mcp__github")
+	if server, tool := rule.MCPParts(); server != "github" || tool != "" {
+		t.Errorf("MCPParts() = %q, %q", server, tool)
+	}
+}
+
+func TestSuggestTool(t *testing.T) {
+	for tool, want := range map[string]string{
+		"bash":     "Bash",
+		"Webfetch": "WebFetch",
+		"Raed":     "Read",
+		"Deploy":   "",
+	} {
+		if got := suggestTool(tool); got != want {
+			t.Errorf("suggestTool(%q) = %q, want %q", tool, got, want)
+		}
+	}
+}
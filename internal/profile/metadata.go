@@ -0,0 +1,97 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Metadata is the on-disk form of a profile's profile.json file. It holds
+// descriptive information and usage stats that are not part of Claude's
+// own configuration.
+type Metadata struct {
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Owner       string     `json:"owner,omitempty"`
+	CreatedAt   time.Time  `json:"created"`
+	UpdatedAt   time.Time  `json:"updated"`
+	LastUsedAt  *time.Time `json:"lastUsed,omitempty"`
+	UseCount    int        `json:"useCount,omitempty"`
+	// Suppress lists health rule IDs ignored for this profile
+	Suppress []string `json:"suppress,omitempty"`
+}
+
+// Metadata returns the profile's metadata
+func (p *Profile) Metadata() Metadata {
+	m := Metadata{
+		Description: p.Description,
+		Tags:        p.Tags,
+		Owner:       p.Owner,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		UseCount:    p.UseCount,
+		Suppress:    p.Suppress,
+	}
+	if !p.LastUsedAt.IsZero() {
+		lastUsed := p.LastUsedAt
+		m.LastUsedAt = &lastUsed
+	}
+	return m
+}
+
+// SetMetadata replaces the profile's metadata
+func (p *Profile) SetMetadata(m Metadata) {
+	p.Description = m.Description
+	p.Tags = m.Tags
+	p.Owner = m.Owner
+	p.CreatedAt = m.CreatedAt
+	p.UpdatedAt = m.UpdatedAt
+	p.UseCount = m.UseCount
+	p.Suppress = m.Suppress
+	p.LastUsedAt = time.Time{}
+	if m.LastUsedAt != nil {
+		p.LastUsedAt = *m.LastUsedAt
+	}
+}
+
+// MarkUsed records that the metadata's profile was just used
+func (m *Metadata) MarkUsed() {
+	now := time.Now()
+	m.LastUsedAt = &now
+	m.UseCount++
+}
+
+// HasTag reports whether the profile is tagged with tag (case-insensitive)
+func (m Metadata) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateMetadata checks user-editable metadata fields
+func ValidateMetadata(m Metadata) error {
+	if len(m.Description) > 1024 {
+		return errors.New("description too long (max 1024 characters)")
+	}
+	if len(m.Tags) > 100 {
+		return errors.New("too many tags (max 100)")
+	}
+	for _, tag := range m.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return errors.New("tags cannot be empty")
+		}
+		if strings.ContainsAny(tag, ", \t\n") {
+			return errors.New("tags cannot contain commas or whitespace")
+		}
+	}
+	for _, rule := range m.Suppress {
+		if strings.TrimSpace(rule) == "" || strings.ContainsAny(rule, ", \t\n") {
+			return fmt.Errorf("invalid rule ID %q in suppress", rule)
+		}
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package profile
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -15,8 +17,42 @@ type Profile struct {
 	Settings   *config.Settings
 	ClaudeMD   string
 	MCPServers mcpconfig.MCPServers
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	// DisabledMCPServers are kept with the profile but left out of the
+	// live configuration and run sessions until re-enabled
+	DisabledMCPServers mcpconfig.MCPServers
+	// ProjectMCPServers are written to projects.<path>.mcpServers in
+	// ~/.claude.json for each declared project path or glob
+	ProjectMCPServers mcpconfig.ProjectServers
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+
+	// Descriptive metadata and usage stats, persisted in profile.json
+	Description string
+	Tags        []string
+	Owner       string
+	LastUsedAt  time.Time
+	UseCount    int
+	// Suppress lists health rule IDs ignored for this profile
+	Suppress []string
+
+	// Project holds the parts applied inside a repository with
+	// "claudectx --project DIR <name>"; nil if the profile has none
+	Project *ProjectConfig
+}
+
+// ProjectConfig is the project-level part of a profile
+type ProjectConfig struct {
+	// Settings is written to .claude/settings.local.json
+	Settings *config.Settings
+	// ClaudeMD is written to CLAUDE.local.md
+	ClaudeMD string
+	// MCPServers are written to the mcpServers of .mcp.json
+	MCPServers mcpconfig.MCPServers
+}
+
+// IsEmpty reports whether the project config has nothing to apply
+func (c *ProjectConfig) IsEmpty() bool {
+	return c == nil || (c.Settings == nil && strings.TrimSpace(c.ClaudeMD) == "" && len(c.MCPServers) == 0)
 }
 
 // NewProfile creates a new empty profile with the given name
@@ -45,6 +81,96 @@ func ProfileFromCurrent(name string, settings *config.Settings, claudeMD string,
 	}
 }
 
+// Clone returns a deep copy of the profile, so the copy's settings and MCP
+// servers can be changed without affecting the original
+func (p *Profile) Clone() (*Profile, error) {
+	clone := *p
+	clone.Tags = append([]string(nil), p.Tags...)
+	clone.Suppress = append([]string(nil), p.Suppress...)
+
+	if p.Settings != nil {
+		settings, err := cloneSettings(p.Settings)
+		if err != nil {
+			return nil, err
+		}
+		clone.Settings = settings
+	}
+
+	if p.MCPServers != nil {
+		servers, err := cloneServers(p.MCPServers)
+		if err != nil {
+			return nil, err
+		}
+		clone.MCPServers = servers
+	}
+
+	if p.DisabledMCPServers != nil {
+		servers, err := cloneServers(p.DisabledMCPServers)
+		if err != nil {
+			return nil, err
+		}
+		clone.DisabledMCPServers = servers
+	}
+
+	if p.ProjectMCPServers != nil {
+		clone.ProjectMCPServers = make(mcpconfig.ProjectServers, len(p.ProjectMCPServers))
+		for projectPath, servers := range p.ProjectMCPServers {
+			copied, err := cloneServers(servers)
+			if err != nil {
+				return nil, err
+			}
+			clone.ProjectMCPServers[projectPath] = copied
+		}
+	}
+
+	if p.Project != nil {
+		project := *p.Project
+		if p.Project.Settings != nil {
+			settings, err := cloneSettings(p.Project.Settings)
+			if err != nil {
+				return nil, err
+			}
+			project.Settings = settings
+		}
+		if p.Project.MCPServers != nil {
+			servers, err := cloneServers(p.Project.MCPServers)
+			if err != nil {
+				return nil, err
+			}
+			project.MCPServers = servers
+		}
+		clone.Project = &project
+	}
+
+	return &clone, nil
+}
+
+// cloneSettings deep-copies settings through JSON, keeping extras
+func cloneSettings(settings *config.Settings) (*config.Settings, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy settings: %w", err)
+	}
+	clone := &config.Settings{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, fmt.Errorf("failed to copy settings: %w", err)
+	}
+	return clone, nil
+}
+
+// cloneServers deep-copies an MCP server map
+func cloneServers(servers mcpconfig.MCPServers) (mcpconfig.MCPServers, error) {
+	data, err := json.Marshal(servers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy MCP servers: %w", err)
+	}
+	clone := make(mcpconfig.MCPServers)
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy MCP servers: %w", err)
+	}
+	return clone, nil
+}
+
 // Touch updates the UpdatedAt timestamp
 func (p *Profile) Touch() {
 	p.UpdatedAt = time.Now()
@@ -73,11 +199,11 @@ func (p *Profile) IsEmpty() bool {
 	hasModel := p.Settings.Model != ""
 	hasEnv := len(p.Settings.Env) > 0
 	hasPermissions := p.Settings.Permissions != nil &&
-		(len(p.Settings.Permissions.Allow) > 0 || len(p.Settings.Permissions.Deny) > 0)
+		(len(p.Settings.Permissions.Allow) > 0 || len(p.Settings.Permissions.Deny) > 0 || len(p.Settings.Permissions.Ask) > 0)
 	hasClaudeMD := strings.TrimSpace(p.ClaudeMD) != ""
-	hasMCPServers := len(p.MCPServers) > 0
+	hasMCPServers := len(p.MCPServers) > 0 || len(p.DisabledMCPServers) > 0 || len(p.ProjectMCPServers) > 0
 
-	return !hasModel && !hasEnv && !hasPermissions && !hasClaudeMD && !hasMCPServers
+	return !hasModel && !hasEnv && !hasPermissions && !hasClaudeMD && !hasMCPServers && p.Project.IsEmpty()
 }
 
 // ValidateProfileName checks if a profile name is valid
@@ -245,3 +245,29 @@ func TestValidateProfileName(t *testing.T) {
 		})
 	}
 }
+
+func TestProfileClone(t *testing.T) {
+	p := NewProfile("work")
+	p.Settings.Env["KEY"] = "value"
+	p.Settings.Permissions = &config.Permissions{Allow: []string{"Read"}}
+	p.MCPServers["github"] = mcpconfig.MCPServer{Command: "npx", Args: []string{"-y"}}
+	p.Tags = []string{"client"}
+
+	clone, err := p.Clone()
+	if err != nil {
+		t.Fatalf("Clone() failed: %v", err)
+	}
+
+	clone.Settings.Env["KEY"] = "changed"
+	clone.Settings.Permissions.Allow[0] = "Write"
+	clone.MCPServers["github"].Args[0] = "--changed"
+	clone.Tags[0] = "personal"
+
+	if p.Settings.Env["KEY"] != "value" || p.Settings.Permissions.Allow[0] != "Read" ||
+		p.MCPServers["github"].Args[0] != "-y" || p.Tags[0] != "client" {
+		t.Error("changing the clone should not affect the original")
+	}
+	if clone.Name != "work" || clone.MCPServers["github"].Command != "npx" {
+		t.Errorf("clone = %+v", clone)
+	}
+}
@@ -0,0 +1,120 @@
+package selector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrCancelled is returned when the user dismisses a selector without choosing
+var ErrCancelled = errors.New("cancelled")
+
+// Finder describes an external fuzzy finder used for selection
+type Finder struct {
+	// Command is the executable and any fixed arguments
+	Command []string
+	// IsFzf is true when the finder accepts fzf's command-line flags
+	IsFzf bool
+}
+
+// DetectFinder returns the external finder to use, or nil when selection
+// should fall back to the built-in selector.
+//
+// Resolution order:
+//   - CLAUDECTX_IGNORE_FZF set: no external finder
+//   - CLAUDECTX_PICKER: arbitrary command that reads lines on stdin and
+//     prints the chosen line on stdout (no extra flags are passed)
+//   - CLAUDECTX_FZF: path to an fzf-compatible binary
+//   - fzf on PATH
+func DetectFinder() *Finder {
+	if os.Getenv("CLAUDECTX_IGNORE_FZF") != "" {
+		return nil
+	}
+
+	if picker := strings.Fields(os.Getenv("CLAUDECTX_PICKER")); len(picker) > 0 {
+		if _, err := exec.LookPath(picker[0]); err == nil {
+			return &Finder{Command: picker}
+		}
+	}
+
+	if fzf := os.Getenv("CLAUDECTX_FZF"); fzf != "" {
+		if path, err := exec.LookPath(fzf); err == nil {
+			return &Finder{Command: []string{path}, IsFzf: true}
+		}
+	}
+
+	if path, err := exec.LookPath("fzf"); err == nil {
+		return &Finder{Command: []string{path}, IsFzf: true}
+	}
+
+	return nil
+}
+
+// Run pipes the option labels to the finder and returns the index of the
+// chosen option. preview is an fzf preview command (e.g. "claudectx show {1}")
+// and is ignored for finders that are not fzf-compatible. fzf-compatible
+// finders are also shown each option's description after a tab.
+func (f *Finder) Run(title string, options []Option, preview string) (int, error) {
+	var input bytes.Buffer
+	for _, opt := range options {
+		input.WriteString(opt.Label)
+		if f.IsFzf && opt.Description != "" {
+			input.WriteString("\t" + opt.Description)
+		}
+		input.WriteByte('\n')
+	}
+
+	args := append([]string{}, f.Command[1:]...)
+	if f.IsFzf {
+		args = append(args, "--height=40%", "--reverse", "--no-multi", "--delimiter=\t")
+		if title != "" {
+			args = append(args, "--header="+title)
+		}
+		if preview != "" {
+			args = append(args, "--preview="+preview)
+		}
+	}
+
+	cmd := exec.Command(f.Command[0], args...)
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	var output bytes.Buffer
+	cmd.Stdout = &output
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// fzf exits 1 for no match and 130 when interrupted; other
+			// codes (2 for fzf) are real errors
+			if code := exitErr.ExitCode(); code == 1 || code == 130 {
+				return -1, ErrCancelled
+			}
+		}
+		return -1, fmt.Errorf("failed to run %s: %w", f.Command[0], err)
+	}
+
+	choice, _, _ := strings.Cut(strings.TrimSpace(output.String()), "\t")
+	if choice == "" {
+		return -1, ErrCancelled
+	}
+
+	for i, opt := range options {
+		if opt.Label == choice {
+			return i, nil
+		}
+	}
+
+	return -1, fmt.Errorf("picker returned unknown option %q", choice)
+}
+
+// Pick chooses one of options using an external fuzzy finder when one is
+// available, falling back to the built-in Select otherwise.
+func Pick(title string, options []Option, preview string) (int, error) {
+	if finder := DetectFinder(); finder != nil {
+		return finder.Run(title, options, preview)
+	}
+	return Select(title, options)
+}
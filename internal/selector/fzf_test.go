@@ -0,0 +1,152 @@
+package selector
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeScript creates an executable shell script in dir and returns its path
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestDetectFinder(t *testing.T) {
+	dir := t.TempDir()
+	fzf := writeScript(t, dir, "fzf", "head -n 1")
+	picker := writeScript(t, dir, "mypicker", "head -n 1")
+
+	t.Run("fzf on PATH", func(t *testing.T) {
+		t.Setenv("PATH", dir)
+		t.Setenv("CLAUDECTX_IGNORE_FZF", "")
+		t.Setenv("CLAUDECTX_PICKER", "")
+		t.Setenv("CLAUDECTX_FZF", "")
+
+		f := DetectFinder()
+		if f == nil || f.Command[0] != fzf || !f.IsFzf {
+			t.Fatalf("expected fzf finder, got %+v", f)
+		}
+	})
+
+	t.Run("ignore fzf", func(t *testing.T) {
+		t.Setenv("PATH", dir)
+		t.Setenv("CLAUDECTX_IGNORE_FZF", "1")
+
+		if f := DetectFinder(); f != nil {
+			t.Fatalf("expected no finder, got %+v", f)
+		}
+	})
+
+	t.Run("custom picker", func(t *testing.T) {
+		t.Setenv("PATH", dir)
+		t.Setenv("CLAUDECTX_IGNORE_FZF", "")
+		t.Setenv("CLAUDECTX_PICKER", picker+" --flag")
+
+		f := DetectFinder()
+		if f == nil || f.IsFzf || len(f.Command) != 2 || f.Command[1] != "--flag" {
+			t.Fatalf("expected custom picker, got %+v", f)
+		}
+	})
+
+	t.Run("explicit fzf path", func(t *testing.T) {
+		t.Setenv("PATH", "")
+		t.Setenv("CLAUDECTX_IGNORE_FZF", "")
+		t.Setenv("CLAUDECTX_PICKER", "")
+		t.Setenv("CLAUDECTX_FZF", fzf)
+
+		f := DetectFinder()
+		if f == nil || f.Command[0] != fzf || !f.IsFzf {
+			t.Fatalf("expected fzf finder, got %+v", f)
+		}
+	})
+
+	t.Run("nothing available", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		t.Setenv("CLAUDECTX_IGNORE_FZF", "")
+		t.Setenv("CLAUDECTX_PICKER", "")
+		t.Setenv("CLAUDECTX_FZF", "")
+
+		if f := DetectFinder(); f != nil {
+			t.Fatalf("expected no finder, got %+v", f)
+		}
+	})
+}
+
+func TestFinderRun(t *testing.T) {
+	dir := t.TempDir()
+	options := []Option{{Label: "personal"}, {Label: "work", IsCurrent: true}}
+
+	t.Run("returns chosen index", func(t *testing.T) {
+		f := &Finder{Command: []string{writeScript(t, dir, "second", "sed -n 2p")}}
+		idx, err := f.Run("Select:", options, "")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if idx != 1 {
+			t.Errorf("index = %d, want 1", idx)
+		}
+	})
+
+	t.Run("passes fzf flags", func(t *testing.T) {
+		script := writeScript(t, dir, "flags", `for a in "$@"; do case "$a" in --preview=*) echo personal;; esac; done`)
+		f := &Finder{Command: []string{script}, IsFzf: true}
+		idx, err := f.Run("Select:", options, "claudectx show {}")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if idx != 0 {
+			t.Errorf("index = %d, want 0", idx)
+		}
+	})
+
+	t.Run("strips description from fzf output", func(t *testing.T) {
+		described := []Option{{Label: "personal", Description: "Home account"}, {Label: "work"}}
+		f := &Finder{Command: []string{writeScript(t, dir, "described", "head -n 1")}, IsFzf: true}
+		idx, err := f.Run("Select:", described, "")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if idx != 0 {
+			t.Errorf("index = %d, want 0", idx)
+		}
+	})
+
+	t.Run("exit 1 or 130 is cancellation", func(t *testing.T) {
+		for _, code := range []string{"1", "130"} {
+			f := &Finder{Command: []string{writeScript(t, dir, "cancel"+code, "exit "+code)}}
+			_, err := f.Run("Select:", options, "")
+			if !errors.Is(err, ErrCancelled) {
+				t.Errorf("exit %s: expected ErrCancelled, got %v", code, err)
+			}
+		}
+	})
+
+	t.Run("other exit codes are errors", func(t *testing.T) {
+		f := &Finder{Command: []string{writeScript(t, dir, "broken", "exit 2")}}
+		_, err := f.Run("Select:", options, "")
+		if err == nil || errors.Is(err, ErrCancelled) {
+			t.Errorf("expected an error, got %v", err)
+		}
+	})
+
+	t.Run("empty output is cancellation", func(t *testing.T) {
+		f := &Finder{Command: []string{writeScript(t, dir, "empty", "cat >/dev/null")}}
+		_, err := f.Run("Select:", options, "")
+		if !errors.Is(err, ErrCancelled) {
+			t.Errorf("expected ErrCancelled, got %v", err)
+		}
+	})
+
+	t.Run("unknown output", func(t *testing.T) {
+		f := &Finder{Command: []string{writeScript(t, dir, "bogus", "echo nope")}}
+		if _, err := f.Run("Select:", options, ""); err == nil {
+			t.Error("expected error for unknown option")
+		}
+	})
+}
@@ -9,8 +9,9 @@ import (
 
 // Option represents a selectable option
 type Option struct {
-	Label     string
-	IsCurrent bool
+	Label       string
+	Description string
+	IsCurrent   bool
 }
 
 // Select displays an interactive selector and returns the selected index
@@ -54,6 +55,9 @@ func Select(title string, options []Option) (int, error) {
 				if opt.IsCurrent {
 					fmt.Fprint(os.Stderr, " \033[2m(current)\033[0m")
 				}
+				if opt.Description != "" {
+					fmt.Fprintf(os.Stderr, " \033[2m- %s\033[0m", opt.Description)
+				}
 				fmt.Fprint(os.Stderr, "\r\n")
 			} else {
 				// Unselected option
@@ -61,6 +65,9 @@ func Select(title string, options []Option) (int, error) {
 				if opt.IsCurrent {
 					fmt.Fprint(os.Stderr, " \033[2m(current)\033[0m")
 				}
+				if opt.Description != "" {
+					fmt.Fprintf(os.Stderr, " \033[2m- %s\033[0m", opt.Description)
+				}
 				fmt.Fprint(os.Stderr, "\r\n")
 			}
 		}
@@ -83,10 +90,10 @@ func Select(title string, options []Option) (int, error) {
 				return selected, nil
 			case 3: // Ctrl+C
 				fmt.Fprint(os.Stderr, "\033[2J\033[H") // Clear screen
-				return -1, fmt.Errorf("cancelled")
+				return -1, ErrCancelled
 			case 27: // ESC
 				fmt.Fprint(os.Stderr, "\033[2J\033[H") // Clear screen
-				return -1, fmt.Errorf("cancelled")
+				return -1, ErrCancelled
 			}
 		} else if n == 3 && buf[0] == 27 && buf[1] == 91 {
 			// Arrow keys
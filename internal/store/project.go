@@ -0,0 +1,89 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/paths"
+)
+
+// GetProjectCurrent returns the name of the profile currently applied to
+// a project
+func (s *Store) GetProjectCurrent(projectDir string) (string, error) {
+	return readProjectTracker(projectDir, "current")
+}
+
+// SetProjectCurrent records the profile currently applied to a project
+func (s *Store) SetProjectCurrent(projectDir, name string) error {
+	return writeProjectTracker(projectDir, "current", name)
+}
+
+// GetProjectPrevious returns the name of the profile previously applied to
+// a project
+func (s *Store) GetProjectPrevious(projectDir string) (string, error) {
+	return readProjectTracker(projectDir, "previous")
+}
+
+// SetProjectPrevious records the profile previously applied to a project
+func (s *Store) SetProjectPrevious(projectDir, name string) error {
+	return writeProjectTracker(projectDir, "previous", name)
+}
+
+// readProjectTracker reads a tracker file from the project's state directory
+func readProjectTracker(projectDir, tracker string) (string, error) {
+	stateDir, err := paths.ProjectStateDir(projectDir)
+	if err != nil {
+		return "", err
+	}
+
+	trackerFile := filepath.Join(stateDir, tracker)
+	if !config.FileExists(trackerFile) {
+		return "", nil
+	}
+
+	content, err := os.ReadFile(trackerFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s project profile file: %w", tracker, err)
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// writeProjectTracker writes a tracker file to the project's state
+// directory, removing it when name is empty. The directory also records the
+// project's path so the state can be traced back to its repository.
+func writeProjectTracker(projectDir, tracker, name string) error {
+	stateDir, err := paths.ProjectStateDir(projectDir)
+	if err != nil {
+		return err
+	}
+
+	trackerFile := filepath.Join(stateDir, tracker)
+	if name == "" {
+		if config.FileExists(trackerFile) {
+			return os.Remove(trackerFile)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create project state directory: %w", err)
+	}
+
+	abs, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, "path"), []byte(abs+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write project path file: %w", err)
+	}
+
+	if err := os.WriteFile(trackerFile, []byte(name), 0644); err != nil {
+		return fmt.Errorf("failed to write %s project profile file: %w", tracker, err)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,6 +13,9 @@ import (
 	"github.com/johnfox/claudectx/internal/profile"
 )
 
+// projectSubdir is the profile subdirectory holding its project part
+const projectSubdir = "project"
+
 // Store manages profile persistence on the filesystem
 type Store struct {
 	profilesDir string
@@ -89,9 +93,128 @@ func (s *Store) Save(prof *profile.Profile) error {
 		}
 	}
 
+	// Save mcp-disabled.json if any MCP servers are disabled
+	disabledPath, err := paths.ProfileFile(prof.Name, "mcp-disabled.json")
+	if err != nil {
+		return err
+	}
+
+	if len(prof.DisabledMCPServers) > 0 {
+		err = mcpconfig.SaveToFile(disabledPath, prof.DisabledMCPServers)
+		if err != nil {
+			return fmt.Errorf("failed to save mcp-disabled.json: %w", err)
+		}
+	} else if config.FileExists(disabledPath) {
+		os.Remove(disabledPath)
+	}
+
+	// Save project-mcp.json if per-project MCP servers are declared
+	projectMCPPath, err := paths.ProfileFile(prof.Name, "project-mcp.json")
+	if err != nil {
+		return err
+	}
+
+	if len(prof.ProjectMCPServers) > 0 {
+		data, err := json.MarshalIndent(prof.ProjectMCPServers, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal project-mcp.json: %w", err)
+		}
+		data = append(data, '\n')
+		if err := os.WriteFile(projectMCPPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to save project-mcp.json: %w", err)
+		}
+	} else if config.FileExists(projectMCPPath) {
+		os.Remove(projectMCPPath)
+	}
+
+	if err := s.saveProject(prof); err != nil {
+		return err
+	}
+
+	// Save profile.json metadata
+	if err := s.SaveMetadata(prof.Name, prof.Metadata()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// saveProject writes the profile's project part to the project/
+// subdirectory, removing it when the profile has none
+func (s *Store) saveProject(prof *profile.Profile) error {
+	projectDir, err := paths.ProfileFile(prof.Name, projectSubdir)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(projectDir); err != nil {
+		return fmt.Errorf("failed to clear project configuration: %w", err)
+	}
+	if prof.Project.IsEmpty() {
+		return nil
+	}
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		return fmt.Errorf("failed to create project configuration directory: %w", err)
+	}
+
+	project := prof.Project
+	if project.Settings != nil {
+		if err := config.SaveSettings(filepath.Join(projectDir, "settings.local.json"), project.Settings); err != nil {
+			return fmt.Errorf("failed to save project settings: %w", err)
+		}
+	}
+	if strings.TrimSpace(project.ClaudeMD) != "" {
+		if err := os.WriteFile(filepath.Join(projectDir, "CLAUDE.local.md"), []byte(project.ClaudeMD), 0644); err != nil {
+			return fmt.Errorf("failed to save project CLAUDE.local.md: %w", err)
+		}
+	}
+	if len(project.MCPServers) > 0 {
+		if err := mcpconfig.SaveToFile(filepath.Join(projectDir, "mcp.json"), project.MCPServers); err != nil {
+			return fmt.Errorf("failed to save project mcp.json: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadProject reads the profile's project part, if it has one
+func (s *Store) loadProject(name string) (*profile.ProjectConfig, error) {
+	projectDir, err := paths.ProfileFile(name, projectSubdir)
+	if err != nil {
+		return nil, err
+	}
+	if !config.FileExists(projectDir) {
+		return nil, nil
+	}
+
+	project := &profile.ProjectConfig{}
+	settingsPath := filepath.Join(projectDir, "settings.local.json")
+	if config.FileExists(settingsPath) {
+		settings, err := config.LoadSettings(settingsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load project settings: %w", err)
+		}
+		project.Settings = settings
+	}
+
+	claudeMDPath := filepath.Join(projectDir, "CLAUDE.local.md")
+	if config.FileExists(claudeMDPath) {
+		content, err := os.ReadFile(claudeMDPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read project CLAUDE.local.md: %w", err)
+		}
+		project.ClaudeMD = string(content)
+	}
+
+	servers, err := mcpconfig.LoadFromFile(filepath.Join(projectDir, "mcp.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project mcp.json: %w", err)
+	}
+	if len(servers) > 0 {
+		project.MCPServers = servers
+	}
+
+	return project, nil
+}
+
 // Load loads a profile from disk
 func (s *Store) Load(name string) (*profile.Profile, error) {
 	if !s.Exists(name) {
@@ -140,9 +263,119 @@ func (s *Store) Load(name string) (*profile.Profile, error) {
 		prof.MCPServers = servers
 	}
 
+	// Load mcp-disabled.json if it exists
+	disabledPath, err := paths.ProfileFile(name, "mcp-disabled.json")
+	if err != nil {
+		return nil, err
+	}
+
+	if config.FileExists(disabledPath) {
+		servers, err := mcpconfig.LoadFromFile(disabledPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mcp-disabled.json: %w", err)
+		}
+		prof.DisabledMCPServers = servers
+	}
+
+	// Load project-mcp.json if it exists
+	projectMCPPath, err := paths.ProfileFile(name, "project-mcp.json")
+	if err != nil {
+		return nil, err
+	}
+
+	if config.FileExists(projectMCPPath) {
+		data, err := os.ReadFile(projectMCPPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read project-mcp.json: %w", err)
+		}
+		if err := json.Unmarshal(data, &prof.ProjectMCPServers); err != nil {
+			return nil, fmt.Errorf("failed to parse project-mcp.json: %w", err)
+		}
+	}
+
+	// Load the project part from project/ if it exists
+	prof.Project, err = s.loadProject(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load profile.json metadata
+	meta, err := s.LoadMetadata(name)
+	if err != nil {
+		return nil, err
+	}
+	prof.SetMetadata(meta)
+
 	return prof, nil
 }
 
+// LoadMetadata reads a profile's profile.json. Profiles saved before
+// metadata existed get timestamps from their settings.json modification time.
+func (s *Store) LoadMetadata(name string) (profile.Metadata, error) {
+	metaPath, err := paths.ProfileFile(name, "profile.json")
+	if err != nil {
+		return profile.Metadata{}, err
+	}
+
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return profile.Metadata{}, fmt.Errorf("failed to read profile.json: %w", err)
+		}
+
+		var meta profile.Metadata
+		settingsPath, err := paths.ProfileFile(name, "settings.json")
+		if err != nil {
+			return profile.Metadata{}, err
+		}
+		if info, err := os.Stat(settingsPath); err == nil {
+			meta.CreatedAt = info.ModTime()
+			meta.UpdatedAt = info.ModTime()
+		}
+		return meta, nil
+	}
+
+	var meta profile.Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return profile.Metadata{}, fmt.Errorf("failed to parse profile.json: %w", err)
+	}
+	return meta, nil
+}
+
+// SaveMetadata writes a profile's profile.json
+func (s *Store) SaveMetadata(name string, meta profile.Metadata) error {
+	metaPath, err := paths.ProfileFile(name, "profile.json")
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile.json: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.WriteFile(metaPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save profile.json: %w", err)
+	}
+	return nil
+}
+
+// MarkUsed records a use of the profile (last-used time and use count)
+// without changing its configuration or updated timestamp
+func (s *Store) MarkUsed(name string) error {
+	if !s.Exists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	meta, err := s.LoadMetadata(name)
+	if err != nil {
+		return err
+	}
+	meta.MarkUsed()
+	return s.SaveMetadata(name, meta)
+}
+
 // List returns the names of all profiles
 func (s *Store) List() ([]string, error) {
 	entries, err := os.ReadDir(s.profilesDir)
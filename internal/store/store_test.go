@@ -4,7 +4,10 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
 	"github.com/johnfox/claudectx/internal/paths"
 	"github.com/johnfox/claudectx/internal/profile"
 )
@@ -376,3 +379,196 @@ func TestSaveWithoutClaudeMD(t *testing.T) {
 		t.Errorf("ClaudeMD should be empty, got %q", loaded.ClaudeMD)
 	}
 }
+
+func TestStoreMetadataRoundtrip(t *testing.T) {
+	setupTestEnv(t)
+	s, _ := NewStore()
+
+	prof := profile.NewProfile("work")
+	prof.Description = "Client work"
+	prof.Tags = []string{"client", "proxy"}
+	prof.Owner = "jane"
+	created := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	prof.CreatedAt = created
+	if err := s.Save(prof); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := s.Load("work")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if loaded.Description != "Client work" || loaded.Owner != "jane" || len(loaded.Tags) != 2 {
+		t.Errorf("metadata not loaded: %+v", loaded.Metadata())
+	}
+	if !loaded.CreatedAt.Equal(created) {
+		t.Errorf("CreatedAt = %v, want %v (must not reset on load)", loaded.CreatedAt, created)
+	}
+}
+
+func TestStoreMarkUsed(t *testing.T) {
+	setupTestEnv(t)
+	s, _ := NewStore()
+
+	prof := profile.NewProfile("work")
+	if err := s.Save(prof); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	before, _ := s.LoadMetadata("work")
+
+	if err := s.MarkUsed("work"); err != nil {
+		t.Fatalf("MarkUsed() failed: %v", err)
+	}
+	if err := s.MarkUsed("work"); err != nil {
+		t.Fatalf("MarkUsed() failed: %v", err)
+	}
+
+	meta, err := s.LoadMetadata("work")
+	if err != nil {
+		t.Fatalf("LoadMetadata() failed: %v", err)
+	}
+	if meta.UseCount != 2 || meta.LastUsedAt == nil {
+		t.Errorf("usage not recorded: %+v", meta)
+	}
+	if !meta.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Error("MarkUsed must not change the updated timestamp")
+	}
+
+	if err := s.MarkUsed("missing"); err == nil {
+		t.Error("expected error for missing profile")
+	}
+}
+
+func TestStoreLoadMetadata_LegacyProfile(t *testing.T) {
+	setupTestEnv(t)
+	s, _ := NewStore()
+
+	if err := s.Save(profile.NewProfile("old")); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	metaPath, _ := paths.ProfileFile("old", "profile.json")
+	if err := os.Remove(metaPath); err != nil {
+		t.Fatalf("failed to remove profile.json: %v", err)
+	}
+
+	meta, err := s.LoadMetadata("old")
+	if err != nil {
+		t.Fatalf("LoadMetadata() failed: %v", err)
+	}
+	if meta.CreatedAt.IsZero() {
+		t.Error("expected CreatedAt from settings.json modification time")
+	}
+	if !s.Exists("old") {
+		t.Error("profile without profile.json should still exist")
+	}
+}
+
+func TestSaveAndLoadProjectPart(t *testing.T) {
+	setupTestEnv(t)
+	store, _ := NewStore()
+
+	prof := profile.NewProfile("work")
+	prof.Project = &profile.ProjectConfig{
+		Settings: &config.Settings{Model: "sonnet"},
+		ClaudeMD: "# Local notes",
+		MCPServers: mcpconfig.MCPServers{
+			"db": {Command: "db-mcp"},
+		},
+	}
+	if err := store.Save(prof); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := store.Load("work")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if loaded.Project == nil {
+		t.Fatal("expected project part to be loaded")
+	}
+	if loaded.Project.Settings.Model != "sonnet" || loaded.Project.ClaudeMD != "# Local notes" || loaded.Project.MCPServers["db"].Command != "db-mcp" {
+		t.Errorf("loaded project part = %+v", loaded.Project)
+	}
+
+	// Removing the project part removes its directory
+	loaded.Project = nil
+	if err := store.Save(loaded); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	projectDir, _ := paths.ProfileFile("work", "project")
+	if _, err := os.Stat(projectDir); !os.IsNotExist(err) {
+		t.Errorf("project directory should be removed, stat err = %v", err)
+	}
+	reloaded, _ := store.Load("work")
+	if reloaded.Project != nil {
+		t.Errorf("expected no project part, got %+v", reloaded.Project)
+	}
+}
+
+func TestProjectTrackers(t *testing.T) {
+	setupTestEnv(t)
+	store, _ := NewStore()
+	projectA := t.TempDir()
+	projectB := t.TempDir()
+
+	if current, _ := store.GetProjectCurrent(projectA); current != "" {
+		t.Errorf("expected no current project profile, got %q", current)
+	}
+
+	if err := store.SetProjectCurrent(projectA, "work"); err != nil {
+		t.Fatalf("SetProjectCurrent() failed: %v", err)
+	}
+	if err := store.SetProjectPrevious(projectA, "personal"); err != nil {
+		t.Fatalf("SetProjectPrevious() failed: %v", err)
+	}
+
+	if current, _ := store.GetProjectCurrent(projectA); current != "work" {
+		t.Errorf("GetProjectCurrent() = %q, want work", current)
+	}
+	if previous, _ := store.GetProjectPrevious(projectA); previous != "personal" {
+		t.Errorf("GetProjectPrevious() = %q, want personal", previous)
+	}
+
+	// Trackers are per project and separate from the global ones
+	if current, _ := store.GetProjectCurrent(projectB); current != "" {
+		t.Errorf("other project should have no current profile, got %q", current)
+	}
+	if current, _ := store.GetCurrent(); current != "" {
+		t.Errorf("global current should be unset, got %q", current)
+	}
+
+	if err := store.SetProjectCurrent(projectA, ""); err != nil {
+		t.Fatalf("SetProjectCurrent(\"\") failed: %v", err)
+	}
+	if current, _ := store.GetProjectCurrent(projectA); current != "" {
+		t.Errorf("expected current project profile to be cleared, got %q", current)
+	}
+}
+
+func TestSaveAndLoadProjectMCPServers(t *testing.T) {
+	setupTestEnv(t)
+	store, _ := NewStore()
+
+	prof := profile.NewProfile("work")
+	prof.ProjectMCPServers = mcpconfig.ProjectServers{
+		"~/src/*": {"db": {Command: "db-mcp"}},
+	}
+	if err := store.Save(prof); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := store.Load("work")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if loaded.ProjectMCPServers["~/src/*"]["db"].Command != "db-mcp" {
+		t.Errorf("ProjectMCPServers = %+v", loaded.ProjectMCPServers)
+	}
+
+	loaded.ProjectMCPServers = nil
+	store.Save(loaded)
+	projectMCPPath, _ := paths.ProfileFile("work", "project-mcp.json")
+	if _, err := os.Stat(projectMCPPath); !os.IsNotExist(err) {
+		t.Errorf("project-mcp.json should be removed, stat err = %v", err)
+	}
+}
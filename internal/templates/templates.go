@@ -0,0 +1,336 @@
+package templates
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/paths"
+	"github.com/johnfox/claudectx/internal/profile"
+)
+
+//go:embed builtin/*.json
+var builtinFS embed.FS
+
+// SourceBuiltin is the Source of templates shipped with claudectx
+const SourceBuiltin = "builtin"
+
+// placeholderPattern matches {{name}} references to template variables
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// variableNamePattern restricts variable names so they are easy to pass as --var
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Variable is a value the user supplies when creating a profile from a template
+type Variable struct {
+	Name string `json:"name"`
+	// Prompt is the question shown by the new-profile wizard
+	Prompt  string `json:"prompt,omitempty"`
+	Default string `json:"default,omitempty"`
+	// Required variables must end up non-empty
+	Required bool `json:"required,omitempty"`
+	// Secret variables are read without echo and masked in output
+	Secret bool `json:"secret,omitempty"`
+	// Pattern is an optional regular expression non-empty values must match
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Template describes a profile with {{variable}} placeholders. Placeholders
+// may appear in any string in settings and mcpServers, and in claudeMD.
+type Template struct {
+	Name        string          `json:"-"`
+	Description string          `json:"description,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	Variables   []Variable      `json:"variables,omitempty"`
+	Settings    json.RawMessage `json:"settings,omitempty"`
+	ClaudeMD    string          `json:"claudeMD,omitempty"`
+	MCPServers  json.RawMessage `json:"mcpServers,omitempty"`
+	// Source is SourceBuiltin or the path of a user template file
+	Source string `json:"-"`
+}
+
+// Parse decodes and checks a template definition
+func Parse(name string, data []byte) (*Template, error) {
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("template %q: invalid JSON: %w", name, err)
+	}
+	t.Name = name
+
+	if err := t.check(); err != nil {
+		return nil, fmt.Errorf("template %q: %w", name, err)
+	}
+	return &t, nil
+}
+
+// check verifies variable declarations and that every placeholder refers to
+// a declared variable
+func (t *Template) check() error {
+	declared := make(map[string]bool, len(t.Variables))
+	for _, v := range t.Variables {
+		if !variableNamePattern.MatchString(v.Name) {
+			return fmt.Errorf("invalid variable name %q", v.Name)
+		}
+		if declared[v.Name] {
+			return fmt.Errorf("variable %q declared twice", v.Name)
+		}
+		declared[v.Name] = true
+
+		if v.Pattern != "" {
+			if _, err := regexp.Compile(v.Pattern); err != nil {
+				return fmt.Errorf("variable %q: invalid pattern: %w", v.Name, err)
+			}
+		}
+	}
+
+	for _, raw := range []string{string(t.Settings), string(t.MCPServers), t.ClaudeMD} {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(raw, -1) {
+			if !declared[m[1]] {
+				return fmt.Errorf("placeholder {{%s}} has no matching variable", m[1])
+			}
+		}
+	}
+	return nil
+}
+
+// Builtin returns the templates embedded in claudectx
+func Builtin() ([]*Template, error) {
+	entries, err := builtinFS.ReadDir("builtin")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Template
+	for _, entry := range entries {
+		data, err := builtinFS.ReadFile("builtin/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		t, err := Parse(strings.TrimSuffix(entry.Name(), ".json"), data)
+		if err != nil {
+			return nil, err
+		}
+		t.Source = SourceBuiltin
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// User returns the templates in ~/.claude/profile-templates. A missing
+// directory yields no templates.
+func User() ([]*Template, error) {
+	dir, err := paths.TemplatesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	var result []*Template
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		t, err := Parse(strings.TrimSuffix(entry.Name(), ".json"), data)
+		if err != nil {
+			return nil, err
+		}
+		t.Source = path
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// List returns all available templates sorted by name. User templates
+// replace built-in templates of the same name.
+func List() ([]*Template, error) {
+	builtin, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	user, err := User()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*Template)
+	for _, t := range append(builtin, user...) {
+		byName[t.Name] = t
+	}
+
+	result := make([]*Template, 0, len(byName))
+	for _, t := range byName {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Get returns the named template
+func Get(name string) (*Template, error) {
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, t := range all {
+		if t.Name == name {
+			return t, nil
+		}
+		names = append(names, t.Name)
+	}
+	return nil, fmt.Errorf("template %q not found (available: %s)", name, strings.Join(names, ", "))
+}
+
+// Variable returns the declared variable with the given name
+func (t *Template) Variable(name string) (Variable, bool) {
+	for _, v := range t.Variables {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+// Check validates a value for the variable
+func (v Variable) Check(value string) error {
+	if value == "" {
+		if v.Required {
+			return fmt.Errorf("%s is required", v.Name)
+		}
+		return nil
+	}
+	if v.Pattern != "" && !regexp.MustCompile(v.Pattern).MatchString(value) {
+		return fmt.Errorf("%s %q does not match %s", v.Name, value, v.Pattern)
+	}
+	return nil
+}
+
+// Resolve fills in defaults for unset variables and validates every value.
+// Values for undeclared variables are rejected.
+func (t *Template) Resolve(values map[string]string) (map[string]string, error) {
+	for name := range values {
+		if _, ok := t.Variable(name); !ok {
+			return nil, fmt.Errorf("template %q has no variable %q", t.Name, name)
+		}
+	}
+
+	resolved := make(map[string]string, len(t.Variables))
+	for _, v := range t.Variables {
+		value, ok := values[v.Name]
+		if !ok {
+			value = v.Default
+		}
+		if err := v.Check(value); err != nil {
+			return nil, err
+		}
+		resolved[v.Name] = value
+	}
+	return resolved, nil
+}
+
+// Render resolves values and builds a profile named name from the template.
+// Keys whose value is a lone placeholder for an empty variable are omitted,
+// so optional variables left blank don't leave empty settings behind.
+func (t *Template) Render(name string, values map[string]string) (*profile.Profile, error) {
+	resolved, err := t.Resolve(values)
+	if err != nil {
+		return nil, err
+	}
+
+	prof := profile.NewProfile(name)
+	prof.Description = t.Description
+	prof.Tags = append([]string(nil), t.Tags...)
+	prof.ClaudeMD = substitute(t.ClaudeMD, resolved)
+
+	if len(t.Settings) > 0 {
+		data, err := renderJSON(t.Settings, resolved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render settings: %w", err)
+		}
+		var settings config.Settings
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("template settings are invalid: %w", err)
+		}
+		prof.Settings = &settings
+	}
+
+	if len(t.MCPServers) > 0 {
+		data, err := renderJSON(t.MCPServers, resolved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render mcpServers: %w", err)
+		}
+		var servers mcpconfig.MCPServers
+		if err := json.Unmarshal(data, &servers); err != nil {
+			return nil, fmt.Errorf("template mcpServers are invalid: %w", err)
+		}
+		prof.MCPServers = servers
+	}
+
+	return prof, nil
+}
+
+// renderJSON substitutes placeholders in every string of a JSON document
+func renderJSON(raw json.RawMessage, values map[string]string) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	rendered, _ := renderNode(doc, values)
+	return json.Marshal(rendered)
+}
+
+// renderNode substitutes placeholders in node. The boolean result is false
+// when node was a lone placeholder for an empty value and should be dropped.
+func renderNode(node interface{}, values map[string]string) (interface{}, bool) {
+	switch n := node.(type) {
+	case string:
+		if m := placeholderPattern.FindStringSubmatch(n); m != nil && m[0] == n && values[m[1]] == "" {
+			return nil, false
+		}
+		return substitute(n, values), true
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			if rendered, keep := renderNode(v, values); keep {
+				out[k] = rendered
+			}
+		}
+		return out, true
+	case []interface{}:
+		out := make([]interface{}, 0, len(n))
+		for _, v := range n {
+			if rendered, keep := renderNode(v, values); keep {
+				out = append(out, rendered)
+			}
+		}
+		return out, true
+	default:
+		return node, true
+	}
+}
+
+// substitute replaces {{name}} placeholders in s
+func substitute(s string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		return values[placeholderPattern.FindStringSubmatch(match)[1]]
+	})
+}
@@ -0,0 +1,179 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinTemplatesRender(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	builtin, err := Builtin()
+	if err != nil {
+		t.Fatalf("Builtin failed: %v", err)
+	}
+
+	want := map[string]bool{"bedrock": true, "vertex": true, "zai": true, "openrouter": true}
+	for _, tmpl := range builtin {
+		delete(want, tmpl.Name)
+
+		// Supply a value for every required variable without a default
+		values := map[string]string{}
+		for _, v := range tmpl.Variables {
+			if v.Required && v.Default == "" {
+				values[v.Name] = "my-project-123"
+			}
+		}
+
+		prof, err := tmpl.Render("test", values)
+		if err != nil {
+			t.Errorf("%s: Render failed: %v", tmpl.Name, err)
+			continue
+		}
+		if prof.Settings == nil || len(prof.Settings.Env) == 0 {
+			t.Errorf("%s: expected env settings, got %+v", tmpl.Name, prof.Settings)
+		}
+		for k, v := range prof.Settings.Env {
+			if strings.Contains(v, "{{") {
+				t.Errorf("%s: %s left unrendered: %q", tmpl.Name, k, v)
+			}
+		}
+	}
+
+	for name := range want {
+		t.Errorf("missing built-in template %q", name)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := Parse("proxy", []byte(`{
+		"description": "Corporate proxy",
+		"tags": ["proxy"],
+		"variables": [
+			{"name": "base_url", "required": true, "pattern": "^https://"},
+			{"name": "token", "secret": true},
+			{"name": "region", "default": "eu"}
+		],
+		"settings": {"model": "opus", "env": {"ANTHROPIC_BASE_URL": "{{base_url}}/{{region}}", "ANTHROPIC_AUTH_TOKEN": "{{token}}"}},
+		"claudeMD": "Routed through {{ base_url }}",
+		"mcpServers": {"docs": {"url": "{{base_url}}/mcp"}}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	prof, err := tmpl.Render("work", map[string]string{"base_url": "https://proxy"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if got := prof.Settings.Env["ANTHROPIC_BASE_URL"]; got != "https://proxy/eu" {
+		t.Errorf("ANTHROPIC_BASE_URL = %q, want https://proxy/eu", got)
+	}
+	if _, ok := prof.Settings.Env["ANTHROPIC_AUTH_TOKEN"]; ok {
+		t.Error("empty optional variable should drop the key")
+	}
+	if prof.Settings.Model != "opus" {
+		t.Errorf("Model = %q, want opus", prof.Settings.Model)
+	}
+	if prof.ClaudeMD != "Routed through https://proxy" {
+		t.Errorf("ClaudeMD = %q", prof.ClaudeMD)
+	}
+	if prof.MCPServers["docs"].URL != "https://proxy/mcp" {
+		t.Errorf("MCP url = %q", prof.MCPServers["docs"].URL)
+	}
+	if prof.Description != "Corporate proxy" || len(prof.Tags) != 1 {
+		t.Errorf("metadata not copied: %+v", prof.Metadata())
+	}
+
+	errorCases := map[string]map[string]string{
+		"missing required": {},
+		"pattern mismatch": {"base_url": "http://insecure"},
+		"unknown variable": {"base_url": "https://proxy", "bogus": "x"},
+	}
+	for name, values := range errorCases {
+		if _, err := tmpl.Render("work", values); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"bad json":          `{`,
+		"undeclared":        `{"settings": {"model": "{{model}}"}}`,
+		"duplicate":         `{"variables": [{"name": "a"}, {"name": "a"}]}`,
+		"bad name":          `{"variables": [{"name": "my-var"}]}`,
+		"bad pattern":       `{"variables": [{"name": "a", "pattern": "("}]}`,
+		"undeclared in md":  `{"claudeMD": "{{who}}"}`,
+		"undeclared in mcp": `{"mcpServers": {"x": {"command": "{{cmd}}"}}}`,
+	}
+	for name, data := range cases {
+		if _, err := Parse("t", []byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestListAndGet_UserTemplates(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".claude", "profile-templates")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"team.json":    `{"description": "Team defaults", "settings": {"model": "sonnet"}}`,
+		"bedrock.json": `{"description": "Our Bedrock setup", "settings": {"env": {"CLAUDE_CODE_USE_BEDROCK": "1"}}}`,
+		"notes.txt":    `ignored`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var names []string
+	for _, tmpl := range all {
+		names = append(names, tmpl.Name)
+	}
+	if got := strings.Join(names, ","); got != "bedrock,openrouter,team,vertex,zai" {
+		t.Errorf("List() names = %s", got)
+	}
+
+	bedrock, err := Get("bedrock")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if bedrock.Source == SourceBuiltin || bedrock.Description != "Our Bedrock setup" {
+		t.Errorf("user template should override built-in, got %+v", bedrock)
+	}
+
+	if _, err := Get("missing"); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestList_InvalidUserTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".claude", "profile-templates")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"settings": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := List(); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected error naming the broken template, got %v", err)
+	}
+}
@@ -3,9 +3,15 @@ package validator
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
+	"github.com/johnfox/claudectx/internal/permissions"
 )
 
 // ValidateJSONFile validates that a file contains valid JSON
@@ -52,6 +58,180 @@ func ValidateSettings(settings *config.Settings) error {
 		return err
 	}
 
+	if err := ValidateHooks(settings); err != nil {
+		return err
+	}
+
+	return ValidateSettingsFields(settings)
+}
+
+// ValidateHooks validates the shape of the hooks setting. Hook types other
+// than command and prompt are accepted as-is; health warns about them, and
+// about hook commands that don't exist on this machine.
+func ValidateHooks(settings *config.Settings) error {
+	hooks, err := settings.Hooks()
+	if err != nil {
+		return fmt.Errorf("invalid hooks: %w", err)
+	}
+
+	for event, matchers := range hooks {
+		for _, matcher := range matchers {
+			for _, hook := range matcher.Hooks {
+				switch hook.Type {
+				case "command":
+					if strings.TrimSpace(hook.Command) == "" {
+						return fmt.Errorf("%s hook has no command", event)
+					}
+				case "prompt":
+					if strings.TrimSpace(hook.Prompt) == "" {
+						return fmt.Errorf("%s hook has no prompt", event)
+					}
+				case "":
+					return fmt.Errorf("%s hook has no type", event)
+				}
+				if hook.Timeout < 0 {
+					return fmt.Errorf("%s hook has a negative timeout", event)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateSettingsFields validates the typed settings kept outside the core
+// fields: statusLine, apiKeyHelper, outputStyle, enabledPlugins,
+// includeCoAuthoredBy, and permissions.defaultMode and
+// additionalDirectories
+func ValidateSettingsFields(settings *config.Settings) error {
+	line, err := settings.StatusLine()
+	if err != nil {
+		return fmt.Errorf("invalid statusLine: %w", err)
+	}
+	if line != nil {
+		if line.Type != "command" {
+			return fmt.Errorf("invalid statusLine type %q (must be command)", line.Type)
+		}
+		if strings.TrimSpace(line.Command) == "" {
+			return fmt.Errorf("statusLine has no command")
+		}
+	}
+
+	if _, err := settings.APIKeyHelper(); err != nil {
+		return fmt.Errorf("invalid apiKeyHelper: %w", err)
+	}
+	if _, err := settings.OutputStyle(); err != nil {
+		return fmt.Errorf("invalid outputStyle: %w", err)
+	}
+	if _, err := settings.EnabledPlugins(); err != nil {
+		return fmt.Errorf("invalid enabledPlugins: %w", err)
+	}
+	if _, err := settings.IncludeCoAuthoredBy(); err != nil {
+		return fmt.Errorf("invalid includeCoAuthoredBy: %w", err)
+	}
+
+	if perms := settings.Permissions; perms != nil {
+		mode, err := perms.DefaultMode()
+		if err != nil {
+			return fmt.Errorf("invalid permissions.defaultMode: %w", err)
+		}
+		if mode != "" && !slices.Contains(config.PermissionModes, mode) {
+			return fmt.Errorf("invalid permissions.defaultMode %q (valid: %s)", mode, strings.Join(config.PermissionModes, ", "))
+		}
+
+		dirs, err := perms.AdditionalDirectories()
+		if err != nil {
+			return fmt.Errorf("invalid permissions.additionalDirectories: %w", err)
+		}
+		for _, dir := range dirs {
+			if strings.TrimSpace(dir) == "" {
+				return fmt.Errorf("permissions.additionalDirectories contains an empty path")
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateMCPServers validates each server with ValidateMCPServer
+func ValidateMCPServers(servers mcpconfig.MCPServers) error {
+	for _, name := range slices.Sorted(maps.Keys(servers)) {
+		if err := ValidateMCPServer(servers[name]); err != nil {
+			return fmt.Errorf("MCP server %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateMCPServer checks that a server has what its transport needs: a
+// command for stdio servers, an http(s) URL for http and sse servers. It
+// then validates the remaining typed fields.
+func ValidateMCPServer(server mcpconfig.MCPServer) error {
+	switch server.Transport() {
+	case "stdio":
+		if strings.TrimSpace(server.Command) == "" {
+			return fmt.Errorf("stdio servers need a command")
+		}
+		if server.URL != "" {
+			return fmt.Errorf("stdio servers cannot have a URL")
+		}
+	case "http", "sse":
+		u, err := url.Parse(server.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s servers need an http(s) URL", server.Transport())
+		}
+		if server.Command != "" {
+			return fmt.Errorf("%s servers cannot have a command", server.Transport())
+		}
+	default:
+		return fmt.Errorf("unknown transport %q (valid: stdio, http, sse)", server.Type)
+	}
+	return ValidateMCPServerFields(server)
+}
+
+// ValidateMCPServerFields validates one server's cwd, timeout,
+// headersHelper and oauth. Whether the cwd or helper exist on this machine
+// is left to health.
+func ValidateMCPServerFields(server mcpconfig.MCPServer) error {
+	remote := server.Transport() != "stdio"
+
+	cwd, err := server.Cwd()
+	if err != nil {
+		return fmt.Errorf("invalid cwd: %w", err)
+	}
+	if cwd != "" && remote {
+		return fmt.Errorf("cwd only applies to stdio servers")
+	}
+
+	timeout, err := server.Timeout()
+	if err != nil {
+		return fmt.Errorf("invalid timeout: %w", err)
+	}
+	if timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	helper, err := server.HeadersHelper()
+	if err != nil {
+		return fmt.Errorf("invalid headersHelper: %w", err)
+	}
+	if helper != "" && !remote {
+		return fmt.Errorf("headersHelper only applies to http and sse servers")
+	}
+
+	oauth, err := server.OAuth()
+	if err != nil {
+		return fmt.Errorf("invalid oauth: %w", err)
+	}
+	if oauth != nil {
+		if !remote {
+			return fmt.Errorf("oauth only applies to http and sse servers")
+		}
+		if oauth.CallbackPort < 0 || oauth.CallbackPort > 65535 {
+			return fmt.Errorf("invalid oauth.callbackPort %d (must be 1-65535)", oauth.CallbackPort)
+		}
+	}
+
 	return nil
 }
 
@@ -87,7 +267,13 @@ func ValidatePermissions(perms *config.Permissions) error {
 		return fmt.Errorf("too many entries in deny list (max 1000)")
 	}
 
-	return nil
+	if len(perms.Ask) > 1000 {
+		return fmt.Errorf("too many entries in ask list (max 1000)")
+	}
+
+	// Rules Claude Code cannot parse are rejected; other problems are
+	// reported by health
+	return permissions.Validate(perms)
 }
 
 // ValidateEnv validates environment variables
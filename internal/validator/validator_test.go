@@ -1,11 +1,13 @@
 package validator
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/johnfox/claudectx/internal/config"
+	"github.com/johnfox/claudectx/internal/mcpconfig"
 )
 
 func TestValidateJSONFile(t *testing.T) {
@@ -180,6 +182,26 @@ func TestValidatePermissions(t *testing.T) {
 			perms:   &config.Permissions{Allow: []string{"*"}},
 			wantErr: false,
 		},
+		{
+			name:    "unknown tool is only a warning",
+			perms:   &config.Permissions{Allow: []string{"Raed(./src/**)"}},
+			wantErr: false,
+		},
+		{
+			name:    "unbalanced parentheses",
+			perms:   &config.Permissions{Allow: []string{"Bash(git diff:*"}},
+			wantErr: true,
+		},
+		{
+			name:    "empty specifier in ask list",
+			perms:   &config.Permissions{Ask: []string{"Bash()"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid glob",
+			perms:   &config.Permissions{Deny: []string{"Read(./[abc)"}},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -292,3 +314,105 @@ func TestValidateClaudeMD(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSettingsFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{
+			name: "valid fields",
+			json: `{"hooks":{"Stop":[{"hooks":[{"type":"command","command":"say done","timeout":10}]}]},` +
+				`"statusLine":{"type":"command","command":"status.sh"},"apiKeyHelper":"key.sh",` +
+				`"enabledPlugins":{"a@b":true},"permissions":{"defaultMode":"plan","additionalDirectories":["../docs"]}}`,
+		},
+		{name: "hooks not a map", json: `{"hooks":[]}`, wantErr: true},
+		{name: "hook without command", json: `{"hooks":{"Stop":[{"hooks":[{"type":"command"}]}]}}`, wantErr: true},
+		{name: "hook with other type", json: `{"hooks":{"Stop":[{"hooks":[{"type":"agent","prompt":"check"},{"type":"http","url":"https://hooks.example.com"}]}]}}`},
+		{name: "hook without type", json: `{"hooks":{"Stop":[{"hooks":[{"command":"x"}]}]}}`, wantErr: true},
+		{name: "negative timeout", json: `{"hooks":{"Stop":[{"hooks":[{"type":"command","command":"x","timeout":-1}]}]}}`, wantErr: true},
+		{name: "statusLine without command", json: `{"statusLine":{"type":"command"}}`, wantErr: true},
+		{name: "apiKeyHelper not a string", json: `{"apiKeyHelper":true}`, wantErr: true},
+		{name: "invalid defaultMode", json: `{"permissions":{"defaultMode":"yolo"}}`, wantErr: true},
+		{name: "empty additional directory", json: `{"permissions":{"additionalDirectories":[""]}}`, wantErr: true},
+		{name: "includeCoAuthoredBy not a bool", json: `{"includeCoAuthoredBy":"no"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var settings config.Settings
+			if err := json.Unmarshal([]byte(tt.json), &settings); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateSettings(&settings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMCPServerFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{name: "stdio with cwd and timeout", json: `{"command":"srv","cwd":"/src","timeout":30000}`},
+		{name: "http with oauth and headersHelper", json: `{"type":"http","url":"https://x.test/mcp","oauth":{"clientId":"a","callbackPort":8080},"headersHelper":"h.sh"}`},
+		{name: "unknown fields are ignored", json: `{"command":"srv","alwaysAllow":["x"]}`},
+		{name: "negative timeout", json: `{"command":"srv","timeout":-1}`, wantErr: true},
+		{name: "timeout not a number", json: `{"command":"srv","timeout":"30s"}`, wantErr: true},
+		{name: "cwd on remote server", json: `{"type":"sse","url":"https://x.test/sse","cwd":"/src"}`, wantErr: true},
+		{name: "headersHelper on stdio server", json: `{"command":"srv","headersHelper":"h.sh"}`, wantErr: true},
+		{name: "oauth on stdio server", json: `{"command":"srv","oauth":{"clientId":"a"}}`, wantErr: true},
+		{name: "oauth port out of range", json: `{"url":"https://x.test/mcp","oauth":{"callbackPort":70000}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server mcpconfig.MCPServer
+			if err := json.Unmarshal([]byte(tt.json), &server); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateMCPServerFields(server)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMCPServerFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMCPServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{name: "stdio", json: `{"command":"npx","args":["-y","srv"]}`},
+		{name: "http", json: `{"type":"http","url":"https://x.test/mcp"}`},
+		{name: "url implies http", json: `{"url":"https://x.test/mcp"}`},
+		{name: "no command or url", json: `{"args":["-y","srv"]}`, wantErr: true},
+		{name: "blank command", json: `{"command":"  "}`, wantErr: true},
+		{name: "stdio with url", json: `{"type":"stdio","command":"srv","url":"https://x.test"}`, wantErr: true},
+		{name: "sse without url", json: `{"type":"sse"}`, wantErr: true},
+		{name: "http with non-http url", json: `{"type":"http","url":"ftp://x.test"}`, wantErr: true},
+		{name: "http with command", json: `{"type":"http","url":"https://x.test","command":"srv"}`, wantErr: true},
+		{name: "unknown transport", json: `{"type":"ws","url":"wss://x.test"}`, wantErr: true},
+		{name: "invalid fields", json: `{"command":"srv","timeout":-1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server mcpconfig.MCPServer
+			if err := json.Unmarshal([]byte(tt.json), &server); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateMCPServer(server)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMCPServer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
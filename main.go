@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/johnfox/claudectx/cmd"
 	"github.com/johnfox/claudectx/internal/store"
@@ -30,6 +31,12 @@ func main() {
 
 	arg := os.Args[1]
 
+	// --project=DIR is the same as --project DIR
+	if strings.HasPrefix(arg, "--project=") {
+		os.Args = append([]string{os.Args[0], "--project", strings.TrimPrefix(arg, "--project=")}, os.Args[2:]...)
+		arg = "--project"
+	}
+
 	switch arg {
 	case "-h", "--help":
 		printHelp()
@@ -39,7 +46,13 @@ func main() {
 
 	case "-l", "--list":
 		// Simple list for scripting/piping
-		if err := cmd.ListProfiles(s); err != nil {
+		opts, err := cmd.ParseListArgs(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: claudectx -l [--tag TAG] [--sort name|recent|count]")
+			os.Exit(1)
+		}
+		if err := cmd.ListProfiles(s, opts); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -56,6 +69,18 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "--project":
+		opts, err := cmd.ParseProjectArgs(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: claudectx --project <dir> [name|-|-c|sync [name]]")
+			os.Exit(1)
+		}
+		if err := cmd.RunProject(s, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 	case "-n":
 		if len(os.Args) < 3 {
 			fmt.Fprintln(os.Stderr, "Error: profile name required")
@@ -68,12 +93,11 @@ func main() {
 		}
 
 	case "-d":
-		if len(os.Args) < 3 {
-			fmt.Fprintln(os.Stderr, "Error: profile name required")
-			fmt.Fprintln(os.Stderr, "Usage: claudectx -d <name>")
-			os.Exit(1)
+		name := ""
+		if len(os.Args) > 2 {
+			name = os.Args[2]
 		}
-		if err := cmd.DeleteProfile(s, os.Args[2]); err != nil {
+		if err := cmd.DeleteProfile(s, name); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -125,6 +149,132 @@ func main() {
 			args = os.Args[2:]
 		}
 		if err := cmd.Health(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(cmd.HealthExitCode(err))
+		}
+
+	case "show":
+		opts, err := cmd.ParseShowArgs(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: claudectx show [name] [--reveal] [--json] [--section NAME]")
+			os.Exit(1)
+		}
+		if err := cmd.ShowProfile(s, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "permissions":
+		opts, err := cmd.ParsePermissionsArgs(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: claudectx permissions [name] [--project DIR] [--json]")
+			os.Exit(1)
+		}
+		if err := cmd.ShowPermissions(s, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "mcp":
+		opts, err := cmd.ParseMCPArgs(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: claudectx mcp list|show|enable|disable|add|rm <name> [server...]")
+			fmt.Fprintln(os.Stderr, "       claudectx mcp import <file> [--into name] [--format auto|claude|desktop|cursor|vscode] [--overwrite]")
+			fmt.Fprintln(os.Stderr, "       claudectx mcp export [name] [--format claude|desktop|cursor|vscode] [-o file]")
+			os.Exit(1)
+		}
+		if err := cmd.RunMCP(s, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "__preview":
+		// Run by the fzf preview pane; not listed in the help
+		if len(os.Args) != 4 {
+			os.Exit(1)
+		}
+		if err := cmd.Preview(s, os.Args[2], os.Args[3]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "backup":
+		opts, err := cmd.ParseBackupArgs(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: claudectx backup list [--json] | verify [id...] | gc")
+			fmt.Fprintln(os.Stderr, "       claudectx backup pin|unpin [id...] | prune [--dry-run] | restore [id] [--yes]")
+			fmt.Fprintln(os.Stderr, "       claudectx backup export <id...|--all> [-o file.tar.gz] | import <file.tar.gz>")
+			os.Exit(1)
+		}
+		if err := cmd.RunBackup(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "edit":
+		name, target := "", ""
+		if len(os.Args) > 2 {
+			name = os.Args[2]
+		}
+		if len(os.Args) > 3 {
+			target = os.Args[3]
+		}
+		if err := cmd.EditProfile(s, name, target); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "get", "set", "unset":
+		opts, err := cmd.ParseKeyArgs(arg, os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			usage := fmt.Sprintf("claudectx %s <name|GLOB|--all> <key.path>", arg)
+			if arg == "set" {
+				usage += " <value>"
+			}
+			fmt.Fprintf(os.Stderr, "Usage: %s\n", usage)
+			os.Exit(1)
+		}
+		if arg == "get" {
+			err = cmd.GetValue(s, opts)
+		} else {
+			err = cmd.SetValue(s, opts)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "cp", "clone":
+		opts, err := cmd.ParseCopyArgs(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: claudectx cp [name|backup-id|file] <new-name> [--set KEY=VALUE] [--model MODEL]")
+			os.Exit(1)
+		}
+		if err := cmd.CopyProfile(s, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "new":
+		opts, err := cmd.ParseNewArgs(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: claudectx new <name> [--template NAME] [--var KEY=VALUE]")
+			os.Exit(1)
+		}
+		if err := cmd.NewProfile(s, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "templates":
+		if err := cmd.ListTemplates(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -132,7 +282,7 @@ func main() {
 	case "run":
 		if len(os.Args) < 3 {
 			fmt.Fprintln(os.Stderr, "Error: profile name required")
-			fmt.Fprintln(os.Stderr, "Usage: claudectx run <name> [-- <claude args...>]")
+			fmt.Fprintln(os.Stderr, "Usage: claudectx run <name> [--mcp-only A,B] [--mcp-exclude C] [-- <claude args...>]")
 			os.Exit(1)
 		}
 		opts, err := cmd.ParseRunArgs(os.Args[2:])
@@ -184,14 +334,46 @@ USAGE:
   claudectx run <NAME> [-- ARGS]   Run Claude with profile for this session only
   claudectx -                      Switch to previous profile
   claudectx -l, --list             Simple list (for scripting/piping)
+  claudectx -l --tag T --sort S    Filter by tag; sort by name, recent or count
   claudectx -c, --current          Show current profile
+  claudectx --project DIR [NAME]   Apply profile's project part to DIR (also -, -c)
+  claudectx --project DIR sync [NAME]
+                                   Save DIR's local files as the profile's project part
   claudectx -n <NAME>              Create new profile from current config
-  claudectx -d <NAME>              Delete profile
+  claudectx new <NAME> [-t TMPL]   Create a profile from a provider template
+  claudectx templates              List profile templates and their variables
+  claudectx -d [NAME]              Delete profile
   claudectx -r <OLD> <NEW>         Rename profile
+  claudectx cp [SRC] <NEW>         Copy a profile, backup or export file to a new profile
   claudectx sync [NAME]            Sync active config to profile (current if no name)
   claudectx export <NAME> [FILE]   Export profile to JSON (stdout if no file)
   claudectx import [FILE] [NAME]   Import profile from JSON (stdin if no file)
-  claudectx health [NAME]          Check profile health (current if no name given)
+  claudectx health [NAME|--all]    Check profile health (current if no name given)
+  claudectx show [NAME]            Show profile details (secrets masked)
+  claudectx permissions [NAME]     Show merged permissions across settings scopes
+  claudectx mcp list [NAME]        List MCP servers and whether each is enabled
+  claudectx mcp enable|disable <NAME> <SERVER>
+                                   Turn an MCP server on or off without removing it
+  claudectx mcp add|rm|show <NAME> <SERVER>
+                                   Add, remove or inspect an MCP server
+  claudectx mcp import <FILE>      Import servers from Claude Desktop, Cursor, VS Code
+                                   or .mcp.json (--into NAME, --format, --overwrite)
+  claudectx mcp export [NAME]      Print servers as --format claude|desktop|cursor|vscode
+  claudectx backup list            List backups with their reason and active profile
+  claudectx backup verify [ID...]  Check backed-up files against their hashes
+  claudectx backup gc              Remove stored files no backup uses
+  claudectx backup pin|unpin [ID]  Keep a backup regardless of the retention policy
+  claudectx backup prune [--dry-run]
+                                   Delete backups the retention policy does not keep
+  claudectx backup export <ID...|--all> -o FILE
+                                   Package backups into a .tar.gz archive
+  claudectx backup import <FILE>   Add the backups from an exported archive
+  claudectx backup restore [ID]    Restore the live configuration from a backup,
+                                   after backing up the current state
+  claudectx edit [NAME] [FILE]     Edit settings, claude-md, mcp or project-mcp
+  claudectx get <NAME> <KEY>       Print a setting (e.g. env.ANTHROPIC_BASE_URL)
+  claudectx set <NAME> <KEY> <VAL> Change a setting (also KEY+=VAL, KEY-=VAL)
+  claudectx unset <NAME> <KEY>     Remove a setting
   claudectx -h, --help             Show this help
   claudectx -v, --version          Show version
 
@@ -202,11 +384,23 @@ EXAMPLES:
   claudectx run work -- --model opus
   claudectx run review -- -p "Review this diff"
   claudectx run work --dry-run     Print the command that would be run
+  claudectx run work --mcp-exclude playwright
+  claudectx run work --mcp-only github,linear
   claudectx -                      Toggle between current and previous profile
   claudectx -l                     List all profiles (simple output)
+  claudectx --project . sync work  Capture this repo's local settings into 'work'
+  claudectx --project . work       Apply 'work' local settings to this repo
+  claudectx --project . -          Toggle this repo back to its previous profile
+  claudectx -l --tag client --sort recent
+  claudectx set work meta.description "Client A via proxy"
+  claudectx set work meta.tags+=client
   claudectx -n personal            Create 'personal' profile from current settings
+  claudectx new aws --template bedrock
+  claudectx new glm -t zai --var token=$ZAI_API_KEY
   claudectx -d old-work            Delete 'old-work' profile
   claudectx -r old-name new-name   Rename profile from 'old-name' to 'new-name'
+  claudectx cp work client-b --set env.ANTHROPIC_BASE_URL=https://proxy --model opus
+  claudectx cp backup-1735689600000000000 restored
   claudectx sync                   Save active config changes to current profile
   claudectx sync work              Save active config to 'work' profile
   claudectx export work work.json  Export 'work' profile to file
@@ -216,6 +410,30 @@ EXAMPLES:
   cat work.json | claudectx import Import from stdin
   claudectx health                 Check current profile health
   claudectx health work            Check 'work' profile health
+  claudectx health work --online   Also test the API endpoint and credentials
+  claudectx health work --mcp      Start MCP servers and list their tools
+  claudectx health --all --strict  Check every profile; fail on warnings (for CI)
+  claudectx health work --fix --dry-run
+                                   Show the automatic fixes as a diff without saving
+  claudectx set personal meta.suppress+=allow-wildcard
+  claudectx show work              Inspect the 'work' profile
+  claudectx show work --reveal     Include secret values in the output
+  claudectx show work --json --section mcp
+  claudectx permissions work --project ~/src/app
+  claudectx mcp disable work playwright
+  claudectx mcp add work github -e GITHUB_TOKEN=$TOKEN -- npx -y @github/mcp
+  claudectx mcp add work linear --type http --url https://mcp.linear.app/mcp
+  claudectx mcp import ~/.cursor/mcp.json --into work
+  claudectx mcp export work --format vscode -o .vscode/mcp.json
+  claudectx edit work              Edit 'work' settings.json with validation
+  claudectx edit work claude-md    Edit the profile's CLAUDE.md
+  claudectx edit work project-mcp  Declare MCP servers per project path or glob
+  claudectx get work env.ANTHROPIC_BASE_URL
+  claudectx set work model opus
+  claudectx set work 'permissions.allow+=Bash(git *)'
+  claudectx set 'client-*' env.DISABLE_TELEMETRY 1
+  claudectx set work mcp.github.args --json '["-y","@github/mcp"]'
+  claudectx unset --all env.HTTP_PROXY
 
 SWITCH VS RUN:
   claudectx work        Permanently switches global config — affects all new sessions
@@ -224,16 +442,30 @@ SWITCH VS RUN:
 NOTES (claudectx run):
   - Profile settings override global ~/.claude/settings.json scalar values
   - Permission arrays (allow/deny) accumulate with global settings — cannot be narrowed
+    (claudectx permissions NAME shows the merged result)
   - Profile CLAUDE.md is appended to the session system prompt (not a full replacement)
   - Global ~/.claude/CLAUDE.md remains active alongside the profile's instructions
 
+A NAME or ID shown in [brackets] above is picked interactively when omitted
+(show falls back to the current profile when not run in a terminal).
+
+ENVIRONMENT:
+  CLAUDECTX_FZF         Path to an fzf binary used for interactive selection
+  CLAUDECTX_PICKER      Any picker command that reads lines on stdin (e.g. "sk")
+  CLAUDECTX_IGNORE_FZF  Set to use the built-in selector even if fzf is installed
+
 WHAT CLAUDECTX MANAGES:
   - ~/.claude/settings.json    User-level settings
   - ~/.claude/CLAUDE.md        Global instructions
   - ~/.claude.json mcpServers  User-scoped MCP server configs
+  - ~/.claude.json projects.<path>.mcpServers
+                               Per-project MCP servers the profile declares
   - Automatic backups in ~/.claude/backups/
+  - With --project: .claude/settings.local.json, CLAUDE.local.md and .mcp.json,
+    with trackers and backups in ~/.claude/claudectx-projects/
 
 Profiles are stored in ~/.claude/profiles/
+User templates are read from ~/.claude/profile-templates/<name>.json
 
 Inspired by kubectx - https://github.com/ahmetb/kubectx
 `